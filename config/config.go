@@ -2,6 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -13,22 +17,70 @@ type Config struct {
 	HBase   HBaseConfig   `yaml:"hbase"`
 	Cache   CacheConfig   `yaml:"cache"`
 	Logging LoggingConfig `yaml:"logging"`
+	Rating  RatingConfig  `yaml:"rating"`
+	Search  SearchConfig  `yaml:"search"`
+	// SearchIndex SQLite搜索索引文件配置（修改后需重启）
+	SearchIndex SearchIndexConfig `yaml:"search_index"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port string `yaml:"port"`
+	Port               string `yaml:"port"`
+	MaxBodyBytes       int64  `yaml:"max_body_bytes"`        // 普通接口请求体上限
+	ImportMaxBodyBytes int64  `yaml:"import_max_body_bytes"` // 导入接口请求体上限
+	AdminKey           string `yaml:"admin_key"`             // 管理接口密钥（X-Admin-Key），为空时禁用管理接口
+	GrpcPort           string `yaml:"grpc_port"`             // gRPC服务端口，为空时不启动gRPC服务
+	// CompressionMinBytes 响应体达到该大小才压缩（gzip/deflate），未设置时为1024，负数表示关闭压缩
+	CompressionMinBytes int `yaml:"compression_min_bytes"`
+	// CORSAllowOrigins 允许跨域请求的来源，未设置时只允许本地前端开发地址；"*"表示任意来源
+	CORSAllowOrigins []string `yaml:"cors_allow_origins"`
+	// CORSAllowCredentials 是否允许跨域请求携带Cookie等凭据，启用时cors_allow_origins不能包含"*"
+	CORSAllowCredentials bool `yaml:"cors_allow_credentials"`
 }
 
 // HBaseConfig HBase数据库配置
 type HBaseConfig struct {
-	Host        string                 `yaml:"host"`
-	ZkQuorum    string                 `yaml:"zk_quorum"`
-	ZkPort      string                 `yaml:"zk_port"`
-	MasterPort  string                 `yaml:"master_port"`
-	ThriftPort  string                 `yaml:"thrift_port"`
-	Performance HBasePerformanceConfig `yaml:"performance"`
-	RandomTest  HBaseRandomTestConfig  `yaml:"random_test"`
+	Host               string                 `yaml:"host"`
+	ZkQuorum           string                 `yaml:"zk_quorum"`
+	ZkPort             string                 `yaml:"zk_port"`
+	ZkSessionTimeoutMs int                    `yaml:"zk_session_timeout_ms"` // ZooKeeper会话超时（毫秒），默认30000
+	MasterPort         string                 `yaml:"master_port"`
+	ThriftPort         string                 `yaml:"thrift_port"`
+	MoviesTable        string                 `yaml:"movies_table"`      // 电影表名，可带命名空间（如staging:movies）
+	UsersTable         string                 `yaml:"users_table"`       // 用户表名
+	SkipSchemaCheck    bool                   `yaml:"skip_schema_check"` // 启动时跳过表和列族检查（也可用--skip-schema-check或HBASE_SKIP_SCHEMA_CHECK=true）
+	Performance        HBasePerformanceConfig `yaml:"performance"`
+	RandomTest         HBaseRandomTestConfig  `yaml:"random_test"`
+	Metrics            HBaseMetricsConfig     `yaml:"metrics"`
+	Schema             HBaseSchemaConfig      `yaml:"schema"`
+}
+
+// HBaseSchemaConfig 启动时自动创建缺失的表所使用的列族设置
+type HBaseSchemaConfig struct {
+	CreateMissing *bool  `yaml:"create_missing"` // 表不存在时自动创建，未设置时开启
+	Compression   string `yaml:"compression"`    // 列族默认压缩算法：NONE、SNAPPY、GZ、LZ4、ZSTD，默认NONE
+	Versions      int    `yaml:"versions"`       // 列族默认保留的版本数，默认1
+	// Families 按表（movies、users）和列族覆盖默认设置，未列出的必需列族使用默认设置，额外列出的列族也会创建
+	Families map[string]map[string]HBaseFamilyConfig `yaml:"families"`
+}
+
+// HBaseFamilyConfig 单个列族的设置，零值表示使用默认设置
+type HBaseFamilyConfig struct {
+	Compression string `yaml:"compression"`
+	Versions    int    `yaml:"versions"`
+	TTLSeconds  int    `yaml:"ttl_seconds"` // 单元格保留时间（秒），0表示永久保留
+}
+
+// HBaseCompressions 支持的列族压缩算法
+var HBaseCompressions = []string{"NONE", "SNAPPY", "GZ", "LZ4", "ZSTD"}
+
+// DefaultFamilyVersions 列族默认保留的版本数
+const DefaultFamilyVersions = 1
+
+// HBaseMetricsConfig HBase操作统计配置
+type HBaseMetricsConfig struct {
+	Enabled         *bool `yaml:"enabled"`           // 是否统计操作次数和延迟，未设置时开启
+	SlowOpsCapacity int   `yaml:"slow_ops_capacity"` // 保留的最慢操作条数
 }
 
 // HBasePerformanceConfig HBase性能配置
@@ -54,6 +106,15 @@ type HBaseRandomTestConfig struct {
 type CacheConfig struct {
 	CleanupInterval   string `yaml:"cleanup_interval"`
 	DefaultExpiration string `yaml:"default_expiration"`
+	// ExpirationJitterPct 过期时间随机抖动比例（0.1表示±10%），避免同时缓存的数据同时过期。
+	// 未设置时使用0.1，负数表示关闭抖动。
+	ExpirationJitterPct float64 `yaml:"expiration_jitter_pct"`
+	// Backend 缓存后端：memory（默认，进程内）或redis（多实例共享，重启后保留）
+	Backend        string `yaml:"backend"`
+	RedisAddr      string `yaml:"redis_addr"`       // Redis地址，如redis:6379
+	RedisPassword  string `yaml:"redis_password"`   // 建议通过环境变量 REDIS_PASSWORD 设置
+	RedisDB        int    `yaml:"redis_db"`         // Redis数据库编号
+	RedisKeyPrefix string `yaml:"redis_key_prefix"` // 键前缀，多个服务共用Redis时用于隔离，默认doroscore:
 }
 
 // LoggingConfig 日志配置
@@ -63,22 +124,117 @@ type LoggingConfig struct {
 	Timestamp bool   `yaml:"timestamp"`
 }
 
-var globalConfig *Config
+// RatingConfig 评分重新计算配置
+// 自上次计算后新增评分数达到 上次评分总数*百分比（且不少于最小值）时重新计算平均分。
+// 可按热度分层：评分数>=HotMinRatings使用HotThresholdPercent，<ColdMaxRatings使用ColdThresholdPercent。
+type RatingConfig struct {
+	RecalcThresholdPercent float64 `yaml:"recalc_threshold_percent"` // 默认阈值百分比，如10表示10%
+	RecalcMinWrites        int     `yaml:"recalc_min_writes"`        // 触发重新计算的最少新增评分数
+	HotMinRatings          int     `yaml:"hot_min_ratings"`          // 热门电影的评分数下限，0表示不启用
+	HotThresholdPercent    float64 `yaml:"hot_threshold_percent"`    // 热门电影阈值百分比
+	ColdMaxRatings         int     `yaml:"cold_max_ratings"`         // 冷门电影的评分数上限，0表示不启用
+	ColdThresholdPercent   float64 `yaml:"cold_threshold_percent"`   // 冷门电影阈值百分比
+	// TrackerMaxRecords 评分追踪器保留的最近写入记录数，同时决定趋势/窗口统计能回看多远。
+	// 每条记录约100字节，100万条约占用100MB内存。
+	TrackerMaxRecords int `yaml:"tracker_max_records"`
+	// LazyStatsRecompute 列表/搜索请求中遇到缺失的_stats时是否现场计算平均分。
+	// 默认关闭，应通过 /api/system/stats/recompute 预先回填。
+	LazyStatsRecompute bool `yaml:"lazy_stats_recompute"`
+}
 
-// GetConfig 获取配置（单例模式）
-func GetConfig() *Config {
-	if globalConfig == nil {
-		globalConfig = loadConfig()
+// SearchConfig 搜索配置（可通过 /api/system/search-config 运行时调整）
+type SearchConfig struct {
+	MaxScanRows         int   `yaml:"max_scan_rows" json:"max_scan_rows"`                 // 回退扫描时最多处理的_info行数
+	MaxResults          int   `yaml:"max_results" json:"max_results"`                     // 回退扫描最多返回的结果数
+	EnableIndexFallback *bool `yaml:"enable_index_fallback" json:"enable_index_fallback"` // 索引不可用或出错时是否回退到HBase扫描
+}
+
+// SearchIndexConfig SQLite搜索索引文件配置
+type SearchIndexConfig struct {
+	Path          string `yaml:"path"`            // 索引文件路径，默认./movie_index.db（环境变量SEARCH_INDEX_PATH）
+	EnableWAL     *bool  `yaml:"enable_wal"`      // 使用WAL日志模式，读写互不阻塞，未设置时开启
+	BusyTimeoutMs int    `yaml:"busy_timeout_ms"` // 数据库被锁定时等待的时间（毫秒），默认5000
+}
+
+// 搜索索引默认配置
+const (
+	DefaultSearchIndexPath    = "./movie_index.db"
+	DefaultIndexBusyTimeoutMs = 5000
+)
+
+// GetPath 获取索引文件路径
+func (s SearchIndexConfig) GetPath() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return DefaultSearchIndexPath
+}
+
+// IsWALEnabled 是否使用WAL日志模式，默认开启
+func (s SearchIndexConfig) IsWALEnabled() bool {
+	if s.EnableWAL != nil {
+		return *s.EnableWAL
+	}
+	return true
+}
+
+// GetBusyTimeout 获取数据库被锁定时的等待时间
+func (s SearchIndexConfig) GetBusyTimeout() time.Duration {
+	if s.BusyTimeoutMs > 0 {
+		return time.Duration(s.BusyTimeoutMs) * time.Millisecond
 	}
-	return globalConfig
+	return DefaultIndexBusyTimeoutMs * time.Millisecond
+}
+
+var (
+	// globalConfig 当前配置（*Config），重新加载时整体原子替换
+	globalConfig atomic.Value
+	loadOnce     sync.Once
+	searchMu     sync.RWMutex
+)
+
+// configFile 配置文件路径
+const configFile = "config.yaml"
+
+const (
+	defaultMaxBodyBytes       int64 = 1 << 20  // 1MB
+	defaultImportMaxBodyBytes int64 = 10 << 20 // 10MB
+
+	defaultCompressionMinBytes = 1024
+
+	defaultRecalcThresholdPercent = 10.0
+	defaultRecalcMinWrites        = 1
+	defaultTrackerMaxRecords      = 10000
+
+	defaultCacheExpirationJitterPct = 0.1
+	defaultCacheBackend             = "memory"
+	defaultRedisAddr                = "localhost:6379"
+	defaultRedisKeyPrefix           = "doroscore:"
+
+	defaultSearchMaxScanRows = 10000
+	defaultSearchMaxResults  = 1000
+)
+
+// defaultCORSAllowOrigins 未配置server.cors_allow_origins时允许的来源（本地前端开发服务器）
+var defaultCORSAllowOrigins = []string{
+	"http://localhost:3000",
+	"http://localhost:5173",
+	"http://127.0.0.1:3000",
+	"http://127.0.0.1:5173",
+}
+
+// GetConfig 获取当前配置。配置重新加载后返回新的实例，调用方不应长期持有返回值。
+func GetConfig() *Config {
+	loadOnce.Do(func() {
+		globalConfig.Store(loadConfig())
+	})
+	return globalConfig.Load().(*Config)
 }
 
 // loadConfig 加载配置
 func loadConfig() *Config {
-	config := &Config{}
-
-	// 尝试从配置文件加载
-	if err := loadFromFile(config, "config.yaml"); err != nil {
+	config, err := readConfig(configFile)
+	if err != nil {
 		// 如果文件不存在或解析失败，使用默认配置
 		config = getDefaultConfig()
 	}
@@ -89,6 +245,15 @@ func loadConfig() *Config {
 	return config
 }
 
+// readConfig 读取并解析配置文件
+func readConfig(filename string) (*Config, error) {
+	config := &Config{}
+	if err := loadFromFile(config, filename); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 // loadFromFile 从文件加载配置
 func loadFromFile(config *Config, filename string) error {
 	data, err := os.ReadFile(filename)
@@ -104,6 +269,9 @@ func overrideFromEnv(config *Config) {
 	if port := os.Getenv("SERVER_PORT"); port != "" {
 		config.Server.Port = port
 	}
+	if grpcPort, ok := os.LookupEnv("GRPC_PORT"); ok {
+		config.Server.GrpcPort = grpcPort
+	}
 	if host := os.Getenv("HBASE_HOST"); host != "" {
 		config.HBase.Host = host
 	}
@@ -113,20 +281,140 @@ func overrideFromEnv(config *Config) {
 	if zkPort := os.Getenv("HBASE_ZK_PORT"); zkPort != "" {
 		config.HBase.ZkPort = zkPort
 	}
+	if moviesTable := os.Getenv("HBASE_MOVIES_TABLE"); moviesTable != "" {
+		config.HBase.MoviesTable = moviesTable
+	}
+	if usersTable := os.Getenv("HBASE_USERS_TABLE"); usersTable != "" {
+		config.HBase.UsersTable = usersTable
+	}
+	if skip, err := strconv.ParseBool(os.Getenv("HBASE_SKIP_SCHEMA_CHECK")); err == nil {
+		config.HBase.SkipSchemaCheck = skip
+	}
+	if adminKey := os.Getenv("ADMIN_KEY"); adminKey != "" {
+		config.Server.AdminKey = adminKey
+	}
+	if backend := os.Getenv("CACHE_BACKEND"); backend != "" {
+		config.Cache.Backend = backend
+	}
+	if redisAddr := os.Getenv("REDIS_ADDR"); redisAddr != "" {
+		config.Cache.RedisAddr = redisAddr
+	}
+	if redisPassword := os.Getenv("REDIS_PASSWORD"); redisPassword != "" {
+		config.Cache.RedisPassword = redisPassword
+	}
+	if indexPath := os.Getenv("SEARCH_INDEX_PATH"); indexPath != "" {
+		config.SearchIndex.Path = indexPath
+	}
+	if origins := os.Getenv("CORS_ALLOW_ORIGINS"); origins != "" {
+		config.Server.CORSAllowOrigins = nil
+		for _, origin := range strings.Split(origins, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.Server.CORSAllowOrigins = append(config.Server.CORSAllowOrigins, origin)
+			}
+		}
+	}
+}
+
+// 默认HBase表名
+const (
+	DefaultMoviesTable = "movies"
+	DefaultUsersTable  = "users"
+)
+
+// DefaultZkSessionTimeoutMs 默认ZooKeeper会话超时（毫秒）
+const DefaultZkSessionTimeoutMs = 30000
+
+// GetZkSessionTimeout 获取ZooKeeper会话超时。
+// 调大可减少集群繁忙时的频繁重连，但ZooKeeper真正宕机时需要更久才能发现。
+func (h *HBaseConfig) GetZkSessionTimeout() time.Duration {
+	if h.ZkSessionTimeoutMs > 0 {
+		return time.Duration(h.ZkSessionTimeoutMs) * time.Millisecond
+	}
+	return DefaultZkSessionTimeoutMs * time.Millisecond
+}
+
+// DefaultSlowOpsCapacity 默认保留的最慢操作条数
+const DefaultSlowOpsCapacity = 20
+
+// IsMetricsEnabled 是否开启HBase操作统计，默认开启
+func (h *HBaseConfig) IsMetricsEnabled() bool {
+	if h.Metrics.Enabled != nil {
+		return *h.Metrics.Enabled
+	}
+	return true
+}
+
+// GetSlowOpsCapacity 获取保留的最慢操作条数
+func (h *HBaseConfig) GetSlowOpsCapacity() int {
+	if h.Metrics.SlowOpsCapacity > 0 {
+		return h.Metrics.SlowOpsCapacity
+	}
+	return DefaultSlowOpsCapacity
+}
+
+// IsCreateMissingTables 启动时是否自动创建缺失的表，默认开启
+func (h *HBaseConfig) IsCreateMissingTables() bool {
+	if h.Schema.CreateMissing != nil {
+		return *h.Schema.CreateMissing
+	}
+	return true
+}
+
+// GetFamilyConfig 获取表（movies或users）中列族的设置，未单独配置的项使用默认值
+func (h *HBaseConfig) GetFamilyConfig(table, family string) HBaseFamilyConfig {
+	fc := h.Schema.Families[table][family]
+	if fc.Compression == "" {
+		fc.Compression = h.Schema.Compression
+	}
+	if fc.Compression == "" {
+		fc.Compression = "NONE"
+	}
+	fc.Compression = strings.ToUpper(fc.Compression)
+	if fc.Versions <= 0 {
+		fc.Versions = h.Schema.Versions
+	}
+	if fc.Versions <= 0 {
+		fc.Versions = DefaultFamilyVersions
+	}
+	if fc.TTLSeconds < 0 {
+		fc.TTLSeconds = 0
+	}
+	return fc
+}
+
+// GetMoviesTable 获取电影表名
+func (h *HBaseConfig) GetMoviesTable() string {
+	if h.MoviesTable != "" {
+		return h.MoviesTable
+	}
+	return DefaultMoviesTable
+}
+
+// GetUsersTable 获取用户表名
+func (h *HBaseConfig) GetUsersTable() string {
+	if h.UsersTable != "" {
+		return h.UsersTable
+	}
+	return DefaultUsersTable
 }
 
 // getDefaultConfig 获取默认配置
 func getDefaultConfig() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: "5000",
+			Port:               "5000",
+			GrpcPort:           "50051",
+			MaxBodyBytes:       defaultMaxBodyBytes,
+			ImportMaxBodyBytes: defaultImportMaxBodyBytes,
 		},
 		HBase: HBaseConfig{
-			Host:       "192.168.2.154",
-			ZkQuorum:   "192.168.2.154",
-			ZkPort:     "2181",
-			MasterPort: "16000",
-			ThriftPort: "9090",
+			Host:        "192.168.2.154",
+			ZkQuorum:    "192.168.2.154",
+			ZkPort:      "2181",
+			MasterPort:  "16000",
+			ThriftPort:  "9090",
+			MoviesTable: DefaultMoviesTable,
+			UsersTable:  DefaultUsersTable,
 			Performance: HBasePerformanceConfig{
 				ConnectionPoolSize: 5,
 				BatchSize:          50,
@@ -144,14 +432,24 @@ func getDefaultConfig() *Config {
 			},
 		},
 		Cache: CacheConfig{
-			CleanupInterval:   "5m",
-			DefaultExpiration: "10m",
+			CleanupInterval:     "5m",
+			DefaultExpiration:   "10m",
+			ExpirationJitterPct: defaultCacheExpirationJitterPct,
 		},
 		Logging: LoggingConfig{
 			Level:     "info",
 			Format:    "text",
 			Timestamp: true,
 		},
+		Rating: RatingConfig{
+			RecalcThresholdPercent: defaultRecalcThresholdPercent,
+			RecalcMinWrites:        defaultRecalcMinWrites,
+			TrackerMaxRecords:      defaultTrackerMaxRecords,
+		},
+		Search: SearchConfig{
+			MaxScanRows: defaultSearchMaxScanRows,
+			MaxResults:  defaultSearchMaxResults,
+		},
 	}
 }
 
@@ -170,3 +468,148 @@ func (c *Config) GetCacheDefaultExpiration() time.Duration {
 	}
 	return 10 * time.Minute
 }
+
+// GetCacheExpirationJitterPct 获取缓存过期时间的抖动比例
+func (c *Config) GetCacheExpirationJitterPct() float64 {
+	if c.Cache.ExpirationJitterPct < 0 {
+		return 0
+	}
+	if c.Cache.ExpirationJitterPct > 0 {
+		return c.Cache.ExpirationJitterPct
+	}
+	return defaultCacheExpirationJitterPct
+}
+
+// GetCacheBackend 获取缓存后端（memory或redis）
+func (c *Config) GetCacheBackend() string {
+	if c.Cache.Backend != "" {
+		return strings.ToLower(c.Cache.Backend)
+	}
+	return defaultCacheBackend
+}
+
+// GetRedisAddr 获取Redis地址
+func (c *Config) GetRedisAddr() string {
+	if c.Cache.RedisAddr != "" {
+		return c.Cache.RedisAddr
+	}
+	return defaultRedisAddr
+}
+
+// GetRedisKeyPrefix 获取Redis键前缀
+func (c *Config) GetRedisKeyPrefix() string {
+	if c.Cache.RedisKeyPrefix != "" {
+		return c.Cache.RedisKeyPrefix
+	}
+	return defaultRedisKeyPrefix
+}
+
+// GetMaxBodyBytes 获取普通接口的请求体大小上限
+func (c *Config) GetMaxBodyBytes() int64 {
+	if c.Server.MaxBodyBytes > 0 {
+		return c.Server.MaxBodyBytes
+	}
+	return defaultMaxBodyBytes
+}
+
+// GetImportMaxBodyBytes 获取导入接口的请求体大小上限
+func (c *Config) GetImportMaxBodyBytes() int64 {
+	if c.Server.ImportMaxBodyBytes > 0 {
+		return c.Server.ImportMaxBodyBytes
+	}
+	return defaultImportMaxBodyBytes
+}
+
+// GetCompressionMinBytes 获取响应压缩的最小响应体大小，负数表示关闭压缩
+func (c *Config) GetCompressionMinBytes() int {
+	if c.Server.CompressionMinBytes < 0 {
+		return -1
+	}
+	if c.Server.CompressionMinBytes > 0 {
+		return c.Server.CompressionMinBytes
+	}
+	return defaultCompressionMinBytes
+}
+
+// GetCORSAllowOrigins 获取允许跨域请求的来源，未配置时返回本地前端开发地址
+func (c *Config) GetCORSAllowOrigins() []string {
+	if len(c.Server.CORSAllowOrigins) > 0 {
+		return c.Server.CORSAllowOrigins
+	}
+	return append([]string(nil), defaultCORSAllowOrigins...)
+}
+
+// CORSAllowsAnyOrigin 允许的来源中是否包含通配符"*"
+func (c *Config) CORSAllowsAnyOrigin() bool {
+	for _, origin := range c.GetCORSAllowOrigins() {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRecalcThresholdPercent 获取指定评分数的电影使用的重新计算阈值百分比
+func (c *Config) GetRecalcThresholdPercent(ratingCount int) float64 {
+	r := c.Rating
+	if r.HotMinRatings > 0 && r.HotThresholdPercent > 0 && ratingCount >= r.HotMinRatings {
+		return r.HotThresholdPercent
+	}
+	if r.ColdMaxRatings > 0 && r.ColdThresholdPercent > 0 && ratingCount < r.ColdMaxRatings {
+		return r.ColdThresholdPercent
+	}
+	if r.RecalcThresholdPercent > 0 {
+		return r.RecalcThresholdPercent
+	}
+	return defaultRecalcThresholdPercent
+}
+
+// GetRecalcMinWrites 获取触发重新计算的最少新增评分数
+func (c *Config) GetRecalcMinWrites() int {
+	if c.Rating.RecalcMinWrites > 0 {
+		return c.Rating.RecalcMinWrites
+	}
+	return defaultRecalcMinWrites
+}
+
+// GetTrackerMaxRecords 获取评分追踪器保留的写入记录数
+func (c *Config) GetTrackerMaxRecords() int {
+	if c.Rating.TrackerMaxRecords > 0 {
+		return c.Rating.TrackerMaxRecords
+	}
+	return defaultTrackerMaxRecords
+}
+
+// LazyStatsRecomputeEnabled 列表/搜索请求中是否按需计算缺失的平均分
+func (c *Config) LazyStatsRecomputeEnabled() bool {
+	return c.Rating.LazyStatsRecompute
+}
+
+// GetSearchConfig 获取搜索配置（已填充默认值的副本）
+func (c *Config) GetSearchConfig() SearchConfig {
+	searchMu.RLock()
+	sc := c.Search
+	searchMu.RUnlock()
+
+	if sc.MaxScanRows <= 0 {
+		sc.MaxScanRows = defaultSearchMaxScanRows
+	}
+	if sc.MaxResults <= 0 {
+		sc.MaxResults = defaultSearchMaxResults
+	}
+	if sc.EnableIndexFallback == nil {
+		enabled := true
+		sc.EnableIndexFallback = &enabled
+	} else {
+		enabled := *sc.EnableIndexFallback
+		sc.EnableIndexFallback = &enabled
+	}
+	return sc
+}
+
+// SetSearchConfig 运行时更新搜索配置
+func (c *Config) SetSearchConfig(sc SearchConfig) {
+	searchMu.Lock()
+	c.Search = sc
+	searchMu.Unlock()
+}
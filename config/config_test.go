@@ -0,0 +1,43 @@
+package config
+
+import "testing"
+
+func TestGetRecalcThresholdPercent(t *testing.T) {
+	tiered := RatingConfig{
+		RecalcThresholdPercent: 10,
+		HotMinRatings:          1000,
+		HotThresholdPercent:    5,
+		ColdMaxRatings:         50,
+		ColdThresholdPercent:   25,
+	}
+
+	tests := []struct {
+		name        string
+		rating      RatingConfig
+		ratingCount int
+		want        float64
+	}{
+		{"未配置使用默认值", RatingConfig{}, 500, defaultRecalcThresholdPercent},
+		{"配置的默认百分比", RatingConfig{RecalcThresholdPercent: 7.5}, 500, 7.5},
+		{"热门电影", tiered, 1000, 5},
+		{"热门下限以下", tiered, 999, 10},
+		{"冷门电影", tiered, 49, 25},
+		{"冷门上限", tiered, 50, 10},
+		{"未配置热门百分比时不分层", RatingConfig{RecalcThresholdPercent: 10, HotMinRatings: 1000}, 5000, 10},
+	}
+	for _, tt := range tests {
+		c := &Config{Rating: tt.rating}
+		if got := c.GetRecalcThresholdPercent(tt.ratingCount); got != tt.want {
+			t.Errorf("%s: GetRecalcThresholdPercent(%d) = %v, want %v", tt.name, tt.ratingCount, got, tt.want)
+		}
+	}
+}
+
+func TestGetRecalcMinWrites(t *testing.T) {
+	if got := (&Config{}).GetRecalcMinWrites(); got != defaultRecalcMinWrites {
+		t.Errorf("未配置时 = %d, want %d", got, defaultRecalcMinWrites)
+	}
+	if got := (&Config{Rating: RatingConfig{RecalcMinWrites: 3}}).GetRecalcMinWrites(); got != 3 {
+		t.Errorf("配置为3时 = %d, want 3", got)
+	}
+}
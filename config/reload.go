@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// reloadMu 串行化重新加载，避免文件监听和SIGHUP同时触发时交错替换
+var reloadMu sync.Mutex
+
+// runtimeReloadable 支持运行时生效的配置项（yaml路径，以"."结尾表示整个小节）
+var runtimeReloadable = []string{
+	"logging.level",
+	"logging.timestamp",
+	"cache.default_expiration",
+	"cache.expiration_jitter_pct",
+	"rating.",
+	"search.",
+	"hbase.metrics.",
+}
+
+// secretFields 日志中需要隐藏取值的配置项
+var secretFields = map[string]bool{
+	"server.admin_key":     true,
+	"cache.redis_password": true,
+}
+
+// FieldChange 重新加载前后取值不同的配置项
+type FieldChange struct {
+	Field   string // yaml路径，如logging.level
+	Old     string
+	New     string
+	Runtime bool // 是否支持运行时生效，否则需要重启
+}
+
+// FilePath 返回配置文件路径
+func FilePath() string {
+	return configFile
+}
+
+// Reload 重新读取配置文件并校验，通过后原子替换全局配置，返回替换前后的配置。
+// 读取、解析或校验失败时保留当前配置并返回错误。
+func Reload() (*Config, *Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next, err := readConfig(configFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	overrideFromEnv(next)
+	if err := next.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("配置校验失败: %w", err)
+	}
+
+	old := GetConfig()
+	globalConfig.Store(next)
+	return old, next, nil
+}
+
+// Validate 校验配置中会在运行时解析的字段
+func (c *Config) Validate() error {
+	if c.Logging.Level != "" {
+		if _, err := logrus.ParseLevel(c.Logging.Level); err != nil {
+			return fmt.Errorf("logging.level无效: %q", c.Logging.Level)
+		}
+	}
+	for field, value := range map[string]string{
+		"cache.cleanup_interval":   c.Cache.CleanupInterval,
+		"cache.default_expiration": c.Cache.DefaultExpiration,
+	} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("%s无效: %q", field, value)
+		}
+	}
+	if backend := c.GetCacheBackend(); backend != "memory" && backend != "redis" {
+		return fmt.Errorf("cache.backend无效: %q（可选memory、redis）", c.Cache.Backend)
+	}
+	if err := c.HBase.Schema.validate(); err != nil {
+		return err
+	}
+	if c.Rating.RecalcThresholdPercent < 0 || c.Rating.HotThresholdPercent < 0 || c.Rating.ColdThresholdPercent < 0 {
+		return fmt.Errorf("rating中的阈值百分比不能为负数")
+	}
+	if c.Search.MaxScanRows < 0 || c.Search.MaxResults < 0 {
+		return fmt.Errorf("search.max_scan_rows和search.max_results不能为负数")
+	}
+	return c.ValidateCORS()
+}
+
+// ValidateCORS 校验跨域配置：允许携带凭据时不能允许任意来源（浏览器会拒绝这种组合，且会把凭据暴露给任意网站）
+func (c *Config) ValidateCORS() error {
+	if c.Server.CORSAllowCredentials && c.CORSAllowsAnyOrigin() {
+		return fmt.Errorf("server.cors_allow_credentials启用时server.cors_allow_origins不能包含\"*\"")
+	}
+	for _, origin := range c.Server.CORSAllowOrigins {
+		if !strings.Contains(origin, "*") && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("server.cors_allow_origins中的来源 %q 必须以http://或https://开头", origin)
+		}
+	}
+	return nil
+}
+
+// Diff 列出两份配置中取值不同的配置项，敏感字段的取值会被隐藏
+func Diff(old, next *Config) []FieldChange {
+	var changes []FieldChange
+	diffValues("", reflect.ValueOf(*old), reflect.ValueOf(*next), &changes)
+	return changes
+}
+
+// diffValues 按yaml标签递归比较结构体字段
+func diffValues(path string, old, next reflect.Value, changes *[]FieldChange) {
+	if old.Kind() == reflect.Struct {
+		for i := 0; i < old.NumField(); i++ {
+			name := strings.Split(old.Type().Field(i).Tag.Get("yaml"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			diffValues(name, old.Field(i), next.Field(i), changes)
+		}
+		return
+	}
+
+	oldStr, nextStr := formatValue(old), formatValue(next)
+	if oldStr == nextStr {
+		return
+	}
+	if secretFields[path] {
+		oldStr, nextStr = "***", "***"
+	}
+	*changes = append(*changes, FieldChange{Field: path, Old: oldStr, New: nextStr, Runtime: isRuntimeReloadable(path)})
+}
+
+// formatValue 格式化字段值，指针取其指向的值
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "<未设置>"
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// isRuntimeReloadable 配置项是否支持运行时生效
+func isRuntimeReloadable(field string) bool {
+	for _, prefix := range runtimeReloadable {
+		if field == prefix || (strings.HasSuffix(prefix, ".") && strings.HasPrefix(field, prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate 检查列族配置中的表名和压缩算法
+func (s HBaseSchemaConfig) validate() error {
+	compressions := []string{s.Compression}
+	for table, families := range s.Families {
+		if table != "movies" && table != "users" {
+			return fmt.Errorf("hbase.schema.families中的表无效: %q（可选movies、users）", table)
+		}
+		for _, family := range families {
+			compressions = append(compressions, family.Compression)
+		}
+	}
+	for _, compression := range compressions {
+		if compression != "" && !slices.Contains(HBaseCompressions, strings.ToUpper(compression)) {
+			return fmt.Errorf("hbase.schema中的压缩算法无效: %q（可选%s）", compression, strings.Join(HBaseCompressions, "、"))
+		}
+	}
+	return nil
+}
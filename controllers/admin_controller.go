@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"gohbase/models"
+	"gohbase/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminController 电影元数据管理控制器
+type AdminController struct{}
+
+// NewAdminController 创建管理控制器
+func NewAdminController() *AdminController {
+	return &AdminController{}
+}
+
+// CreateMovie 新建电影，自动分配电影ID
+// @Summary 新建电影
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param body body models.MovieInput true "请求体"
+// @Success 201 {object} map[string]interface{} "movieId"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "新建电影失败"
+// @Router /api/admin/movies [post]
+func (ac *AdminController) CreateMovie(c *gin.Context) {
+	var req models.MovieInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	movieID, err := models.CreateMovie(context.Background(), req)
+	if err != nil {
+		respondMovieAdminError(c, "新建电影失败", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+	})
+}
+
+// UpdateMovie 部分更新电影的标题、类型和外部链接
+// @Summary 更新电影标题、类型和外部链接
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param id path string true "电影ID"
+// @Param body body models.MovieInput true "请求体"
+// @Success 200 {object} map[string]interface{} "movieId"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "更新电影失败"
+// @Router /api/admin/movies/{id} [patch]
+func (ac *AdminController) UpdateMovie(c *gin.Context) {
+	movieID := c.Param("id")
+
+	var req models.MovieInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+	if req.Title == nil && req.Genres == nil && req.ImdbID == nil && req.TmdbID == nil {
+		utils.BadRequest(c, "至少需要提供title、genres、imdbId或tmdbId之一")
+		return
+	}
+
+	if err := models.UpdateMovie(context.Background(), movieID, req); err != nil {
+		respondMovieAdminError(c, "更新电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+	})
+}
+
+// PutMovie 以指定ID新建或整体替换电影的标题、类型和外部链接，新建时返回201
+// @Summary 以指定ID新建或替换电影标题和类型（ID须为数字，省略的外部ID不变）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param id path string true "电影ID"
+// @Param body body models.MovieInput true "请求体"
+// @Success 200 {object} map[string]interface{} "created、movieId"
+// @Success 201 {object} map[string]interface{} "created、movieId"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "保存电影失败"
+// @Router /api/movies/{id} [put]
+func (ac *AdminController) PutMovie(c *gin.Context) {
+	movieID := c.Param("id")
+
+	var req models.MovieInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	created, err := models.PutMovie(c.Request.Context(), movieID, req)
+	if err != nil {
+		respondMovieAdminError(c, "保存电影失败", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+		"created": created,
+	})
+}
+
+// PostMovie 写入指定ID电影的元数据，电影不存在时新建并记录添加时间（返回201），已存在时只更新提供的字段
+// @Summary 写入电影元数据（不存在时以该ID新建并记录添加时间，title必填；已存在时部分更新）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param id path string true "电影ID"
+// @Param body body models.MovieInput true "请求体"
+// @Success 200 {object} map[string]interface{} "created、movieId"
+// @Success 201 {object} map[string]interface{} "created、movieId"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 500 {object} utils.ErrorResponse "保存电影失败"
+// @Router /api/movies/{id} [post]
+func (ac *AdminController) PostMovie(c *gin.Context) {
+	movieID := c.Param("id")
+
+	var req models.MovieInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+	if req.Title == nil && req.Genres == nil && req.ImdbID == nil && req.TmdbID == nil {
+		utils.BadRequest(c, "至少需要提供title、genres、imdbId或tmdbId之一")
+		return
+	}
+
+	created, err := models.PostMovie(c.Request.Context(), movieID, req)
+	if err != nil {
+		respondMovieAdminError(c, "保存电影失败", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+		"created": created,
+	})
+}
+
+// DeleteMovie 删除电影的全部数据和索引条目
+// @Summary 删除电影的全部行（_info、_stats、_links、_ratings、_tags、_genome）和索引条目
+// @Tags admin
+// @Produce json
+// @Security AdminKey
+// @Param id path string true "电影ID"
+// @Success 200 {object} map[string]interface{} "movieId"
+// @Failure 400 {object} utils.ErrorResponse "电影数据无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "删除电影失败"
+// @Router /api/movies/{id} [delete]
+// @Router /api/admin/movies/{id} [delete]
+func (ac *AdminController) DeleteMovie(c *gin.Context) {
+	movieID := c.Param("id")
+
+	if err := models.DeleteMovie(context.Background(), movieID); err != nil {
+		respondMovieAdminError(c, "删除电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+	})
+}
+
+// BatchDeleteMovies 批量删除电影（如测试控制器写入的测试数据），单次最多100部
+// @Summary 批量删除电影，单次最多100部
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param body body movieIDsRequest true "请求体"
+// @Success 200 {object} map[string]interface{} "deleted、errors、notFound"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "批量删除电影失败"
+// @Router /api/movies/batch-delete [delete]
+func (ac *AdminController) BatchDeleteMovies(c *gin.Context) {
+	var req movieIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	result, err := models.DeleteMovies(context.Background(), req.IDs)
+	if err != nil {
+		respondMovieAdminError(c, "批量删除电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"deleted":  result.Deleted,
+		"notFound": result.NotFound,
+		"errors":   result.Errors,
+	})
+}
+
+// PurgeOldRatings 删除评分时间早于ts（Unix秒）的全部评分并重新计算受影响电影的统计，
+// dry_run=true时只统计将删除的数量
+// @Summary 删除评分时间早于ts的全部评分并重新计算受影响电影的统计
+// @Tags ratings
+// @Produce json
+// @Security AdminKey
+// @Param ts query integer true "Unix秒，不能晚于当前时间"
+// @Param dry_run query boolean false "只统计将删除的数量" default(false)
+// @Success 200 {object} map[string]interface{} "result（models.RatingPurgeResult）"
+// @Failure 400 {object} utils.ErrorResponse "ts必须是正整数（Unix秒）"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 500 {object} utils.ErrorResponse "删除旧评分失败"
+// @Router /api/ratings/older-than [delete]
+func (ac *AdminController) PurgeOldRatings(c *gin.Context) {
+	ts, err := strconv.ParseInt(c.Query("ts"), 10, 64)
+	if err != nil || ts <= 0 {
+		utils.BadRequest(c, "ts必须是正整数（Unix秒）")
+		return
+	}
+	before := time.Unix(ts, 0)
+	if before.After(time.Now()) {
+		utils.BadRequest(c, "ts不能晚于当前时间")
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	result, err := models.PurgeRatingsOlderThan(c.Request.Context(), before, dryRun)
+	if err != nil {
+		utils.InternalError(c, "删除旧评分失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"result": result,
+	})
+}
+
+// respondMovieAdminError 将电影管理错误映射为HTTP响应
+func respondMovieAdminError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrMovieNotFound):
+		utils.NotFound(c, "电影不存在")
+	case errors.Is(err, models.ErrInvalidMovieInput):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.InternalError(c, message, err)
+	}
+}
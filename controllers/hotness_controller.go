@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"gohbase/services"
 	"gohbase/utils"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // HotnessController 热度控制器
@@ -17,6 +23,13 @@ func NewHotnessController() *HotnessController {
 }
 
 // GetHotMovies 获取热门电影列表
+// @Summary 获取热门电影
+// @Tags hotness
+// @Produce json
+// @Param limit query integer false "返回数量，最大100" default(20)
+// @Success 200 {object} utils.Response{data=object} "data: count、hotMovies（[]services.MovieHotness）、limit"
+// @Failure 500 {object} utils.ErrorResponse "获取热门电影失败"
+// @Router /api/hotness/movies [get]
 func (hc *HotnessController) GetHotMovies(c *gin.Context) {
 	// 获取限制数量，默认20部
 	limitStr := c.DefaultQuery("limit", "20")
@@ -47,6 +60,13 @@ func (hc *HotnessController) GetHotMovies(c *gin.Context) {
 }
 
 // GetMovieRatingThreshold 获取电影评分阈值状态
+// @Summary 获取电影评分阈值状态
+// @Tags hotness
+// @Produce json
+// @Param id path string true "电影ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Router /api/hotness/movie/{id}/threshold [get]
 func (hc *HotnessController) GetMovieRatingThreshold(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -58,13 +78,95 @@ func (hc *HotnessController) GetMovieRatingThreshold(c *gin.Context) {
 	status := services.GlobalRatingTracker.GetMovieRatingThresholdStatus(movieID)
 
 	utils.SuccessData(c, gin.H{
-		"status": "success",
-		"data":   status,
+		"status":  "success",
+		"data":    status,
 		"message": "获取评分阈值状态成功",
 	})
 }
 
+// RecalculateMovieRating 立即重新计算电影的平均评分并重置阈值计数
+// @Summary 立即重新计算电影平均评分（不检查阈值）
+// @Tags hotness
+// @Produce json
+// @Security AdminKey
+// @Param id path string true "电影ID"
+// @Success 200 {object} utils.Response
+// @Success 202 {object} map[string]interface{} "message"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 500 {object} utils.ErrorResponse "重新计算评分失败"
+// @Router /api/hotness/movie/{id}/recalculate [post]
+func (hc *HotnessController) RecalculateMovieRating(c *gin.Context) {
+	movieID := c.Param("id")
+	if movieID == "" {
+		utils.BadRequest(c, "电影ID不能为空")
+		return
+	}
+
+	err := services.GlobalRatingTracker.ForceRecalculate(movieID)
+	if errors.Is(err, services.ErrRecalculationQueued) {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "success",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "重新计算评分失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":  "success",
+		"data":    services.GlobalRatingTracker.GetMovieRatingThresholdStatus(movieID),
+		"message": "重新计算评分成功",
+	})
+}
+
+// GetRatingThresholds 获取所有追踪电影的评分阈值进度
+// @Summary 获取追踪电影的评分阈值进度
+// @Tags hotness
+// @Produce json
+// @Param limit query integer false "返回数量，最大500" default(50)
+// @Param needsRecalc query boolean false "只返回需要重新计算的电影"
+// @Success 200 {object} utils.Response{data=object} "data: count、limit、needsRecalc、thresholds（[]services.ThresholdStatus）"
+// @Router /api/hotness/thresholds [get]
+func (hc *HotnessController) GetRatingThresholds(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "50")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500 // 最大500部
+	}
+
+	needsRecalc, _ := strconv.ParseBool(c.DefaultQuery("needsRecalc", "false"))
+
+	statuses := services.GlobalRatingTracker.GetAllThresholdStatuses(limit, needsRecalc)
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"thresholds":  statuses,
+			"count":       len(statuses),
+			"limit":       limit,
+			"needsRecalc": needsRecalc,
+		},
+		"message": "获取评分阈值进度成功",
+	})
+}
+
 // GetMovieHotness 获取指定电影的热度信息
+// @Summary 获取电影热度
+// @Tags hotness
+// @Produce json
+// @Param id path string true "电影ID"
+// @Success 200 {object} utils.Response{data=services.MovieHotness}
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 404 {object} utils.ErrorResponse "电影未被追踪"
+// @Router /api/hotness/movie/{id} [get]
 func (hc *HotnessController) GetMovieHotness(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -87,6 +189,11 @@ func (hc *HotnessController) GetMovieHotness(c *gin.Context) {
 }
 
 // GetWriteStats 获取写入统计信息
+// @Summary 获取写入统计
+// @Tags hotness
+// @Produce json
+// @Success 200 {object} utils.Response{data=object} "data: totalWrites、totalMovies、lastHour、lastDay、sourceStats等"
+// @Router /api/hotness/stats [get]
 func (hc *HotnessController) GetWriteStats(c *gin.Context) {
 	stats := services.GlobalRatingTracker.GetWriteStats()
 
@@ -98,6 +205,12 @@ func (hc *HotnessController) GetWriteStats(c *gin.Context) {
 }
 
 // GetRecentWrites 获取最近的写入记录
+// @Summary 获取最近的写入记录
+// @Tags hotness
+// @Produce json
+// @Param limit query integer false "返回数量，最大500" default(50)
+// @Success 200 {object} utils.Response{data=object} "data: count、limit、records（[]services.RatingWriteRecord）"
+// @Router /api/hotness/writes [get]
 func (hc *HotnessController) GetRecentWrites(c *gin.Context) {
 	// 获取限制数量，默认50条
 	limitStr := c.DefaultQuery("limit", "50")
@@ -124,6 +237,14 @@ func (hc *HotnessController) GetRecentWrites(c *gin.Context) {
 }
 
 // GetHotnessRanking 获取热度排行榜
+// @Summary 获取热度排行榜
+// @Tags hotness
+// @Produce json
+// @Param type query string false "hotness、writeCount、avgRating或recent" default(hotness)
+// @Param limit query integer false "返回数量，最大100" default(50)
+// @Success 200 {object} utils.Response{data=object} "data: count、limit、ranking（[]services.MovieHotness）、type"
+// @Failure 500 {object} utils.ErrorResponse "获取热度排行榜失败"
+// @Router /api/hotness/ranking [get]
 func (hc *HotnessController) GetHotnessRanking(c *gin.Context) {
 	// 获取排行榜类型，默认为综合热度
 	rankType := c.DefaultQuery("type", "hotness")
@@ -190,8 +311,17 @@ func (hc *HotnessController) GetHotnessRanking(c *gin.Context) {
 	})
 }
 
-// GetHotnessTrends 获取热度趋势（简化版）
+// GetHotnessTrends 获取热度趋势（简化版），按tz参数（IANA时区名）所在时区的小时统计，
+// 未指定或时区无效时使用UTC
+// @Summary 获取按小时统计的写入趋势
+// @Tags hotness
+// @Produce json
+// @Param tz query string false "IANA时区名，如Asia/Shanghai，按该时区的小时统计；未指定或无效时使用UTC" default(UTC)
+// @Success 200 {object} utils.Response{data=object} "data: hourlyStats、peakHour、peakHourWrites、timezone、totalRecords、tzFallback"
+// @Router /api/hotness/trends [get]
 func (hc *HotnessController) GetHotnessTrends(c *gin.Context) {
+	location, tzFallback := trendsLocation(c.Query("tz"))
+
 	// 获取最近的写入记录
 	records := services.GlobalRatingTracker.GetRecentWrites(1000)
 
@@ -200,7 +330,7 @@ func (hc *HotnessController) GetHotnessTrends(c *gin.Context) {
 	movieHourlyStats := make(map[string]map[int]int)
 
 	for _, record := range records {
-		hour := record.Timestamp.Hour()
+		hour := record.Timestamp.In(location).Hour()
 		hourlyStats[hour]++
 
 		if movieHourlyStats[record.MovieID] == nil {
@@ -226,7 +356,47 @@ func (hc *HotnessController) GetHotnessTrends(c *gin.Context) {
 			"peakHour":       peakHour,
 			"peakHourWrites": maxWrites,
 			"totalRecords":   len(records),
+			"timezone":       location.String(),
+			"tzFallback":     tzFallback,
 		},
 		"message": "获取热度趋势成功",
 	})
 }
+
+// trendsLocation 解析tz参数，为空时使用UTC；无法识别的时区也使用UTC，fallback为true
+func trendsLocation(tz string) (location *time.Location, fallback bool) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" {
+		return time.UTC, false
+	}
+	// time.LoadLocation把"Local"解释为服务器时区，这里只接受IANA时区名
+	if tz == "Local" {
+		return time.UTC, true
+	}
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.UTC, true
+	}
+	return location, false
+}
+
+// ExportHotness 以可下载的JSON导出热度追踪快照，includeWrites=true时包含最近写入记录
+// @Summary 导出热度追踪快照（JSON附件）
+// @Tags hotness
+// @Produce json
+// @Param includeWrites query boolean false "包含最近写入记录"
+// @Success 200 {object} map[string]interface{} "exportTime、totalMovies、hotness（[]services.MovieHotness），includeWrites时还有writeRecords（[]services.RatingWriteRecord）"
+// @Router /api/hotness/export [get]
+func (hc *HotnessController) ExportHotness(c *gin.Context) {
+	includeWrites, _ := strconv.ParseBool(c.DefaultQuery("includeWrites", "false"))
+
+	filename := fmt.Sprintf("hotness-export-%s.json", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，出错时只能记录日志
+	if err := services.GlobalRatingTracker.ExportSnapshot(c.Request.Context(), c.Writer, includeWrites); err != nil {
+		logrus.Errorf("导出热度快照失败: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"gohbase/config"
+	"gohbase/utils"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMain 把索引文件放到临时目录并初始化内存缓存，测试不读写工作目录下的索引
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	hbasetest.Main(m, func(string) { utils.InitCache(config.GetConfig()) })
+}
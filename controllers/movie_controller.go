@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"gohbase/models"
 	"gohbase/services"
 	"gohbase/utils"
+	"math"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // MovieController 电影控制器
@@ -20,7 +26,20 @@ func NewMovieController() *MovieController {
 	}
 }
 
-// GetMovies 获取电影列表
+// GetMovies 获取电影列表，带tag参数时只返回带有该标签的电影，fields参数只返回所选字段。
+// 带cursor参数时使用游标分页（忽略page），cursor为空表示第一页。
+// @Summary 获取电影列表，tag参数只返回带有该标签的电影
+// @Tags movies
+// @Produce json
+// @Param page query integer false "页码" default(1)
+// @Param per_page query integer false "每页数量，最大50" default(12)
+// @Param tag query string false "标签（不区分大小写、精确匹配），分页信息为过滤后的总数"
+// @Param cursor query string false "游标分页：取上一页响应的nextCursor，空值表示第一页；使用时忽略page，不能与tag同时使用"
+// @Param fields query string false "逗号分隔的电影字段，只返回这些字段（movieId总是返回），默认全部。可选: avgRating,cleanTitle,genres,links,movieId,tags,title,year"
+// @Success 200 {object} models.MovieList
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "获取电影列表失败"
+// @Router /api/movies [get]
 func (mc *MovieController) GetMovies(c *gin.Context) {
 	page := getIntParam(c, "page", 1)
 	perPage := getIntParam(c, "per_page", 12)
@@ -30,16 +49,267 @@ func (mc *MovieController) GetMovies(c *gin.Context) {
 		perPage = 50
 	}
 
-	movies, err := mc.movieService.GetMoviesList(page, perPage)
+	fields, err := models.ParseMovieFields(c.Query("fields"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		if strings.TrimSpace(c.Query("tag")) != "" {
+			utils.BadRequest(c, "cursor不能与tag同时使用")
+			return
+		}
+		movies, err := mc.movieService.GetMoviesListAfter(cursor, perPage, fields)
+		if errors.Is(err, models.ErrInvalidCursor) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		if err != nil {
+			utils.InternalError(c, "获取电影列表失败", err)
+			return
+		}
+		utils.SuccessData(c, fields.ProjectList(withCursorLinks(c, movies)))
+		return
+	}
+
+	if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+		movies, err := mc.movieService.GetMoviesByTag(tag, page, perPage, fields)
+		if err != nil {
+			utils.InternalError(c, "获取标签电影列表失败", err)
+			return
+		}
+		utils.SuccessData(c, fields.ProjectList(withPageLinks(c, movies)))
+		return
+	}
+
+	movies, err := mc.movieService.GetMoviesList(page, perPage, fields)
 	if err != nil {
 		utils.InternalError(c, "获取电影列表失败", err)
 		return
 	}
 
-	utils.SuccessData(c, movies)
+	utils.SuccessData(c, fields.ProjectList(withPageLinks(c, movies)))
+}
+
+// withPageLinks 返回带分页导航URL的列表副本（列表可能来自缓存，不能直接修改）。
+// URL基于当前请求，保留其他查询参数，只替换page和per_page。
+func withPageLinks(c *gin.Context, list *models.MovieList) *models.MovieList {
+	if list == nil {
+		return nil
+	}
+
+	pageURL := func(page int) *string {
+		u := *c.Request.URL
+		query := u.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(list.PerPage))
+		u.RawQuery = query.Encode()
+		s := u.RequestURI()
+		return &s
+	}
+
+	lastPage := list.TotalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := map[string]*string{
+		"self":  pageURL(list.Page),
+		"first": pageURL(1),
+		"last":  pageURL(lastPage),
+		"next":  nil,
+		"prev":  nil,
+	}
+	if list.Page < lastPage {
+		links["next"] = pageURL(list.Page + 1)
+	}
+	if list.Page > 1 {
+		links["prev"] = pageURL(min(list.Page-1, lastPage))
+	}
+
+	result := *list
+	result.Links = links
+	return &result
+}
+
+// withCursorLinks 返回带游标导航URL（self、first、next）的列表副本，没有下一页时next为null
+func withCursorLinks(c *gin.Context, list *models.MovieList) *models.MovieList {
+	cursorURL := func(cursor string) *string {
+		u := *c.Request.URL
+		query := u.Query()
+		query.Del("page")
+		query.Set("cursor", cursor)
+		query.Set("per_page", strconv.Itoa(list.PerPage))
+		u.RawQuery = query.Encode()
+		s := u.RequestURI()
+		return &s
+	}
+
+	links := map[string]*string{
+		"self":  cursorURL(c.Query("cursor")),
+		"first": cursorURL(""),
+		"next":  nil,
+	}
+	if list.NextCursor != "" {
+		links["next"] = cursorURL(list.NextCursor)
+	}
+
+	result := *list
+	result.Links = links
+	return &result
+}
+
+// GetMoviesByYear 分页获取指定年份的电影
+// @Summary 获取指定年份的电影（年份取自标题末尾，范围1888到今年）
+// @Tags movies
+// @Produce json
+// @Param year path integer true "年份"
+// @Param page query integer false "页码" default(1)
+// @Param per_page query integer false "每页数量，最大50" default(12)
+// @Success 200 {object} models.MovieList
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "获取年份电影列表失败"
+// @Router /api/movies/year/{year} [get]
+func (mc *MovieController) GetMoviesByYear(c *gin.Context) {
+	year, err := models.ParseMovieYear(c.Param("year"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	page := getIntParam(c, "page", 1)
+	perPage := getIntParam(c, "per_page", 12)
+	if perPage > 50 {
+		perPage = 50
+	}
+
+	movies, err := mc.movieService.GetMoviesByYear(year, page, perPage)
+	if err != nil {
+		utils.InternalError(c, "获取年份电影列表失败", err)
+		return
+	}
+
+	utils.SuccessData(c, withPageLinks(c, movies))
+}
+
+// GetMovieActivity 获取电影最近24小时、7天的评分数和最新的limit条评分
+// @Summary 获取电影最近24小时、7天的评分数和最新的评分（缓存30秒）
+// @Tags movies
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param limit query integer false "返回数量，最大50" default(5)
+// @Success 200 {object} map[string]interface{} "activity（models.MovieActivity）"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "获取电影评分动态失败"
+// @Router /api/movies/{id}/activity [get]
+func (mc *MovieController) GetMovieActivity(c *gin.Context) {
+	movieID := c.Param("id")
+	limit := getIntParam(c, "limit", models.DefaultActivityLatest)
+
+	activity, err := mc.movieService.GetMovieActivity(movieID, limit)
+	if err != nil {
+		utils.InternalError(c, "获取电影评分动态失败", err)
+		return
+	}
+	if activity == nil {
+		utils.NotFound(c, "电影不存在")
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"activity": activity,
+	})
+}
+
+// movieIDsRequest 按ID批量操作电影的请求体
+type movieIDsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// GetMoviesBatch 按ID批量获取电影，请求体{"ids": [...]}，去重后单次最多50部
+// @Summary 按ID批量获取电影（去重后单次最多50部）
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param body body movieIDsRequest true "请求体"
+// @Success 200 {object} map[string]interface{} "count、movies、notFound"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "批量获取电影失败"
+// @Router /api/movies/batch [post]
+func (mc *MovieController) GetMoviesBatch(c *gin.Context) {
+	var req movieIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	batch, err := mc.movieService.GetMoviesBatch(req.IDs)
+	if errors.Is(err, models.ErrInvalidMovieIDs) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "批量获取电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"movies":   batch.Movies,
+		"notFound": batch.NotFound,
+		"count":    len(batch.Movies),
+	})
+}
+
+// ExportMovies 以NDJSON（每行一部电影）流式导出电影数据，include选择导出的部分，limit默认10000、最大100000
+// @Summary 以NDJSON流式导出电影数据（每行一个ExportedMovie，按行键顺序）
+// @Tags movies
+// @Produce application/x-ndjson
+// @Security AdminKey
+// @Param include query string false "逗号分隔的info、stats、ratings、tags" default(info,stats)
+// @Param limit query integer false "最多导出的电影数，最大100000" default(10000)
+// @Success 200 {object} models.ExportedMovie "每行一个"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Router /api/export/movies [get]
+func (mc *MovieController) ExportMovies(c *gin.Context) {
+	include, err := models.ParseExportInclude(c.Query("include"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	limit := getIntParam(c, "limit", models.DefaultExportLimit)
+	if limit > models.MaxExportLimit {
+		limit = models.MaxExportLimit
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+
+	// 响应头已发送，出错时只能记录日志
+	exported, err := mc.movieService.ExportMovies(c.Request.Context(), c.Writer, include, limit)
+	if err != nil {
+		logrus.Errorf("导出电影失败（已导出 %d 部）: %v", exported, err)
+		return
+	}
+	logrus.Infof("导出电影 %d 部", exported)
 }
 
-// GetMovie 获取电影详情
+// GetMovie 获取电影详情，fields参数只返回movie中的所选字段。
+// 详情整体缓存，因此仍读取完整数据，只裁剪响应。
+// @Summary 获取电影详情：基本信息、评分分布、标签用户、相关度最高的基因标签和统计（各行并发读取，结果缓存）
+// @Tags movies
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param fields query string false "逗号分隔的电影字段，只返回这些字段（movieId总是返回），默认全部。可选: avgRating,cleanTitle,genres,links,movieId,tags,title,year"
+// @Success 200 {object} models.MovieDetail
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "获取电影详情失败"
+// @Router /api/movies/{id} [get]
 func (mc *MovieController) GetMovie(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -47,6 +317,12 @@ func (mc *MovieController) GetMovie(c *gin.Context) {
 		return
 	}
 
+	fields, err := models.ParseMovieFields(c.Query("fields"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
 	movie, err := mc.movieService.GetMovieByID(movieID)
 	if err != nil {
 		utils.InternalError(c, "获取电影详情失败", err)
@@ -58,30 +334,208 @@ func (mc *MovieController) GetMovie(c *gin.Context) {
 		return
 	}
 
-	utils.SuccessData(c, movie)
+	utils.SuccessData(c, fields.ProjectDetail(movie))
 }
 
-// GetRandomMovies 获取随机电影
+// GetRandomMovies 获取随机电影，genre、min_rating只返回该类型、平均评分不低于该值的电影。
+// 满足条件的电影不足count部时返回找到的电影，并设置Random-Partial: true响应头。
+// @Summary 获取随机电影，满足genre、min_rating的电影不足count部时返回找到的电影并设置Random-Partial: true响应头
+// @Tags movies
+// @Produce json
+// @Param count query integer false "数量" default(10)
+// @Param genre query string false "只返回该类型的电影（不区分大小写）"
+// @Param min_rating query number false "只返回平均评分不低于该值的电影，没有评分的电影按0计"
+// @Success 200 {array} models.Movie
+// @Failure 400 {object} utils.ErrorResponse "min_rating必须是0到5之间的数字"
+// @Failure 500 {object} utils.ErrorResponse "获取随机电影失败"
+// @Router /api/movies/random [get]
 func (mc *MovieController) GetRandomMovies(c *gin.Context) {
 	count := getIntParam(c, "count", 10)
 
-	movies, err := mc.movieService.GetRandomMovies(count)
+	filter := models.RandomMoviesFilter{Genre: c.Query("genre")}
+	if s := strings.TrimSpace(c.Query("min_rating")); s != "" {
+		minRating, err := strconv.ParseFloat(s, 64)
+		if err != nil || minRating < 0 || minRating > 5 {
+			utils.BadRequest(c, "min_rating必须是0到5之间的数字")
+			return
+		}
+		filter.MinRating = minRating
+	}
+
+	movies, partial, err := mc.movieService.GetRandomMovies(count, filter)
 	if err != nil {
 		utils.InternalError(c, "获取随机电影失败", err)
 		return
 	}
 
+	if partial {
+		c.Header("Random-Partial", "true")
+	}
 	utils.SuccessData(c, movies)
 }
 
+// rateMovieRequest 提交评分的请求体
+type rateMovieRequest struct {
+	UserID string  `json:"userId"`
+	Rating float64 `json:"rating"` // 0.5到5.0之间0.5的整数倍
+}
+
+// RateMovie 用户提交电影评分
+// @Summary 提交评分
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param Idempotency-Key header string false "幂等键，重复请求返回首次结果"
+// @Param body body rateMovieRequest true "请求体"
+// @Success 200 {object} map[string]interface{} "action、avgRating、movieId、rating、ratingCount、userId"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "提交评分失败"
+// @Router /api/movies/{id}/rate [post]
+func (mc *MovieController) RateMovie(c *gin.Context) {
+	movieID := c.Param("id")
+	if movieID == "" {
+		utils.BadRequest(c, "电影ID不能为空")
+		return
+	}
+
+	var req rateMovieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	req.UserID = strings.TrimSpace(req.UserID)
+	if req.UserID == "" {
+		utils.BadRequest(c, "用户ID不能为空")
+		return
+	}
+	// 评分必须是0.5的整数倍，范围0.5-5.0
+	if req.Rating < 0.5 || req.Rating > 5.0 || math.Mod(req.Rating*2, 1) != 0 {
+		utils.BadRequest(c, "评分必须是0.5到5.0之间0.5的整数倍")
+		return
+	}
+
+	result, err := mc.movieService.RateMovie(movieID, req.UserID, req.Rating)
+	if err != nil {
+		utils.InternalError(c, "提交评分失败", err)
+		return
+	}
+
+	if result == nil {
+		utils.NotFound(c, "电影不存在")
+		return
+	}
+
+	utils.SuccessData(c, result)
+}
+
+// movieTagRequest 添加标签的请求体
+type movieTagRequest struct {
+	UserID string `json:"userId"`
+	Tag    string `json:"tag"` // 1到50个字符，不能包含冒号，保存为小写
+}
+
+// AddMovieTag 用户为电影添加标签
+// @Summary 为电影添加标签
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param Idempotency-Key header string false "幂等键，重复请求返回首次结果"
+// @Param body body movieTagRequest true "请求体"
+// @Success 201 {object} map[string]interface{} "movieId、tag、userId"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 404 {object} utils.ErrorResponse "电影不存在"
+// @Failure 500 {object} utils.ErrorResponse "添加标签失败"
+// @Router /api/movies/{id}/tags [post]
+func (mc *MovieController) AddMovieTag(c *gin.Context) {
+	movieID := c.Param("id")
+	if movieID == "" {
+		utils.BadRequest(c, "电影ID不能为空")
+		return
+	}
+
+	var req movieTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	req.UserID = strings.TrimSpace(req.UserID)
+	if req.UserID == "" {
+		utils.BadRequest(c, "用户ID不能为空")
+		return
+	}
+
+	result, err := mc.movieService.AddMovieTag(movieID, req.UserID, req.Tag)
+	switch {
+	case errors.Is(err, models.ErrInvalidTag):
+		utils.BadRequest(c, err.Error())
+		return
+	case errors.Is(err, models.ErrMovieNotFound):
+		utils.NotFound(c, "电影不存在")
+		return
+	case err != nil:
+		utils.InternalError(c, "添加标签失败", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
 // RandomMoviesPost POST方式获取随机电影
+// @Summary 获取随机电影（POST）
+// @Tags movies
+// @Produce json
+// @Param count query integer false "数量" default(10)
+// @Param genre query string false "只返回该类型的电影（不区分大小写）"
+// @Param min_rating query number false "只返回平均评分不低于该值的电影，没有评分的电影按0计"
+// @Success 200 {array} models.Movie
+// @Router /api/movies/random [post]
 func (mc *MovieController) RandomMoviesPost(c *gin.Context) {
 	mc.GetRandomMovies(c)
 }
 
 // SearchMovies 搜索电影
+// @Summary 搜索电影
+// @Tags movies
+// @Produce json
+// @Param q query string false "搜索关键词；tt0111161、imdb:0111161或tmdb:278按外部ID精确查找"
+// @Param search_type query string false "title、genre、tag或all" default(all)
+// @Param rank query string false "relevance（匹配度）、popularity（匹配度+评分人数）或rating（匹配度+贝叶斯平均评分），仅索引搜索生效" default(relevance)
+// @Param tag query string false "q为空时等同于 q=tag&search_type=tag"
+// @Param page query integer false "页码" default(1)
+// @Param per_page query integer false "每页数量，最大50" default(12)
+// @Param fields query string false "逗号分隔的电影字段，只返回这些字段（movieId总是返回），默认全部。可选: avgRating,cleanTitle,genres,links,movieId,tags,title,year"
+// @Success 200 {object} models.MovieList
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "搜索电影失败"
+// @Router /api/movies/search [get]
 func (mc *MovieController) SearchMovies(c *gin.Context) {
 	query := c.Query("q")
+	searchType, err := models.ParseSearchType(c.Query("search_type"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	rank, err := models.ParseRankMode(c.Query("rank"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	fields, err := models.ParseMovieFields(c.Query("fields"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	// ?tag=xxx 是 ?q=xxx&search_type=tag 的简写
+	if tag := c.Query("tag"); query == "" && tag != "" {
+		query = tag
+		searchType = models.SearchTypeTag
+	}
 	if query == "" {
 		utils.BadRequest(c, "搜索关键词不能为空")
 		return
@@ -90,16 +544,57 @@ func (mc *MovieController) SearchMovies(c *gin.Context) {
 	page := getIntParam(c, "page", 1)
 	perPage := getIntParam(c, "per_page", 12)
 
-	result, err := mc.movieService.SearchMovies(query, page, perPage)
+	result, err := mc.movieService.SearchMovies(query, searchType, rank, page, perPage, fields)
 	if err != nil {
 		utils.InternalError(c, "搜索电影失败", err)
 		return
 	}
 
-	utils.SuccessData(c, result)
+	utils.SuccessData(c, fields.ProjectList(result))
 }
 
-// GetMovieRatings 获取电影评分
+// SuggestMovies 搜索框输入联想，只查询SQLite索引
+// @Summary 标题输入联想（只查询SQLite索引，索引未构建时返回空列表）
+// @Tags movies
+// @Produce json
+// @Param q query string true "标题前缀"
+// @Param limit query integer false "返回数量，最大20" default(8)
+// @Success 200 {object} map[string]interface{} "count、suggestions（[]models.MovieSuggestion）"
+// @Failure 500 {object} utils.ErrorResponse "获取输入联想失败"
+// @Router /api/movies/suggest [get]
+func (mc *MovieController) SuggestMovies(c *gin.Context) {
+	query := c.Query("q")
+	limit := getIntParam(c, "limit", models.DefaultSuggestLimit)
+
+	suggestions, err := mc.movieService.SuggestMovies(query, limit)
+	if err != nil {
+		utils.InternalError(c, "获取输入联想失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":      "success",
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+	})
+}
+
+// GetMovieRatings 分页获取电影评分，sort、order指定排序（默认最新的在前），min_rating、max_rating过滤评分范围；
+// count和平均、最低、最高分基于全部评分
+// @Summary 分页获取电影评分，可排序和按评分过滤（默认最新的在前）
+// @Tags ratings
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param page query integer false "页码" default(1)
+// @Param per_page query integer false "每页数量，最大200" default(50)
+// @Param sort query string false "timestamp或rating，相同时按评分时间倒序" default(timestamp)
+// @Param order query string false "asc或desc" default(desc)
+// @Param min_rating query number false "只返回不低于该分的评分" default(0)
+// @Param max_rating query number false "只返回不高于该分的评分" default(5)
+// @Success 200 {object} map[string]interface{} "avgRating、count、filteredCount、maxRating、minRating、page、perPage、ratings、totalPages"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "获取电影评分失败"
+// @Router /api/ratings/movie/{id} [get]
 func (mc *MovieController) GetMovieRatings(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -107,31 +602,396 @@ func (mc *MovieController) GetMovieRatings(c *gin.Context) {
 		return
 	}
 
-	ratings, err := mc.movieService.GetMovieRatings(movieID)
+	query := models.DefaultRatingsQuery()
+	query.Page = getIntParam(c, "page", 1)
+	query.PerPage = getIntParam(c, "per_page", models.DefaultRatingsPerPage)
+	if query.PerPage > models.MaxRatingsPerPage {
+		query.PerPage = models.MaxRatingsPerPage
+	}
+
+	var err error
+	query.Sort, query.Desc, err = models.ParseRatingsSort(c.Query("sort"), c.Query("order"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	query.MinRating, query.MaxRating, err = models.ParseRatingRange(c.Query("min_rating"), c.Query("max_rating"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	ratings, err := mc.movieService.GetMovieRatingsPage(movieID, query)
 	if err != nil {
 		utils.InternalError(c, "获取电影评分失败", err)
 		return
 	}
 
-	if ratings == nil {
+	utils.SuccessData(c, gin.H{
+		"status":        "success",
+		"ratings":       ratings["ratings"],
+		"count":         ratings["count"],
+		"avgRating":     ratings["avgRating"],
+		"minRating":     ratings["minRating"],
+		"maxRating":     ratings["maxRating"],
+		"filteredCount": ratings["filteredCount"],
+		"page":          ratings["page"],
+		"perPage":       ratings["perPage"],
+		"totalPages":    ratings["totalPages"],
+	})
+}
+
+// GetRecentlyAddedMovies 按添加时间倒序获取最近添加的电影
+// @Summary 按添加时间倒序获取最近添加的电影（只查询SQLite索引，缓存1分钟）
+// @Tags movies
+// @Produce json
+// @Param limit query integer false "返回数量，最大50" default(12)
+// @Success 200 {object} map[string]interface{} "count、movies（[]models.RecentMovie）"
+// @Failure 500 {object} utils.ErrorResponse "获取最近添加的电影失败"
+// @Router /api/movies/recent [get]
+func (mc *MovieController) GetRecentlyAddedMovies(c *gin.Context) {
+	limit := getIntParam(c, "limit", models.DefaultRecentMovies)
+
+	movies, err := mc.movieService.GetRecentlyAddedMovies(limit)
+	if err != nil {
+		utils.InternalError(c, "获取最近添加的电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"movies": movies,
+		"count":  len(movies),
+	})
+}
+
+// GetYearStats 获取每个上映年份的电影数、平均评分和评分数，from、to限定年份范围
+// @Summary 获取每个上映年份的电影数、平均评分和评分数，按年份升序，没有年份的电影归入unknown（索引未就绪时返回503）
+// @Tags movies
+// @Produce json
+// @Param from query integer false "最早的年份（含），指定from或to时不返回unknown"
+// @Param to query integer false "最晚的年份（含）"
+// @Success 200 {object} map[string]interface{} "count、years（[]models.YearStats）"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "获取年份统计失败"
+// @Failure 503 {object} utils.ErrorResponse "搜索索引未就绪"
+// @Router /api/stats/years [get]
+func (mc *MovieController) GetYearStats(c *gin.Context) {
+	from, to, err := models.ParseYearRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	years, err := mc.movieService.GetYearStats(from, to)
+	if errors.Is(err, models.ErrYearStatsUnavailable) {
+		utils.Error(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "获取年份统计失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"years":  years,
+		"count":  len(years),
+	})
+}
+
+// GetTopUsers 获取活跃用户排行：by=ratings|tags，limit每页数量，includeTest=true时包含压力测试生成的用户
+// @Summary 按评分数或标签数分页获取活跃用户排行，数据由后台任务定期扫描users表计算（尚未计算完成时返回503）
+// @Tags users
+// @Produce json
+// @Param by query string false "ratings或tags" default(ratings)
+// @Param limit query integer false "返回数量，最大200" default(50)
+// @Param page query integer false "页码" default(1)
+// @Param includeTest query boolean false "包含压力测试生成的用户" default(false)
+// @Success 200 {object} models.TopUsers
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "获取活跃用户排行失败"
+// @Failure 503 {object} utils.ErrorResponse "用户排行尚未计算完成"
+// @Router /api/users/top [get]
+func (mc *MovieController) GetTopUsers(c *gin.Context) {
+	by := c.DefaultQuery("by", models.TopUsersByRatings)
+	page := getIntParam(c, "page", 1)
+	limit := getIntParam(c, "limit", models.DefaultTopUsersLimit)
+	includeTest, _ := strconv.ParseBool(c.DefaultQuery("includeTest", "false"))
+
+	users, err := mc.movieService.GetTopUsers(by, page, limit, includeTest)
+	if errors.Is(err, models.ErrInvalidTopUsersBy) {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if errors.Is(err, models.ErrUserActivityNotReady) {
+		utils.Error(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "获取活跃用户排行失败", err)
+		return
+	}
+
+	utils.SuccessData(c, users)
+}
+
+// GetUserProfile 获取用户的评分数、平均评分、喜欢的类型、评分最高和最近评分的电影
+// @Summary 获取用户的评分数、平均评分、喜欢的类型、评分最高和最近评分的5部电影（缓存5分钟，没有评分时返回404）
+// @Tags users
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {object} models.UserProfile
+// @Failure 400 {object} utils.ErrorResponse "用户ID不能为空"
+// @Failure 404 {object} utils.ErrorResponse "用户不存在或没有评分"
+// @Failure 500 {object} utils.ErrorResponse "获取用户资料失败"
+// @Router /api/users/{id} [get]
+func (mc *MovieController) GetUserProfile(c *gin.Context) {
+	userID := strings.TrimSpace(c.Param("id"))
+	if userID == "" {
+		utils.BadRequest(c, "用户ID不能为空")
+		return
+	}
+
+	profile, err := mc.movieService.GetUserProfile(userID)
+	if err != nil {
+		utils.InternalError(c, "获取用户资料失败", err)
+		return
+	}
+	if profile == nil {
+		utils.NotFound(c, "用户不存在或没有评分")
+		return
+	}
+
+	utils.SuccessData(c, profile)
+}
+
+// GetUserTasteProfile 获取用户的口味概况，没有评分和标签的用户返回exists为false的空概况
+// @Summary 获取用户的口味概况：评分数、平均评分、标准差、评分倾向、常看的类型、常用的标签和首末活动时间（缓存5分钟，没有数据时exists为false）
+// @Tags users
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {object} models.UserTasteProfile
+// @Failure 400 {object} utils.ErrorResponse "用户ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "获取用户口味概况失败"
+// @Router /api/users/{id}/profile [get]
+func (mc *MovieController) GetUserTasteProfile(c *gin.Context) {
+	userID := strings.TrimSpace(c.Param("id"))
+	if userID == "" {
+		utils.BadRequest(c, "用户ID不能为空")
+		return
+	}
+
+	profile, err := mc.movieService.GetUserTasteProfile(userID)
+	if err != nil {
+		utils.InternalError(c, "获取用户口味概况失败", err)
+		return
+	}
+
+	utils.SuccessData(c, profile)
+}
+
+// GetGlobalRating 获取全局平均评分、电影数和评分数
+// @Summary 获取全部电影按评分数加权的平均评分（扫描_stats行，缓存1小时）
+// @Tags movies
+// @Produce json
+// @Success 200 {object} models.GlobalRating
+// @Failure 500 {object} utils.ErrorResponse "获取全局平均评分失败"
+// @Router /api/analytics/global-rating [get]
+func (mc *MovieController) GetGlobalRating(c *gin.Context) {
+	rating, err := mc.movieService.GetGlobalRating()
+	if err != nil {
+		utils.InternalError(c, "获取全局平均评分失败", err)
+		return
+	}
+
+	utils.SuccessData(c, rating)
+}
+
+// GetGenres 获取全部类型及其电影数，用于渲染筛选标签
+// @Summary 获取全部类型及其电影数
+// @Tags movies
+// @Produce json
+// @Success 200 {object} map[string]interface{} "count、genres（[]models.GenreCount）"
+// @Failure 500 {object} utils.ErrorResponse "获取类型列表失败"
+// @Router /api/genres [get]
+func (mc *MovieController) GetGenres(c *gin.Context) {
+	genres, err := mc.movieService.GetGenreCounts()
+	if err != nil {
+		utils.InternalError(c, "获取类型列表失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"genres": genres,
+		"count":  len(genres),
+	})
+}
+
+// GetGenreStats 获取各类型的平均分、电影数和评分数，window限定只统计评分追踪器中最近的评分
+// @Summary 获取各类型的平均分、电影数和评分数，按评分数降序（后台每10分钟计算，尚未计算完成时返回503）
+// @Tags movies
+// @Produce json
+// @Param window query string false "只统计评分追踪器中最近这段时间内的评分，如24h；为空时统计全部评分"
+// @Success 200 {object} map[string]interface{} "stats（models.GenreStatsReport）"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 500 {object} utils.ErrorResponse "获取类型统计失败"
+// @Failure 503 {object} utils.ErrorResponse "类型统计尚未计算完成"
+// @Router /api/stats/genres [get]
+func (mc *MovieController) GetGenreStats(c *gin.Context) {
+	window, err := models.ParseGenreStatsWindow(c.Query("window"))
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := mc.movieService.GetGenreStats(window)
+	if errors.Is(err, models.ErrGenreStatsNotReady) {
+		utils.Error(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "获取类型统计失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"stats":  report,
+	})
+}
+
+// GetPopularTags 获取热门标签及使用次数，用于标签云
+// @Summary 获取热门标签
+// @Tags movies
+// @Produce json
+// @Param limit query integer false "返回数量，最大200" default(50)
+// @Success 200 {object} map[string]interface{} "count、tags（[]models.TagCount）"
+// @Failure 500 {object} utils.ErrorResponse "获取热门标签失败"
+// @Router /api/tags/popular [get]
+func (mc *MovieController) GetPopularTags(c *gin.Context) {
+	limit := getIntParam(c, "limit", 50)
+	if limit > 200 {
+		limit = 200
+	}
+
+	tags, err := mc.movieService.GetPopularTags(limit)
+	if err != nil {
+		utils.InternalError(c, "获取热门标签失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"tags":   tags,
+		"count":  len(tags),
+	})
+}
+
+// GetUserRating 获取用户对电影的评分，用于展示"我的评分"
+// @Summary 获取用户对电影的评分
+// @Tags ratings
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param userId path string true "用户ID"
+// @Success 200 {object} map[string]interface{} "hasRated、movieId、rating、timestamp、userId"
+// @Failure 400 {object} utils.ErrorResponse "电影ID和用户ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "获取用户评分失败"
+// @Router /api/ratings/movie/{id}/user/{userId} [get]
+func (mc *MovieController) GetUserRating(c *gin.Context) {
+	movieID := c.Param("id")
+	userID := strings.TrimSpace(c.Param("userId"))
+	if movieID == "" || userID == "" {
+		utils.BadRequest(c, "电影ID和用户ID不能为空")
+		return
+	}
+
+	result, err := mc.movieService.GetUserRating(movieID, userID)
+	if err != nil {
+		utils.InternalError(c, "获取用户评分失败", err)
+		return
+	}
+
+	utils.SuccessData(c, result)
+}
+
+// GetSimilarMovies 获取相似电影
+// @Summary 获取相似电影
+// @Tags movies
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param limit query integer false "返回数量，最大50" default(10)
+// @Success 200 {object} map[string]interface{} "count、movieId、similar（[]models.SimilarMovie）"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "获取相似电影失败"
+// @Router /api/movies/{id}/similar [get]
+func (mc *MovieController) GetSimilarMovies(c *gin.Context) {
+	movieID := c.Param("id")
+	if movieID == "" {
+		utils.BadRequest(c, "电影ID不能为空")
+		return
+	}
+
+	limit := getIntParam(c, "limit", 10)
+	if limit > 50 {
+		limit = 50
+	}
+
+	similar, err := mc.movieService.GetSimilarMovies(movieID, limit)
+	if err != nil {
+		utils.InternalError(c, "获取相似电影失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":  "success",
+		"movieId": movieID,
+		"similar": similar,
+		"count":   len(similar),
+	})
+}
+
+// GetMovieSimilarity 获取两部电影的基因相似度，范围[0,1]；任一电影没有基因数据时为-1
+// @Summary 获取两部电影的基因相似度
+// @Tags movies
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param otherId path string true "另一部电影ID"
+// @Success 200 {object} map[string]interface{} "method、movieId、otherId、reason、similarity"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "计算电影相似度失败"
+// @Router /api/movies/{id}/similarity/{otherId} [get]
+func (mc *MovieController) GetMovieSimilarity(c *gin.Context) {
+	movieID := c.Param("id")
+	otherID := c.Param("otherId")
+	if movieID == "" || otherID == "" {
+		utils.BadRequest(c, "电影ID不能为空")
+		return
+	}
+
+	similarity, err := mc.movieService.GetGenomeSimilarity(movieID, otherID)
+	if errors.Is(err, models.ErrNoGenomeData) {
 		utils.SuccessData(c, gin.H{
-			"status":    "success",
-			"ratings":   []interface{}{},
-			"count":     0,
-			"avgRating": 0.0,
-			"minRating": 0.0,
-			"maxRating": 0.0,
+			"status":     "success",
+			"movieId":    movieID,
+			"otherId":    otherID,
+			"similarity": -1,
+			"reason":     err.Error(),
 		})
 		return
 	}
+	if err != nil {
+		utils.InternalError(c, "计算电影相似度失败", err)
+		return
+	}
 
 	utils.SuccessData(c, gin.H{
-		"status":    "success",
-		"ratings":   ratings["ratings"],
-		"count":     ratings["count"],
-		"avgRating": ratings["avgRating"],
-		"minRating": ratings["minRating"],
-		"maxRating": ratings["maxRating"],
+		"status":     "success",
+		"movieId":    movieID,
+		"otherId":    otherID,
+		"similarity": similarity,
+		"method":     "genome_cosine",
 	})
 }
 
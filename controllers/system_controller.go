@@ -1,11 +1,21 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
+	"gohbase/config"
+	"gohbase/models"
+	"gohbase/services"
 	"gohbase/utils"
+	"gohbase/utils/hbase"
+	"net/http"
 	"runtime"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // SystemController 系统控制器
@@ -17,15 +27,163 @@ func NewSystemController() *SystemController {
 }
 
 // GetSystemLogs 获取系统日志
+// @Summary 获取系统日志
+// @Tags system
+// @Produce json
+// @Param limit query integer false "返回数量，最大1000" default(100)
+// @Param level query string false "最低日志级别，如warn"
+// @Success 200 {object} map[string]interface{} "count、level、limit、logs（[]utils.LogEntry）"
+// @Failure 400 {object} utils.ErrorResponse "无效的日志级别"
+// @Router /api/system/logs [get]
 func (sc *SystemController) GetSystemLogs(c *gin.Context) {
+	limit := getIntParam(c, "limit", 100)
+	if limit > utils.SystemLogs.Capacity() {
+		limit = utils.SystemLogs.Capacity()
+	}
+
+	// level参数表示最低严重级别，例如 level=warn 返回 warning/error/fatal/panic
+	minLevel := logrus.TraceLevel
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := logrus.ParseLevel(levelStr)
+		if err != nil {
+			utils.BadRequest(c, "无效的日志级别")
+			return
+		}
+		minLevel = level
+	}
+
+	logs := utils.SystemLogs.Recent(limit, minLevel)
+
 	utils.SuccessData(c, gin.H{
-		"status":  "success",
-		"message": "系统日志功能待实现",
-		"logs":    []string{},
+		"status": "success",
+		"logs":   logs,
+		"count":  len(logs),
+		"level":  minLevel.String(),
+		"limit":  limit,
+	})
+}
+
+// logLevelRequest 调整日志级别的请求体
+type logLevelRequest struct {
+	Level string `json:"level"` // panic、fatal、error、warn、info、debug或trace
+}
+
+// SetLogLevel 运行时调整日志级别
+// @Summary 运行时调整日志级别
+// @Tags system
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param body body logLevelRequest true "请求体"
+// @Success 200 {object} map[string]interface{} "level、previous"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效，需要提供level"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Router /api/system/log-level [put]
+func (sc *SystemController) SetLogLevel(c *gin.Context) {
+	var req logLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Level == "" {
+		utils.BadRequest(c, "请求参数无效，需要提供level")
+		return
+	}
+
+	level, err := logrus.ParseLevel(req.Level)
+	if err != nil {
+		utils.BadRequest(c, "无效的日志级别，可选值: panic, fatal, error, warn, info, debug, trace")
+		return
+	}
+
+	previous := logrus.GetLevel()
+	logrus.SetLevel(level)
+	logrus.Warnf("日志级别已从 %s 调整为 %s", previous, level)
+
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"level":    logrus.GetLevel().String(),
+		"previous": previous.String(),
+	})
+}
+
+// GetSearchConfig 获取当前搜索配置
+// @Summary 获取搜索配置
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "search（config.SearchConfig）"
+// @Router /api/system/search-config [get]
+func (sc *SystemController) GetSearchConfig(c *gin.Context) {
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"search": config.GetConfig().GetSearchConfig(),
+	})
+}
+
+// searchConfigRequest 更新搜索配置的请求体，省略的字段不修改
+type searchConfigRequest struct {
+	MaxScanRows         *int  `json:"max_scan_rows"`
+	MaxResults          *int  `json:"max_results"`
+	EnableIndexFallback *bool `json:"enable_index_fallback"`
+}
+
+// UpdateSearchConfig 运行时更新搜索配置，只修改请求中提供的字段
+// @Summary 运行时更新搜索配置，只修改提供的字段
+// @Tags system
+// @Accept json
+// @Produce json
+// @Security AdminKey
+// @Param body body searchConfigRequest true "请求体"
+// @Success 200 {object} map[string]interface{} "clearedCaches、search（config.SearchConfig）"
+// @Failure 400 {object} utils.ErrorResponse "请求参数无效"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Router /api/system/search-config [patch]
+func (sc *SystemController) UpdateSearchConfig(c *gin.Context) {
+	var req searchConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	cfg := config.GetConfig()
+	searchCfg := cfg.GetSearchConfig()
+
+	if req.MaxScanRows != nil {
+		if *req.MaxScanRows <= 0 {
+			utils.BadRequest(c, "max_scan_rows必须大于0")
+			return
+		}
+		searchCfg.MaxScanRows = *req.MaxScanRows
+	}
+	if req.MaxResults != nil {
+		if *req.MaxResults <= 0 {
+			utils.BadRequest(c, "max_results必须大于0")
+			return
+		}
+		searchCfg.MaxResults = *req.MaxResults
+	}
+	if req.EnableIndexFallback != nil {
+		searchCfg.EnableIndexFallback = req.EnableIndexFallback
+	}
+
+	cfg.SetSearchConfig(searchCfg)
+
+	// 旧配置下缓存的搜索结果不再有效
+	cleared := utils.Cache.DeletePrefix("search:")
+	logrus.Warnf("搜索配置已更新: max_scan_rows=%d, max_results=%d, enable_index_fallback=%t",
+		searchCfg.MaxScanRows, searchCfg.MaxResults, *searchCfg.EnableIndexFallback)
+
+	utils.SuccessData(c, gin.H{
+		"status":        "success",
+		"search":        cfg.GetSearchConfig(),
+		"clearedCaches": cleared,
 	})
 }
 
 // GetCacheStats 获取缓存统计
+// @Summary 获取缓存统计
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "stats（cache.CacheStats）"
+// @Router /api/system/cache [get]
 func (sc *SystemController) GetCacheStats(c *gin.Context) {
 	stats := utils.Cache.Stats()
 
@@ -35,24 +193,293 @@ func (sc *SystemController) GetCacheStats(c *gin.Context) {
 	})
 }
 
-// BuildSearchIndex 构建搜索索引
+// searchIndexStatsPath 搜索索引状态查询地址
+const searchIndexStatsPath = "/api/system/search-index/stats"
+
+// searchIndexBuild 搜索索引构建状态：status、started_at、indexed_count、duration、error
+var (
+	searchIndexBuild   sync.Map
+	searchIndexBuildMu sync.Mutex
+)
+
+// BuildSearchIndex 在后台构建搜索索引，立即返回202
+// @Summary 在后台构建搜索索引
+// @Tags system
+// @Produce json
+// @Success 202 {object} map[string]interface{} "message、started_at"
+// @Failure 409 {object} utils.ErrorResponse "搜索索引正在构建中"
+// @Router /api/system/search-index/build [post]
 func (sc *SystemController) BuildSearchIndex(c *gin.Context) {
-	utils.SuccessData(c, gin.H{
-		"status":  "success",
-		"message": "搜索索引构建功能待实现",
+	searchIndexBuildMu.Lock()
+	if status, _ := searchIndexBuild.Load("status"); status == "building" {
+		searchIndexBuildMu.Unlock()
+		c.Header("Location", searchIndexStatsPath)
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": "搜索索引正在构建中",
+		})
+		return
+	}
+
+	startedAt := time.Now()
+	searchIndexBuild.Store("status", "building")
+	searchIndexBuild.Store("started_at", startedAt.Format(time.RFC3339))
+	searchIndexBuild.Delete("indexed_count")
+	searchIndexBuild.Delete("duration")
+	searchIndexBuild.Delete("error")
+	searchIndexBuildMu.Unlock()
+
+	// 请求结束后继续构建，不随请求取消
+	ctx := context.WithoutCancel(c.Request.Context())
+	go func() {
+		index := models.GetSearchIndex()
+		if err := index.BuildSearchIndex(ctx); err != nil {
+			logrus.Errorf("构建搜索索引失败: %v", err)
+			searchIndexBuild.Store("error", err.Error())
+			searchIndexBuild.Store("duration", time.Since(startedAt).String())
+			searchIndexBuild.Store("status", "failed")
+			return
+		}
+
+		count, err := index.IndexedCount()
+		if err != nil {
+			logrus.Warnf("读取索引电影数量失败: %v", err)
+		}
+		searchIndexBuild.Store("indexed_count", count)
+		searchIndexBuild.Store("duration", time.Since(startedAt).String())
+		searchIndexBuild.Store("status", "ready")
+	}()
+
+	c.Header("Location", searchIndexStatsPath)
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":     "building",
+		"message":    "搜索索引开始构建",
+		"started_at": startedAt.Format(time.RFC3339),
 	})
 }
 
+// searchIndexBuildStatus 获取搜索索引构建状态快照
+func searchIndexBuildStatus() gin.H {
+	status := gin.H{}
+	searchIndexBuild.Range(func(key, value interface{}) bool {
+		status[key.(string)] = value
+		return true
+	})
+	if _, ok := status["status"]; !ok {
+		status["status"] = "idle"
+	}
+	return status
+}
+
 // GetSearchIndexStats 获取搜索索引统计
+// @Summary 获取搜索索引统计和构建状态（stats.rebuilding为true时正在重建，期间继续使用当前索引）
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "build、stats（models.IndexStats）"
+// @Failure 500 {object} utils.ErrorResponse "获取搜索索引统计失败"
+// @Router /api/system/search-index/stats [get]
 func (sc *SystemController) GetSearchIndexStats(c *gin.Context) {
+	stats, err := models.GetSearchIndex().GetIndexStats()
+	if err != nil {
+		utils.InternalError(c, "获取搜索索引统计失败", err)
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"stats":  stats,
+		"build":  searchIndexBuildStatus(),
+	})
+}
+
+// statsRecomputeStatusPath stats回填进度查询地址
+const statsRecomputeStatusPath = "/api/system/stats/recompute/status"
+
+// RecomputeStats 回填电影_stats行。
+// 指定movieId时同步处理单部电影；否则在后台遍历全部电影，支持resumeFrom或resume=true从上次checkpoint继续。
+// @Summary 回填电影评分统计
+// @Tags system
+// @Produce json
+// @Security AdminKey
+// @Param movieId query string false "只同步处理该电影"
+// @Param resumeFrom query string false "从该电影ID之后继续"
+// @Param resume query boolean false "从上次checkpoint继续"
+// @Param workers query integer false "并发数"
+// @Param rate query integer false "每秒最多处理的电影数"
+// @Success 200 {object} map[string]interface{} "avgRating、movieId、ratingCount"
+// @Success 202 {object} map[string]interface{} "message、progress（services.RecomputeStatus）"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 404 {object} utils.ErrorResponse "电影没有可用的评分数据"
+// @Failure 409 {object} map[string]interface{} "已有回填在运行：status、message、progress（services.RecomputeStatus）"
+// @Failure 500 {object} utils.ErrorResponse "回填电影stats失败"
+// @Router /api/system/stats/recompute [post]
+func (sc *SystemController) RecomputeStats(c *gin.Context) {
+	ctx := context.WithoutCancel(c.Request.Context())
+
+	if movieID := c.Query("movieId"); movieID != "" {
+		avgRating, ratingCount, err := services.GlobalStatsRecompute.RecomputeOne(ctx, movieID)
+		if err != nil {
+			if ratingCount == 0 {
+				utils.NotFound(c, "电影没有可用的评分数据")
+				return
+			}
+			utils.InternalError(c, "回填电影stats失败", err)
+			return
+		}
+		utils.SuccessData(c, gin.H{
+			"status":      "success",
+			"movieId":     movieID,
+			"avgRating":   avgRating,
+			"ratingCount": ratingCount,
+		})
+		return
+	}
+
+	resumeFrom := c.Query("resumeFrom")
+	if resumeFrom == "" && c.Query("resume") == "true" {
+		resumeFrom = services.GlobalStatsRecompute.LastCheckpoint()
+	}
+
+	status, err := services.GlobalStatsRecompute.Start(ctx, services.RecomputeOptions{
+		ResumeFrom: resumeFrom,
+		Workers:    getIntParam(c, "workers", 0),
+		Rate:       getIntParam(c, "rate", 0),
+	})
+	c.Header("Location", statsRecomputeStatusPath)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":   "error",
+			"message":  err.Error(),
+			"progress": status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":   "running",
+		"message":  "stats回填任务已启动",
+		"progress": status,
+	})
+}
+
+// GetStatsRecomputeStatus 获取stats回填进度
+// @Summary 获取回填进度
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "progress（services.RecomputeStatus）"
+// @Router /api/system/stats/recompute/status [get]
+func (sc *SystemController) GetStatsRecomputeStatus(c *gin.Context) {
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"progress": services.GlobalStatsRecompute.Status(),
+	})
+}
+
+// integrityReportPath 一致性检查报告查询地址
+const integrityReportPath = "/api/system/verify/report"
+
+// VerifyIntegrity 在后台检查_ratings、_stats和users表的一致性。
+// sample指定随机抽查的电影数（默认全量），userSample指定反向抽查的用户数（-1跳过），repair=true时以_ratings为准修复。
+// @Summary 检查评分、统计和用户表的一致性
+// @Tags system
+// @Produce json
+// @Security AdminKey
+// @Param sample query integer false "随机抽查的电影数，0表示全量" default(0)
+// @Param userSample query integer false "反向抽查的用户数，-1跳过" default(0)
+// @Param workers query integer false "并发数"
+// @Param repair query boolean false "以_ratings为准修复"
+// @Success 202 {object} map[string]interface{} "message、report（models.IntegrityReport）"
+// @Failure 400 {object} utils.ErrorResponse "sample必须是非负整数"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Failure 409 {object} map[string]interface{} "已有检查在运行：status、message、report（models.IntegrityReport）"
+// @Router /api/system/verify [post]
+func (sc *SystemController) VerifyIntegrity(c *gin.Context) {
+	sample, err := strconv.Atoi(c.DefaultQuery("sample", "0"))
+	if err != nil || sample < 0 {
+		utils.BadRequest(c, "sample必须是非负整数")
+		return
+	}
+	userSample, err := strconv.Atoi(c.DefaultQuery("userSample", "0"))
+	if err != nil || userSample < -1 {
+		utils.BadRequest(c, "userSample必须是非负整数或-1")
+		return
+	}
+
+	opts := models.IntegrityOptions{
+		SampleSize:     sample,
+		UserSampleSize: userSample,
+		Workers:        getIntParam(c, "workers", 0),
+		Repair:         c.Query("repair") == "true",
+	}
+
+	report, err := services.GlobalIntegrity.Start(context.WithoutCancel(c.Request.Context()), opts)
+	c.Header("Location", integrityReportPath)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"status":  "error",
+			"message": err.Error(),
+			"report":  report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":  "running",
+		"message": "一致性检查已启动",
+		"report":  report,
+	})
+}
+
+// GetIntegrityReport 获取最近一次一致性检查的报告
+// @Summary 获取最近一次一致性检查报告
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "report（models.IntegrityReport）"
+// @Failure 404 {object} utils.ErrorResponse "尚未运行一致性检查"
+// @Router /api/system/verify/report [get]
+func (sc *SystemController) GetIntegrityReport(c *gin.Context) {
+	report := services.GlobalIntegrity.Report()
+	if report == nil {
+		utils.NotFound(c, "尚未运行一致性检查")
+		return
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status": "success",
+		"report": report,
+	})
+}
+
+// CancelIntegrityCheck 取消正在运行的一致性检查
+// @Summary 取消正在运行的一致性检查
+// @Tags system
+// @Produce json
+// @Security AdminKey
+// @Success 200 {object} map[string]interface{} "message"
+// @Failure 400 {object} utils.ErrorResponse "没有正在运行的一致性检查"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Router /api/system/verify/cancel [post]
+func (sc *SystemController) CancelIntegrityCheck(c *gin.Context) {
+	if !services.GlobalIntegrity.Cancel() {
+		utils.BadRequest(c, "没有正在运行的一致性检查")
+		return
+	}
+
 	utils.SuccessData(c, gin.H{
 		"status":  "success",
-		"message": "搜索索引统计功能待实现",
-		"stats":   gin.H{},
+		"message": "一致性检查已取消",
 	})
 }
 
 // GetHBasePerformanceStats 获取HBase性能统计
+// @Summary HBase操作统计和内存使用
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "data、recommendations"
+// @Router /api/system/performance [get]
 func (sc *SystemController) GetHBasePerformanceStats(c *gin.Context) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -69,6 +496,10 @@ func (sc *SystemController) GetHBasePerformanceStats(c *gin.Context) {
 				"heap_inuse_mb":      bToMb(m.HeapInuse),
 				"heap_released_mb":   bToMb(m.HeapReleased),
 			},
+			"hbase": gin.H{
+				"metrics_enabled": utils.HBaseMetricsEnabled(),
+				"operations":      utils.GetHBaseOperationStats(),
+			},
 			"goroutines": runtime.NumGoroutine(),
 			"timestamp":  time.Now().Format("2006-01-02 15:04:05"),
 		},
@@ -79,6 +510,11 @@ func (sc *SystemController) GetHBasePerformanceStats(c *gin.Context) {
 }
 
 // GetHBaseDiagnostics 获取HBase诊断信息
+// @Summary 诊断信息和最慢的HBase操作
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "diagnostics、slow_operations（[]hbase.SlowOperation）、suggestions、timestamp"
+// @Router /api/system/diagnostics [get]
 func (sc *SystemController) GetHBaseDiagnostics(c *gin.Context) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
@@ -89,16 +525,90 @@ func (sc *SystemController) GetHBaseDiagnostics(c *gin.Context) {
 			"memory_pressure":   sc.checkMemoryPressure(&m),
 			"gc_pressure":       sc.checkGCPressure(&m),
 			"goroutine_leak":    sc.checkGoroutineLeak(),
-			"connection_health": "需要实现HBase连接健康检查",
+			"connection_health": sc.checkConnectionHealth(),
 		},
-		"suggestions": sc.getOptimizationSuggestions(&m),
-		"timestamp":   time.Now().Format("2006-01-02 15:04:05"),
+		"slow_operations": utils.GetHBaseSlowOperations(),
+		"suggestions":     sc.getOptimizationSuggestions(&m),
+		"timestamp":       time.Now().Format("2006-01-02 15:04:05"),
 	}
 
 	utils.SuccessData(c, diagnostics)
 }
 
+// GetMetrics 以Prometheus文本格式输出HBase操作指标
+// @Summary Prometheus文本格式的HBase操作指标
+// @Tags system
+// @Produce plain
+// @Success 200 {string} string
+// @Router /metrics [get]
+func (sc *SystemController) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := utils.WriteHBaseMetrics(c.Writer); err != nil {
+		logrus.Warnf("输出指标失败: %v", err)
+	}
+}
+
+// GetPoolHealth 获取HBase连接池各客户端最近一次健康检查的结果
+// @Summary HBase连接池各客户端的健康检查结果
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "healthy、interval、slots（[]hbase.PoolSlotHealth）、total"
+// @Router /api/system/pool-health [get]
+func (sc *SystemController) GetPoolHealth(c *gin.Context) {
+	slots := utils.GetPoolHealth()
+
+	healthy := 0
+	for _, slot := range slots {
+		if slot.HealthStatus == hbase.PoolSlotHealthy {
+			healthy++
+		}
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":   "success",
+		"total":    len(slots),
+		"healthy":  healthy,
+		"interval": hbase.PoolHealthCheckInterval.String(),
+		"slots":    slots,
+	})
+}
+
+// maxGoroutineStacks 协程列表接口最多返回的协程数
+const maxGoroutineStacks = 200
+
+// GetGoroutines 列出当前协程的状态和调用栈函数，比pprof更轻量，便于快速排查阻塞
+// @Summary 列出协程状态和调用栈
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "goroutines（[]utils.GoroutineInfo）、returned、suspicious、total、truncated"
+// @Router /api/system/goroutines [get]
+func (sc *SystemController) GetGoroutines(c *gin.Context) {
+	goroutines, total := utils.ParseGoroutineStacks(utils.DumpGoroutineStacks(), maxGoroutineStacks)
+
+	suspicious := 0
+	for _, g := range goroutines {
+		if g.Suspicious {
+			suspicious++
+		}
+	}
+
+	utils.SuccessData(c, gin.H{
+		"status":     "success",
+		"total":      total,
+		"returned":   len(goroutines),
+		"truncated":  total > len(goroutines),
+		"suspicious": suspicious,
+		"goroutines": goroutines,
+	})
+}
+
 // ForceGC 强制垃圾回收
+// @Summary 强制垃圾回收
+// @Tags system
+// @Produce json
+// @Success 200 {object} map[string]interface{} "after、before、message"
+// @Router /api/system/gc [post]
 func (sc *SystemController) ForceGC(c *gin.Context) {
 	var beforeGC, afterGC runtime.MemStats
 	runtime.ReadMemStats(&beforeGC)
@@ -165,6 +675,20 @@ func (sc *SystemController) checkGoroutineLeak() string {
 	return "正常"
 }
 
+// 检查连接池健康状态
+func (sc *SystemController) checkConnectionHealth() string {
+	replaced := 0
+	for _, slot := range utils.GetPoolHealth() {
+		if slot.HealthStatus == hbase.PoolSlotReplaced {
+			replaced++
+		}
+	}
+	if replaced > 0 {
+		return fmt.Sprintf("最近一次检查有%d个连接失效并已替换", replaced)
+	}
+	return "正常"
+}
+
 // 获取性能建议
 func (sc *SystemController) getPerformanceRecommendations(m *runtime.MemStats) []string {
 	var recommendations []string
@@ -3,12 +3,16 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"gohbase/utils/hbase/rowkey"
+	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"gohbase/models"
 	"gohbase/services"
 	"gohbase/utils"
 
@@ -17,9 +21,11 @@ import (
 )
 
 // TestController 测试控制器 - 优化版本
+// 锁顺序：mu（统计和日志） < batchMu（批量缓冲区） < writesMu（写入记录），
+// 需要同时持有多把锁时必须按此顺序获取；HBase写入不在任何锁内进行。
 type TestController struct {
 	isRunning     int32 // 使用原子操作
-	stopChan      chan bool
+	dryRun        int32 // 模拟模式（原子操作）：完整执行生成和批处理，但不写入HBase
 	mu            sync.RWMutex
 	logs          []string
 	movieStats    map[string]int64 // 使用int64支持原子操作
@@ -31,6 +37,8 @@ type TestController struct {
 	batchBuffer []BatchWriteItem
 	batchMu     sync.Mutex
 	lastFlush   time.Time
+	rng         *rand.Rand // 本次任务的随机数源，由batchMu保护
+	seed        int64      // 本次任务的随机种子，由mu保护
 
 	// 新增：性能监控
 	writeLatency []time.Duration
@@ -39,6 +47,12 @@ type TestController struct {
 	// 新增：详细写入记录
 	recentWrites []WriteRecord
 	writesMu     sync.RWMutex
+
+	// 后台任务生命周期：lifecycleMu串行化启动和停止，
+	// cancel通知任务退出，done在任务完成最后一次刷新后关闭
+	lifecycleMu sync.Mutex
+	cancel      context.CancelFunc
+	done        chan struct{}
 }
 
 // BatchWriteItem 批量写入项
@@ -56,13 +70,13 @@ type WriteRecord struct {
 	Rating    float64   `json:"rating"`
 	Source    string    `json:"source"`
 	Timestamp time.Time `json:"timestamp"`
+	Simulated bool      `json:"simulated,omitempty"` // 模拟模式下的写入，未实际写入HBase
 }
 
 // NewTestController 创建测试控制器
 func NewTestController() *TestController {
 	return &TestController{
 		isRunning:    0,
-		stopChan:     make(chan bool),
 		logs:         make([]string, 0, 1000), // 预分配容量
 		movieStats:   make(map[string]int64),
 		batchSize:    50, // 批量大小
@@ -73,29 +87,69 @@ func NewTestController() *TestController {
 }
 
 // StartRandomRatings 开始随机写入评分数据 - 优化版本
+// @Summary 启动随机评分写入
+// @Tags test
+// @Produce json
+// @Param seed query integer false "随机种子，相同种子生成相同数据，默认为当前时间"
+// @Param dryRun query boolean false "只生成数据不写入HBase"
+// @Success 200 {object} map[string]interface{} "batchSize、dryRun、maxDuration、message、mode、seed、startTime"
+// @Failure 400 {object} utils.ErrorResponse "seed必须是整数"
+// @Router /api/test/ratings/start [post]
 func (tc *TestController) StartRandomRatings(c *gin.Context) {
-	if !atomic.CompareAndSwapInt32(&tc.isRunning, 0, 1) {
+	seed, err := parseSeed(c)
+	if err != nil {
+		utils.BadRequest(c, "seed必须是整数")
+		return
+	}
+
+	tc.lifecycleMu.Lock()
+	defer tc.lifecycleMu.Unlock()
+
+	if atomic.LoadInt32(&tc.isRunning) == 1 {
 		utils.BadRequest(c, "随机写入已在运行中")
 		return
 	}
+	// 上一次任务可能因超时结束但仍在做最后一次刷新，等它完全退出后再重置状态
+	if tc.done != nil {
+		<-tc.done
+	}
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if dryRun {
+		atomic.StoreInt32(&tc.dryRun, 1)
+	} else {
+		atomic.StoreInt32(&tc.dryRun, 0)
+	}
 
 	// 重置状态
 	tc.mu.Lock()
-	tc.stopChan = make(chan bool)
 	tc.logs = tc.logs[:0] // 重用切片，避免重新分配
 	tc.movieStats = make(map[string]int64)
 	atomic.StoreInt64(&tc.totalInserted, 0)
 	atomic.StoreInt64(&tc.errorCount, 0)
 	tc.startTime = time.Now()
-	tc.lastFlush = time.Now()
-	tc.batchBuffer = tc.batchBuffer[:0]
 	tc.writeLatency = tc.writeLatency[:0]
+	tc.seed = seed
 	tc.mu.Unlock()
 
+	tc.batchMu.Lock()
+	tc.lastFlush = time.Now()
+	tc.batchBuffer = tc.batchBuffer[:0]
+	tc.rng = rand.New(rand.NewSource(seed))
+	tc.batchMu.Unlock()
+
 	// 启动后台写入任务
-	go tc.runOptimizedRandomRatingsTask()
+	ctx, cancel := context.WithCancel(context.Background())
+	tc.cancel = cancel
+	tc.done = make(chan struct{})
+	atomic.StoreInt32(&tc.isRunning, 1)
+	go tc.runOptimizedRandomRatingsTask(ctx, tc.done)
 
-	tc.addLog("🚀 优化版随机评分写入任务已启动 (批量模式)")
+	if dryRun {
+		tc.addLog("🧪 优化版随机评分写入任务已启动 (模拟模式，不写入HBase)")
+	} else {
+		tc.addLog("🚀 优化版随机评分写入任务已启动 (批量模式)")
+	}
 
 	utils.SuccessData(c, gin.H{
 		"status":      "success",
@@ -103,29 +157,42 @@ func (tc *TestController) StartRandomRatings(c *gin.Context) {
 		"startTime":   tc.startTime.Format("2006-01-02 15:04:05"),
 		"maxDuration": "5分钟",
 		"batchSize":   tc.batchSize,
-		"mode":        "optimized_batch",
+		"mode":        tc.mode(),
+		"dryRun":      dryRun,
+		"seed":        seed,
 	})
 }
 
-// StopRandomRatings 停止随机写入评分数据
+// StopRandomRatings 停止随机写入评分数据，等待任务写完剩余数据后返回最终统计
+// @Summary 停止随机评分写入
+// @Tags test
+// @Produce json
+// @Success 200 {object} map[string]interface{} "duration、errorCount、latency、message、successRate、totalInserted"
+// @Failure 400 {object} utils.ErrorResponse "随机写入未在运行"
+// @Router /api/test/ratings/stop [post]
 func (tc *TestController) StopRandomRatings(c *gin.Context) {
-	if !atomic.CompareAndSwapInt32(&tc.isRunning, 1, 0) {
+	tc.lifecycleMu.Lock()
+	defer tc.lifecycleMu.Unlock()
+
+	if atomic.LoadInt32(&tc.isRunning) == 0 {
 		utils.BadRequest(c, "随机写入未在运行")
 		return
 	}
 
-	// 停止任务
-	close(tc.stopChan)
-
-	// 刷新剩余的批量数据
-	tc.flushBatch()
+	// 通知任务退出，并等待最后一次刷新完成
+	tc.cancel()
+	<-tc.done
 
 	duration := time.Since(tc.startTime)
 	totalInserted := atomic.LoadInt64(&tc.totalInserted)
 	errorCount := atomic.LoadInt64(&tc.errorCount)
 
-	tc.addLog(fmt.Sprintf("⏹️ 随机评分写入任务已停止，运行时长: %v, 成功: %d, 错误: %d",
-		duration, totalInserted, errorCount))
+	tc.mu.RLock()
+	latency := tc.latencyPercentilesLocked()
+	tc.mu.RUnlock()
+
+	tc.addLog(fmt.Sprintf("⏹️ 随机评分写入任务已停止，运行时长: %v, 成功: %d, 错误: %d, 延迟 p50/p95/p99: %s/%s/%s",
+		duration, totalInserted, errorCount, latency["p50"], latency["p95"], latency["p99"]))
 
 	utils.SuccessData(c, gin.H{
 		"status":        "success",
@@ -134,10 +201,42 @@ func (tc *TestController) StopRandomRatings(c *gin.Context) {
 		"totalInserted": totalInserted,
 		"errorCount":    errorCount,
 		"successRate":   fmt.Sprintf("%.2f%%", float64(totalInserted)/float64(totalInserted+errorCount)*100),
+		"latency":       latency,
 	})
 }
 
+// latencyPercentilesLocked 计算最近100次批量写入延迟的p50/p95/p99（调用方持有mu）
+func (tc *TestController) latencyPercentilesLocked() gin.H {
+	sorted := make([]time.Duration, len(tc.writeLatency))
+	copy(sorted, tc.writeLatency)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	// 最近秩法：第ceil(p*n)个值
+	percentile := func(p float64) string {
+		if len(sorted) == 0 {
+			return time.Duration(0).String()
+		}
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return sorted[rank].String()
+	}
+
+	return gin.H{
+		"p50":     percentile(0.50),
+		"p95":     percentile(0.95),
+		"p99":     percentile(0.99),
+		"samples": len(sorted),
+	}
+}
+
 // GetRandomRatingsStatus 获取随机写入状态 - 优化版本
+// @Summary 获取随机写入状态
+// @Tags test
+// @Produce json
+// @Success 200 {object} map[string]interface{} "avgLatency、batchSize、dryRun、duration、errorCount、isRunning、latency、mode、movieCount、ratingStats、seed、startTime、successRate、topMovie、totalInserted、writeRecords"
+// @Router /api/test/ratings/status [get]
 func (tc *TestController) GetRandomRatingsStatus(c *gin.Context) {
 	tc.mu.RLock()
 	tc.writesMu.RLock()
@@ -171,8 +270,11 @@ func (tc *TestController) GetRandomRatingsStatus(c *gin.Context) {
 		avgLatency = total / time.Duration(len(tc.writeLatency))
 	}
 
+	latency := tc.latencyPercentilesLocked()
+
 	// 计算评分统计
 	ratingStats := tc.calculateRatingStats()
+	seed := tc.seed
 
 	tc.writesMu.RUnlock()
 	tc.mu.RUnlock()
@@ -186,19 +288,41 @@ func (tc *TestController) GetRandomRatingsStatus(c *gin.Context) {
 		"errorCount":    errorCount,
 		"successRate":   fmt.Sprintf("%.2f%%", float64(totalInserted)/float64(totalInserted+errorCount)*100),
 		"avgLatency":    avgLatency.String(),
+		"latency":       latency,
 		"topMovie": gin.H{
 			"movieId": topMovie,
 			"count":   maxCount,
 		},
 		"movieCount":   len(tc.movieStats),
 		"batchSize":    tc.batchSize,
-		"mode":         "optimized_batch",
+		"mode":         tc.mode(),
+		"dryRun":       tc.isDryRun(),
+		"seed":         seed,
 		"ratingStats":  ratingStats,
 		"writeRecords": len(tc.recentWrites),
 	})
 }
 
+// isDryRun 是否处于模拟模式
+func (tc *TestController) isDryRun() bool {
+	return atomic.LoadInt32(&tc.dryRun) == 1
+}
+
+// mode 当前写入模式
+func (tc *TestController) mode() string {
+	if tc.isDryRun() {
+		return "dry_run"
+	}
+	return "optimized_batch"
+}
+
 // GetRandomRatingsLogs 获取随机写入日志
+// @Summary 获取随机写入日志
+// @Tags test
+// @Produce json
+// @Param limit query integer false "返回数量，最大500" default(50)
+// @Success 200 {object} map[string]interface{} "isRunning、logs、movieCount、ratingStats、recentWrites、topMovies、totalInserted"
+// @Router /api/test/ratings/logs [get]
 func (tc *TestController) GetRandomRatingsLogs(c *gin.Context) {
 	tc.mu.RLock()
 	tc.writesMu.RLock()
@@ -320,10 +444,16 @@ func (tc *TestController) calculateRatingStats() map[string]interface{} {
 	}
 }
 
-// runOptimizedRandomRatingsTask 运行优化的随机评分写入任务
-func (tc *TestController) runOptimizedRandomRatingsTask() {
+// runOptimizedRandomRatingsTask 运行优化的随机评分写入任务，直到ctx取消或达到5分钟。
+// 退出前刷新剩余的批量数据，然后清除运行标志并关闭done。
+func (tc *TestController) runOptimizedRandomRatingsTask(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	defer atomic.StoreInt32(&tc.isRunning, 0)
+	defer tc.flushBatch()
+
 	// 设置5分钟超时
-	timeout := time.After(5 * time.Minute)
+	timeout := time.NewTimer(5 * time.Minute)
+	defer timeout.Stop()
 
 	// 降低写入频率，改为每500ms生成一批数据
 	ticker := time.NewTicker(500 * time.Millisecond)
@@ -337,11 +467,10 @@ func (tc *TestController) runOptimizedRandomRatingsTask() {
 
 	for {
 		select {
-		case <-tc.stopChan:
+		case <-ctx.Done():
 			tc.addLog("🛑 收到停止信号，任务结束")
 			return
-		case <-timeout:
-			atomic.StoreInt32(&tc.isRunning, 0)
+		case <-timeout.C:
 			tc.addLog("⏰ 达到5分钟时间限制，任务自动结束")
 			return
 		case <-ticker.C:
@@ -356,23 +485,24 @@ func (tc *TestController) runOptimizedRandomRatingsTask() {
 
 // generateBatchData 生成批量数据
 func (tc *TestController) generateBatchData() {
+	var full []BatchWriteItem
+
 	tc.batchMu.Lock()
-	defer tc.batchMu.Unlock()
 
 	// 生成5-10个随机评分数据
-	batchCount := rand.Intn(6) + 5
+	batchCount := tc.rng.Intn(6) + 5
 
 	for i := 0; i < batchCount; i++ {
 		// 随机选择电影ID (1-50)
-		movieID := rand.Intn(50) + 1
+		movieID := tc.rng.Intn(50) + 1
 		movieIDStr := strconv.Itoa(movieID)
 
 		// 生成随机用户ID (10000-99999)
-		userID := rand.Intn(90000) + 10000
+		userID := tc.rng.Intn(90000) + 10000
 		userIDStr := strconv.Itoa(userID)
 
 		// 生成随机评分 (0.5-5.0, 步长0.5)
-		ratingFloat := (float64(rand.Intn(10)) + 1) * 0.5
+		ratingFloat := (float64(tc.rng.Intn(10)) + 1) * 0.5
 
 		tc.batchBuffer = append(tc.batchBuffer, BatchWriteItem{
 			MovieID: movieIDStr,
@@ -382,22 +512,40 @@ func (tc *TestController) generateBatchData() {
 		})
 	}
 
-	// 如果批量缓冲区满了，立即刷新
+	// 如果批量缓冲区满了，取出后立即刷新
 	if len(tc.batchBuffer) >= tc.batchSize {
-		tc.flushBatchUnsafe()
+		full = tc.takeBatchLocked()
 	}
+
+	tc.batchMu.Unlock()
+
+	tc.writeBatch(full)
 }
 
-// flushBatch 刷新批量数据（带锁）
+// flushBatch 刷新批量数据
 func (tc *TestController) flushBatch() {
 	tc.batchMu.Lock()
-	defer tc.batchMu.Unlock()
-	tc.flushBatchUnsafe()
+	items := tc.takeBatchLocked()
+	tc.batchMu.Unlock()
+
+	tc.writeBatch(items)
 }
 
-// flushBatchUnsafe 刷新批量数据（不带锁）
-func (tc *TestController) flushBatchUnsafe() {
+// takeBatchLocked 取出当前缓冲区中的数据并换上新缓冲区，调用方需持有batchMu
+func (tc *TestController) takeBatchLocked() []BatchWriteItem {
 	if len(tc.batchBuffer) == 0 {
+		return nil
+	}
+
+	items := tc.batchBuffer
+	tc.batchBuffer = make([]BatchWriteItem, 0, tc.batchSize)
+	tc.lastFlush = time.Now()
+	return items
+}
+
+// writeBatch 将取出的批量数据写入HBase并更新统计，不持有batchMu
+func (tc *TestController) writeBatch(items []BatchWriteItem) {
+	if len(items) == 0 {
 		return
 	}
 
@@ -405,32 +553,44 @@ func (tc *TestController) flushBatchUnsafe() {
 	ctx := context.Background()
 
 	// 批量写入到HBase
-	successCount, errorCount := tc.batchWriteToHBase(ctx, tc.batchBuffer)
+	successCount, errorCount := tc.batchWriteToHBase(ctx, items)
 
 	// 更新统计信息
 	atomic.AddInt64(&tc.totalInserted, int64(successCount))
 	atomic.AddInt64(&tc.errorCount, int64(errorCount))
 
-	// 计算本批次的统计信息（在清空缓冲区之前）
+	latency := time.Since(startTime)
+	tc.recordBatchStats(items, latency)
+
+	// 计算本批次的统计信息
 	var avgRating float64
 	userCount := make(map[string]bool)
-	batchSize := len(tc.batchBuffer)
-
-	for _, item := range tc.batchBuffer {
+	for _, item := range items {
 		avgRating += item.Rating
 		userCount[item.UserID] = true
 	}
+	avgRating /= float64(len(items))
 
-	if batchSize > 0 {
-		avgRating /= float64(batchSize)
+	// 记录详细日志
+	if successCount > 0 && tc.isDryRun() {
+		tc.addLog(fmt.Sprintf("🧪 [模拟] 批量写入完成: 成功 %d 条, 失败 %d 条, 耗时 %v | 平均评分: %.1f, 用户数: %d",
+			successCount, errorCount, latency, avgRating, len(userCount)))
+	} else if successCount > 0 {
+		tc.addLog(fmt.Sprintf("✅ 批量写入完成: 成功 %d 条, 失败 %d 条, 耗时 %v | 平均评分: %.1f, 用户数: %d",
+			successCount, errorCount, latency, avgRating, len(userCount)))
 	}
+}
 
-	// 更新电影统计和写入记录
+// recordBatchStats 更新电影统计、写入记录和延迟（按mu < writesMu顺序加锁）
+func (tc *TestController) recordBatchStats(items []BatchWriteItem, latency time.Duration) {
 	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	tc.writesMu.Lock()
+	defer tc.writesMu.Unlock()
 
 	timestamp := time.Now()
-	for _, item := range tc.batchBuffer {
+	simulated := tc.isDryRun()
+	for _, item := range items {
 		tc.movieStats[item.MovieID]++
 
 		// 记录详细写入信息
@@ -440,6 +600,7 @@ func (tc *TestController) flushBatchUnsafe() {
 			Rating:    item.Rating,
 			Source:    item.Source,
 			Timestamp: timestamp,
+			Simulated: simulated,
 		}
 		tc.recentWrites = append(tc.recentWrites, writeRecord)
 	}
@@ -449,102 +610,127 @@ func (tc *TestController) flushBatchUnsafe() {
 		tc.recentWrites = tc.recentWrites[len(tc.recentWrites)-500:]
 	}
 
-	// 记录延迟
-	latency := time.Since(startTime)
+	// 记录延迟，保持最近100次的延迟记录
 	if len(tc.writeLatency) >= 100 {
-		tc.writeLatency = tc.writeLatency[1:] // 保持最近100次的延迟记录
+		tc.writeLatency = tc.writeLatency[1:]
 	}
 	tc.writeLatency = append(tc.writeLatency, latency)
-
-	tc.writesMu.Unlock()
-	tc.mu.Unlock()
-
-	// 清空缓冲区
-	tc.batchBuffer = tc.batchBuffer[:0]
-	tc.lastFlush = time.Now()
-
-	// 记录详细日志
-	if successCount > 0 {
-		tc.addLog(fmt.Sprintf("✅ 批量写入完成: 成功 %d 条, 失败 %d 条, 耗时 %v | 平均评分: %.1f, 用户数: %d",
-			successCount, errorCount, latency, avgRating, len(userCount)))
-	}
 }
 
-// batchWriteToHBase 批量写入到HBase
+// batchWriteToHBase 批量写入到HBase：每部电影一个Put，通过连接池并发执行
 func (tc *TestController) batchWriteToHBase(ctx context.Context, items []BatchWriteItem) (int, int) {
 	if len(items) == 0 {
 		return 0, 0
 	}
 
 	var successCount, errorCount int
+	timestamp := time.Now().Unix()
 
-	// 按电影ID分组，减少HBase行锁竞争
+	// 按电影ID分组，每部电影的评分合并为一次Put，减少HBase行锁竞争
 	movieGroups := make(map[string][]BatchWriteItem)
 	for _, item := range items {
 		movieGroups[item.MovieID] = append(movieGroups[item.MovieID], item)
 	}
 
-	// 并发写入不同电影的数据
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
+	var puts []*hrpc.Mutate
+	var putGroups [][]BatchWriteItem
 	for movieID, movieItems := range movieGroups {
-		wg.Add(1)
-		go func(mID string, mItems []BatchWriteItem) {
-			defer wg.Done()
+		put, err := buildMovieRatingsPut(ctx, movieID, movieItems, timestamp)
+		if err != nil {
+			errorCount += len(movieItems)
+			continue
+		}
+		puts = append(puts, put)
+		putGroups = append(putGroups, movieItems)
+	}
+
+	// 模拟模式：请求已构建完成，跳过实际写入和评分追踪（追踪会触发stats重算写入）
+	if tc.isDryRun() {
+		for _, group := range putGroups {
+			successCount += len(group)
+		}
+		return successCount, errorCount
+	}
 
-			success, errors := tc.writeMovieRatingsBatch(ctx, mID, mItems)
+	var written []BatchWriteItem
+	for i, err := range utils.BatchPut(ctx, puts) {
+		if err != nil {
+			errorCount += len(putGroups[i])
+			continue
+		}
+		successCount += len(putGroups[i])
+		written = append(written, putGroups[i]...)
 
-			mu.Lock()
-			successCount += success
-			errorCount += errors
-			mu.Unlock()
-		}(movieID, movieItems)
+		// 记录到追踪服务（批量写入不区分新增和重新评分）
+		for _, item := range putGroups[i] {
+			services.GlobalRatingTracker.RecordRatingWrite(item.MovieID, item.UserID, item.Rating, item.Source, false)
+		}
+	}
+
+	// 电影行写入成功后，按用户分组同步写入users表
+	if len(written) > 0 {
+		tc.writeUserRatingsBatch(ctx, written, timestamp)
 	}
 
-	wg.Wait()
 	return successCount, errorCount
 }
 
-// writeMovieRatingsBatch 批量写入单个电影的评分数据
-func (tc *TestController) writeMovieRatingsBatch(ctx context.Context, movieID string, items []BatchWriteItem) (int, int) {
-	// 构建批量Put请求
-	values := make(map[string][]byte)
-	timestamp := time.Now().Unix()
-
+// writeUserRatingsBatch 将已写入电影行的评分按用户分组写入users表，失败记录到追踪服务
+func (tc *TestController) writeUserRatingsBatch(ctx context.Context, items []BatchWriteItem, timestamp int64) {
+	userGroups := make(map[string]map[string][]byte)
 	for _, item := range items {
-		ratingValue := fmt.Sprintf("%.1f:%s:%d", item.Rating, item.UserID, timestamp)
-		values[item.UserID] = []byte(ratingValue)
+		if userGroups[item.UserID] == nil {
+			userGroups[item.UserID] = make(map[string][]byte)
+		}
+		userGroups[item.UserID][item.MovieID] = utils.UserRatingValue(item.Rating, item.MovieID, timestamp)
 	}
 
-	// 构建行键
-	rowKey := fmt.Sprintf("%s_ratings", movieID)
-
-	// 创建Put请求
-	putRequest, err := hrpc.NewPutStr(ctx, "movies", rowKey, map[string]map[string][]byte{
-		"ratings": values,
-	})
-
-	if err != nil {
-		return 0, len(items)
+	var puts []*hrpc.Mutate
+	var userIDs []string
+	for userID, values := range userGroups {
+		put, err := utils.NewUserMovieRatingsPut(ctx, userID, values)
+		if err != nil {
+			tc.recordUserWriteError(userID, values, err)
+			continue
+		}
+		puts = append(puts, put)
+		userIDs = append(userIDs, userID)
 	}
 
-	// 获取HBase客户端并执行
-	client := utils.GetClient().(interface {
-		Put(request *hrpc.Mutate) (*hrpc.Result, error)
-	})
+	var written []string
+	for i, err := range utils.BatchPut(ctx, puts) {
+		if err != nil {
+			tc.recordUserWriteError(userIDs[i], userGroups[userIDs[i]], err)
+			continue
+		}
+		written = append(written, userIDs[i])
+	}
+	if err := models.MarkTestUsers(ctx, written); err != nil {
+		tc.addLog(fmt.Sprintf("⚠️ 记录 %d 个测试用户失败: %v", len(written), err))
+	}
+}
 
-	_, err = client.Put(putRequest)
-	if err != nil {
-		return 0, len(items)
+// recordUserWriteError 记录users表写入失败
+func (tc *TestController) recordUserWriteError(userID string, values map[string][]byte, err error) {
+	movieIDs := make([]string, 0, len(values))
+	for movieID := range values {
+		movieIDs = append(movieIDs, movieID)
 	}
+	sort.Strings(movieIDs)
+	services.GlobalRatingTracker.RecordUserWriteError(userID, movieIDs, err)
+	tc.addLog(fmt.Sprintf("⚠️ 用户 %s 的 %d 条评分写入users表失败: %v", userID, len(values), err))
+}
 
-	// 记录到追踪服务
+// buildMovieRatingsPut 构建单个电影评分行的批量Put请求
+func buildMovieRatingsPut(ctx context.Context, movieID string, items []BatchWriteItem, timestamp int64) (*hrpc.Mutate, error) {
+	values := make(map[string][]byte)
 	for _, item := range items {
-		services.GlobalRatingTracker.RecordRatingWrite(item.MovieID, item.UserID, item.Rating, item.Source)
+		values[item.UserID] = utils.RatingValue(item.Rating, item.UserID, timestamp, item.Source)
 	}
 
-	return len(items), 0
+	return hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(movieID), map[string]map[string][]byte{
+		"ratings": values,
+	})
 }
 
 // addLog 添加日志
@@ -563,6 +749,15 @@ func (tc *TestController) addLog(message string) {
 }
 
 // GenerateRandomRatingsForMovie 为指定电影生成随机评分
+// @Summary 为电影生成随机评分
+// @Tags test
+// @Produce json
+// @Param id path string true "电影ID"
+// @Param count query integer false "数量" default(10)
+// @Param seed query integer false "随机种子，相同种子生成相同数据，默认为当前时间"
+// @Success 200 {object} map[string]interface{} "data、errorCount、errors、message"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Router /api/test/ratings/movie/{id} [post]
 func (tc *TestController) GenerateRandomRatingsForMovie(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -582,49 +777,209 @@ func (tc *TestController) GenerateRandomRatingsForMovie(c *gin.Context) {
 		count = 100 // 限制最大数量
 	}
 
+	seed, err := parseSeed(c)
+	if err != nil {
+		utils.BadRequest(c, "seed必须是整数")
+		return
+	}
+
+	inserted, errors := generateRandomRatings(ctx, rand.New(rand.NewSource(seed)), movieID, count)
+
+	// 构建响应
+	response := gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("为电影 %s 生成随机评分完成", movieID),
+		"data": gin.H{
+			"movieId":     movieID,
+			"seed":        seed,
+			"requested":   count,
+			"inserted":    inserted,
+			"successRate": fmt.Sprintf("%.1f%%", float64(inserted)/float64(count)*100),
+		},
+	}
+
+	if len(errors) > 0 {
+		response["errors"] = errors
+		response["errorCount"] = len(errors)
+	}
+
+	utils.SuccessData(c, response)
+}
+
+// parseSeed 读取seed参数，未提供时使用当前时间
+func parseSeed(c *gin.Context) (int64, error) {
+	seedStr := c.Query("seed")
+	if seedStr == "" {
+		return time.Now().UnixNano(), nil
+	}
+	return strconv.ParseInt(seedStr, 10, 64)
+}
+
+// generateRandomRatings 为电影写入count个随机用户的随机评分，返回成功数和错误信息
+func generateRandomRatings(ctx context.Context, rng *rand.Rand, movieID string, count int) (int, []string) {
 	var inserted int
 	var errors []string
+	var userIDs []string
 
 	// 生成指定数量的随机评分
 	for i := 0; i < count; i++ {
 		// 生成随机用户ID (10000-99999)
-		userID := rand.Intn(90000) + 10000
+		userID := rng.Intn(90000) + 10000
 		userIDStr := strconv.Itoa(userID)
 
 		// 生成随机评分 (0.5-5.0, 步长0.5)
-		ratingFloat := (float64(rand.Intn(10)) + 1) * 0.5
+		ratingFloat := (float64(rng.Intn(10)) + 1) * 0.5
 
 		// 使用通用评分写入函数
-		err := services.GlobalRatingTracker.WriteRatingToHBase(ctx, movieID, userIDStr, ratingFloat, "api")
+		_, err := services.GlobalRatingTracker.WriteRatingToHBase(ctx, movieID, userIDStr, ratingFloat, "test")
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("写入失败 (用户%s): %v", userIDStr, err))
 			continue
 		}
 
 		inserted++
+		userIDs = append(userIDs, userIDStr)
 	}
 
-	// 构建响应
-	response := gin.H{
-		"status":  "success",
-		"message": fmt.Sprintf("为电影 %s 生成随机评分完成", movieID),
-		"data": gin.H{
-			"movieId":     movieID,
-			"requested":   count,
-			"inserted":    inserted,
-			"successRate": fmt.Sprintf("%.1f%%", float64(inserted)/float64(count)*100),
-		},
+	// 记录生成的用户，活跃用户排行默认排除这些用户
+	if err := models.MarkTestUsers(ctx, userIDs); err != nil {
+		errors = append(errors, fmt.Sprintf("记录测试用户失败: %v", err))
 	}
 
-	if len(errors) > 0 {
-		response["errors"] = errors
-		response["errorCount"] = len(errors)
+	return inserted, errors
+}
+
+const (
+	rangeRatingsMaxMovies = 1000 // 单次请求最多覆盖的电影数
+	rangeRatingsMaxWrites = 5000 // 单次请求最多写入的评分数
+	rangeRatingsWorkers   = 8    // 并发写入的电影数
+)
+
+// GenerateRandomRatingsForRange 为ID区间[from, to]内的每部电影生成随机评分，用于快速准备测试数据
+// @Summary 为ID区间内的电影生成随机评分
+// @Tags test
+// @Produce json
+// @Security AdminKey
+// @Param from query integer true "from"
+// @Param to query integer true "to"
+// @Param count query integer false "每部电影的评分数，最大100" default(10)
+// @Param seed query integer false "随机种子，相同种子生成相同数据，默认为当前时间"
+// @Success 200 {object} utils.Response{data=object} "data: countPerMovie、errorCount、from、inserted、movies、seed、skipped、to"
+// @Failure 400 {object} utils.ErrorResponse "from和to必须是正整数且from不大于to"
+// @Failure 401 {object} utils.ErrorResponse "管理密钥无效"
+// @Failure 403 {object} utils.ErrorResponse "管理接口未启用，请先配置管理密钥"
+// @Router /api/test/ratings/range [post]
+func (tc *TestController) GenerateRandomRatingsForRange(c *gin.Context) {
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < from {
+		utils.BadRequest(c, "from和to必须是正整数且from不大于to")
+		return
+	}
+	if to-from+1 > rangeRatingsMaxMovies {
+		utils.BadRequest(c, fmt.Sprintf("区间最多包含%d部电影", rangeRatingsMaxMovies))
+		return
 	}
 
-	utils.SuccessData(c, response)
+	count, err := strconv.Atoi(c.DefaultQuery("count", "10"))
+	if err != nil || count <= 0 {
+		count = 10
+	}
+	if count > 100 {
+		count = 100 // 限制最大数量
+	}
+
+	movieCount := to - from + 1
+	if movieCount*count > rangeRatingsMaxWrites {
+		utils.BadRequest(c, fmt.Sprintf("总写入量 %d 超过上限 %d，请缩小区间或减少count", movieCount*count, rangeRatingsMaxWrites))
+		return
+	}
+
+	seed, err := parseSeed(c)
+	if err != nil {
+		utils.BadRequest(c, "seed必须是整数")
+		return
+	}
+
+	ctx := context.Background()
+
+	type movieResult struct {
+		MovieID  string   `json:"movieId"`
+		Inserted int      `json:"inserted"`
+		Skipped  bool     `json:"skipped,omitempty"` // 电影不存在
+		Errors   []string `json:"errors,omitempty"`
+	}
+	results := make([]movieResult, movieCount)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < rangeRatingsWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				movieID := strconv.Itoa(from + i)
+				result := movieResult{MovieID: movieID}
+
+				// 不为不存在的电影写入评分，避免产生孤立的_ratings行
+				movie, err := utils.GetMovie(ctx, movieID)
+				switch {
+				case err != nil:
+					result.Errors = []string{fmt.Sprintf("查询电影失败: %v", err)}
+				case movie == nil:
+					result.Skipped = true
+				default:
+					// 每部电影使用独立的随机数源，结果与并发调度顺序无关
+					rng := rand.New(rand.NewSource(seed + int64(i)))
+					result.Inserted, result.Errors = generateRandomRatings(ctx, rng, movieID, count)
+				}
+				results[i] = result
+			}
+		}()
+	}
+	for i := 0; i < movieCount; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var inserted, skipped, errorCount int
+	for _, result := range results {
+		inserted += result.Inserted
+		errorCount += len(result.Errors)
+		if result.Skipped {
+			skipped++
+		}
+	}
+
+	tc.addLog(fmt.Sprintf("🎲 为电影 %d-%d 生成随机评分: 成功 %d 条, 跳过 %d 部, 错误 %d 条",
+		from, to, inserted, skipped, errorCount))
+
+	utils.SuccessData(c, gin.H{
+		"status":  "success",
+		"message": fmt.Sprintf("为电影 %d-%d 生成随机评分完成", from, to),
+		"data": gin.H{
+			"from":          from,
+			"to":            to,
+			"countPerMovie": count,
+			"seed":          seed,
+			"inserted":      inserted,
+			"skipped":       skipped,
+			"errorCount":    errorCount,
+			"movies":        results,
+		},
+	})
 }
 
 // ClearMovieRatings 清除指定电影的所有评分数据
+// @Summary 清除电影的全部评分
+// @Tags test
+// @Produce json
+// @Param id path string true "电影ID"
+// @Success 200 {object} map[string]interface{} "message、movieId"
+// @Failure 400 {object} utils.ErrorResponse "电影ID不能为空"
+// @Failure 500 {object} utils.ErrorResponse "创建删除请求失败"
+// @Router /api/test/ratings/movie/{id} [delete]
 func (tc *TestController) ClearMovieRatings(c *gin.Context) {
 	movieID := c.Param("id")
 	if movieID == "" {
@@ -640,10 +995,10 @@ func (tc *TestController) ClearMovieRatings(c *gin.Context) {
 	})
 
 	// 构建行键: "{movieId}_ratings"
-	rowKey := fmt.Sprintf("%s_ratings", movieID)
+	rowKey := rowkey.MovieRatingsKey(movieID)
 
 	// 创建Delete请求
-	deleteRequest, err := hrpc.NewDelStr(ctx, "movies", rowKey, nil)
+	deleteRequest, err := hrpc.NewDelStr(ctx, utils.MoviesTable(), rowKey, nil)
 	if err != nil {
 		utils.InternalError(c, "创建删除请求失败", err)
 		return
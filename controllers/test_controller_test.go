@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"gohbase/utils/hbase"
+	"gohbase/utils/hbase/hbasetest"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestRatingsRouter 返回只注册随机写入启动和停止接口的路由
+func newTestRatingsRouter(tc *TestController) *gin.Engine {
+	router := gin.New()
+	router.POST("/start", tc.StartRandomRatings)
+	router.POST("/stop", tc.StopRandomRatings)
+	return router
+}
+
+// post 发送POST请求并返回状态码
+func post(router *gin.Engine, path string) int {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+	return w.Code
+}
+
+// TestStartStopRandomRatings 反复启动和停止：停止返回时后台任务已经退出并写完缓冲区中的数据
+func TestStartStopRandomRatings(t *testing.T) {
+	hbase.SetClient(hbasetest.New())
+	tc := NewTestController()
+	router := newTestRatingsRouter(tc)
+
+	for i := 0; i < 100; i++ {
+		path := "/start?seed=1"
+		if i%2 == 0 {
+			path += "&dryRun=true"
+		}
+		if code := post(router, path); code != http.StatusOK {
+			t.Fatalf("第%d次启动: status = %d", i, code)
+		}
+		done := tc.done
+
+		// 在缓冲区中留下数据，停止时应由任务的最后一次刷新写入
+		tc.generateBatchData()
+		if code := post(router, "/stop"); code != http.StatusOK {
+			t.Fatalf("第%d次停止: status = %d", i, code)
+		}
+
+		select {
+		case <-done:
+		default:
+			t.Fatalf("第%d次停止返回时后台任务仍在运行", i)
+		}
+		if atomic.LoadInt32(&tc.isRunning) != 0 {
+			t.Fatalf("第%d次停止后isRunning仍为1", i)
+		}
+		tc.batchMu.Lock()
+		pending := len(tc.batchBuffer)
+		tc.batchMu.Unlock()
+		if pending != 0 || atomic.LoadInt64(&tc.totalInserted) == 0 {
+			t.Fatalf("第%d次停止后缓冲区剩余%d条，已写入%d条", i, pending, atomic.LoadInt64(&tc.totalInserted))
+		}
+	}
+
+	if code := post(router, "/stop"); code != http.StatusBadRequest {
+		t.Errorf("未运行时停止: status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+// TestConcurrentStartStopRandomRatings 并发启动和停止不会重复关闭通道或遗留运行中的任务
+func TestConcurrentStartStopRandomRatings(t *testing.T) {
+	hbase.SetClient(hbasetest.New())
+	tc := NewTestController()
+	router := newTestRatingsRouter(tc)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				post(router, "/start?dryRun=true")
+				post(router, "/stop")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 最后一次操作可能是启动，停止后不应再有任务运行
+	post(router, "/stop")
+	tc.lifecycleMu.Lock()
+	done := tc.done
+	tc.lifecycleMu.Unlock()
+	if done != nil {
+		select {
+		case <-done:
+		default:
+			t.Error("停止后后台任务仍在运行")
+		}
+	}
+	if atomic.LoadInt32(&tc.isRunning) != 0 {
+		t.Error("停止后isRunning仍为1")
+	}
+}
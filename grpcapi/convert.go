@@ -0,0 +1,89 @@
+package grpcapi
+
+import (
+	"gohbase/grpcapi/moviepb"
+	"gohbase/models"
+)
+
+// toMovie 将models.Movie转换为protobuf消息
+func toMovie(m models.Movie) *moviepb.Movie {
+	return &moviepb.Movie{
+		MovieId:    m.MovieID,
+		Title:      m.Title,
+		CleanTitle: m.CleanTitle,
+		Genres:     m.Genres,
+		Year:       int32(m.Year),
+		AvgRating:  m.AvgRating,
+		Links: &moviepb.Links{
+			ImdbId:  m.Links.ImdbID,
+			ImdbUrl: m.Links.ImdbURL,
+			TmdbId:  m.Links.TmdbID,
+			TmdbUrl: m.Links.TmdbURL,
+		},
+		Tags: m.Tags,
+	}
+}
+
+// toMovieList 将models.MovieList转换为protobuf消息
+func toMovieList(list *models.MovieList) *moviepb.MovieList {
+	movies := make([]*moviepb.Movie, 0, len(list.Movies))
+	for _, m := range list.Movies {
+		movies = append(movies, toMovie(m))
+	}
+	return &moviepb.MovieList{
+		Movies:      movies,
+		TotalMovies: int32(list.TotalMovies),
+		Page:        int32(list.Page),
+		PerPage:     int32(list.PerPage),
+		TotalPages:  int32(list.TotalPages),
+		Truncated:   list.Truncated,
+	}
+}
+
+// toMovieDetail 将models.MovieDetail转换为protobuf消息
+func toMovieDetail(detail *models.MovieDetail) *moviepb.MovieDetail {
+	ratings := make([]*moviepb.Rating, 0, len(detail.Ratings))
+	for _, r := range detail.Ratings {
+		ratings = append(ratings, &moviepb.Rating{UserId: r.UserID, Rating: r.Rating})
+	}
+
+	taggedUsers := make([]*moviepb.TaggedUser, 0, len(detail.TaggedUsers))
+	for _, t := range detail.TaggedUsers {
+		taggedUsers = append(taggedUsers, &moviepb.TaggedUser{
+			UserId:    t["userId"],
+			Tag:       t["tag"],
+			Timestamp: t["timestamp"],
+		})
+	}
+
+	return &moviepb.MovieDetail{
+		Movie:       toMovie(detail.Movie),
+		Ratings:     ratings,
+		TaggedUsers: taggedUsers,
+		Stats:       detail.Stats,
+	}
+}
+
+// toMovieRatings 将GetMovieRatings返回的map转换为protobuf消息
+func toMovieRatings(movieID string, data map[string]interface{}) *moviepb.MovieRatings {
+	result := &moviepb.MovieRatings{MovieId: movieID}
+
+	if ratings, ok := data["ratings"].([]map[string]interface{}); ok {
+		result.Ratings = make([]*moviepb.Rating, 0, len(ratings))
+		for _, r := range ratings {
+			rating := &moviepb.Rating{}
+			rating.UserId, _ = r["userId"].(string)
+			rating.Rating, _ = r["rating"].(float64)
+			rating.Timestamp, _ = r["timestamp"].(int64)
+			result.Ratings = append(result.Ratings, rating)
+		}
+	}
+	if count, ok := data["count"].(int); ok {
+		result.Count = int32(count)
+	}
+	result.AvgRating, _ = data["avgRating"].(float64)
+	result.MinRating, _ = data["minRating"].(float64)
+	result.MaxRating, _ = data["maxRating"].(float64)
+
+	return result
+}
@@ -0,0 +1,937 @@
+// 电影评分系统的gRPC接口，消息结构与REST接口返回的JSON（models.Movie、models.MovieList等）一致。
+//
+// 修改后重新生成：
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     grpcapi/moviepb/movie.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: grpcapi/moviepb/movie.proto
+
+package moviepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Links 外部链接
+type Links struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ImdbId        string                 `protobuf:"bytes,1,opt,name=imdb_id,json=imdbId,proto3" json:"imdb_id,omitempty"`
+	ImdbUrl       string                 `protobuf:"bytes,2,opt,name=imdb_url,json=imdbUrl,proto3" json:"imdb_url,omitempty"`
+	TmdbId        string                 `protobuf:"bytes,3,opt,name=tmdb_id,json=tmdbId,proto3" json:"tmdb_id,omitempty"`
+	TmdbUrl       string                 `protobuf:"bytes,4,opt,name=tmdb_url,json=tmdbUrl,proto3" json:"tmdb_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Links) Reset() {
+	*x = Links{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Links) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Links) ProtoMessage() {}
+
+func (x *Links) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Links.ProtoReflect.Descriptor instead.
+func (*Links) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Links) GetImdbId() string {
+	if x != nil {
+		return x.ImdbId
+	}
+	return ""
+}
+
+func (x *Links) GetImdbUrl() string {
+	if x != nil {
+		return x.ImdbUrl
+	}
+	return ""
+}
+
+func (x *Links) GetTmdbId() string {
+	if x != nil {
+		return x.TmdbId
+	}
+	return ""
+}
+
+func (x *Links) GetTmdbUrl() string {
+	if x != nil {
+		return x.TmdbUrl
+	}
+	return ""
+}
+
+// Movie 电影
+type Movie struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	CleanTitle    string                 `protobuf:"bytes,3,opt,name=clean_title,json=cleanTitle,proto3" json:"clean_title,omitempty"` // 去掉末尾年份的标题
+	Genres        []string               `protobuf:"bytes,4,rep,name=genres,proto3" json:"genres,omitempty"`
+	Year          int32                  `protobuf:"varint,5,opt,name=year,proto3" json:"year,omitempty"`
+	AvgRating     float64                `protobuf:"fixed64,6,opt,name=avg_rating,json=avgRating,proto3" json:"avg_rating,omitempty"`
+	Links         *Links                 `protobuf:"bytes,7,opt,name=links,proto3" json:"links,omitempty"`
+	Tags          []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Movie) Reset() {
+	*x = Movie{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Movie) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Movie) ProtoMessage() {}
+
+func (x *Movie) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Movie.ProtoReflect.Descriptor instead.
+func (*Movie) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Movie) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+func (x *Movie) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Movie) GetCleanTitle() string {
+	if x != nil {
+		return x.CleanTitle
+	}
+	return ""
+}
+
+func (x *Movie) GetGenres() []string {
+	if x != nil {
+		return x.Genres
+	}
+	return nil
+}
+
+func (x *Movie) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *Movie) GetAvgRating() float64 {
+	if x != nil {
+		return x.AvgRating
+	}
+	return 0
+}
+
+func (x *Movie) GetLinks() *Links {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Movie) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// MovieList 分页的电影列表
+type MovieList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movies        []*Movie               `protobuf:"bytes,1,rep,name=movies,proto3" json:"movies,omitempty"`
+	TotalMovies   int32                  `protobuf:"varint,2,opt,name=total_movies,json=totalMovies,proto3" json:"total_movies,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PerPage       int32                  `protobuf:"varint,4,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,5,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	Truncated     bool                   `protobuf:"varint,6,opt,name=truncated,proto3" json:"truncated,omitempty"` // 结果数达到搜索上限，可能不完整
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovieList) Reset() {
+	*x = MovieList{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieList) ProtoMessage() {}
+
+func (x *MovieList) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieList.ProtoReflect.Descriptor instead.
+func (*MovieList) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MovieList) GetMovies() []*Movie {
+	if x != nil {
+		return x.Movies
+	}
+	return nil
+}
+
+func (x *MovieList) GetTotalMovies() int32 {
+	if x != nil {
+		return x.TotalMovies
+	}
+	return 0
+}
+
+func (x *MovieList) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *MovieList) GetPerPage() int32 {
+	if x != nil {
+		return x.PerPage
+	}
+	return 0
+}
+
+func (x *MovieList) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *MovieList) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// Rating 单条评分
+type Rating struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Rating        float64                `protobuf:"fixed64,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix秒，详情接口中为0
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rating) Reset() {
+	*x = Rating{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rating) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rating) ProtoMessage() {}
+
+func (x *Rating) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rating.ProtoReflect.Descriptor instead.
+func (*Rating) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Rating) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Rating) GetRating() float64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Rating) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// TaggedUser 用户为电影添加的标签
+type TaggedUser struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Tag           string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	Timestamp     string                 `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaggedUser) Reset() {
+	*x = TaggedUser{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaggedUser) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaggedUser) ProtoMessage() {}
+
+func (x *TaggedUser) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaggedUser.ProtoReflect.Descriptor instead.
+func (*TaggedUser) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TaggedUser) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TaggedUser) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TaggedUser) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+// MovieDetail 电影详情
+type MovieDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Movie         *Movie                 `protobuf:"bytes,1,opt,name=movie,proto3" json:"movie,omitempty"`
+	Ratings       []*Rating              `protobuf:"bytes,2,rep,name=ratings,proto3" json:"ratings,omitempty"`
+	TaggedUsers   []*TaggedUser          `protobuf:"bytes,3,rep,name=tagged_users,json=taggedUsers,proto3" json:"tagged_users,omitempty"`
+	Stats         map[string]float64     `protobuf:"bytes,4,rep,name=stats,proto3" json:"stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovieDetail) Reset() {
+	*x = MovieDetail{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieDetail) ProtoMessage() {}
+
+func (x *MovieDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieDetail.ProtoReflect.Descriptor instead.
+func (*MovieDetail) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MovieDetail) GetMovie() *Movie {
+	if x != nil {
+		return x.Movie
+	}
+	return nil
+}
+
+func (x *MovieDetail) GetRatings() []*Rating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+func (x *MovieDetail) GetTaggedUsers() []*TaggedUser {
+	if x != nil {
+		return x.TaggedUsers
+	}
+	return nil
+}
+
+func (x *MovieDetail) GetStats() map[string]float64 {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// MovieRatings 电影的全部评分及统计
+type MovieRatings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	Ratings       []*Rating              `protobuf:"bytes,2,rep,name=ratings,proto3" json:"ratings,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	AvgRating     float64                `protobuf:"fixed64,4,opt,name=avg_rating,json=avgRating,proto3" json:"avg_rating,omitempty"`
+	MinRating     float64                `protobuf:"fixed64,5,opt,name=min_rating,json=minRating,proto3" json:"min_rating,omitempty"`
+	MaxRating     float64                `protobuf:"fixed64,6,opt,name=max_rating,json=maxRating,proto3" json:"max_rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MovieRatings) Reset() {
+	*x = MovieRatings{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MovieRatings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MovieRatings) ProtoMessage() {}
+
+func (x *MovieRatings) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MovieRatings.ProtoReflect.Descriptor instead.
+func (*MovieRatings) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *MovieRatings) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+func (x *MovieRatings) GetRatings() []*Rating {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+func (x *MovieRatings) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *MovieRatings) GetAvgRating() float64 {
+	if x != nil {
+		return x.AvgRating
+	}
+	return 0
+}
+
+func (x *MovieRatings) GetMinRating() float64 {
+	if x != nil {
+		return x.MinRating
+	}
+	return 0
+}
+
+func (x *MovieRatings) GetMaxRating() float64 {
+	if x != nil {
+		return x.MaxRating
+	}
+	return 0
+}
+
+type GetMovieRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMovieRequest) Reset() {
+	*x = GetMovieRequest{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMovieRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMovieRequest) ProtoMessage() {}
+
+func (x *GetMovieRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMovieRequest.ProtoReflect.Descriptor instead.
+func (*GetMovieRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetMovieRequest) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+type ListMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`                      // 默认1
+	PerPage       int32                  `protobuf:"varint,2,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"` // 默认12，最大50
+	Tag           string                 `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`                         // 可选，按标签过滤（不区分大小写、精确匹配）
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMoviesRequest) Reset() {
+	*x = ListMoviesRequest{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMoviesRequest) ProtoMessage() {}
+
+func (x *ListMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMoviesRequest.ProtoReflect.Descriptor instead.
+func (*ListMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListMoviesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListMoviesRequest) GetPerPage() int32 {
+	if x != nil {
+		return x.PerPage
+	}
+	return 0
+}
+
+func (x *ListMoviesRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type SearchMoviesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	SearchType    string                 `protobuf:"bytes,2,opt,name=search_type,json=searchType,proto3" json:"search_type,omitempty"` // title、genre、tag或all，默认all
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PerPage       int32                  `protobuf:"varint,4,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"`
+	Rank          string                 `protobuf:"bytes,5,opt,name=rank,proto3" json:"rank,omitempty"` // relevance、popularity或rating，默认relevance
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMoviesRequest) Reset() {
+	*x = SearchMoviesRequest{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMoviesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMoviesRequest) ProtoMessage() {}
+
+func (x *SearchMoviesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMoviesRequest.ProtoReflect.Descriptor instead.
+func (*SearchMoviesRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchMoviesRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchMoviesRequest) GetSearchType() string {
+	if x != nil {
+		return x.SearchType
+	}
+	return ""
+}
+
+func (x *SearchMoviesRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *SearchMoviesRequest) GetPerPage() int32 {
+	if x != nil {
+		return x.PerPage
+	}
+	return 0
+}
+
+func (x *SearchMoviesRequest) GetRank() string {
+	if x != nil {
+		return x.Rank
+	}
+	return ""
+}
+
+type GetMovieRatingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovieId       string                 `protobuf:"bytes,1,opt,name=movie_id,json=movieId,proto3" json:"movie_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMovieRatingsRequest) Reset() {
+	*x = GetMovieRatingsRequest{}
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMovieRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMovieRatingsRequest) ProtoMessage() {}
+
+func (x *GetMovieRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_grpcapi_moviepb_movie_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMovieRatingsRequest.ProtoReflect.Descriptor instead.
+func (*GetMovieRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_grpcapi_moviepb_movie_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetMovieRatingsRequest) GetMovieId() string {
+	if x != nil {
+		return x.MovieId
+	}
+	return ""
+}
+
+var File_grpcapi_moviepb_movie_proto protoreflect.FileDescriptor
+
+const file_grpcapi_moviepb_movie_proto_rawDesc = "" +
+	"\n" +
+	"\x1bgrpcapi/moviepb/movie.proto\x12\x12doroscore.movie.v1\"o\n" +
+	"\x05Links\x12\x17\n" +
+	"\aimdb_id\x18\x01 \x01(\tR\x06imdbId\x12\x19\n" +
+	"\bimdb_url\x18\x02 \x01(\tR\aimdbUrl\x12\x17\n" +
+	"\atmdb_id\x18\x03 \x01(\tR\x06tmdbId\x12\x19\n" +
+	"\btmdb_url\x18\x04 \x01(\tR\atmdbUrl\"\xe9\x01\n" +
+	"\x05Movie\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x1f\n" +
+	"\vclean_title\x18\x03 \x01(\tR\n" +
+	"cleanTitle\x12\x16\n" +
+	"\x06genres\x18\x04 \x03(\tR\x06genres\x12\x12\n" +
+	"\x04year\x18\x05 \x01(\x05R\x04year\x12\x1d\n" +
+	"\n" +
+	"avg_rating\x18\x06 \x01(\x01R\tavgRating\x12/\n" +
+	"\x05links\x18\a \x01(\v2\x19.doroscore.movie.v1.LinksR\x05links\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\"\xcf\x01\n" +
+	"\tMovieList\x121\n" +
+	"\x06movies\x18\x01 \x03(\v2\x19.doroscore.movie.v1.MovieR\x06movies\x12!\n" +
+	"\ftotal_movies\x18\x02 \x01(\x05R\vtotalMovies\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x19\n" +
+	"\bper_page\x18\x04 \x01(\x05R\aperPage\x12\x1f\n" +
+	"\vtotal_pages\x18\x05 \x01(\x05R\n" +
+	"totalPages\x12\x1c\n" +
+	"\ttruncated\x18\x06 \x01(\bR\ttruncated\"W\n" +
+	"\x06Rating\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x01R\x06rating\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\"U\n" +
+	"\n" +
+	"TaggedUser\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\tR\ttimestamp\"\xb3\x02\n" +
+	"\vMovieDetail\x12/\n" +
+	"\x05movie\x18\x01 \x01(\v2\x19.doroscore.movie.v1.MovieR\x05movie\x124\n" +
+	"\aratings\x18\x02 \x03(\v2\x1a.doroscore.movie.v1.RatingR\aratings\x12A\n" +
+	"\ftagged_users\x18\x03 \x03(\v2\x1e.doroscore.movie.v1.TaggedUserR\vtaggedUsers\x12@\n" +
+	"\x05stats\x18\x04 \x03(\v2*.doroscore.movie.v1.MovieDetail.StatsEntryR\x05stats\x1a8\n" +
+	"\n" +
+	"StatsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"\xd2\x01\n" +
+	"\fMovieRatings\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId\x124\n" +
+	"\aratings\x18\x02 \x03(\v2\x1a.doroscore.movie.v1.RatingR\aratings\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1d\n" +
+	"\n" +
+	"avg_rating\x18\x04 \x01(\x01R\tavgRating\x12\x1d\n" +
+	"\n" +
+	"min_rating\x18\x05 \x01(\x01R\tminRating\x12\x1d\n" +
+	"\n" +
+	"max_rating\x18\x06 \x01(\x01R\tmaxRating\",\n" +
+	"\x0fGetMovieRequest\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId\"T\n" +
+	"\x11ListMoviesRequest\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x19\n" +
+	"\bper_page\x18\x02 \x01(\x05R\aperPage\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\"\x8f\x01\n" +
+	"\x13SearchMoviesRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1f\n" +
+	"\vsearch_type\x18\x02 \x01(\tR\n" +
+	"searchType\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x19\n" +
+	"\bper_page\x18\x04 \x01(\x05R\aperPage\x12\x12\n" +
+	"\x04rank\x18\x05 \x01(\tR\x04rank\"3\n" +
+	"\x16GetMovieRatingsRequest\x12\x19\n" +
+	"\bmovie_id\x18\x01 \x01(\tR\amovieId2\xed\x02\n" +
+	"\fMovieService\x12P\n" +
+	"\bGetMovie\x12#.doroscore.movie.v1.GetMovieRequest\x1a\x1f.doroscore.movie.v1.MovieDetail\x12R\n" +
+	"\n" +
+	"ListMovies\x12%.doroscore.movie.v1.ListMoviesRequest\x1a\x1d.doroscore.movie.v1.MovieList\x12V\n" +
+	"\fSearchMovies\x12'.doroscore.movie.v1.SearchMoviesRequest\x1a\x1d.doroscore.movie.v1.MovieList\x12_\n" +
+	"\x0fGetMovieRatings\x12*.doroscore.movie.v1.GetMovieRatingsRequest\x1a .doroscore.movie.v1.MovieRatingsB\x19Z\x17gohbase/grpcapi/moviepbb\x06proto3"
+
+var (
+	file_grpcapi_moviepb_movie_proto_rawDescOnce sync.Once
+	file_grpcapi_moviepb_movie_proto_rawDescData []byte
+)
+
+func file_grpcapi_moviepb_movie_proto_rawDescGZIP() []byte {
+	file_grpcapi_moviepb_movie_proto_rawDescOnce.Do(func() {
+		file_grpcapi_moviepb_movie_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_grpcapi_moviepb_movie_proto_rawDesc), len(file_grpcapi_moviepb_movie_proto_rawDesc)))
+	})
+	return file_grpcapi_moviepb_movie_proto_rawDescData
+}
+
+var file_grpcapi_moviepb_movie_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_grpcapi_moviepb_movie_proto_goTypes = []any{
+	(*Links)(nil),                  // 0: doroscore.movie.v1.Links
+	(*Movie)(nil),                  // 1: doroscore.movie.v1.Movie
+	(*MovieList)(nil),              // 2: doroscore.movie.v1.MovieList
+	(*Rating)(nil),                 // 3: doroscore.movie.v1.Rating
+	(*TaggedUser)(nil),             // 4: doroscore.movie.v1.TaggedUser
+	(*MovieDetail)(nil),            // 5: doroscore.movie.v1.MovieDetail
+	(*MovieRatings)(nil),           // 6: doroscore.movie.v1.MovieRatings
+	(*GetMovieRequest)(nil),        // 7: doroscore.movie.v1.GetMovieRequest
+	(*ListMoviesRequest)(nil),      // 8: doroscore.movie.v1.ListMoviesRequest
+	(*SearchMoviesRequest)(nil),    // 9: doroscore.movie.v1.SearchMoviesRequest
+	(*GetMovieRatingsRequest)(nil), // 10: doroscore.movie.v1.GetMovieRatingsRequest
+	nil,                            // 11: doroscore.movie.v1.MovieDetail.StatsEntry
+}
+var file_grpcapi_moviepb_movie_proto_depIdxs = []int32{
+	0,  // 0: doroscore.movie.v1.Movie.links:type_name -> doroscore.movie.v1.Links
+	1,  // 1: doroscore.movie.v1.MovieList.movies:type_name -> doroscore.movie.v1.Movie
+	1,  // 2: doroscore.movie.v1.MovieDetail.movie:type_name -> doroscore.movie.v1.Movie
+	3,  // 3: doroscore.movie.v1.MovieDetail.ratings:type_name -> doroscore.movie.v1.Rating
+	4,  // 4: doroscore.movie.v1.MovieDetail.tagged_users:type_name -> doroscore.movie.v1.TaggedUser
+	11, // 5: doroscore.movie.v1.MovieDetail.stats:type_name -> doroscore.movie.v1.MovieDetail.StatsEntry
+	3,  // 6: doroscore.movie.v1.MovieRatings.ratings:type_name -> doroscore.movie.v1.Rating
+	7,  // 7: doroscore.movie.v1.MovieService.GetMovie:input_type -> doroscore.movie.v1.GetMovieRequest
+	8,  // 8: doroscore.movie.v1.MovieService.ListMovies:input_type -> doroscore.movie.v1.ListMoviesRequest
+	9,  // 9: doroscore.movie.v1.MovieService.SearchMovies:input_type -> doroscore.movie.v1.SearchMoviesRequest
+	10, // 10: doroscore.movie.v1.MovieService.GetMovieRatings:input_type -> doroscore.movie.v1.GetMovieRatingsRequest
+	5,  // 11: doroscore.movie.v1.MovieService.GetMovie:output_type -> doroscore.movie.v1.MovieDetail
+	2,  // 12: doroscore.movie.v1.MovieService.ListMovies:output_type -> doroscore.movie.v1.MovieList
+	2,  // 13: doroscore.movie.v1.MovieService.SearchMovies:output_type -> doroscore.movie.v1.MovieList
+	6,  // 14: doroscore.movie.v1.MovieService.GetMovieRatings:output_type -> doroscore.movie.v1.MovieRatings
+	11, // [11:15] is the sub-list for method output_type
+	7,  // [7:11] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_grpcapi_moviepb_movie_proto_init() }
+func file_grpcapi_moviepb_movie_proto_init() {
+	if File_grpcapi_moviepb_movie_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_grpcapi_moviepb_movie_proto_rawDesc), len(file_grpcapi_moviepb_movie_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_grpcapi_moviepb_movie_proto_goTypes,
+		DependencyIndexes: file_grpcapi_moviepb_movie_proto_depIdxs,
+		MessageInfos:      file_grpcapi_moviepb_movie_proto_msgTypes,
+	}.Build()
+	File_grpcapi_moviepb_movie_proto = out.File
+	file_grpcapi_moviepb_movie_proto_goTypes = nil
+	file_grpcapi_moviepb_movie_proto_depIdxs = nil
+}
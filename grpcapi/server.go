@@ -0,0 +1,153 @@
+// Package grpcapi 提供与REST接口并行的gRPC只读接口，供内部服务调用。
+// 所有方法复用services.MovieService，消息定义见moviepb/movie.proto。
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"gohbase/grpcapi/moviepb"
+	"gohbase/models"
+	"gohbase/services"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// 与REST接口一致的分页默认值
+const (
+	defaultPerPage = 12
+	maxPerPage     = 50
+)
+
+// NewServer 创建注册了电影服务的gRPC服务器
+func NewServer(movieService services.MovieService) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(recoveryInterceptor, loggingInterceptor))
+	moviepb.RegisterMovieServiceServer(server, &movieServer{movieService: movieService})
+	return server
+}
+
+// movieServer 实现moviepb.MovieServiceServer
+type movieServer struct {
+	moviepb.UnimplementedMovieServiceServer
+	movieService services.MovieService
+}
+
+// GetMovie 获取电影详情
+func (s *movieServer) GetMovie(ctx context.Context, req *moviepb.GetMovieRequest) (*moviepb.MovieDetail, error) {
+	movieID := strings.TrimSpace(req.GetMovieId())
+	if movieID == "" {
+		return nil, status.Error(codes.InvalidArgument, "电影ID不能为空")
+	}
+
+	detail, err := s.movieService.GetMovieByID(movieID)
+	if err != nil {
+		return nil, internalError("获取电影详情失败", err)
+	}
+	if detail == nil {
+		return nil, status.Errorf(codes.NotFound, "电影 %s 不存在", movieID)
+	}
+	return toMovieDetail(detail), nil
+}
+
+// ListMovies 分页获取电影列表，设置tag时只返回带有该标签的电影
+func (s *movieServer) ListMovies(ctx context.Context, req *moviepb.ListMoviesRequest) (*moviepb.MovieList, error) {
+	page, perPage := pagination(req.GetPage(), req.GetPerPage())
+
+	var (
+		list *models.MovieList
+		err  error
+	)
+	if tag := strings.TrimSpace(req.GetTag()); tag != "" {
+		list, err = s.movieService.GetMoviesByTag(tag, page, perPage, nil)
+	} else {
+		list, err = s.movieService.GetMoviesList(page, perPage, nil)
+	}
+	if err != nil {
+		return nil, internalError("获取电影列表失败", err)
+	}
+	return toMovieList(list), nil
+}
+
+// SearchMovies 搜索电影
+func (s *movieServer) SearchMovies(ctx context.Context, req *moviepb.SearchMoviesRequest) (*moviepb.MovieList, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "搜索关键词不能为空")
+	}
+	searchType, err := models.ParseSearchType(req.GetSearchType())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	rank, err := models.ParseRankMode(req.GetRank())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, perPage := pagination(req.GetPage(), req.GetPerPage())
+	list, err := s.movieService.SearchMovies(query, searchType, rank, page, perPage, nil)
+	if err != nil {
+		return nil, internalError("搜索电影失败", err)
+	}
+	return toMovieList(list), nil
+}
+
+// GetMovieRatings 获取电影的全部评分及统计
+func (s *movieServer) GetMovieRatings(ctx context.Context, req *moviepb.GetMovieRatingsRequest) (*moviepb.MovieRatings, error) {
+	movieID := strings.TrimSpace(req.GetMovieId())
+	if movieID == "" {
+		return nil, status.Error(codes.InvalidArgument, "电影ID不能为空")
+	}
+
+	ratings, err := s.movieService.GetMovieRatings(movieID)
+	if err != nil {
+		return nil, internalError("获取电影评分失败", err)
+	}
+	return toMovieRatings(movieID, ratings), nil
+}
+
+// pagination 规范化分页参数，规则与REST接口相同
+func pagination(page, perPage int32) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return int(page), int(perPage)
+}
+
+// internalError 记录错误并返回不含内部细节的INTERNAL状态
+func internalError(message string, err error) error {
+	logrus.Errorf("gRPC %s: %v", message, err)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, message)
+}
+
+// recoveryInterceptor 将处理函数中的panic转换为INTERNAL错误，避免整个进程退出
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Errorf("gRPC %s panic: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "服务器内部错误")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// loggingInterceptor 记录每次调用的方法、状态码和耗时
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logrus.Debugf("gRPC %s %s %v", info.FullMethod, status.Code(err), time.Since(start))
+	return resp, err
+}
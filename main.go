@@ -2,18 +2,24 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"gohbase/config"
+	"gohbase/grpcapi"
 	"gohbase/models"
 	"gohbase/routes"
+	"gohbase/services"
 	"gohbase/utils"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 嵌入时区数据，没有系统时区文件的镜像也能解析tz参数
 
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
 func init() {
@@ -25,6 +31,9 @@ func init() {
 	})
 	logrus.SetOutput(os.Stdout)
 
+	// 捕获最近的日志，供 /api/system/logs 查询
+	logrus.AddHook(utils.SystemLogs)
+
 	// 设置日志级别
 	if level, err := logrus.ParseLevel(cfg.Logging.Level); err == nil {
 		logrus.SetLevel(level)
@@ -33,8 +42,26 @@ func init() {
 	}
 }
 
+// Swagger文档的全局信息，由swag init读取（见Makefile的swagger目标）
+//
+// @title DoroScore API
+// @version 1.0.0
+// @description 电影评分、搜索和热度追踪接口
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name X-Admin-Key
 func main() {
+	skipSchemaCheck := flag.Bool("skip-schema-check", false, "启动时跳过HBase表和列族检查")
+	flag.Parse()
+
 	cfg := config.GetConfig()
+	if *skipSchemaCheck {
+		cfg.HBase.SkipSchemaCheck = true
+	}
+
+	if err := cfg.ValidateCORS(); err != nil {
+		logrus.Fatalf("CORS配置无效: %v", err)
+	}
 
 	logrus.Infof("配置信息: HBase主机=%s, ZooKeeper地址=%s, ZooKeeper端口=%s",
 		cfg.HBase.Host, cfg.HBase.ZkQuorum, cfg.HBase.ZkPort)
@@ -62,6 +89,25 @@ func main() {
 		}
 	}()
 
+	// 启动评分写入激增检测
+	services.GlobalRatingTracker.Surge().Start(context.Background())
+
+	// 配置文件变化或收到SIGHUP时重新加载配置
+	go services.WatchConfig(context.Background())
+
+	// 定期刷新类型统计
+	models.StartGenreCountsRefresher(context.Background(), models.GenreCountsRefreshInterval)
+	models.StartPopularTagsRefresher(context.Background(), models.PopularTagsRefreshInterval)
+	models.StartGenreStatsRefresher(context.Background(), models.GenreStatsRefreshInterval)
+	models.StartUserActivityRefresher(context.Background(), models.UserActivityRefreshInterval)
+
+	// 预热全局平均评分，供按评分排序的贝叶斯平均使用
+	go func() {
+		if _, err := models.ComputeGlobalAverageRating(context.Background()); err != nil {
+			logrus.Warnf("计算全局平均评分失败: %v", err)
+		}
+	}()
+
 	// 设置路由
 	router := routes.SetupRouter()
 
@@ -79,6 +125,22 @@ func main() {
 		}
 	}()
 
+	// 启动gRPC服务器（与HTTP共用MovieService）
+	var grpcServer *grpc.Server
+	if cfg.Server.GrpcPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Server.GrpcPort))
+		if err != nil {
+			logrus.Fatalf("监听gRPC端口失败: %v", err)
+		}
+		grpcServer = grpcapi.NewServer(services.NewMovieService())
+		go func() {
+			logrus.Infof("gRPC服务启动 [端口: %s]", cfg.Server.GrpcPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				logrus.Fatalf("启动gRPC服务失败: %v", err)
+			}
+		}()
+	}
+
 	// 优雅关闭
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -88,9 +150,29 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// HTTP和gRPC共用同一个关闭期限
 	if err := srv.Shutdown(ctx); err != nil {
 		logrus.Fatalf("服务器强制关闭: %v", err)
 	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
 
 	logrus.Info("服务器已退出")
 }
+
+// stopGRPC 等待进行中的gRPC调用完成，ctx超时后强制关闭
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logrus.Warn("gRPC服务关闭超时，强制关闭")
+		server.Stop()
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"gohbase/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminKeyHeader 管理密钥请求头
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminAuth 校验X-Admin-Key请求头，用于保护写操作和管理接口。
+// 未配置管理密钥时拒绝所有请求，避免接口在默认配置下被公开调用。
+func AdminAuth(adminKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminKey == "" {
+			utils.Forbidden(c, "管理接口未启用，请先配置管理密钥")
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader(AdminKeyHeader)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(adminKey)) != 1 {
+			utils.Unauthorized(c, "管理密钥无效")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
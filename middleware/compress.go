@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 支持的压缩编码。HTTP中的deflate指zlib格式（RFC 1950）
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// compressibleTypes 会被压缩的Content-Type前缀
+var compressibleTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/javascript",
+	"application/xml",
+	"image/svg+xml",
+	"text/",
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+	zlibWriters = sync.Pool{New: func() interface{} { return zlib.NewWriter(io.Discard) }}
+)
+
+// Compress 按Accept-Encoding以gzip或deflate压缩响应，响应体小于minBytes时不压缩，minBytes<0表示关闭压缩。
+// 响应先缓存在内存中，处理完成时不足minBytes的响应原样写出并设置Content-Length；
+// 缓存达到minBytes或处理过程中调用Flush时改为边写边压缩，不设置Content-Length，
+// 大的列表和导出不会整体保留在内存中。
+func Compress(minBytes int) gin.HandlerFunc {
+	if minBytes < 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &compressWriter{ResponseWriter: original, encoding: encoding, minBytes: minBytes}
+		c.Writer = writer
+		// 处理函数panic时恢复原始writer，由Recovery直接写出错误响应
+		defer func() { c.Writer = original }()
+
+		c.Next()
+		writer.finish()
+	}
+}
+
+// negotiateEncoding 从Accept-Encoding中选择编码，优先gzip，q=0表示不接受
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		if ok, listed := accepted[encoding]; listed {
+			if ok {
+				return encoding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// isCompressible 响应的Content-Type是否值得压缩
+func isCompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter 缓存不足minBytes的响应体，达到minBytes后改为流式压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding  string
+	minBytes  int
+	buf       bytes.Buffer
+	streaming bool           // 缓存已达到minBytes或已调用Flush，之后的数据直接写出
+	stream    io.WriteCloser // 流式压缩器，为nil表示不压缩
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.streaming {
+		n, err := w.buf.Write(data)
+		if w.buf.Len() >= w.minBytes {
+			if err := w.startStreaming(); err != nil {
+				return n, err
+			}
+		}
+		return n, err
+	}
+	if w.stream != nil {
+		return w.stream.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 已有缓存的响应体时也视为已开始写出
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// shouldCompress 是否压缩：已设置Content-Encoding或已写出响应头时不再压缩
+func (w *compressWriter) shouldCompress() bool {
+	header := w.Header()
+	return header.Get("Content-Encoding") == "" &&
+		!w.ResponseWriter.Written() &&
+		isCompressible(header.Get("Content-Type"))
+}
+
+// startStreaming 切换到流式写出：按需创建压缩器，写出已缓存的数据，之后的数据不再缓存
+func (w *compressWriter) startStreaming() error {
+	if w.streaming {
+		return nil
+	}
+	w.streaming = true
+	if w.shouldCompress() {
+		w.Header().Set("Content-Encoding", w.encoding)
+		w.Header().Del("Content-Length")
+		w.stream = w.newCompressor(w.ResponseWriter)
+	}
+	pending := w.buf.Bytes()
+	w.buf = bytes.Buffer{}
+	if len(pending) == 0 {
+		return nil
+	}
+	_, err := w.Write(pending)
+	return err
+}
+
+// Flush 切换到流式写出，并把压缩器中的数据立即发送
+func (w *compressWriter) Flush() {
+	w.startStreaming()
+	if flusher, ok := w.stream.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// finish 流式响应关闭压缩器，否则原样写出不足minBytes的缓存响应体
+func (w *compressWriter) finish() {
+	if w.streaming {
+		if w.stream != nil {
+			w.stream.Close()
+		}
+		return
+	}
+	if w.buf.Len() == 0 {
+		return
+	}
+
+	body := w.buf.Bytes()
+	if !w.ResponseWriter.Written() {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.ResponseWriter.Write(body)
+}
+
+// newCompressor 从池中取出写入dst的压缩器，Close时放回池中
+func (w *compressWriter) newCompressor(dst io.Writer) io.WriteCloser {
+	if w.encoding == encodingGzip {
+		gz := gzipWriters.Get().(*gzip.Writer)
+		gz.Reset(dst)
+		return &pooledCompressor{writer: gz, pool: &gzipWriters}
+	}
+	zw := zlibWriters.Get().(*zlib.Writer)
+	zw.Reset(dst)
+	return &pooledCompressor{writer: zw, pool: &zlibWriters}
+}
+
+// resettableWriter gzip.Writer和zlib.Writer的公共方法
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// pooledCompressor 关闭后放回池中的压缩器
+type pooledCompressor struct {
+	writer resettableWriter
+	pool   *sync.Pool
+}
+
+func (p *pooledCompressor) Write(data []byte) (int, error) {
+	return p.writer.Write(data)
+}
+
+func (p *pooledCompressor) Flush() error {
+	return p.writer.Flush()
+}
+
+func (p *pooledCompressor) Close() error {
+	err := p.writer.Close()
+	p.writer.Reset(io.Discard)
+	p.pool.Put(p.writer)
+	return err
+}
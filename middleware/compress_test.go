@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", encodingGzip},
+		{"deflate", encodingDeflate},
+		{"deflate, gzip", encodingGzip},
+		{"GZIP;q=0.5", encodingGzip},
+		{"gzip;q=0, deflate", encodingDeflate},
+		{"gzip;q=0", ""},
+		{"*", encodingGzip},
+		{"gzip;q=0, *", encodingDeflate},
+		{"br", ""},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+// movieJSON 测试响应体的重复单元
+const movieJSON = `{"title":"Toy Story (1995)"}`
+
+// newCompressRouter 返回使用Compress的路由
+func newCompressRouter(minBytes int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Compress(minBytes))
+	router.GET("/json", func(c *gin.Context) {
+		n, _ := strconv.Atoi(c.Query("n"))
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(strings.Repeat(c.DefaultQuery("unit", movieJSON), n)))
+	})
+	router.GET("/png", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", []byte(strings.Repeat("x", 4096)))
+	})
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "br")
+		c.Data(http.StatusOK, "application/json", []byte(strings.Repeat("x", 4096)))
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-ndjson")
+		for i := 0; i < 3; i++ {
+			c.Writer.WriteString(`{"movieId":"1"}` + "\n")
+			c.Writer.Flush()
+		}
+	})
+	return router
+}
+
+// decodeBody 按Content-Encoding解压响应体
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var reader io.Reader = w.Body
+	switch w.Header().Get("Content-Encoding") {
+	case encodingGzip:
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip响应无效: %v", err)
+		}
+		reader = gz
+	case encodingDeflate:
+		zr, err := zlib.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("deflate响应无效: %v", err)
+		}
+		reader = zr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("读取响应失败: %v", err)
+	}
+	return string(body)
+}
+
+func TestCompress(t *testing.T) {
+	const minBytes = 1024
+	router := newCompressRouter(minBytes)
+	large := strings.Repeat(movieJSON, 100)
+	small := movieJSON
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		method         string
+		wantBody       string
+		wantEncoding   string
+		wantLength     bool // 是否设置Content-Length
+	}{
+		{"大响应gzip压缩", "/json?n=100", "gzip", http.MethodGet, large, encodingGzip, false},
+		{"大响应deflate压缩", "/json?n=100", "deflate", http.MethodGet, large, encodingDeflate, false},
+		{"小响应不压缩", "/json?n=1", "gzip", http.MethodGet, small, "", true},
+		{"客户端不支持压缩", "/json?n=100", "", http.MethodGet, large, "", false},
+		{"不可压缩的类型", "/png", "gzip", http.MethodGet, strings.Repeat("x", 4096), "", false},
+		{"已设置Content-Encoding", "/encoded", "gzip", http.MethodGet, strings.Repeat("x", 4096), "br", false},
+		{"流式响应Flush后压缩", "/stream", "gzip", http.MethodGet, strings.Repeat(`{"movieId":"1"}`+"\n", 3), encodingGzip, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+			t.Errorf("%s: Content-Encoding = %q, want %q", tt.name, got, tt.wantEncoding)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("%s: Vary = %q, want Accept-Encoding", tt.name, got)
+		}
+		if hasLength := w.Header().Get("Content-Length") != ""; hasLength != tt.wantLength {
+			t.Errorf("%s: Content-Length = %q, want set: %v", tt.name, w.Header().Get("Content-Length"), tt.wantLength)
+		}
+		if tt.wantEncoding == "br" {
+			continue
+		}
+		if got := decodeBody(t, w); got != tt.wantBody {
+			t.Errorf("%s: 解压后响应体长度 %d, want %d", tt.name, len(got), len(tt.wantBody))
+		}
+	}
+}
+
+func TestCompressDisabled(t *testing.T) {
+	router := newCompressRouter(-1)
+	large := strings.Repeat(movieJSON, 200)
+	req := httptest.NewRequest(http.MethodGet, "/json?n=200", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+		t.Errorf("minBytes<0时不应压缩: Content-Encoding = %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+// TestCompressReusesWriters 池中取出的压缩器在多个请求间复用时输出互不干扰
+func TestCompressReusesWriters(t *testing.T) {
+	router := newCompressRouter(16)
+	for i := 0; i < 20; i++ {
+		unit := string(rune('a' + i))
+		body := strings.Repeat(unit, 100+i)
+		req := httptest.NewRequest(http.MethodGet, "/json?unit="+unit+"&n="+strconv.Itoa(100+i), nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if got := decodeBody(t, w); !bytes.Equal([]byte(got), []byte(body)) {
+			t.Fatalf("第%d个请求解压后 = %q, want %q", i, got, body)
+		}
+	}
+}
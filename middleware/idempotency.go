@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"gohbase/utils"
+	"gohbase/utils/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader 幂等键请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyTTL 已完成请求的响应保留时间
+const idempotencyTTL = 24 * time.Hour
+
+// idempotentResponse 已完成请求的响应快照（字段导出以便Redis缓存序列化）
+type idempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+func init() {
+	// 其他实例写入的响应也能在本实例重放
+	cache.RegisterType(&idempotentResponse{})
+}
+
+// idempotencyInFlight 正在处理中的幂等键
+var idempotencyInFlight sync.Map
+
+// responseRecorder 在写出响应的同时记录响应体
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 根据Idempotency-Key请求头防止重复提交。
+// 相同方法、路径和键的请求成功后，在有效期内重放第一次的响应；
+// 第一次请求仍在处理时返回409。未携带该请求头的请求不受影响。
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		cacheKey := "idempotency:" + c.Request.Method + ":" + c.Request.URL.Path + ":" + key
+		if cached, found := utils.Cache.Get(cacheKey); found {
+			if resp, ok := cached.(*idempotentResponse); ok {
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(resp.Status, resp.ContentType, resp.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		if _, loaded := idempotencyInFlight.LoadOrStore(cacheKey, struct{}{}); loaded {
+			utils.Error(c, http.StatusConflict, "相同Idempotency-Key的请求正在处理中", nil)
+			c.Abort()
+			return
+		}
+		defer idempotencyInFlight.Delete(cacheKey)
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// 只保存成功的响应，失败时允许客户端使用相同的键重试
+		if status := recorder.Status(); status >= 200 && status < 300 {
+			utils.Cache.SetWithExpiration(cacheKey, &idempotentResponse{
+				Status:      status,
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			}, idempotencyTTL)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"gohbase/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit 限制请求体大小，超出上限时返回413。
+// 导入类接口（路由以/import结尾）使用importMaxBytes作为上限，其余接口使用maxBytes。
+func BodyLimit(maxBytes, importMaxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		if strings.HasSuffix(c.FullPath(), "/import") {
+			limit = importMaxBytes
+		}
+
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		// Content-Length已超出上限时直接拒绝，无需读取请求体
+		if c.Request.ContentLength > limit {
+			utils.RequestEntityTooLarge(c, limit)
+			c.Abort()
+			return
+		}
+
+		// 通过MaxBytesReader读取请求体，兼容未声明Content-Length的分块请求
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				utils.RequestEntityTooLarge(c, limit)
+			} else {
+				utils.BadRequest(c, "读取请求体失败")
+			}
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
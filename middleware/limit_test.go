@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLimitRouter 返回使用BodyLimit的路由，处理器读取并返回请求体长度
+func newLimitRouter(maxBytes, importMaxBytes int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(maxBytes, importMaxBytes))
+	handler := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	router.POST("/api/ratings/import", handler)
+	router.POST("/api/movies/:id/rate", handler)
+	return router
+}
+
+func TestBodyLimit(t *testing.T) {
+	const (
+		maxBytes       = 1 << 20
+		importMaxBytes = 10 << 20
+	)
+	router := newLimitRouter(maxBytes, importMaxBytes)
+
+	tests := []struct {
+		name     string
+		path     string
+		size     int
+		chunked  bool // 不声明Content-Length，只能在读取时发现超限
+		wantCode int
+	}{
+		{"普通接口未超限", "/api/movies/1/rate", maxBytes, false, http.StatusOK},
+		{"普通接口超限", "/api/movies/1/rate", maxBytes + 1, false, http.StatusRequestEntityTooLarge},
+		{"普通接口分块超限", "/api/movies/1/rate", maxBytes + 1, true, http.StatusRequestEntityTooLarge},
+		{"导入接口使用较大上限", "/api/ratings/import", 5 << 20, false, http.StatusOK},
+		{"导入接口超限", "/api/ratings/import", importMaxBytes + 1, false, http.StatusRequestEntityTooLarge},
+		{"导入接口分块超限", "/api/ratings/import", importMaxBytes + 1, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		var body io.Reader = bytes.NewReader(make([]byte, tt.size))
+		if tt.chunked {
+			body = io.LimitReader(body, int64(tt.size)) // 隐藏长度，httptest不会设置ContentLength
+		}
+		req := httptest.NewRequest(http.MethodPost, tt.path, body)
+		if tt.chunked && req.ContentLength > 0 {
+			t.Fatalf("%s: 请求不应声明Content-Length", tt.name)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestBodyLimitDisabled(t *testing.T) {
+	router := newLimitRouter(0, 0)
+	req := httptest.NewRequest(http.MethodPost, "/api/movies/1/rate", bytes.NewReader(make([]byte, 2<<20)))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "2097152" {
+		t.Errorf("上限为0时应不限制: status = %d, body = %q", w.Code, w.Body.String())
+	}
+}
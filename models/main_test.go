@@ -0,0 +1,35 @@
+package models
+
+import (
+	"context"
+	"gohbase/config"
+	"gohbase/utils"
+	"gohbase/utils/hbase"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// TestMain 把索引文件放到临时目录并初始化内存缓存，测试不读写工作目录下的索引
+func TestMain(m *testing.M) {
+	hbasetest.Main(m, func(string) { utils.InitCache(config.GetConfig()) })
+}
+
+// newTestClient 安装内存客户端并写入movies
+func newTestClient(t testing.TB, movies []hbasetest.Movie) *hbasetest.Client {
+	t.Helper()
+	client := hbasetest.New()
+	client.SetMovies(utils.MoviesTable(), movies...)
+	hbase.SetClient(client)
+	utils.Cache.Flush()
+	return client
+}
+
+// newTestIndex 安装内存客户端并从movies重建搜索索引
+func newTestIndex(t testing.TB, movies []hbasetest.Movie) *hbasetest.Client {
+	t.Helper()
+	client := newTestClient(t, movies)
+	if err := GetSearchIndex().BuildSearchIndex(context.Background()); err != nil {
+		t.Fatalf("构建索引失败: %v", err)
+	}
+	return client
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"sort"
+	"time"
+)
+
+// 电影评分动态
+const (
+	DefaultActivityLatest = 5
+	MaxActivityLatest     = 50
+	activityExpiration    = 30 * time.Second
+	// activityMatchWindow 追踪器记录与评分行中同一用户、同一评分的时间差在此范围内视为同一次评分
+	activityMatchWindow = 2
+)
+
+// RatingEvent 一次评分
+type RatingEvent struct {
+	UserID    string  `json:"userId"`
+	Rating    float64 `json:"rating"`
+	Timestamp int64   `json:"timestamp"` // Unix秒
+}
+
+// MovieActivity 电影最近的评分动态
+type MovieActivity struct {
+	MovieID        string        `json:"movieId"`
+	RecentCount24h int           `json:"recentCount24h"`
+	RecentCount7d  int           `json:"recentCount7d"`
+	LatestRatings  []RatingEvent `json:"latestRatings"` // 最新的评分，按时间倒序
+}
+
+// GetMovieActivity 统计电影最近24小时、7天的评分数并返回最新的latest条评分（缓存30秒）。
+// 评分行中每个用户只保留最新一次评分，trackerEvents为评分追踪器记录的本进程写入，
+// 用于补上被覆盖的重新评分；追踪器重启后只按评分行中的时间戳统计。电影不存在时返回nil。
+func GetMovieActivity(ctx context.Context, movieID string, latest int, trackerEvents []RatingEvent) (*MovieActivity, error) {
+	if latest < 1 {
+		latest = DefaultActivityLatest
+	}
+	latest = min(latest, MaxActivityLatest)
+
+	cacheKey := fmt.Sprintf("movie_activity:%s:%d", movieID, latest)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if activity, ok := cached.(*MovieActivity); ok {
+			return activity, nil
+		}
+	}
+
+	movie, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+	if movie == nil {
+		return nil, nil
+	}
+
+	data, err := utils.GetMovieRatings(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+	var events []RatingEvent
+	ratings, _ := data["ratings"].([]map[string]interface{})
+	for _, rating := range ratings {
+		event := RatingEvent{}
+		event.UserID, _ = rating["userId"].(string)
+		event.Rating, _ = rating["rating"].(float64)
+		event.Timestamp, _ = rating["timestamp"].(int64)
+		events = append(events, event)
+	}
+
+	activity := buildMovieActivity(movieID, mergeRatingEvents(events, trackerEvents), latest, time.Now())
+	utils.Cache.SetWithExpiration(cacheKey, activity, activityExpiration)
+	return activity, nil
+}
+
+// mergeRatingEvents 合并评分行和追踪器中的评分，追踪器中已在评分行出现的评分不重复计入
+func mergeRatingEvents(rowEvents, trackerEvents []RatingEvent) []RatingEvent {
+	byUser := make(map[string][]RatingEvent, len(rowEvents))
+	for _, event := range rowEvents {
+		byUser[event.UserID] = append(byUser[event.UserID], event)
+	}
+
+	merged := append([]RatingEvent{}, rowEvents...)
+	for _, event := range trackerEvents {
+		duplicate := false
+		for _, existing := range byUser[event.UserID] {
+			diff := existing.Timestamp - event.Timestamp
+			if existing.Rating == event.Rating && diff >= -activityMatchWindow && diff <= activityMatchWindow {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, event)
+		}
+	}
+	return merged
+}
+
+// buildMovieActivity 按now统计最近24小时、7天的评分数，并取最新的latest条评分
+func buildMovieActivity(movieID string, events []RatingEvent, latest int, now time.Time) *MovieActivity {
+	activity := &MovieActivity{MovieID: movieID, LatestRatings: []RatingEvent{}}
+
+	dayAgo := now.Add(-24 * time.Hour).Unix()
+	weekAgo := now.Add(-7 * 24 * time.Hour).Unix()
+	for _, event := range events {
+		if event.Timestamp >= dayAgo {
+			activity.RecentCount24h++
+		}
+		if event.Timestamp >= weekAgo {
+			activity.RecentCount7d++
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Timestamp != events[j].Timestamp {
+			return events[i].Timestamp > events[j].Timestamp
+		}
+		return events[i].UserID < events[j].UserID
+	})
+	activity.LatestRatings = append(activity.LatestRatings, events[:min(latest, len(events))]...)
+	return activity
+}
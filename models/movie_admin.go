@@ -0,0 +1,470 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// movieIDCounterRow 电影ID计数器所在行（不是合法的电影行键，不会被电影扫描读取）
+const movieIDCounterRow = "_movie_id_counter"
+
+// knownGenres MovieLens数据集中的类型（小写 -> 标准写法）
+var knownGenres = map[string]string{
+	"action": "Action", "adventure": "Adventure", "animation": "Animation", "children": "Children",
+	"comedy": "Comedy", "crime": "Crime", "documentary": "Documentary", "drama": "Drama",
+	"fantasy": "Fantasy", "film-noir": "Film-Noir", "horror": "Horror", "imax": "IMAX",
+	"musical": "Musical", "mystery": "Mystery", "romance": "Romance", "sci-fi": "Sci-Fi",
+	"thriller": "Thriller", "war": "War", "western": "Western", "(no genres listed)": "(no genres listed)",
+}
+
+var (
+	// ErrMovieNotFound 电影不存在
+	ErrMovieNotFound = errors.New("电影不存在")
+	// ErrInvalidMovieInput 电影元数据无效
+	ErrInvalidMovieInput = errors.New("电影元数据无效")
+)
+
+// MovieInput 新建或更新电影的元数据，更新时nil字段保持不变
+type MovieInput struct {
+	Title              *string   `json:"title"`
+	Genres             *[]string `json:"genres"`
+	ImdbID             *string   `json:"imdbId"`
+	TmdbID             *string   `json:"tmdbId"`
+	AllowUnknownGenres bool      `json:"allowUnknownGenres"` // 允许不在已知类型列表中的类型
+}
+
+// normalize 校验并规范化输入
+func (in *MovieInput) normalize() error {
+	if in.Title != nil {
+		title := strings.TrimSpace(*in.Title)
+		if title == "" {
+			return fmt.Errorf("%w: 标题不能为空", ErrInvalidMovieInput)
+		}
+		in.Title = &title
+	}
+
+	if in.Genres != nil {
+		genres := utils.ParseGenres(strings.Join(*in.Genres, "|"))
+		if !in.AllowUnknownGenres {
+			for _, genre := range genres {
+				if _, ok := knownGenres[strings.ToLower(genre)]; !ok {
+					return fmt.Errorf("%w: 未知类型 %q（可设置allowUnknownGenres跳过校验）", ErrInvalidMovieInput, genre)
+				}
+			}
+		}
+		if len(genres) == 0 {
+			genres = []string{"(no genres listed)"}
+		}
+		in.Genres = &genres
+	}
+
+	if in.ImdbID != nil {
+		// IMDB链接格式为tt{imdbId}，存储时去掉前缀
+		imdbID := strings.TrimPrefix(strings.TrimSpace(*in.ImdbID), "tt")
+		if !isDigits(imdbID) {
+			return fmt.Errorf("%w: imdbId必须是数字", ErrInvalidMovieInput)
+		}
+		in.ImdbID = &imdbID
+	}
+
+	if in.TmdbID != nil {
+		tmdbID := strings.TrimSpace(*in.TmdbID)
+		if !isDigits(tmdbID) {
+			return fmt.Errorf("%w: tmdbId必须是数字", ErrInvalidMovieInput)
+		}
+		in.TmdbID = &tmdbID
+	}
+
+	return nil
+}
+
+// isDigits 是否为非空数字串
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateMovie 分配新的电影ID并写入_info和_links行，同时更新搜索索引
+func CreateMovie(ctx context.Context, in MovieInput) (string, error) {
+	if in.Title == nil {
+		return "", fmt.Errorf("%w: 标题不能为空", ErrInvalidMovieInput)
+	}
+	if in.Genres == nil {
+		in.Genres = &[]string{}
+	}
+	if err := in.normalize(); err != nil {
+		return "", err
+	}
+
+	info := map[string][]byte{
+		"title":      []byte(*in.Title),
+		"genres":     []byte(strings.Join(*in.Genres, "|")),
+		"added_time": addedTimeValue(time.Now()),
+	}
+	movieID, err := allocateMovieID(ctx, info)
+	if err != nil {
+		return "", err
+	}
+
+	if err := putMovieLinks(ctx, movieID, in); err != nil {
+		return movieID, err
+	}
+
+	if err := GetSearchIndex().UpsertMovie(ctx, movieID, *in.Title, *in.Genres); err != nil {
+		logrus.Warnf("更新电影 %s 的搜索索引失败: %v", movieID, err)
+	}
+	recordAddedTime(ctx, movieID, info["added_time"])
+	invalidateMovieCaches(movieID)
+	utils.Cache.Delete("total_movies_count")
+
+	logrus.Infof("新建电影 %s: %s", movieID, *in.Title)
+	return movieID, nil
+}
+
+// allocateMovieID 从计数器行分配下一个电影ID，并以CheckAndPut写入_info行。
+// 计数器落后于已有数据时（如导入后首次使用），跳到当前最大ID之后重试。
+func allocateMovieID(ctx context.Context, info map[string][]byte) (string, error) {
+	client := utils.GetClient().(gohbase.Client)
+
+	const maxAttempts = 5
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		next, err := incrementMovieIDCounter(ctx, client, 1)
+		if err != nil {
+			return "", err
+		}
+		movieID := strconv.FormatInt(next, 10)
+
+		put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieInfoKey(movieID), map[string]map[string][]byte{"info": info})
+		if err != nil {
+			return "", err
+		}
+		created, err := client.CheckAndPut(put, "info", "title", nil)
+		if err != nil {
+			return "", fmt.Errorf("写入电影信息失败: %w", err)
+		}
+		if created {
+			return movieID, nil
+		}
+
+		// ID已被占用，把计数器推进到已有最大ID
+		maxID, err := maxNumericMovieID(ctx)
+		if err != nil {
+			return "", err
+		}
+		if maxID > next {
+			if _, err := incrementMovieIDCounter(ctx, client, maxID-next); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return "", fmt.Errorf("分配电影ID失败: 重试%d次后仍冲突", maxAttempts)
+}
+
+// incrementMovieIDCounter 原子递增电影ID计数器并返回新值
+func incrementMovieIDCounter(ctx context.Context, client gohbase.Client, delta int64) (int64, error) {
+	inc, err := hrpc.NewIncStrSingle(ctx, utils.MoviesTable(), movieIDCounterRow, "info", "next_id", delta)
+	if err != nil {
+		return 0, err
+	}
+	value, err := client.Increment(inc)
+	if err != nil {
+		return 0, fmt.Errorf("递增电影ID计数器失败: %w", err)
+	}
+	return value, nil
+}
+
+// maxNumericMovieID 获取已有电影中最大的数字ID
+func maxNumericMovieID(ctx context.Context) (int64, error) {
+	ids, err := ListAllMovieIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var maxID int64
+	for _, id := range ids {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil && n > maxID {
+			maxID = n
+		}
+	}
+	return maxID, nil
+}
+
+// UpdateMovie 部分更新电影的标题、类型和外部链接，并刷新索引和缓存
+func UpdateMovie(ctx context.Context, movieID string, in MovieInput) error {
+	if err := in.normalize(); err != nil {
+		return err
+	}
+
+	existing, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrMovieNotFound
+	}
+
+	info := map[string][]byte{}
+	if in.Title != nil {
+		info["title"] = []byte(*in.Title)
+	}
+	if in.Genres != nil {
+		info["genres"] = []byte(strings.Join(*in.Genres, "|"))
+	}
+	if len(info) > 0 {
+		put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieInfoKey(movieID), map[string]map[string][]byte{"info": info})
+		if err != nil {
+			return err
+		}
+		if _, err := utils.GetClient().(gohbase.Client).Put(put); err != nil {
+			return fmt.Errorf("更新电影信息失败: %w", err)
+		}
+	}
+
+	if err := putMovieLinks(ctx, movieID, in); err != nil {
+		return err
+	}
+
+	// 用更新后的完整信息刷新索引（外部ID从_links行读取）
+	if len(info) > 0 || in.ImdbID != nil || in.TmdbID != nil {
+		title := string(existing["info"]["title"])
+		genres := utils.ParseGenres(string(existing["info"]["genres"]))
+		if in.Title != nil {
+			title = *in.Title
+		}
+		if in.Genres != nil {
+			genres = *in.Genres
+		}
+		if err := GetSearchIndex().UpsertMovie(ctx, movieID, title, genres); err != nil {
+			logrus.Warnf("更新电影 %s 的搜索索引失败: %v", movieID, err)
+		}
+	}
+
+	invalidateMovieCaches(movieID)
+	return nil
+}
+
+// PutMovie 以指定ID新建或整体替换电影的标题和类型，用于修正导入数据中的错误标题。
+// 电影ID必须是数字；title必填，省略genres时为"(no genres listed)"，省略的imdbId/tmdbId保持不变。
+// 返回是否新建了电影。
+func PutMovie(ctx context.Context, movieID string, in MovieInput) (bool, error) {
+	if !isDigits(movieID) {
+		return false, fmt.Errorf("%w: 电影ID必须是数字", ErrInvalidMovieInput)
+	}
+	if in.Title == nil {
+		return false, fmt.Errorf("%w: 标题不能为空", ErrInvalidMovieInput)
+	}
+	if in.Genres == nil {
+		in.Genres = &[]string{}
+	}
+	if err := in.normalize(); err != nil {
+		return false, err
+	}
+
+	existing, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return false, err
+	}
+	created := existing == nil
+
+	info := map[string][]byte{
+		"title":  []byte(*in.Title),
+		"genres": []byte(strings.Join(*in.Genres, "|")),
+	}
+	if created {
+		info["added_time"] = addedTimeValue(time.Now())
+	}
+	put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieInfoKey(movieID), map[string]map[string][]byte{"info": info})
+	if err != nil {
+		return false, err
+	}
+	if _, err := utils.GetClient().(gohbase.Client).Put(put); err != nil {
+		return false, fmt.Errorf("写入电影信息失败: %w", err)
+	}
+
+	if err := putMovieLinks(ctx, movieID, in); err != nil {
+		return created, err
+	}
+
+	if err := GetSearchIndex().UpsertMovie(ctx, movieID, *in.Title, *in.Genres); err != nil {
+		logrus.Warnf("更新电影 %s 的搜索索引失败: %v", movieID, err)
+	}
+	invalidateMovieCaches(movieID)
+	if created {
+		recordAddedTime(ctx, movieID, info["added_time"])
+		utils.Cache.Delete("total_movies_count")
+		logrus.Infof("新建电影 %s: %s", movieID, *in.Title)
+	} else {
+		logrus.Infof("更新电影 %s: %s", movieID, *in.Title)
+	}
+	return created, nil
+}
+
+// PostMovie 写入指定ID电影的元数据：电影不存在时同PutMovie新建（title必填）并记录添加时间，
+// 已存在时同UpdateMovie只更新提供的字段，不修改添加时间。返回是否新建了电影。
+func PostMovie(ctx context.Context, movieID string, in MovieInput) (bool, error) {
+	existing, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		return PutMovie(ctx, movieID, in)
+	}
+	return false, UpdateMovie(ctx, movieID, in)
+}
+
+// DeleteMovie 删除电影的全部行和索引条目
+func DeleteMovie(ctx context.Context, movieID string) error {
+	if err := deleteMovieData(ctx, movieID); err != nil {
+		return err
+	}
+	invalidateMovieCaches(movieID)
+	utils.Cache.Delete("total_movies_count")
+
+	logrus.Infof("已删除电影 %s", movieID)
+	return nil
+}
+
+// maxBatchDeleteMovies 单次批量删除的电影数上限
+const maxBatchDeleteMovies = 100
+
+// batchDeleteWorkers 批量删除的并发数
+const batchDeleteWorkers = 10
+
+// BatchDeleteResult 批量删除结果
+type BatchDeleteResult struct {
+	Deleted  []string          `json:"deleted"`
+	NotFound []string          `json:"notFound"`
+	Errors   map[string]string `json:"errors"` // 电影ID -> 错误信息
+}
+
+// DeleteMovies 并发删除多部电影的全部行和索引条目，单部失败不影响其他电影
+func DeleteMovies(ctx context.Context, ids []string) (BatchDeleteResult, error) {
+	result := BatchDeleteResult{Deleted: []string{}, NotFound: []string{}, Errors: map[string]string{}}
+
+	movieIDs := dedupeMovieIDs(ids)
+	if len(movieIDs) == 0 {
+		return result, fmt.Errorf("%w: ids不能为空", ErrInvalidMovieInput)
+	}
+	if len(movieIDs) > maxBatchDeleteMovies {
+		return result, fmt.Errorf("%w: 单次最多删除%d部电影", ErrInvalidMovieInput, maxBatchDeleteMovies)
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < batchDeleteWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for movieID := range jobs {
+				err := deleteMovieData(ctx, movieID)
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					result.Deleted = append(result.Deleted, movieID)
+				case errors.Is(err, ErrMovieNotFound):
+					result.NotFound = append(result.NotFound, movieID)
+				default:
+					result.Errors[movieID] = err.Error()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, movieID := range movieIDs {
+		jobs <- movieID
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(result.Deleted, func(i, j int) bool { return CompareMovieIDs(result.Deleted[i], result.Deleted[j]) < 0 })
+	sort.Slice(result.NotFound, func(i, j int) bool { return CompareMovieIDs(result.NotFound[i], result.NotFound[j]) < 0 })
+
+	if len(result.Deleted) > 0 {
+		for _, movieID := range result.Deleted {
+			invalidateMovieCaches(movieID)
+		}
+		utils.Cache.Delete("total_movies_count")
+	}
+
+	logrus.Infof("批量删除电影: 删除 %d 部, 不存在 %d 部, 失败 %d 部",
+		len(result.Deleted), len(result.NotFound), len(result.Errors))
+	return result, nil
+}
+
+// deleteMovieData 删除单部电影的全部行和索引条目，不处理缓存
+func deleteMovieData(ctx context.Context, movieID string) error {
+	existing, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrMovieNotFound
+	}
+
+	if err := utils.DeleteMovieAllRows(ctx, movieID); err != nil {
+		return err
+	}
+
+	if err := GetSearchIndex().DeleteMovie(ctx, movieID); err != nil {
+		logrus.Warnf("删除电影 %s 的索引条目失败: %v", movieID, err)
+	}
+	return nil
+}
+
+// putMovieLinks 写入输入中提供的外部链接
+func putMovieLinks(ctx context.Context, movieID string, in MovieInput) error {
+	links := map[string][]byte{}
+	if in.ImdbID != nil {
+		links["imdbId"] = []byte(*in.ImdbID)
+	}
+	if in.TmdbID != nil {
+		links["tmdbId"] = []byte(*in.TmdbID)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieLinksKey(movieID), map[string]map[string][]byte{"info": links})
+	if err != nil {
+		return err
+	}
+	if _, err := utils.GetClient().(gohbase.Client).Put(put); err != nil {
+		return fmt.Errorf("写入电影链接失败: %w", err)
+	}
+	return nil
+}
+
+// invalidateMovieCaches 清除与电影元数据相关的缓存
+func invalidateMovieCaches(movieID string) {
+	utils.Cache.Delete(fmt.Sprintf("movie_detail:%s", movieID))
+	utils.Cache.DeletePrefix("search:")
+	utils.Cache.DeletePrefix("similar_movies:")
+	utils.Cache.DeletePrefix("random_movies:")
+	utils.Cache.DeletePrefix("genome_sim:")
+	utils.Cache.DeletePrefix("movies_by_tag:")
+	utils.Cache.DeletePrefix("movies_by_year:")
+	utils.Cache.DeletePrefix("suggest:")
+	utils.Cache.Delete(genreCountsCacheKey)
+}
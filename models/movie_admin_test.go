@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// createMoviesConcurrently 并发新建n部电影，返回分配的ID（按数值升序）
+func createMoviesConcurrently(t *testing.T, n int) []int {
+	t.Helper()
+	ctx := context.Background()
+	ids := make([]int, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			title := fmt.Sprintf("Concurrent %d (2024)", i)
+			movieID, err := CreateMovie(ctx, MovieInput{Title: &title, Genres: &[]string{"Drama"}})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ids[i], errs[i] = strconv.Atoi(movieID)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("第%d个CreateMovie失败: %v", i, err)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// TestCreateMovieConcurrentIDs 并发新建电影时分配的ID互不相同，计数器已初始化时是连续的
+func TestCreateMovieConcurrentIDs(t *testing.T) {
+	const n = 50
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		seedCounter bool // 计数器已推进到已有最大ID
+		consecutive bool
+	}{
+		{"计数器已初始化", true, true},
+		// 计数器落后于导入的数据时，冲突的请求各自把计数器推进到最大ID之后，ID不重复但可能跳号
+		{"计数器落后于已有数据", false, false},
+	}
+	for _, tt := range tests {
+		client := newTestIndex(t, indexFixture(10))
+		if tt.seedCounter {
+			if _, err := incrementMovieIDCounter(ctx, client, 10); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		ids := createMoviesConcurrently(t, n)
+		for i, id := range ids {
+			if id <= 10 {
+				t.Errorf("%s: 分配了已有的ID %d", tt.name, id)
+			}
+			if i > 0 && id == ids[i-1] {
+				t.Errorf("%s: ID %d 被分配了两次", tt.name, id)
+			}
+		}
+		if tt.consecutive && (ids[0] != 11 || ids[n-1] != 10+n) {
+			t.Errorf("%s: 分配的ID = %v, want 11..%d连续", tt.name, ids, 10+n)
+		}
+
+		for _, id := range ids {
+			if row := client.Row(utils.MoviesTable(), rowkey.MovieInfoKey(strconv.Itoa(id))); row == nil || len(row["info"]["title"]) == 0 {
+				t.Errorf("%s: 电影%d的_info行未写入", tt.name, id)
+			}
+		}
+	}
+}
+
+// TestPostMovieStampsAddedTime POST新建电影时写入添加时间并出现在最近添加列表中，再次POST只更新提供的字段，不修改添加时间
+func TestPostMovieStampsAddedTime(t *testing.T) {
+	client := newTestIndex(t, indexFixture(3))
+	ctx := context.Background()
+	infoKey := rowkey.MovieInfoKey("100")
+
+	title := "Posted (2024)"
+	if _, err := PostMovie(ctx, "100", MovieInput{Genres: &[]string{"Drama"}}); !errors.Is(err, ErrInvalidMovieInput) {
+		t.Errorf("新建时缺少标题: err = %v, want ErrInvalidMovieInput", err)
+	}
+	created, err := PostMovie(ctx, "100", MovieInput{Title: &title, Genres: &[]string{"Drama"}})
+	if err != nil || !created {
+		t.Fatalf("PostMovie新建 = %v, %v, want true, nil", created, err)
+	}
+	addedTime := string(client.Row(utils.MoviesTable(), infoKey)["info"]["added_time"])
+	if addedTime == "" {
+		t.Fatal("新建电影没有写入info:added_time")
+	}
+
+	recent, err := GetRecentlyAddedMovies(ctx, 10)
+	if err != nil {
+		t.Fatalf("读取最近添加的电影失败: %v", err)
+	}
+	if len(recent) != 1 || recent[0].MovieID != "100" {
+		t.Errorf("最近添加的电影 = %+v, want 只有电影100", recent)
+	}
+
+	newTitle := "Posted Again (2024)"
+	created, err = PostMovie(ctx, "100", MovieInput{Title: &newTitle})
+	if err != nil || created {
+		t.Fatalf("PostMovie更新 = %v, %v, want false, nil", created, err)
+	}
+	info := client.Row(utils.MoviesTable(), infoKey)["info"]
+	if string(info["title"]) != newTitle || string(info["genres"]) != "Drama" {
+		t.Errorf("更新后 title = %q, genres = %q, want %q, %q", info["title"], info["genres"], newTitle, "Drama")
+	}
+	if string(info["added_time"]) != addedTime {
+		t.Errorf("更新后 added_time = %q, want 保持 %q", info["added_time"], addedTime)
+	}
+}
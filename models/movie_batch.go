@@ -0,0 +1,79 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"strings"
+)
+
+// MaxBatchMovies 批量获取电影的ID数上限
+const MaxBatchMovies = 50
+
+// ErrInvalidMovieIDs 批量请求的电影ID列表为空或超过上限
+var ErrInvalidMovieIDs = errors.New("电影ID列表无效")
+
+// MovieBatch 批量获取电影的结果
+type MovieBatch struct {
+	Movies   map[string]Movie `json:"movies"`   // 电影ID -> 电影
+	NotFound []string         `json:"notFound"` // 不存在的电影ID，按请求顺序
+}
+
+// GetMoviesBatch 批量获取电影的完整信息（标题、类型、平均评分、链接和标签），ID去重，单次最多MaxBatchMovies部
+func GetMoviesBatch(ctx context.Context, ids []string) (*MovieBatch, error) {
+	movieIDs := dedupeMovieIDs(ids)
+	if len(movieIDs) == 0 {
+		return nil, fmt.Errorf("%w: ids不能为空", ErrInvalidMovieIDs)
+	}
+	if len(movieIDs) > MaxBatchMovies {
+		return nil, fmt.Errorf("%w: 单次最多获取%d部电影", ErrInvalidMovieIDs, MaxBatchMovies)
+	}
+
+	data, err := utils.GetMoviesWithAllDataBatch(ctx, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &MovieBatch{Movies: make(map[string]Movie, len(data)), NotFound: []string{}}
+	for _, movieID := range movieIDs {
+		movieData, ok := data[movieID]
+		title, hasTitle := movieData["title"].(string)
+		// 只剩评分等行、没有_info行的电影视为不存在
+		if !ok || !hasTitle {
+			batch.NotFound = append(batch.NotFound, movieID)
+			continue
+		}
+
+		movie := Movie{MovieID: movieID}
+		movie.setTitle(title)
+		if genres, ok := movieData["genres"].([]string); ok {
+			movie.Genres = genres
+		}
+		if avgRating, ok := movieData["avgRating"].(float64); ok {
+			movie.AvgRating = avgRating
+		}
+		if linksData, ok := movieData["links"].(map[string]interface{}); ok {
+			movie.Links = newLinks(linksData)
+		}
+		if uniqueTags, ok := movieData["uniqueTags"].([]string); ok {
+			movie.Tags = uniqueTags
+		}
+		batch.Movies[movieID] = movie
+	}
+	return batch, nil
+}
+
+// dedupeMovieIDs 去掉空ID和重复ID，保持原顺序
+func dedupeMovieIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	movieIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			movieIDs = append(movieIDs, id)
+		}
+	}
+	return movieIDs
+}
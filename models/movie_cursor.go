@@ -0,0 +1,118 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// ErrInvalidCursor cursor参数无法解码或不是电影行键
+var ErrInvalidCursor = errors.New("无效的cursor参数")
+
+// EncodeMovieCursor 把最后返回的_info行键编码为不透明的游标
+func EncodeMovieCursor(rowKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(rowKey))
+}
+
+// DecodeMovieCursor 解码游标，返回其中的_info行键
+func DecodeMovieCursor(cursor string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", fmt.Errorf("%w: 不是有效的base64", ErrInvalidCursor)
+	}
+	rowKey := string(raw)
+	if !rowkey.IsMovieKeyOfType(rowKey, rowkey.TypeInfo) {
+		return "", fmt.Errorf("%w: 不是电影行键", ErrInvalidCursor)
+	}
+	return rowKey, nil
+}
+
+// GetMoviesListAfter 游标分页：从cursor对应的行键之后开始扫描perPage部电影，不需要跳过前面的页。
+// cursor为空时从第一部电影开始。游标指向的电影已被删除时从其后一行继续，不影响翻页。
+// 还有更多电影时返回的NextCursor非空；totalMovies取自索引或缓存的电影总数，不做全表扫描。
+func GetMoviesListAfter(cursor string, perPage int, fields MovieFields) (*MovieList, error) {
+	if perPage < 1 {
+		perPage = 12
+	}
+	ctx := context.Background()
+
+	startRow := ""
+	if cursor != "" {
+		after, err := DecodeMovieCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		// 扫描起始行包含在结果中，追加\x00得到紧跟其后的行键
+		startRow = after + "\x00"
+	}
+
+	// 多取一行判断是否还有下一页
+	results, err := utils.ScanMovies(ctx, startRow, "", int64(perPage+1))
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(results) > perPage
+	if hasMore {
+		results = results[:perPage]
+	}
+
+	movies := moviesFromInfoResults(results)
+	fillMovieListDetails(ctx, movies, fields, "电影列表")
+
+	totalMovies, err := maintainedMovieCount(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取电影总数失败: %w", err)
+	}
+
+	list := &MovieList{
+		Movies:      movies,
+		TotalMovies: totalMovies,
+		PerPage:     perPage,
+		TotalPages:  (totalMovies + perPage - 1) / perPage,
+	}
+	if hasMore {
+		list.NextCursor = EncodeMovieCursor(string(results[len(results)-1].Cells[0].Row))
+	}
+	return list, nil
+}
+
+// maintainedMovieCount 电影总数：索引就绪时取索引的行数（随增删电影更新），否则取缓存的总数
+func maintainedMovieCount(ctx context.Context) (int, error) {
+	if searchIndex := GetSearchIndex(); searchIndex.IsIndexReady() {
+		count, err := searchIndex.IndexedCount()
+		if err == nil {
+			return count, nil
+		}
+		logrus.Warnf("从索引读取电影总数失败: %v", err)
+	}
+	return GetTotalMoviesCount(ctx)
+}
+
+// moviesFromInfoResults 由_info行的扫描结果构建电影基本信息，跳过非_info行
+func moviesFromInfoResults(results []*hrpc.Result) []Movie {
+	movies := []Movie{}
+	for _, result := range results {
+		if len(result.Cells) == 0 {
+			continue
+		}
+		movieID, ok := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeInfo)
+		if !ok {
+			continue
+		}
+
+		infoFamily := make(map[string][]byte)
+		for _, cell := range result.Cells {
+			if string(cell.Family) == "info" {
+				infoFamily[string(cell.Qualifier)] = cell.Value
+			}
+		}
+		movies = append(movies, movieFromInfo(movieID, infoFamily))
+	}
+	return movies
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// walkMovieCursor 按游标翻页直到没有下一页，afterPage在每页返回后调用
+func walkMovieCursor(t *testing.T, perPage int, afterPage func(page int, list *MovieList)) []string {
+	t.Helper()
+	var ids []string
+	cursor := ""
+	for page := 1; ; page++ {
+		if page > 1000 {
+			t.Fatalf("perPage=%d: 翻页未结束", perPage)
+		}
+		list, err := GetMoviesListAfter(cursor, perPage, MovieFields{"movieId": true, "title": true})
+		if err != nil {
+			t.Fatalf("perPage=%d: 第%d页失败: %v", perPage, page, err)
+		}
+		if len(list.Movies) > perPage {
+			t.Fatalf("perPage=%d: 第%d页返回%d部电影", perPage, page, len(list.Movies))
+		}
+		for _, movie := range list.Movies {
+			ids = append(ids, movie.MovieID)
+		}
+		if afterPage != nil {
+			afterPage(page, list)
+		}
+		if list.NextCursor == "" {
+			return ids
+		}
+		cursor = list.NextCursor
+	}
+}
+
+// checkCatalogWalk 检查翻页结果按行键顺序覆盖want中的每部电影且不重复
+func checkCatalogWalk(t *testing.T, name string, got []string, want map[string]bool) {
+	t.Helper()
+	seen := make(map[string]bool)
+	for i, id := range got {
+		if seen[id] {
+			t.Errorf("%s: 电影%s重复出现", name, id)
+		}
+		seen[id] = true
+		if !want[id] {
+			t.Errorf("%s: 返回了不应出现的电影%s", name, id)
+		}
+		if i > 0 && rowkey.MovieInfoKey(got[i-1]) >= rowkey.MovieInfoKey(id) {
+			t.Errorf("%s: 未按行键顺序: %s 在 %s 之后", name, id, got[i-1])
+		}
+	}
+	for id := range want {
+		if !seen[id] {
+			t.Errorf("%s: 缺少电影%s", name, id)
+		}
+	}
+}
+
+func TestGetMoviesListAfterWalksCatalog(t *testing.T) {
+	movies := indexFixture(25)
+	newTestClient(t, movies)
+	want := make(map[string]bool)
+	for _, movie := range movies {
+		want[movie.ID] = true
+	}
+
+	for _, perPage := range []int{1, 4, 7, 25, 100} {
+		pages := 0
+		ids := walkMovieCursor(t, perPage, func(int, *MovieList) { pages++ })
+		checkCatalogWalk(t, "perPage="+strconv.Itoa(perPage), ids, want)
+		if wantPages := max(1, (len(movies)+perPage-1)/perPage); pages != wantPages {
+			t.Errorf("perPage=%d: 翻了%d页, want %d", perPage, pages, wantPages)
+		}
+	}
+}
+
+// TestGetMoviesListAfterWithDeletes 翻页期间删除游标指向的电影和后面的电影，剩余电影不重复也不遗漏
+func TestGetMoviesListAfterWithDeletes(t *testing.T) {
+	movies := indexFixture(30)
+	client := newTestClient(t, movies)
+	want := make(map[string]bool)
+	var keys []string
+	for _, movie := range movies {
+		want[movie.ID] = true
+		keys = append(keys, rowkey.MovieInfoKey(movie.ID))
+	}
+	sort.Strings(keys)
+
+	deleteMovie := func(id string) {
+		for _, rowType := range rowkey.RowTypes {
+			del, err := hrpc.NewDelStr(context.Background(), utils.MoviesTable(), rowkey.MovieKey(id, rowType), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Delete(del); err != nil {
+				t.Fatal(err)
+			}
+		}
+		delete(want, id)
+	}
+	// 最后一页中的一部电影，翻到时已被删除
+	laterID, _ := rowkey.MovieIDFromKey(keys[len(keys)-2], rowkey.TypeInfo)
+
+	ids := walkMovieCursor(t, 5, func(page int, list *MovieList) {
+		if page == 1 {
+			deleteMovie(list.Movies[len(list.Movies)-1].MovieID) // 游标指向的电影
+			deleteMovie(laterID)
+		}
+	})
+
+	// 第一页已返回的被删电影仍在结果中
+	firstPageDeleted := ids[4]
+	want[firstPageDeleted] = true
+	checkCatalogWalk(t, "删除后翻页", ids, want)
+	if len(ids) != 29 {
+		t.Errorf("共返回%d部电影, want 29", len(ids))
+	}
+}
+
+func TestDecodeMovieCursor(t *testing.T) {
+	tests := []struct {
+		cursor  string
+		want    string
+		wantErr bool
+	}{
+		{EncodeMovieCursor("42_info"), "42_info", false},
+		{EncodeMovieCursor("42_stats"), "", true},
+		{EncodeMovieCursor("not a key"), "", true},
+		{"!!!", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeMovieCursor(tt.cursor)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("DecodeMovieCursor(%q) = (%q, %v), want %q", tt.cursor, got, err, tt.want)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeMovieCursor(%q) 错误应为ErrInvalidCursor: %v", tt.cursor, err)
+		}
+	}
+
+	if _, err := GetMoviesListAfter("!!!", 10, nil); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("GetMoviesListAfter使用无效游标: err = %v, want ErrInvalidCursor", err)
+	}
+}
@@ -4,38 +4,123 @@ import (
 	"context"
 	"fmt"
 	"gohbase/utils"
-	"strconv"
-	"strings"
+	"gohbase/utils/hbase/rowkey"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
 )
 
-// GetMovieByID 根据ID获取电影（带缓存）
+// ratingBuckets 评分分布的分值
+var ratingBuckets = []string{"0.5", "1.0", "1.5", "2.0", "2.5", "3.0", "3.5", "4.0", "4.5", "5.0"}
+
+// movieDetailRows 并发读取的电影各行数据
+type movieDetailRows struct {
+	info    map[string]map[string][]byte
+	stats   map[string]interface{}
+	ratings map[string]interface{}
+	links   map[string]interface{}
+	tags    map[string]interface{}
+	genome  []GenomeTag
+}
+
+// loadMovieDetailRows 并发读取电影的_info、_stats、_ratings、_links、_tags和_genome行。
+// 只有_info行读取失败时返回错误，其他行读取失败时记录日志并当作没有数据
+func loadMovieDetailRows(ctx context.Context, movieID string) (*movieDetailRows, error) {
+	rows := &movieDetailRows{}
+	var infoErr error
+	var wg sync.WaitGroup
+
+	fetch := func(name string, load func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := load(); err != nil {
+				logrus.Warnf("读取电影 %s 的%s行失败: %v", movieID, name, err)
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		rows.info, infoErr = utils.GetMovie(ctx, movieID)
+	}()
+	fetch("stats", func() (err error) {
+		rows.stats, err = utils.GetMovieStats(ctx, movieID)
+		return err
+	})
+	fetch("ratings", func() (err error) {
+		rows.ratings, err = utils.GetMovieRatings(ctx, movieID)
+		return err
+	})
+	fetch("links", func() (err error) {
+		rows.links, err = utils.GetMovieLinks(ctx, movieID)
+		return err
+	})
+	fetch("tags", func() (err error) {
+		rows.tags, err = utils.GetMovieTags(ctx, movieID)
+		return err
+	})
+	fetch("genome", func() error {
+		get, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieGenomeKey(movieID),
+			hrpc.Families(map[string][]string{"genome": nil}))
+		if err != nil {
+			return err
+		}
+		result, err := utils.GetClient().(gohbase.Client).Get(get)
+		if err != nil {
+			return err
+		}
+		rows.genome = topKGenomeTags(result.Cells, genomeTopK)
+		return nil
+	})
+
+	wg.Wait()
+	if infoErr != nil {
+		return nil, infoErr
+	}
+	return rows, nil
+}
+
+// GetMovieByID 根据ID获取电影详情（带缓存）：基本信息、评分统计和分布、标签、外部链接和基因标签，
+// 各行并发读取
 func GetMovieByID(movieID string) (*MovieDetail, error) {
 	// 构建缓存键
 	cacheKey := fmt.Sprintf("movie_detail:%s", movieID)
 
 	// 检查缓存
 	if cachedData, found := utils.Cache.Get(cacheKey); found {
-		return cachedData.(*MovieDetail), nil
+		if detail, ok := cachedData.(*MovieDetail); ok {
+			return detail, nil
+		}
 	}
 
 	ctx := context.Background()
 
-	// 从HBase获取电影数据
-	data, err := utils.GetMovie(ctx, movieID)
+	rows, err := loadMovieDetailRows(ctx, movieID)
 	if err != nil {
 		return nil, err
 	}
 
 	// 如果电影不存在
-	if data == nil {
+	if rows.info == nil {
 		return nil, nil
 	}
 
 	// 解析电影数据
-	movieData := utils.ParseMovieData(movieID, data)
+	movieData := utils.ParseMovieData(movieID, rows.info)
 
 	// 构建电影详情响应
-	detail := &MovieDetail{}
+	detail := &MovieDetail{
+		RatingDistribution: make(map[string]int, len(ratingBuckets)),
+		TaggedUsers:        []map[string]string{},
+		Genome:             []GenomeTag{},
+	}
+	for _, bucket := range ratingBuckets {
+		detail.RatingDistribution[bucket] = 0
+	}
 
 	// 设置基本信息
 	movie := Movie{
@@ -43,78 +128,86 @@ func GetMovieByID(movieID string) (*MovieDetail, error) {
 	}
 
 	if title, ok := movieData["title"].(string); ok {
-		movie.Title = title
-		// 尝试从标题中提取年份
-		if matches := strings.Split(title, " ("); len(matches) > 1 {
-			yearStr := strings.TrimSuffix(matches[len(matches)-1], ")")
-			if year, err := strconv.Atoi(yearStr); err == nil {
-				movie.Year = year
-			}
-		}
+		movie.setTitle(title)
 	}
 
 	if genres, ok := movieData["genres"].([]string); ok {
 		movie.Genres = genres
 	}
 
-	// 使用 utils.GetMovieRatings 获取评分数据，与 /api/ratings/movie/:id 保持一致
-	ratingData, err := utils.GetMovieRatings(ctx, movieID)
-	if err == nil && ratingData != nil {
-		if avgRating, ok := ratingData["avgRating"].(float64); ok {
-			movie.AvgRating = avgRating
+	// 评分分布和评分用户取自ratings行
+	users := make(map[string]bool)
+	ratings, _ := rows.ratings["ratings"].([]map[string]interface{})
+	if _, ok := rows.info["info"]["added_time"]; !ok {
+		backfillAddedTime(ctx, movieID, ratings)
+	}
+	for _, rating := range ratings {
+		if value, ok := rating["rating"].(float64); ok {
+			detail.RatingDistribution[fmt.Sprintf("%.1f", value)]++
+		}
+		if userID, ok := rating["userId"].(string); ok {
+			users[userID] = true
 		}
-	} else {
-		movie.AvgRating = 0.0
 	}
 
-	// 设置链接（使用通用函数）
-	if linksData, err := utils.GetMovieLinks(ctx, movieID); err == nil {
-		linkObj := Links{}
-
-		if imdbId, ok := linksData["imdbId"].(string); ok {
-			linkObj.ImdbID = imdbId
-		}
-		if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
-			linkObj.ImdbURL = imdbUrl
+	// 优先使用stats行中的预计算评分，与列表/搜索接口保持一致
+	var ratingCount int
+	if avgRating, ok := rows.stats["avgRating"].(float64); ok {
+		movie.AvgRating = avgRating
+		if count, ok := rows.stats["ratingCount"].(int); ok {
+			ratingCount = count
 		}
-		if tmdbId, ok := linksData["tmdbId"].(string); ok {
-			linkObj.TmdbID = tmdbId
+	} else {
+		// 没有stats行时，使用ratings行实时计算的结果
+		if avgRating, ok := rows.ratings["avgRating"].(float64); ok {
+			movie.AvgRating = avgRating
 		}
-		if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
-			linkObj.TmdbURL = tmdbUrl
+		if count, ok := rows.ratings["count"].(int); ok {
+			ratingCount = count
 		}
+	}
 
-		movie.Links = linkObj
+	// 设置链接（包含imdb/tmdb完整URL）
+	if rows.links != nil {
+		movie.Links = newLinks(rows.links)
 	}
 
 	// 设置标签（使用通用函数）
-	var tagCount int
-	if tagsData, err := utils.GetMovieTags(ctx, movieID); err == nil {
-		if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
-			movie.Tags = uniqueTags
-		}
-		if taggedUsers, ok := tagsData["taggedUsers"].([]map[string]string); ok {
-			detail.TaggedUsers = taggedUsers
-		}
-		if count, ok := tagsData["tagCount"].(int); ok {
-			tagCount = count
+	var tagCount, userTagCount int
+	if uniqueTags, ok := rows.tags["uniqueTags"].([]string); ok {
+		movie.Tags = uniqueTags
+	}
+	if taggedUsers, ok := rows.tags["taggedUsers"].([]map[string]string); ok {
+		detail.TaggedUsers = taggedUsers
+		for _, tagged := range taggedUsers {
+			users[tagged["userId"]] = true
 		}
 	}
+	if count, ok := rows.tags["tagCount"].(int); ok {
+		tagCount = count
+	}
+	if count, ok := rows.tags["userTagCount"].(int); ok {
+		userTagCount = count
+	}
+	if movie.Tags == nil {
+		movie.Tags = []string{}
+	}
 
-	detail.Movie = movie
-
-	// 获取评分计数，但不填充评分数组
-	var ratingCount int
-	if ratingData != nil {
-		if count, ok := ratingData["count"].(int); ok {
-			ratingCount = count
-		}
+	if rows.genome != nil {
+		detail.Genome = rows.genome
 	}
 
-	// 构建统计数据
+	movie.RatingCount = ratingCount
+	movie.TagCount = tagCount
+	detail.Movie = movie
+
+	// 构建统计数据，uniqueUsers为评分或打过标签的不同用户数
 	detail.Stats = map[string]float64{
-		"ratingCount": float64(ratingCount),
-		"tagCount":    float64(tagCount),
+		"avgRating":    movie.AvgRating,
+		"ratingCount":  float64(ratingCount),
+		"tagCount":     float64(tagCount),
+		"userTagCount": float64(userTagCount),
+		"uniqueUsers":  float64(len(users)),
 	}
 
 	// 将结果存入缓存
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"gohbase/utils"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 导出数量限制
+const (
+	DefaultExportLimit = 10000
+	MaxExportLimit     = 100000
+)
+
+// 导出的分批大小和每批读取附加数据的并发数
+const (
+	exportChunkSize = 200
+	exportWorkers   = 8
+)
+
+// 导出的数据部分（include参数）
+const (
+	ExportInfo    = "info"
+	ExportStats   = "stats"
+	ExportRatings = "ratings"
+	ExportTags    = "tags"
+)
+
+// ErrInvalidExportInclude include参数包含未知的数据部分
+var ErrInvalidExportInclude = errors.New("include只能包含info、stats、ratings、tags")
+
+// ExportInclude 导出的数据部分
+type ExportInclude map[string]bool
+
+// ParseExportInclude 解析逗号分隔的include参数，为空时导出info和stats
+func ParseExportInclude(s string) (ExportInclude, error) {
+	if strings.TrimSpace(s) == "" {
+		return ExportInclude{ExportInfo: true, ExportStats: true}, nil
+	}
+	include := ExportInclude{}
+	for _, part := range strings.Split(s, ",") {
+		switch part = strings.ToLower(strings.TrimSpace(part)); part {
+		case "":
+		case ExportInfo, ExportStats, ExportRatings, ExportTags:
+			include[part] = true
+		default:
+			return nil, ErrInvalidExportInclude
+		}
+	}
+	return include, nil
+}
+
+// ExportedMovie 导出的一行（一部电影），未导出的部分省略
+type ExportedMovie struct {
+	MovieID     string                   `json:"movieId"`
+	Title       string                   `json:"title,omitempty"`
+	Genres      []string                 `json:"genres,omitempty"`
+	AvgRating   *float64                 `json:"avgRating,omitempty"`
+	RatingCount *int                     `json:"ratingCount,omitempty"`
+	Ratings     []map[string]interface{} `json:"ratings,omitempty"` // userId、rating、timestamp
+	Tags        []map[string]string      `json:"tags,omitempty"`    // userId、tag、timestamp
+}
+
+// ExportMovies 按行键顺序扫描_info行，把最多limit部电影以NDJSON（每行一部电影）写入w，返回导出的电影数。
+// 每次扫描exportChunkSize行并关闭扫描器，本批的stats、ratings、tags并发读取，
+// 写完一批后刷新w（实现了Flush时），不在内存中缓存全部数据。
+func ExportMovies(ctx context.Context, w io.Writer, include ExportInclude, limit int) (int, error) {
+	if limit < 1 {
+		limit = DefaultExportLimit
+	}
+	limit = min(limit, MaxExportLimit)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	flusher, _ := w.(interface{ Flush() })
+
+	exported := 0
+	startRow := ""
+	for exported < limit {
+		if err := ctx.Err(); err != nil {
+			return exported, err
+		}
+
+		chunk := min(exportChunkSize, limit-exported)
+		results, err := utils.ScanMovies(ctx, startRow, "", int64(chunk))
+		if err != nil {
+			return exported, err
+		}
+		if len(results) == 0 {
+			break
+		}
+		startRow = string(results[len(results)-1].Cells[0].Row) + "\x00"
+
+		movies := exportChunk(ctx, moviesFromInfoResults(results), include)
+		for i := range movies {
+			if err := enc.Encode(&movies[i]); err != nil {
+				return exported, err
+			}
+		}
+		exported += len(movies)
+
+		if err := bw.Flush(); err != nil {
+			return exported, err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(results) < chunk {
+			break
+		}
+	}
+	return exported, nil
+}
+
+// exportChunk 并发读取一批电影需要导出的stats、ratings、tags，单部读取失败时省略该部分并记录日志
+func exportChunk(ctx context.Context, movies []Movie, include ExportInclude) []ExportedMovie {
+	exported := make([]ExportedMovie, len(movies))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < exportWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				exported[idx] = exportMovie(ctx, movies[idx], include)
+			}
+		}()
+	}
+	for i := range movies {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return exported
+}
+
+// exportMovie 读取一部电影需要导出的部分
+func exportMovie(ctx context.Context, movie Movie, include ExportInclude) ExportedMovie {
+	row := ExportedMovie{MovieID: movie.MovieID}
+	if include[ExportInfo] {
+		row.Title = movie.Title
+		row.Genres = movie.Genres
+	}
+
+	if include[ExportStats] {
+		if stats, err := utils.GetMovieStats(ctx, movie.MovieID); err != nil {
+			logrus.Warnf("导出电影 %s 的stats失败: %v", movie.MovieID, err)
+		} else {
+			if avgRating, ok := stats["avgRating"].(float64); ok {
+				row.AvgRating = &avgRating
+			}
+			if ratingCount, ok := stats["ratingCount"].(int); ok {
+				row.RatingCount = &ratingCount
+			}
+		}
+	}
+
+	if include[ExportRatings] {
+		if data, err := utils.GetMovieRatings(ctx, movie.MovieID); err != nil {
+			logrus.Warnf("导出电影 %s 的评分失败: %v", movie.MovieID, err)
+		} else if ratings, ok := data["ratings"].([]map[string]interface{}); ok {
+			sort.Slice(ratings, func(i, j int) bool {
+				ui, _ := ratings[i]["userId"].(string)
+				uj, _ := ratings[j]["userId"].(string)
+				return ui < uj
+			})
+			row.Ratings = ratings
+		}
+	}
+
+	if include[ExportTags] {
+		if data, err := utils.GetMovieTags(ctx, movie.MovieID); err != nil {
+			logrus.Warnf("导出电影 %s 的标签失败: %v", movie.MovieID, err)
+		} else if tags, ok := data["taggedUsers"].([]map[string]string); ok {
+			row.Tags = tags
+		}
+	}
+	return row
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// 外部ID来源
+const (
+	ExternalSourceImdb = "imdb"
+	ExternalSourceTmdb = "tmdb"
+)
+
+// externalIDPattern 匹配tt0111161、imdb:0111161、imdb:tt0111161和tmdb:278（不区分大小写）
+var externalIDPattern = regexp.MustCompile(`^(?i)(?:(tt)|(imdb):(?:tt)?|(tmdb):)(\d+)$`)
+
+// externalIDs 电影的IMDB和TMDB ID（已规范化）
+type externalIDs struct {
+	Imdb string
+	Tmdb string
+}
+
+func (ids externalIDs) empty() bool {
+	return ids.Imdb == "" && ids.Tmdb == ""
+}
+
+// ParseExternalID 判断查询是否为外部ID，返回来源（imdb或tmdb）和规范化后的ID。
+// 纯数字查询按电影ID处理，不视为外部ID。
+func ParseExternalID(query string) (source, id string, ok bool) {
+	m := externalIDPattern.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return "", "", false
+	}
+	source = ExternalSourceImdb
+	if m[3] != "" {
+		source = ExternalSourceTmdb
+	}
+	return source, normalizeExternalID(m[4]), true
+}
+
+// normalizeExternalID 去掉IMDB的tt前缀和前导0，使0111161与111161视为同一ID
+func normalizeExternalID(id string) string {
+	id = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), "tt")
+	if id == "" {
+		return ""
+	}
+	if trimmed := strings.TrimLeft(id, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
+
+// parseExternalIDCells 从_links行的info:imdbId和info:tmdbId解析外部ID
+func parseExternalIDCells(cells []*hrpc.Cell) externalIDs {
+	var ids externalIDs
+	for _, cell := range cells {
+		if string(cell.Family) != "info" {
+			continue
+		}
+		switch string(cell.Qualifier) {
+		case "imdbId":
+			ids.Imdb = normalizeExternalID(string(cell.Value))
+		case "tmdbId":
+			ids.Tmdb = normalizeExternalID(string(cell.Value))
+		}
+	}
+	return ids
+}
+
+// fetchExternalIDs 读取电影_links行中的外部ID
+func fetchExternalIDs(ctx context.Context, movieID string) (externalIDs, error) {
+	linksData, err := utils.GetMovieLinks(ctx, movieID)
+	if err != nil {
+		return externalIDs{}, err
+	}
+	imdbID, _ := linksData["imdbId"].(string)
+	tmdbID, _ := linksData["tmdbId"].(string)
+	return externalIDs{Imdb: normalizeExternalID(imdbID), Tmdb: normalizeExternalID(tmdbID)}, nil
+}
+
+// nullIfEmpty 空字符串写入为NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// MovieIDByExternalID 在索引中按外部ID精确查找电影ID，found为false表示索引中没有该ID
+func (si *SearchIndex) MovieIDByExternalID(ctx context.Context, source, id string) (movieID string, found bool, err error) {
+	column := "imdb_id"
+	if source == ExternalSourceTmdb {
+		column = "tmdb_id"
+	}
+
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return "", false, err
+	}
+	defer release()
+
+	// 同一外部ID对应多部电影时（数据重复），取ID最小的一部
+	err = db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT movie_id FROM movie_index WHERE %s = ? ORDER BY CAST(movie_id AS INTEGER) LIMIT 1", column),
+		normalizeExternalID(id)).Scan(&movieID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return movieID, true, nil
+}
+
+// searchByExternalID 按外部ID搜索，结果中的电影与GET /api/movies/:id返回的movie相同。
+// ok为false表示索引不可用，调用方应继续普通搜索；外部ID不存在时返回空列表。
+func searchByExternalID(ctx context.Context, source, id string, page, perPage int) (*MovieList, bool) {
+	searchIndex := GetSearchIndex()
+	if !searchIndex.IsIndexReady() {
+		return nil, false
+	}
+
+	movieID, found, err := searchIndex.MovieIDByExternalID(ctx, source, id)
+	if err != nil {
+		logrus.Warnf("按外部ID %s:%s 查找失败，改用普通搜索: %v", source, id, err)
+		return nil, false
+	}
+
+	var movies []Movie
+	if found {
+		detail, err := GetMovieByID(movieID)
+		if err != nil {
+			logrus.Warnf("获取外部ID %s:%s 对应的电影 %s 失败: %v", source, id, movieID, err)
+			return nil, false
+		}
+		// 索引中存在但HBase中已删除时按未找到处理
+		if detail != nil {
+			movies = append(movies, detail.Movie)
+		}
+	}
+
+	result := &MovieList{
+		Movies:      []Movie{},
+		TotalMovies: len(movies),
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  (len(movies) + perPage - 1) / perPage,
+	}
+	if page == 1 {
+		result.Movies = append(result.Movies, movies...)
+	}
+	return result, true
+}
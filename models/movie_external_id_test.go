@@ -0,0 +1,89 @@
+package models
+
+import (
+	"gohbase/utils/hbase/hbasetest"
+	"reflect"
+	"testing"
+)
+
+func TestParseExternalID(t *testing.T) {
+	tests := []struct {
+		query      string
+		wantSource string
+		wantID     string
+		wantOK     bool
+	}{
+		{"tt0111161", ExternalSourceImdb, "111161", true},
+		{"TT0111161", ExternalSourceImdb, "111161", true},
+		{"imdb:0111161", ExternalSourceImdb, "111161", true},
+		{"imdb:tt0111161", ExternalSourceImdb, "111161", true},
+		{"IMDB:111161", ExternalSourceImdb, "111161", true},
+		{"tmdb:278", ExternalSourceTmdb, "278", true},
+		{" tmdb:0278 ", ExternalSourceTmdb, "278", true},
+		{"tt0000000", ExternalSourceImdb, "0", true},
+		{"278", "", "", false},
+		{"tmdb:", "", "", false},
+		{"tmdb:tt278", "", "", false},
+		{"imdb:abc", "", "", false},
+		{"ttx", "", "", false},
+		{"shawshank", "", "", false},
+	}
+	for _, tt := range tests {
+		source, id, ok := ParseExternalID(tt.query)
+		if source != tt.wantSource || id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("ParseExternalID(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.query, source, id, ok, tt.wantSource, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+// TestSearchMoviesByExternalID 按IMDB/TMDB ID搜索返回与GET /api/movies/:id相同的电影，未知ID返回空结果
+func TestSearchMoviesByExternalID(t *testing.T) {
+	newTestIndex(t, []hbasetest.Movie{
+		{ID: "318", Title: "Shawshank Redemption, The (1994)", Genres: "Crime|Drama",
+			Stats: map[string]string{"avg_rating": "4.4", "rating_count": "317"},
+			Links: map[string]string{"imdbId": "0111161", "tmdbId": "278"}},
+		{ID: "1", Title: "Toy Story (1995)", Genres: "Animation|Children",
+			Links: map[string]string{"imdbId": "0114709", "tmdbId": "862"}},
+		// 标题中包含数字，未知外部ID不应退回到文本匹配
+		{ID: "2", Title: "Movie 278 (2000)", Genres: "Drama"},
+	})
+
+	detail, err := GetMovieByID("318")
+	if err != nil || detail == nil {
+		t.Fatalf("GetMovieByID(318) = %v, %v", detail, err)
+	}
+
+	tests := []struct {
+		query  string
+		wantID string // 空表示没有结果
+	}{
+		{"tt0111161", "318"},
+		{"imdb:0111161", "318"},
+		{"imdb:tt111161", "318"},
+		{"tmdb:278", "318"},
+		{"tmdb:862", "1"},
+		{"tt9999999", ""},
+		{"imdb:0000001", ""},
+		{"tmdb:999999", ""},
+	}
+	for _, tt := range tests {
+		result, err := SearchMovies(tt.query, SearchTypeAll, RankRelevance, 1, 10, nil)
+		if err != nil {
+			t.Fatalf("SearchMovies(%q) 失败: %v", tt.query, err)
+		}
+		if tt.wantID == "" {
+			if result.TotalMovies != 0 || len(result.Movies) != 0 {
+				t.Errorf("SearchMovies(%q) = %+v, want 空结果", tt.query, result.Movies)
+			}
+			continue
+		}
+		if result.TotalMovies != 1 || len(result.Movies) != 1 || result.Movies[0].MovieID != tt.wantID {
+			t.Errorf("SearchMovies(%q) = %+v, want 电影%s", tt.query, result.Movies, tt.wantID)
+			continue
+		}
+		if tt.wantID == "318" && !reflect.DeepEqual(result.Movies[0], detail.Movie) {
+			t.Errorf("SearchMovies(%q) = %+v, want 与GetMovieByID相同: %+v", tt.query, result.Movies[0], detail.Movie)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidFields fields参数包含未知字段
+var ErrInvalidFields = errors.New("无效的fields参数")
+
+// movieFieldIndex Movie的JSON字段名 -> 结构体字段下标
+var movieFieldIndex = func() map[string]int {
+	index := make(map[string]int)
+	t := reflect.TypeOf(Movie{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}()
+
+// MovieFieldNames 返回fields参数可选的字段名（Movie的JSON字段名）
+func MovieFieldNames() []string {
+	names := make([]string, 0, len(movieFieldIndex))
+	for name := range movieFieldIndex {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MovieFields 需要返回的Movie字段（JSON字段名），nil表示全部字段
+type MovieFields map[string]bool
+
+// ParseMovieFields 解析逗号分隔的fields参数，为空时返回nil（全部字段）。movieId总是返回。
+func ParseMovieFields(s string) (MovieFields, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	fields := MovieFields{"movieId": true}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := movieFieldIndex[name]; !ok {
+			return nil, fmt.Errorf("%w: 未知字段%q，可选 %s", ErrInvalidFields, name, strings.Join(MovieFieldNames(), ","))
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// Has 是否需要返回指定字段
+func (f MovieFields) Has(name string) bool {
+	return f == nil || f[name]
+}
+
+// key 用于缓存键的字段列表（排序后逗号分隔），nil时为"*"
+func (f MovieFields) key() string {
+	if f == nil {
+		return "*"
+	}
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Project 将电影裁剪为只包含所选字段的map，fields为nil时原样返回
+func (f MovieFields) Project(movie Movie) interface{} {
+	if f == nil {
+		return movie
+	}
+	v := reflect.ValueOf(movie)
+	projected := make(map[string]interface{}, len(f))
+	for name := range f {
+		projected[name] = v.Field(movieFieldIndex[name]).Interface()
+	}
+	return projected
+}
+
+// sparseMovieList 电影列表响应，movies中的电影已按fields裁剪（外层movies字段覆盖内嵌的同名字段）
+type sparseMovieList struct {
+	*MovieList
+	Movies []interface{} `json:"movies"`
+}
+
+// ProjectList 按fields裁剪列表中的电影，分页等其他字段不变；fields为nil时原样返回
+func (f MovieFields) ProjectList(list *MovieList) interface{} {
+	if f == nil || list == nil {
+		return list
+	}
+	movies := make([]interface{}, len(list.Movies))
+	for i, movie := range list.Movies {
+		movies[i] = f.Project(movie)
+	}
+	return sparseMovieList{MovieList: list, Movies: movies}
+}
+
+// sparseMovieDetail 电影详情响应，movie已按fields裁剪
+type sparseMovieDetail struct {
+	*MovieDetail
+	Movie interface{} `json:"movie"`
+}
+
+// ProjectDetail 按fields裁剪详情中的movie，评分、标签用户等其他字段不变；fields为nil时原样返回
+func (f MovieFields) ProjectDetail(detail *MovieDetail) interface{} {
+	if f == nil || detail == nil {
+		return detail
+	}
+	return sparseMovieDetail{MovieDetail: detail, Movie: f.Project(detail.Movie)}
+}
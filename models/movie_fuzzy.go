@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gohbase/utils"
+	"sort"
+	"strings"
+)
+
+// 模糊搜索的限制，避免拼写纠错拖慢搜索
+const (
+	// fuzzyMaxQueryRunes 超过该长度的查询不做模糊匹配
+	fuzzyMaxQueryRunes = 40
+	// fuzzyMaxCandidates 按三元组筛选出的候选电影上限
+	fuzzyMaxCandidates = 5000
+	// fuzzyMaxResults 模糊匹配最多返回的电影数
+	fuzzyMaxResults = 20
+)
+
+// titleTrigrams 返回规范化标题（不含年份）中各词的三元组，词两端补空格，如"toy" → " to"、"toy"、"oy "
+func titleTrigrams(title string) []string {
+	name, _, _ := utils.ExtractYearFromTitle(title)
+	return trigrams(utils.NormalizeTitle(name))
+}
+
+// trigrams 返回normalized中各词去重后的三元组
+func trigrams(normalized string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, word := range strings.Fields(normalized) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			trigram := string(runes[i : i+3])
+			if !seen[trigram] {
+				seen[trigram] = true
+				result = append(result, trigram)
+			}
+		}
+	}
+	return result
+}
+
+// replaceTitleTrigrams 用title的三元组替换电影在movie_trigrams中的记录
+func replaceTitleTrigrams(ctx context.Context, tx *sql.Tx, movieID, title string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_trigrams WHERE movie_id = ?", movieID); err != nil {
+		return fmt.Errorf("删除标题三元组失败: %w", err)
+	}
+	for _, trigram := range titleTrigrams(title) {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO movie_trigrams (trigram, movie_id) VALUES (?, ?)", trigram, movieID); err != nil {
+			return fmt.Errorf("写入标题三元组失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// fuzzyMatch 模糊匹配的候选电影及其与查询的编辑距离
+type fuzzyMatch struct {
+	movie    MovieIdWithTitle
+	distance int
+	shared   int // 与查询共有的三元组数
+}
+
+// fuzzyTitleMatches 标题拼写纠错：先用三元组筛选候选标题，再按与查询的编辑距离排序。
+// 编辑距离取查询与标题中最相近的一段之间的距离（相邻字符交换算一次），
+// 超过maxFuzzyDistance的候选丢弃。调用方持有读锁。
+func fuzzyTitleMatches(ctx context.Context, db *sql.DB, query string) ([]MovieIdWithTitle, error) {
+	normalized := utils.NormalizeTitle(query)
+	queryRunes := []rune(normalized)
+	if len(queryRunes) == 0 || len([]rune(query)) > fuzzyMaxQueryRunes {
+		return nil, nil
+	}
+	queryTrigrams := trigrams(normalized)
+	if len(queryTrigrams) == 0 {
+		return nil, nil
+	}
+
+	// 至少共有三分之一的三元组才作为候选
+	minShared := max(1, len(queryTrigrams)/3)
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(queryTrigrams)), ",")
+	args := make([]interface{}, 0, len(queryTrigrams)+2)
+	for _, trigram := range queryTrigrams {
+		args = append(args, trigram)
+	}
+	args = append(args, minShared, fuzzyMaxCandidates)
+
+	rows, err := db.QueryContext(ctx, `SELECT mi.movie_id, mi.title, c.shared FROM (
+			SELECT movie_id, COUNT(*) AS shared FROM movie_trigrams WHERE trigram IN (`+placeholders+`)
+			GROUP BY movie_id HAVING shared >= ? ORDER BY shared DESC LIMIT ?
+		) c JOIN movie_index mi ON mi.movie_id = c.movie_id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询候选标题失败: %w", err)
+	}
+	defer rows.Close()
+
+	maxDistance := maxFuzzyDistance(len(queryRunes))
+	var matches []fuzzyMatch
+	for rows.Next() {
+		var match fuzzyMatch
+		if err := rows.Scan(&match.movie.ID, &match.movie.Title, &match.shared); err != nil {
+			return nil, err
+		}
+		name, _, _ := utils.ExtractYearFromTitle(match.movie.Title)
+		match.distance = substringEditDistance(queryRunes, []rune(utils.NormalizeTitle(name)))
+		if match.distance <= maxDistance {
+			matches = append(matches, match)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].shared != matches[j].shared {
+			return matches[i].shared > matches[j].shared
+		}
+		return matches[i].movie.ID < matches[j].movie.ID
+	})
+	if len(matches) > fuzzyMaxResults {
+		matches = matches[:fuzzyMaxResults]
+	}
+
+	result := make([]MovieIdWithTitle, len(matches))
+	for i, match := range matches {
+		result[i] = match.movie
+	}
+	return result, nil
+}
+
+// maxFuzzyDistance 允许的最大编辑距离：约每4个字符允许一处错误，至少1处
+func maxFuzzyDistance(queryLen int) int {
+	return max(1, queryLen/4)
+}
+
+// substringEditDistance 返回query与text中任意一段之间的最小编辑距离（插入、删除、替换和相邻字符交换各算一次）。
+// text开头和结尾多出的部分不计入距离。
+func substringEditDistance(query, text []rune) int {
+	// prev2、prev、cur分别为query前i-2、i-1、i个字符对应的一行，列j表示text的前j个字符
+	prev2 := make([]int, len(text)+1)
+	prev := make([]int, len(text)+1)
+	cur := make([]int, len(text)+1)
+	for i := 1; i <= len(query); i++ {
+		cur[0] = i
+		for j := 1; j <= len(text); j++ {
+			cost := 1
+			if query[i-1] == text[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && query[i-1] == text[j-2] && query[i-2] == text[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	best := len(query)
+	for _, distance := range prev {
+		best = min(best, distance)
+	}
+	return best
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// fuzzyFixture 拼写纠错测试的电影
+var fuzzyFixture = []hbasetest.Movie{
+	{ID: "1", Title: "Toy Story (1995)", Genres: "Animation|Children"},
+	{ID: "2", Title: "Godfather, The (1972)", Genres: "Crime|Drama"},
+	{ID: "3", Title: "Shawshank Redemption, The (1994)", Genres: "Crime|Drama"},
+	{ID: "4", Title: "Amélie (Fabuleux destin d'Amélie Poulain, Le) (2001)", Genres: "Comedy|Romance"},
+	{ID: "5", Title: "Matrix, The (1999)", Genres: "Action|Sci-Fi"},
+	{ID: "6", Title: "Heat (1995)", Genres: "Action|Crime"},
+}
+
+// TestFuzzyTitleSearch 有一处拼写错误时仍能找到电影，并标记didYouMean；精确匹配不标记
+func TestFuzzyTitleSearch(t *testing.T) {
+	newTestIndex(t, fuzzyFixture)
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		query          string
+		wantIDs        string
+		wantDidYouMean bool
+	}{
+		{"精确匹配", "toy story", "[1]", false},
+		{"替换", "toy stroy", "[1]", true},
+		{"相邻交换", "godfahter", "[2]", true},
+		{"删除", "shawshnk", "[3]", true},
+		{"插入", "matrixx", "[5]", true},
+		{"替换一个字符", "shawshank redemptoin", "[3]", true},
+		{"不带重音且拼错", "amelei", "[4]", true},
+		{"短查询替换", "heta", "[6]", true},
+		{"错误太多", "xyzzy plugh", "[]", false},
+	}
+	for _, tt := range tests {
+		result, err := GetSearchIndex().SearchMoviesWithIndex(ctx, tt.query, SearchTypeTitle, RankRelevance, 1, 20, MovieFields{"movieId": true})
+		if err != nil {
+			t.Fatalf("%s: 搜索%q失败: %v", tt.name, tt.query, err)
+		}
+		ids := make([]string, 0, len(result.Movies))
+		for _, movie := range result.Movies {
+			ids = append(ids, movie.MovieID)
+		}
+		if got := fmt.Sprint(ids); got != tt.wantIDs || result.DidYouMean != tt.wantDidYouMean {
+			t.Errorf("%s: 搜索%q = %s (didYouMean=%v), want %s (didYouMean=%v)",
+				tt.name, tt.query, got, result.DidYouMean, tt.wantIDs, tt.wantDidYouMean)
+		}
+	}
+}
+
+func TestSubstringEditDistance(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        int
+	}{
+		{"toy", "toy story", 0},
+		{"story", "toy story", 0},
+		{"stroy", "toy story", 1},
+		{"sotry", "toy story", 1},
+		{"stry", "toy story", 1},
+		{"storyy", "toy story", 1},
+		{"abc", "xyz", 3},
+		{"abc", "", 3},
+		{"", "abc", 0},
+	}
+	for _, tt := range tests {
+		if got := substringEditDistance([]rune(tt.query), []rune(tt.text)); got != tt.want {
+			t.Errorf("substringEditDistance(%q, %q) = %d, want %d", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTitleTrigrams(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Toy Story (1995)", "[ to toy oy   st sto tor ory ry ]"},
+		{"Heat", "[ he hea eat at ]"},
+		{"Amélie (2001)", "[ am ame mel eli lie ie ]"},
+		{"Up Up (2009)", "[ up up ]"},
+		{"", "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(titleTrigrams(tt.title)); got != tt.want {
+			t.Errorf("titleTrigrams(%q) = %s, want %s", tt.title, got, tt.want)
+		}
+	}
+}
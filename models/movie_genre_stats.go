@@ -0,0 +1,230 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GenreStatsRefreshInterval 后台重新计算类型评分统计的间隔
+	GenreStatsRefreshInterval = 10 * time.Minute
+	// genreStatsRetryInterval 索引未就绪或计算失败时的重试间隔
+	genreStatsRetryInterval = 30 * time.Second
+)
+
+var (
+	// ErrGenreStatsNotReady 后台任务尚未完成第一次计算
+	ErrGenreStatsNotReady = errors.New("类型统计尚未计算完成，请稍后重试")
+	// ErrInvalidGenreStatsWindow window参数不是正的时长
+	ErrInvalidGenreStatsWindow = errors.New("无效的window参数")
+)
+
+// ParseGenreStatsWindow 解析window参数（如"24h"、"30m"），为空时返回0表示统计全部评分
+func ParseGenreStatsWindow(s string) (time.Duration, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("%w: 应为正的时长，如24h", ErrInvalidGenreStatsWindow)
+	}
+	return window, nil
+}
+
+// GenreStats 一个类型的评分统计
+type GenreStats struct {
+	Genre       string  `json:"genre"`
+	AvgRating   float64 `json:"avgRating"`   // 按评分数加权的平均分
+	MovieCount  int     `json:"movieCount"`  // 全部统计时为该类型的电影数，按窗口统计时为窗口内有评分的电影数
+	RatingCount int     `json:"ratingCount"` // 评分数
+}
+
+// GenreStatsReport 各类型的评分统计，按评分数降序、类型名称升序
+type GenreStatsReport struct {
+	Genres     []GenreStats `json:"genres"`
+	ComputedAt time.Time    `json:"computedAt"`      // 电影类型和评分统计的计算时间
+	Since      *time.Time   `json:"since,omitempty"` // 按窗口统计时只包含该时间之后的评分
+}
+
+// genreStatsState 后台计算的结果，movieGenres供按窗口统计时查找电影的类型
+var genreStatsState struct {
+	mu          sync.RWMutex
+	report      *GenreStatsReport
+	movieGenres map[string][]string
+}
+
+// GetGenreStats 返回后台最近一次计算的各类型评分统计，尚未计算时返回ErrGenreStatsNotReady
+func GetGenreStats() (*GenreStatsReport, error) {
+	genreStatsState.mu.RLock()
+	defer genreStatsState.mu.RUnlock()
+
+	if genreStatsState.report == nil {
+		return nil, ErrGenreStatsNotReady
+	}
+	return genreStatsState.report, nil
+}
+
+// GetGenreStatsForRatings 按类型汇总since之后的评分，ratings为电影ID -> 评分列表。
+// 电影的类型取自后台最近一次计算，之后新增的电影不计入。
+func GetGenreStatsForRatings(ratings map[string][]float64, since time.Time) (*GenreStatsReport, error) {
+	genreStatsState.mu.RLock()
+	defer genreStatsState.mu.RUnlock()
+
+	if genreStatsState.report == nil {
+		return nil, ErrGenreStatsNotReady
+	}
+
+	acc := newGenreStatsAccumulator()
+	for movieID, movieRatings := range ratings {
+		var sum float64
+		for _, rating := range movieRatings {
+			sum += rating
+		}
+		acc.add(genreStatsState.movieGenres[movieID], sum, len(movieRatings))
+	}
+	return &GenreStatsReport{
+		Genres:     acc.result(),
+		ComputedAt: genreStatsState.report.ComputedAt,
+		Since:      &since,
+	}, nil
+}
+
+// RefreshGenreStats 从SQLite索引中每部电影的类型和评分统计重新计算各类型的评分统计
+func RefreshGenreStats(ctx context.Context) error {
+	movieGenres, acc, err := loadGenreStatsFromIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	report := &GenreStatsReport{Genres: acc.result(), ComputedAt: time.Now()}
+	genreStatsState.mu.Lock()
+	genreStatsState.report = report
+	genreStatsState.movieGenres = movieGenres
+	genreStatsState.mu.Unlock()
+	return nil
+}
+
+// StartGenreStatsRefresher 立即在后台计算类型评分统计，之后每隔interval重新计算；
+// 索引未就绪或计算失败时按较短的间隔重试。ctx取消时退出
+func StartGenreStatsRefresher(ctx context.Context, interval time.Duration) {
+	go func() {
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				next := interval
+				if err := RefreshGenreStats(ctx); err != nil {
+					logrus.Debugf("计算类型评分统计失败，%v后重试: %v", genreStatsRetryInterval, err)
+					next = genreStatsRetryInterval
+				}
+				timer.Reset(next)
+			}
+		}
+	}()
+}
+
+// loadGenreStatsFromIndex 读取索引中每部电影的类型和评分统计，返回电影ID -> 类型和按类型的汇总
+func loadGenreStatsFromIndex(ctx context.Context) (map[string][]string, *genreStatsAccumulator, error) {
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, nil, fmt.Errorf("搜索索引未就绪")
+	}
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	rows, err := db.QueryContext(ctx, "SELECT movie_id, COALESCE(genres, ''), COALESCE(avg_rating, 0), COALESCE(rating_count, 0) FROM movie_index")
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取索引中的评分统计失败: %w", err)
+	}
+	defer rows.Close()
+
+	movieGenres := make(map[string][]string)
+	acc := newGenreStatsAccumulator()
+	for rows.Next() {
+		var movieID, genres string
+		var avgRating float64
+		var ratingCount int
+		if err := rows.Scan(&movieID, &genres, &avgRating, &ratingCount); err != nil {
+			return nil, nil, err
+		}
+		parsed := utils.ParseGenres(genres)
+		movieGenres[movieID] = parsed
+		acc.add(parsed, avgRating*float64(ratingCount), ratingCount)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return movieGenres, acc, nil
+}
+
+// genreStatsAccumulator 按类型（忽略大小写）累计电影数、评分数和评分总和
+type genreStatsAccumulator struct {
+	names   map[string]string
+	movies  map[string]int
+	ratings map[string]int
+	sums    map[string]float64
+}
+
+func newGenreStatsAccumulator() *genreStatsAccumulator {
+	return &genreStatsAccumulator{
+		names:   make(map[string]string),
+		movies:  make(map[string]int),
+		ratings: make(map[string]int),
+		sums:    make(map[string]float64),
+	}
+}
+
+// add 把一部电影的评分总和和评分数计入它的每个类型
+func (a *genreStatsAccumulator) add(genres []string, ratingSum float64, ratingCount int) {
+	seen := make(map[string]bool, len(genres))
+	for _, genre := range genres {
+		key := strings.ToLower(genre)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, ok := a.names[key]; !ok {
+			a.names[key] = canonicalGenreName(genre)
+		}
+		a.movies[key]++
+		a.ratings[key] += ratingCount
+		a.sums[key] += ratingSum
+	}
+}
+
+// result 按评分数降序、类型名称升序返回各类型的统计
+func (a *genreStatsAccumulator) result() []GenreStats {
+	stats := make([]GenreStats, 0, len(a.names))
+	for key, name := range a.names {
+		genre := GenreStats{Genre: name, MovieCount: a.movies[key], RatingCount: a.ratings[key]}
+		if genre.RatingCount > 0 {
+			genre.AvgRating = a.sums[key] / float64(genre.RatingCount)
+		}
+		stats = append(stats, genre)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].RatingCount != stats[j].RatingCount {
+			return stats[i].RatingCount > stats[j].RatingCount
+		}
+		return stats[i].Genre < stats[j].Genre
+	})
+	return stats
+}
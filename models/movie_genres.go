@@ -0,0 +1,174 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// genreCountsCacheKey 类型统计的缓存键
+	genreCountsCacheKey = "genre_counts"
+	// genreCountsExpiration 类型统计的缓存时间，应长于后台刷新间隔
+	genreCountsExpiration = time.Hour
+	// GenreCountsRefreshInterval 后台刷新类型统计的间隔
+	GenreCountsRefreshInterval = 30 * time.Minute
+)
+
+// GenreCount 类型及其电影数，用于筛选界面
+type GenreCount struct {
+	Genre string `json:"genre"`
+	Count int    `json:"count"`
+}
+
+// genreCountsMu 避免缓存失效时多个请求同时扫描
+var genreCountsMu sync.Mutex
+
+// GetGenreCounts 获取全部类型及其电影数（带缓存），按电影数降序
+func GetGenreCounts(ctx context.Context) ([]GenreCount, error) {
+	if cached, found := utils.Cache.Get(genreCountsCacheKey); found {
+		if genres, ok := cached.([]GenreCount); ok {
+			return genres, nil
+		}
+	}
+
+	genreCountsMu.Lock()
+	defer genreCountsMu.Unlock()
+
+	// 等待锁期间可能已被其他请求计算
+	if cached, found := utils.Cache.Get(genreCountsCacheKey); found {
+		if genres, ok := cached.([]GenreCount); ok {
+			return genres, nil
+		}
+	}
+	return RefreshGenreCounts(ctx)
+}
+
+// RefreshGenreCounts 重新统计类型并写入缓存。优先读取SQLite索引，索引不可用时扫描HBase _info行。
+func RefreshGenreCounts(ctx context.Context) ([]GenreCount, error) {
+	var genreLists []string
+
+	if GetSearchIndex().IsIndexReady() {
+		var err error
+		genreLists, err = listGenresFromIndex(ctx)
+		if err != nil {
+			logrus.Warnf("从索引读取类型失败，回退到HBase扫描: %v", err)
+			genreLists = nil
+		}
+	}
+
+	if genreLists == nil {
+		results, err := utils.ScanMovies(ctx, "", "", math.MaxInt64)
+		if err != nil {
+			return nil, fmt.Errorf("扫描电影类型失败: %w", err)
+		}
+		genreLists = make([]string, 0, len(results))
+		for _, result := range results {
+			for _, cell := range result.Cells {
+				if string(cell.Family) == "info" && string(cell.Qualifier) == "genres" {
+					genreLists = append(genreLists, string(cell.Value))
+				}
+			}
+		}
+	}
+
+	counts := countGenres(genreLists)
+	utils.Cache.SetWithExpiration(genreCountsCacheKey, counts, genreCountsExpiration)
+	return counts, nil
+}
+
+// StartGenreCountsRefresher 在后台定期刷新类型统计，ctx取消时退出
+func StartGenreCountsRefresher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := RefreshGenreCounts(ctx); err != nil {
+					logrus.Warnf("刷新类型统计失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// listGenresFromIndex 从SQLite索引读取每部电影的类型字段
+func listGenresFromIndex(ctx context.Context) ([]string, error) {
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := db.QueryContext(ctx, "SELECT COALESCE(genres, '') FROM movie_index")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genreLists := []string{}
+	for rows.Next() {
+		var genres string
+		if err := rows.Scan(&genres); err != nil {
+			return nil, err
+		}
+		genreLists = append(genreLists, genres)
+	}
+	return genreLists, rows.Err()
+}
+
+// countGenres 统计每个类型的电影数，大小写不同的类型合并，名称使用MovieLens的标准写法
+func countGenres(genreLists []string) []GenreCount {
+	counts := make(map[string]int)
+	names := make(map[string]string)
+
+	for _, raw := range genreLists {
+		seen := make(map[string]bool)
+		for _, genre := range utils.ParseGenres(raw) {
+			key := strings.ToLower(genre)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			counts[key]++
+			if _, ok := names[key]; !ok {
+				names[key] = canonicalGenreName(genre)
+			}
+		}
+	}
+
+	result := make([]GenreCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, GenreCount{Genre: names[key], Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Genre < result[j].Genre
+	})
+	return result
+}
+
+// canonicalGenreName 已知类型返回标准写法，未知类型保持原样
+func canonicalGenreName(genre string) string {
+	if name, ok := knownGenres[strings.ToLower(genre)]; ok {
+		return name
+	}
+	return genre
+}
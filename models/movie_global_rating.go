@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+const (
+	// globalRatingCacheKey 全局平均评分的缓存键
+	globalRatingCacheKey = "global_avg_rating"
+	// globalRatingExpiration 全局平均评分的缓存时间
+	globalRatingExpiration = time.Hour
+)
+
+// GlobalRating 全部电影按评分数加权的平均评分
+type GlobalRating struct {
+	GlobalAvg    float64   `json:"global_avg"`
+	TotalMovies  int       `json:"total_movies"` // 有_stats行的电影数
+	TotalRatings int       `json:"total_ratings"`
+	ComputedAt   time.Time `json:"computed_at"`
+}
+
+// globalRatingMu 避免缓存失效时多个请求同时扫描
+var globalRatingMu sync.Mutex
+
+// ComputeGlobalAverageRating 扫描全部_stats行，按评分数加权计算全局平均评分并缓存1小时
+func ComputeGlobalAverageRating(ctx context.Context) (float64, error) {
+	rating, err := computeGlobalRating(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return rating.GlobalAvg, nil
+}
+
+// GetGlobalRating 获取全局平均评分（带缓存），缓存失效时重新扫描_stats行
+func GetGlobalRating(ctx context.Context) (*GlobalRating, error) {
+	if cached, found := utils.Cache.Get(globalRatingCacheKey); found {
+		if rating, ok := cached.(*GlobalRating); ok {
+			return rating, nil
+		}
+	}
+
+	globalRatingMu.Lock()
+	defer globalRatingMu.Unlock()
+
+	// 等待锁期间可能已被其他请求计算
+	if cached, found := utils.Cache.Get(globalRatingCacheKey); found {
+		if rating, ok := cached.(*GlobalRating); ok {
+			return rating, nil
+		}
+	}
+	return computeGlobalRating(ctx)
+}
+
+// cachedGlobalAverage 返回缓存中的全局平均评分，不触发计算
+func cachedGlobalAverage() (float64, bool) {
+	cached, found := utils.Cache.Get(globalRatingCacheKey)
+	if !found {
+		return 0, false
+	}
+	rating, ok := cached.(*GlobalRating)
+	if !ok || rating.TotalRatings == 0 {
+		return 0, false
+	}
+	return rating.GlobalAvg, true
+}
+
+// computeGlobalRating 扫描_stats行计算全局平均评分并写入缓存
+func computeGlobalRating(ctx context.Context) (*GlobalRating, error) {
+	rating := &GlobalRating{}
+	var sum float64
+	err := utils.ScanStatsRows(ctx, func(movieID string, cells []*hrpc.Cell) error {
+		stats, ok := parseIndexedStats(cells)
+		if !ok {
+			return nil
+		}
+		rating.TotalMovies++
+		rating.TotalRatings += stats.RatingCount
+		sum += stats.AvgRating * float64(stats.RatingCount)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描评分统计失败: %w", err)
+	}
+
+	if rating.TotalRatings > 0 {
+		rating.GlobalAvg = sum / float64(rating.TotalRatings)
+	}
+	rating.ComputedAt = time.Now()
+	utils.Cache.SetWithExpiration(globalRatingCacheKey, rating, globalRatingExpiration)
+	return rating, nil
+}
@@ -0,0 +1,498 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"io"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// 一致性检查的问题类别
+const (
+	IntegrityMissingStats       = "missing_stats"        // 有评分但没有_stats行
+	IntegrityStaleAvg           = "stale_avg"            // _stats的平均分或评分数与_ratings不一致
+	IntegrityMissingUserEntry   = "missing_user_entry"   // _ratings中的评分在users表中不存在
+	IntegrityUserRatingMismatch = "user_rating_mismatch" // users表中的评分值与_ratings不一致
+	IntegrityOrphanUserEntry    = "orphan_user_entry"    // users表中的评分在_ratings中不存在
+)
+
+const (
+	defaultIntegrityWorkers    = 4
+	maxIntegrityWorkers        = 16
+	defaultIntegrityUserSample = 1000
+	maxIntegrityMismatches     = 1000 // 报告中保留的问题明细上限
+	integrityAvgTolerance      = 1e-4
+)
+
+// IntegrityOptions 一致性检查参数
+type IntegrityOptions struct {
+	SampleSize     int  // 随机抽查的电影数，0表示遍历全部电影
+	UserSampleSize int  // 反向检查的用户数，0使用默认值，-1表示跳过
+	Workers        int  // 并发数
+	Repair         bool // 是否以_ratings为准重写_stats和users表
+}
+
+// IntegrityMismatch 一条不一致记录
+type IntegrityMismatch struct {
+	Category string `json:"category"`
+	MovieID  string `json:"movieId"`
+	UserID   string `json:"userId,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Repaired bool   `json:"repaired"`
+}
+
+// IntegrityReport 一致性检查报告，检查过程中实时更新
+type IntegrityReport struct {
+	mu                  sync.Mutex
+	Status              string              `json:"status"` // running, completed, cancelled, failed
+	StartedAt           string              `json:"startedAt"`
+	FinishedAt          string              `json:"finishedAt,omitempty"`
+	Duration            string              `json:"duration,omitempty"`
+	Mode                string              `json:"mode"` // full 或 sample
+	Repair              bool                `json:"repair"`
+	TotalMovies         int                 `json:"totalMovies"`
+	MoviesChecked       int                 `json:"moviesChecked"`
+	UsersChecked        int                 `json:"usersChecked"`
+	Counts              map[string]int      `json:"counts"`
+	Repaired            map[string]int      `json:"repaired"`
+	RepairErrors        int                 `json:"repairErrors"`
+	Mismatches          []IntegrityMismatch `json:"mismatches"`
+	MismatchesTruncated bool                `json:"mismatchesTruncated"`
+	Error               string              `json:"error,omitempty"`
+}
+
+// NewIntegrityReport 创建一份空报告
+func NewIntegrityReport(opts IntegrityOptions) *IntegrityReport {
+	mode := "full"
+	if opts.SampleSize > 0 {
+		mode = "sample"
+	}
+	return &IntegrityReport{
+		Status:     "running",
+		StartedAt:  time.Now().Format(time.RFC3339),
+		Mode:       mode,
+		Repair:     opts.Repair,
+		Counts:     make(map[string]int),
+		Repaired:   make(map[string]int),
+		Mismatches: []IntegrityMismatch{},
+	}
+}
+
+// Snapshot 返回报告副本
+func (r *IntegrityReport) Snapshot() *IntegrityReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := &IntegrityReport{
+		Status:              r.Status,
+		StartedAt:           r.StartedAt,
+		FinishedAt:          r.FinishedAt,
+		Duration:            r.Duration,
+		Mode:                r.Mode,
+		Repair:              r.Repair,
+		TotalMovies:         r.TotalMovies,
+		MoviesChecked:       r.MoviesChecked,
+		UsersChecked:        r.UsersChecked,
+		Counts:              make(map[string]int, len(r.Counts)),
+		Repaired:            make(map[string]int, len(r.Repaired)),
+		RepairErrors:        r.RepairErrors,
+		Mismatches:          append([]IntegrityMismatch(nil), r.Mismatches...),
+		MismatchesTruncated: r.MismatchesTruncated,
+		Error:               r.Error,
+	}
+	for k, v := range r.Counts {
+		snapshot.Counts[k] = v
+	}
+	for k, v := range r.Repaired {
+		snapshot.Repaired[k] = v
+	}
+	return snapshot
+}
+
+// IsRunning 检查是否仍在运行
+func (r *IntegrityReport) IsRunning() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Status == "running"
+}
+
+// addMismatch 记录一条不一致
+func (r *IntegrityReport) addMismatch(m IntegrityMismatch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Counts[m.Category]++
+	if m.Repaired {
+		r.Repaired[m.Category]++
+	}
+	if len(r.Mismatches) < maxIntegrityMismatches {
+		r.Mismatches = append(r.Mismatches, m)
+	} else {
+		r.MismatchesTruncated = true
+	}
+}
+
+// VerifyRatingsIntegrity 同步执行一致性检查并返回报告
+func VerifyRatingsIntegrity(ctx context.Context, opts IntegrityOptions) (*IntegrityReport, error) {
+	report := NewIntegrityReport(opts)
+	err := RunIntegrityCheck(ctx, opts, report)
+	return report.Snapshot(), err
+}
+
+// RunIntegrityCheck 以_ratings行为准，检查_stats行和users表，结果写入report。
+// 正向：逐部电影比较_stats和users表；反向：抽查users表中的评分是否存在于_ratings。
+func RunIntegrityCheck(ctx context.Context, opts IntegrityOptions, report *IntegrityReport) error {
+	startedAt := time.Now()
+	err := runIntegrityCheck(ctx, opts, report)
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.FinishedAt = time.Now().Format(time.RFC3339)
+	report.Duration = time.Since(startedAt).String()
+	switch {
+	case errors.Is(err, context.Canceled):
+		report.Status = "cancelled"
+	case err != nil:
+		report.Status = "failed"
+		report.Error = err.Error()
+	default:
+		report.Status = "completed"
+	}
+	return err
+}
+
+func runIntegrityCheck(ctx context.Context, opts IntegrityOptions, report *IntegrityReport) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = defaultIntegrityWorkers
+	}
+	if workers > maxIntegrityWorkers {
+		workers = maxIntegrityWorkers
+	}
+
+	ids, err := ListAllMovieIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if opts.SampleSize > 0 && opts.SampleSize < len(ids) {
+		rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		ids = ids[:opts.SampleSize]
+	}
+
+	report.mu.Lock()
+	report.TotalMovies = len(ids)
+	report.mu.Unlock()
+
+	logrus.Infof("开始一致性检查: %d 部电影, 并发 %d, 修复=%t", len(ids), workers, opts.Repair)
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for movieID := range jobs {
+				if err := verifyMovie(ctx, movieID, opts.Repair, report); err != nil {
+					logrus.Debugf("检查电影 %s 失败: %v", movieID, err)
+				}
+				report.mu.Lock()
+				report.MoviesChecked++
+				report.mu.Unlock()
+			}
+		}()
+	}
+
+dispatch:
+	for _, movieID := range ids {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- movieID:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	userSample := opts.UserSampleSize
+	if userSample == 0 {
+		userSample = defaultIntegrityUserSample
+	}
+	if userSample < 0 {
+		return nil
+	}
+	return verifyUserEntries(ctx, userSample, workers, opts.Repair, report)
+}
+
+// ratingEntry _ratings行中的一条评分
+type ratingEntry struct {
+	Rating    float64
+	Timestamp int64
+}
+
+// verifyMovie 检查单部电影的_stats行和users表
+func verifyMovie(ctx context.Context, movieID string, repair bool, report *IntegrityReport) error {
+	ratings, err := loadRatingsRow(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	var sum float64
+	for _, entry := range ratings {
+		sum += entry.Rating
+	}
+	count := len(ratings)
+	avg := sum / float64(count)
+
+	stats, err := utils.GetMovieStats(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	statsAvg, hasAvg := stats["avgRating"].(float64)
+	statsCount, hasCount := stats["ratingCount"].(int)
+
+	category := ""
+	switch {
+	case !hasAvg && !hasCount:
+		category = IntegrityMissingStats
+	case !hasAvg || !hasCount || statsCount != count || math.Abs(statsAvg-avg) > integrityAvgTolerance:
+		category = IntegrityStaleAvg
+	}
+	if category != "" {
+		m := IntegrityMismatch{
+			Category: category,
+			MovieID:  movieID,
+			Expected: fmt.Sprintf("avg=%.4f count=%d", avg, count),
+		}
+		if category == IntegrityStaleAvg {
+			m.Actual = fmt.Sprintf("avg=%.4f count=%d", statsAvg, statsCount)
+		}
+		if repair {
+			if err := StoreMovieAvgRatingToStats(ctx, movieID, avg, count); err != nil {
+				report.repairFailed(err)
+			} else {
+				m.Repaired = true
+			}
+		}
+		report.addMismatch(m)
+	}
+
+	for userID, entry := range ratings {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := verifyUserEntry(ctx, movieID, userID, entry, repair, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyUserEntry 检查users表中是否有与_ratings一致的评分
+func verifyUserEntry(ctx context.Context, movieID, userID string, entry ratingEntry, repair bool, report *IntegrityReport) error {
+	value, err := getSingleCell(ctx, utils.UsersTable(), userID, "movies", movieID)
+	if err != nil {
+		return err
+	}
+
+	expected := fmt.Sprintf("%.1f", entry.Rating)
+	category := ""
+	actual := ""
+	if value == nil {
+		category = IntegrityMissingUserEntry
+	} else {
+		parsed, ok := utils.ParseRatingValue(string(value))
+		if !ok || math.Abs(parsed.Rating-entry.Rating) > integrityAvgTolerance {
+			category = IntegrityUserRatingMismatch
+			actual = string(value)
+		}
+	}
+	if category == "" {
+		return nil
+	}
+
+	m := IntegrityMismatch{
+		Category: category,
+		MovieID:  movieID,
+		UserID:   userID,
+		Expected: expected,
+		Actual:   actual,
+	}
+	if repair {
+		userValue := utils.UserRatingValue(entry.Rating, movieID, entry.Timestamp)
+		if err := putCell(ctx, utils.UsersTable(), userID, "movies", movieID, userValue); err != nil {
+			report.repairFailed(err)
+		} else {
+			m.Repaired = true
+		}
+	}
+	report.addMismatch(m)
+	return nil
+}
+
+// verifyUserEntries 抽查users表，找出_ratings中不存在的评分
+func verifyUserEntries(ctx context.Context, limit, workers int, repair bool, report *IntegrityReport) error {
+	scan, err := hrpc.NewScanStr(ctx, utils.UsersTable(), hrpc.Families(map[string][]string{"movies": nil}))
+	if err != nil {
+		return err
+	}
+	scanner := utils.GetClient().(gohbase.Client).Scan(scan)
+	defer scanner.Close()
+
+	type userCell struct {
+		userID  string
+		movieID string
+	}
+	jobs := make(chan userCell)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				value, err := getSingleCell(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(job.movieID), "ratings", job.userID)
+				if err != nil || value != nil {
+					continue
+				}
+				m := IntegrityMismatch{
+					Category: IntegrityOrphanUserEntry,
+					MovieID:  job.movieID,
+					UserID:   job.userID,
+				}
+				if repair {
+					if err := deleteCell(ctx, utils.UsersTable(), job.userID, "movies", job.movieID); err != nil {
+						report.repairFailed(err)
+					} else {
+						m.Repaired = true
+					}
+				}
+				report.addMismatch(m)
+			}
+		}()
+	}
+
+	var scanErr error
+	for users := 0; users < limit; users++ {
+		res, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			scanErr = fmt.Errorf("扫描users表失败: %w", err)
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			scanErr = err
+			break
+		}
+		if len(res.Cells) == 0 {
+			continue
+		}
+
+		userID := string(res.Cells[0].Row)
+		for _, cell := range res.Cells {
+			select {
+			case jobs <- userCell{userID: userID, movieID: string(cell.Qualifier)}:
+			case <-ctx.Done():
+			}
+		}
+		report.mu.Lock()
+		report.UsersChecked++
+		report.mu.Unlock()
+	}
+	close(jobs)
+	wg.Wait()
+
+	return scanErr
+}
+
+// repairFailed 记录一次修复失败
+func (r *IntegrityReport) repairFailed(err error) {
+	logrus.Warnf("一致性修复失败: %v", err)
+	r.mu.Lock()
+	r.RepairErrors++
+	r.mu.Unlock()
+}
+
+// loadRatingsRow 读取电影_ratings行，返回userId到评分的映射
+func loadRatingsRow(ctx context.Context, movieID string) (map[string]ratingEntry, error) {
+	get, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(movieID),
+		hrpc.Families(map[string][]string{"ratings": nil}))
+	if err != nil {
+		return nil, err
+	}
+	result, err := utils.GetClient().(gohbase.Client).Get(get)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make(map[string]ratingEntry, len(result.Cells))
+	for _, cell := range result.Cells {
+		parsed, ok := utils.ParseRatingValue(string(cell.Value))
+		if !ok {
+			continue
+		}
+		ratings[string(cell.Qualifier)] = ratingEntry{Rating: parsed.Rating, Timestamp: parsed.Timestamp}
+	}
+	return ratings, nil
+}
+
+// getSingleCell 读取单个单元格，不存在时返回nil
+func getSingleCell(ctx context.Context, table, rowKey, family, qualifier string) ([]byte, error) {
+	get, err := hrpc.NewGetStr(ctx, table, rowKey,
+		hrpc.Families(map[string][]string{family: {qualifier}}))
+	if err != nil {
+		return nil, err
+	}
+	result, err := utils.GetClient().(gohbase.Client).Get(get)
+	if err != nil {
+		return nil, err
+	}
+	for _, cell := range result.Cells {
+		if string(cell.Qualifier) == qualifier {
+			return cell.Value, nil
+		}
+	}
+	return nil, nil
+}
+
+// putCell 写入单个单元格
+func putCell(ctx context.Context, table, rowKey, family, qualifier string, value []byte) error {
+	put, err := hrpc.NewPutStr(ctx, table, rowKey, map[string]map[string][]byte{
+		family: {qualifier: value},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = utils.GetClient().(gohbase.Client).Put(put)
+	return err
+}
+
+// deleteCell 删除单个单元格
+func deleteCell(ctx context.Context, table, rowKey, family, qualifier string) error {
+	del, err := hrpc.NewDelStr(ctx, table, rowKey, map[string]map[string][]byte{
+		family: {qualifier: nil},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = utils.GetClient().(gohbase.Client).Delete(del)
+	return err
+}
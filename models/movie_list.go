@@ -4,18 +4,19 @@ import (
 	"context"
 	"fmt"
 	"gohbase/utils"
-	"strconv"
-	"strings"
-
-	"github.com/tsuna/gohbase/hrpc"
 )
 
+// movieListScanParallelism 电影列表分页扫描的并行度
+const movieListScanParallelism = 4
+
 // GetTotalMoviesCount 获取电影总数
 func GetTotalMoviesCount(ctx context.Context) (int, error) {
 	// 使用缓存优化性能
 	cacheKey := "total_movies_count"
 	if cachedCount, found := utils.Cache.Get(cacheKey); found {
-		return cachedCount.(int), nil
+		if count, ok := cachedCount.(int); ok {
+			return count, nil
+		}
 	}
 
 	// 使用 ScanMoviesWithPagination，它会返回总数，而不直接使用客户端
@@ -30,8 +31,8 @@ func GetTotalMoviesCount(ctx context.Context) (int, error) {
 	return totalCount, nil
 }
 
-// GetMoviesList 获取电影列表（适配新的数据库结构）
-func GetMoviesList(page, perPage int) (*MovieList, error) {
+// GetMoviesList 获取电影列表（适配新的数据库结构），fields不为nil时只读取所需字段对应的行
+func GetMoviesList(page, perPage int, fields MovieFields) (*MovieList, error) {
 	ctx := context.Background()
 
 	// 获取总电影数
@@ -40,8 +41,8 @@ func GetMoviesList(page, perPage int) (*MovieList, error) {
 		return nil, fmt.Errorf("获取电影总数失败: %w", err)
 	}
 
-	// 使用分页扫描获取电影列表
-	results, actualTotal, err := utils.ScanMoviesWithPagination(ctx, page, perPage)
+	// 按行键区间并行扫描获取电影列表
+	results, actualTotal, err := utils.ParallelScanWithMerge(ctx, page, perPage, movieListScanParallelism)
 	if err != nil {
 		return nil, err
 	}
@@ -52,130 +53,86 @@ func GetMoviesList(page, perPage int) (*MovieList, error) {
 		utils.Cache.Set("total_movies_count", totalMovies)
 	}
 
-	// 解析电影列表 - 改进为同时读取stats数据
-	movies := []Movie{}
+	// 解析电影列表：先从_info行构建基本信息
+	movies := moviesFromInfoResults(results)
 
-	for _, result := range results {
-		// 获取行键
-		if len(result.Cells) == 0 {
-			continue
-		}
+	// 批量获取本页电影的stats、_links和_tags行
+	fillMovieListDetails(ctx, movies, fields, "电影列表")
 
-		rowKey := string(result.Cells[0].Row)
-
-		// 确保是_info行，提取电影ID
-		if !strings.HasSuffix(rowKey, "_info") {
-			continue
-		}
+	// 构建响应
+	totalPages := (totalMovies + perPage - 1) / perPage // 计算总页数
 
-		movieID := strings.TrimSuffix(rowKey, "_info")
+	list := &MovieList{
+		Movies:      movies,
+		TotalMovies: totalMovies,
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  totalPages,
+	}
+	// 便于从页码分页切换到游标分页
+	if page < totalPages && len(results) > 0 {
+		list.NextCursor = EncodeMovieCursor(string(results[len(results)-1].Cells[0].Row))
+	}
+	return list, nil
+}
 
-		// 手动构建结果映射
-		resultMap := make(map[string]map[string][]byte)
-		infoFamily := make(map[string][]byte)
+// movieFromInfo 由_info行的列构建列表用的电影基本信息
+func movieFromInfo(movieID string, infoFamily map[string][]byte) Movie {
+	movieData := utils.ParseMovieData(movieID, map[string]map[string][]byte{"info": infoFamily})
 
-		// 处理_info行数据
-		for _, cell := range result.Cells {
-			family := string(cell.Family)
-			qualifier := string(cell.Qualifier)
+	movie := Movie{
+		MovieID: movieID,
+	}
 
-			if family == "info" {
-				infoFamily[qualifier] = cell.Value
-			}
-		}
+	if title, ok := movieData["title"].(string); ok {
+		movie.setTitle(title)
+	}
 
-		// 尝试读取stats数据
-		statsGet, err := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_stats", movieID))
-		if err == nil {
-			client := utils.GetClient().(interface {
-				Get(request *hrpc.Get) (*hrpc.Result, error)
-			})
-
-			if statsResult, err := client.Get(statsGet); err == nil && len(statsResult.Cells) > 0 {
-				// 将stats数据合并到infoFamily中
-				for _, cell := range statsResult.Cells {
-					family := string(cell.Family)
-					qualifier := string(cell.Qualifier)
-
-					if family == "info" {
-						infoFamily[qualifier] = cell.Value
-					}
-				}
-			}
-		}
+	if genres, ok := movieData["genres"].([]string); ok {
+		movie.Genres = genres
+	}
 
-		resultMap["info"] = infoFamily
-		movieData := utils.ParseMovieData(movieID, resultMap)
+	return movie
+}
 
-		movie := Movie{
-			MovieID: movieID,
-		}
+// fillMovieListDetails 批量获取电影的stats、_links和_tags行并填入列表（含评分数和标签数），fields中未包含的字段对应的行不读取。
+// source用于按需计算平均分时的日志
+func fillMovieListDetails(ctx context.Context, movies []Movie, fields MovieFields, source string) {
+	movieIDs := make([]string, len(movies))
+	for i := range movies {
+		movieIDs[i] = movies[i].MovieID
+	}
 
-		if title, ok := movieData["title"].(string); ok {
-			movie.Title = title
-			// 尝试从标题中提取年份
-			if matches := strings.Split(title, " ("); len(matches) > 1 {
-				yearStr := strings.TrimSuffix(matches[len(matches)-1], ")")
-				if year, err := strconv.Atoi(yearStr); err == nil {
-					movie.Year = year
-				}
-			}
-		}
+	var statsMap, linksMap, tagsMap map[string]map[string]interface{}
+	if fields.Has("avgRating") || fields.Has("ratingCount") {
+		statsMap = utils.GetMoviesStatsBatch(ctx, movieIDs)
+	}
+	if fields.Has("links") {
+		linksMap = utils.GetMoviesLinksBatch(ctx, movieIDs)
+	}
+	if fields.Has("tags") || fields.Has("tagCount") {
+		tagsMap = utils.GetMoviesTagsBatch(ctx, movieIDs)
+	}
 
-		if genres, ok := movieData["genres"].([]string); ok {
-			movie.Genres = genres
-		}
+	for i := range movies {
+		movieID := movies[i].MovieID
 
-		if avgRating, ok := movieData["avgRating"].(float64); ok {
-			movie.AvgRating = avgRating
-		} else {
-			// 如果没有评分数据，尝试计算并存储
-			avgRating, ratingCount, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
-			if err == nil && avgRating > 0.0 {
-				movie.AvgRating = avgRating
-				fmt.Printf("✅ 电影列表: 成功计算并存储电影 %s 的平均评分: %.2f (基于 %d 个评分)\n",
-					movieID, avgRating, ratingCount)
+		if avgRating, ok := statsMap[movieID]["avgRating"].(float64); ok {
+			movies[i].AvgRating = avgRating
+			movies[i].RatingCount, _ = statsMap[movieID]["ratingCount"].(int)
+		} else if statsMap != nil {
+			if avgRating, ratingCount, ok := lazyAvgRating(ctx, movieID, source); ok {
+				movies[i].AvgRating, movies[i].RatingCount = avgRating, ratingCount
 			}
 		}
 
-		// 添加链接数据（使用通用函数）
-		if linksData, err := utils.GetMovieLinks(ctx, movieID); err == nil {
-			linkObj := Links{}
-
-			if imdbId, ok := linksData["imdbId"].(string); ok {
-				linkObj.ImdbID = imdbId
-			}
-			if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
-				linkObj.ImdbURL = imdbUrl
-			}
-			if tmdbId, ok := linksData["tmdbId"].(string); ok {
-				linkObj.TmdbID = tmdbId
-			}
-			if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
-				linkObj.TmdbURL = tmdbUrl
-			}
-
-			movie.Links = linkObj
+		if linksData, ok := linksMap[movieID]; ok {
+			movies[i].Links = newLinks(linksData)
 		}
 
-		// 添加标签数据（使用通用函数）
-		if tagsData, err := utils.GetMovieTags(ctx, movieID); err == nil {
-			if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
-				movie.Tags = uniqueTags
-			}
+		if uniqueTags, ok := tagsMap[movieID]["uniqueTags"].([]string); ok {
+			movies[i].Tags = uniqueTags
 		}
-
-		movies = append(movies, movie)
+		movies[i].TagCount, _ = tagsMap[movieID]["tagCount"].(int)
 	}
-
-	// 构建响应
-	totalPages := (totalMovies + perPage - 1) / perPage // 计算总页数
-
-	return &MovieList{
-		Movies:      movies,
-		TotalMovies: totalMovies,
-		Page:        page,
-		PerPage:     perPage,
-		TotalPages:  totalPages,
-	}, nil
 }
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase"
+	"gohbase/utils/hbase/hbasetest"
+	"gohbase/utils/hbase/rowkey"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// familyRequest 一次Get或Scan请求：行键（Scan为起始行）、请求的列族和返回单元格的列族
+type familyRequest struct {
+	kind      string
+	row       string
+	requested map[string][]string
+	returned  map[string]bool
+}
+
+// familyRecorder 记录每次Get、Scan请求的列投影和实际返回的单元格列族
+type familyRecorder struct {
+	*hbasetest.Client
+	mu       sync.Mutex
+	requests []*familyRequest
+}
+
+func (r *familyRecorder) record(kind, row string, call hrpc.Call) *familyRequest {
+	req := &familyRequest{kind: kind, row: row, requested: hbasetest.RequestedColumns(call), returned: map[string]bool{}}
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.mu.Unlock()
+	return req
+}
+
+func (r *familyRecorder) addCells(req *familyRequest, cells []*hrpc.Cell) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cell := range cells {
+		req.returned[string(cell.Family)] = true
+	}
+}
+
+func (r *familyRecorder) Get(g *hrpc.Get) (*hrpc.Result, error) {
+	req := r.record("Get", string(g.Key()), g)
+	result, err := r.Client.Get(g)
+	if err == nil {
+		r.addCells(req, result.Cells)
+	}
+	return result, err
+}
+
+func (r *familyRecorder) Scan(s *hrpc.Scan) hrpc.Scanner {
+	return &familyScanner{Scanner: r.Client.Scan(s), recorder: r, req: r.record("Scan", string(s.StartRow()), s)}
+}
+
+// reset 清空记录
+func (r *familyRecorder) reset() []*familyRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := r.requests
+	r.requests = nil
+	return requests
+}
+
+// familyScanner 把扫描返回的单元格列族记入请求
+type familyScanner struct {
+	hrpc.Scanner
+	recorder *familyRecorder
+	req      *familyRequest
+}
+
+func (s *familyScanner) Next() (*hrpc.Result, error) {
+	result, err := s.Scanner.Next()
+	if err == nil {
+		s.recorder.addCells(s.req, result.Cells)
+	}
+	return result, err
+}
+
+// TestListPathsNeverReadRatingsFamily 列表、搜索回退和索引搜索的批量详情只请求所需的列，
+// 即使ratings列族的单元格和标题、统计写在同一行里也不会返回
+func TestListPathsNeverReadRatingsFamily(t *testing.T) {
+	const movieCount = 20
+	movies := indexFixture(movieCount)
+	for i := range movies {
+		movies[i].Links = map[string]string{"imdbId": fmt.Sprintf("%07d", i+1), "tmdbId": strconv.Itoa(i + 1)}
+		movies[i].Tags = map[string]string{"1_1000": "classic:1:1000"}
+		movies[i].Ratings = map[string]string{"1": "3.0:1:1000", "2": "4.0:2:1000"}
+	}
+	client := &familyRecorder{Client: newTestIndex(t, movies)}
+	// _tags行之外的每一行都混入ratings列族的单元格，没有投影的读取会把它们带回来
+	for _, movie := range movies {
+		for _, rowType := range []string{rowkey.TypeInfo, rowkey.TypeStats, rowkey.TypeLinks} {
+			client.SetRow(utils.MoviesTable(), rowkey.MovieKey(movie.ID, rowType), map[string]map[string][]byte{
+				"ratings": {"1": []byte("3.0:1:1000")},
+			})
+		}
+	}
+	hbase.SetClient(client)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"列表", func() error {
+			_, err := GetMoviesList(1, 10, nil)
+			return err
+		}},
+		{"游标列表", func() error {
+			_, err := GetMoviesListAfter("", 10, nil)
+			return err
+		}},
+		{"回退文本搜索", func() error {
+			_, err := searchByTextOptimized(ctx, "movie", SearchTypeAll, 100, 10)
+			return err
+		}},
+		{"索引搜索批量详情", func() error {
+			_, err := GetSearchIndex().SearchMoviesWithIndex(ctx, "movie", SearchTypeTitle, RankRelevance, 1, 10, nil)
+			return err
+		}},
+		{"索引搜索批量详情（选择tags）", func() error {
+			_, err := GetSearchIndex().SearchMoviesWithIndex(ctx, "movie", SearchTypeTitle, RankRelevance, 1, 10,
+				MovieFields{"movieId": true, "avgRating": true, "links": true, "tags": true})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		utils.Cache.Flush()
+		client.reset()
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s: 失败: %v", tt.name, err)
+		}
+		requests := client.reset()
+		if len(requests) == 0 {
+			t.Errorf("%s: 没有任何HBase请求", tt.name)
+		}
+
+		for _, req := range requests {
+			if req.returned["ratings"] {
+				t.Errorf("%s: %s %s 返回了ratings列族的单元格", tt.name, req.kind, req.row)
+			}
+			if _, ok := req.requested["ratings"]; ok {
+				t.Errorf("%s: %s %s 请求了ratings列族", tt.name, req.kind, req.row)
+			}
+			// _tags行的列族在不同导入版本中不同，只有它可以不指定投影
+			if len(req.requested) == 0 {
+				if _, rowType, err := rowkey.ParseMovieRowKey(req.row); err != nil || rowType != rowkey.TypeTags {
+					t.Errorf("%s: %s %s 没有指定列投影", tt.name, req.kind, req.row)
+				}
+			}
+		}
+	}
+}
+
+// TestRequestedColumnsStatsProjection 批量读取stats和links时只请求评分列和外部ID列
+func TestRequestedColumnsStatsProjection(t *testing.T) {
+	client := &familyRecorder{Client: newTestClient(t, indexFixture(3))}
+	hbase.SetClient(client)
+	ctx := context.Background()
+
+	utils.GetMoviesStatsBatch(ctx, []string{"1", "2", "3"})
+	utils.GetMoviesLinksBatch(ctx, []string{"1", "2", "3"})
+
+	got := map[string]string{}
+	for _, req := range client.reset() {
+		_, rowType, _ := rowkey.ParseMovieRowKey(req.row)
+		columns := req.requested["info"]
+		sort.Strings(columns)
+		got[rowType] = fmt.Sprint(len(req.requested), columns)
+	}
+	want := map[string]string{
+		rowkey.TypeStats: "1 [avg_rating rating_count]",
+		rowkey.TypeLinks: "1 [imdbId tmdbId]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("请求的列投影 = %v, want %v", got, want)
+	}
+}
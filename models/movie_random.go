@@ -4,141 +4,206 @@ import (
 	"context"
 	"fmt"
 	"gohbase/utils"
-	"strconv"
+	"gohbase/utils/hbase/rowkey"
 	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/tsuna/gohbase/hrpc"
 )
 
+// randomAttemptsPerMovie 索引不可用时，每部所需电影最多尝试的随机ID数
+const randomAttemptsPerMovie = 20
+
+// RandomMoviesFilter 随机电影的过滤条件
+type RandomMoviesFilter struct {
+	Genre     string  // 类型（不区分大小写），为空不过滤
+	MinRating float64 // 平均评分下限，0不过滤（没有评分的电影平均评分按0计）
+}
+
+// active 是否设置了过滤条件
+func (f RandomMoviesFilter) active() bool {
+	return f.Genre != "" || f.MinRating > 0
+}
+
+// matches 电影是否满足过滤条件
+func (f RandomMoviesFilter) matches(movie Movie) bool {
+	if movie.AvgRating < f.MinRating {
+		return false
+	}
+	if f.Genre == "" {
+		return true
+	}
+	for _, genre := range movie.Genres {
+		if strings.EqualFold(genre, f.Genre) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomMoviesResult 缓存的随机电影结果
+type randomMoviesResult struct {
+	Movies  []Movie
+	Partial bool
+}
+
 // GetRandomMovies 获取随机电影（带缓存）- 适配新的数据库结构
 func GetRandomMovies(count int) ([]Movie, error) {
+	movies, _, err := GetRandomMoviesFiltered(count, RandomMoviesFilter{})
+	return movies, err
+}
+
+// GetRandomMoviesFiltered 获取满足过滤条件的随机电影（带缓存，每小时刷新）。
+// 满足条件的电影不足count部时返回找到的电影，partial为true。
+func GetRandomMoviesFiltered(count int, filter RandomMoviesFilter) (movies []Movie, partial bool, err error) {
 	ctx := context.Background()
+	filter.Genre = strings.TrimSpace(filter.Genre)
 
 	// 获取总电影数
 	totalMovies, err := GetTotalMoviesCount(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取电影总数失败: %w", err)
+		return nil, false, fmt.Errorf("获取电影总数失败: %w", err)
 	}
 
 	// 构建缓存键 - 使用当前时间的小时数作为缓存键，这样每小时刷新一次随机结果
 	currentHour := time.Now().Hour()
-	cacheKey := fmt.Sprintf("random_movies:%d:%d", count, currentHour)
+	cacheKey := fmt.Sprintf("random_movies:%d:%s:%g:%d", count, strings.ToLower(filter.Genre), filter.MinRating, currentHour)
 
 	// 检查缓存中是否有随机电影数据
-	if cachedMovies, found := utils.Cache.Get(cacheKey); found {
-		return cachedMovies.([]Movie), nil
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if result, ok := cached.(*randomMoviesResult); ok {
+			return result.Movies, result.Partial, nil
+		}
 	}
 
-	// 生成随机ID列表
-	randomIDs := generateRandomIDs(totalMovies, count)
-	movies := []Movie{}
+	movies = randomMovies(ctx, totalMovies, count, filter)
+	partial = len(movies) < count
 
-	// 获取每个随机ID的电影信息
-	for _, id := range randomIDs {
-		movieID := fmt.Sprintf("%d", id)
+	// 将结果存入缓存
+	utils.Cache.Set(cacheKey, &randomMoviesResult{Movies: movies, Partial: partial})
 
-		// 使用新的数据库结构获取电影信息 - 同时读取stats数据
-		data, err := utils.GetMovie(ctx, movieID)
-		if err != nil {
-			continue
-		}
+	return movies, partial, nil
+}
 
-		if data == nil {
-			continue
+// randomMovies 随机取最多count部满足过滤条件的电影。
+// 优先从索引中实际存在的电影ID里按类型和评分随机取候选（MovieLens的ID不连续，不能假设为1..N）；
+// 索引中的评分可能略旧，候选仍按_stats中的评分检查。
+// 索引不可用时在1..totalMovies中随机抽取ID：没有过滤条件时只抽取count个，
+// 有过滤条件时抽取后过滤，最多尝试count*randomAttemptsPerMovie个ID。
+func randomMovies(ctx context.Context, totalMovies, count int, filter RandomMoviesFilter) []Movie {
+	movies := []Movie{}
+	accept := func(movieID string) {
+		movie, ok := randomMovieBase(ctx, movieID)
+		if ok && filter.matches(movie) {
+			fillRandomMovieExtras(ctx, &movie)
+			movies = append(movies, movie)
 		}
+	}
 
-		// 尝试读取stats数据
-		resultMap := data
-		statsGet, err := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_stats", movieID))
-		if err == nil {
-			client := utils.GetClient().(interface {
-				Get(request *hrpc.Get) (*hrpc.Result, error)
-			})
-
-			if statsResult, err := client.Get(statsGet); err == nil && len(statsResult.Cells) > 0 {
-				// 将stats数据合并到resultMap中
-				if resultMap["info"] == nil {
-					resultMap["info"] = make(map[string][]byte)
-				}
-				for _, cell := range statsResult.Cells {
-					family := string(cell.Family)
-					qualifier := string(cell.Qualifier)
-
-					if family == "info" {
-						resultMap["info"][qualifier] = cell.Value
-					}
-				}
+	movieIDs, ok, err := GetSearchIndex().RandomMovieIDs(ctx, filter.Genre, filter.MinRating, count*2)
+	if err != nil {
+		logrus.Warnf("从索引获取随机电影候选失败: %v", err)
+	}
+	if ok && err == nil {
+		for _, movieID := range movieIDs {
+			if len(movies) >= count {
+				break
 			}
+			accept(movieID)
 		}
+		return movies
+	}
 
-		movieData := utils.ParseMovieData(movieID, resultMap)
-
-		movie := Movie{
-			MovieID: movieID,
+	if !filter.active() {
+		for _, id := range generateRandomIDs(totalMovies, count) {
+			accept(fmt.Sprintf("%d", id))
 		}
+		return movies
+	}
 
-		if title, ok := movieData["title"].(string); ok {
-			movie.Title = title
-			// 尝试从标题中提取年份
-			if matches := strings.Split(title, " ("); len(matches) > 1 {
-				yearStr := strings.TrimSuffix(matches[len(matches)-1], ")")
-				if year, err := strconv.Atoi(yearStr); err == nil {
-					movie.Year = year
-				}
-			}
+	tried := make(map[int]bool)
+	for attempts := 0; attempts < count*randomAttemptsPerMovie && len(movies) < count && len(tried) < totalMovies; attempts++ {
+		id := rng.Intn(totalMovies) + 1
+		if tried[id] {
+			continue
 		}
+		tried[id] = true
+		accept(fmt.Sprintf("%d", id))
+	}
+	return movies
+}
 
-		if genres, ok := movieData["genres"].([]string); ok {
-			movie.Genres = genres
-		}
+// randomMovieBase 读取电影的基本信息和_stats中的平均评分，电影不存在时ok为false
+func randomMovieBase(ctx context.Context, movieID string) (Movie, bool) {
+	// 使用新的数据库结构获取电影信息 - 同时读取stats数据
+	data, err := utils.GetMovie(ctx, movieID)
+	if err != nil || data == nil {
+		return Movie{}, false
+	}
 
-		if avgRating, ok := movieData["avgRating"].(float64); ok {
-			movie.AvgRating = avgRating
-		} else {
-			// 如果没有评分数据，尝试计算并存储
-			avgRating, ratingCount, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
-			if err == nil && avgRating > 0.0 {
-				movie.AvgRating = avgRating
-				fmt.Printf("✅ 随机电影: 成功计算并存储电影 %s 的平均评分: %.2f (基于 %d 个评分)\n",
-					movieID, avgRating, ratingCount)
+	// 尝试读取stats数据
+	resultMap := data
+	statsGet, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieStatsKey(movieID))
+	if err == nil {
+		client := utils.GetClient().(interface {
+			Get(request *hrpc.Get) (*hrpc.Result, error)
+		})
+
+		if statsResult, err := client.Get(statsGet); err == nil && len(statsResult.Cells) > 0 {
+			// 将stats数据合并到resultMap中
+			if resultMap["info"] == nil {
+				resultMap["info"] = make(map[string][]byte)
 			}
-		}
+			for _, cell := range statsResult.Cells {
+				family := string(cell.Family)
+				qualifier := string(cell.Qualifier)
 
-		// 添加标签（使用通用函数）
-		if tagsData, err := utils.GetMovieTags(ctx, movieID); err == nil {
-			if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
-				movie.Tags = uniqueTags
+				if family == "info" {
+					resultMap["info"][qualifier] = cell.Value
+				}
 			}
 		}
+	}
 
-		// 添加链接数据（使用通用函数）
-		if linksData, err := utils.GetMovieLinks(ctx, movieID); err == nil {
-			linkObj := Links{}
+	movieData := utils.ParseMovieData(movieID, resultMap)
 
-			if imdbId, ok := linksData["imdbId"].(string); ok {
-				linkObj.ImdbID = imdbId
-			}
-			if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
-				linkObj.ImdbURL = imdbUrl
-			}
-			if tmdbId, ok := linksData["tmdbId"].(string); ok {
-				linkObj.TmdbID = tmdbId
-			}
-			if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
-				linkObj.TmdbURL = tmdbUrl
-			}
+	movie := Movie{
+		MovieID: movieID,
+	}
 
-			movie.Links = linkObj
-		}
+	if title, ok := movieData["title"].(string); ok {
+		movie.setTitle(title)
+	}
 
-		movies = append(movies, movie)
+	if genres, ok := movieData["genres"].([]string); ok {
+		movie.Genres = genres
 	}
 
-	// 将结果存入缓存
-	utils.Cache.Set(cacheKey, movies)
+	if avgRating, ok := movieData["avgRating"].(float64); ok {
+		movie.AvgRating = avgRating
+		movie.RatingCount, _ = movieData["ratingCount"].(int)
+	} else if avgRating, ratingCount, ok := lazyAvgRating(ctx, movieID, "随机电影"); ok {
+		movie.AvgRating, movie.RatingCount = avgRating, ratingCount
+	}
+
+	return movie, true
+}
 
-	return movies, nil
+// fillRandomMovieExtras 补充标签和链接，只对最终返回的电影读取
+func fillRandomMovieExtras(ctx context.Context, movie *Movie) {
+	// 添加标签（使用通用函数）
+	if tagsData, err := utils.GetMovieTags(ctx, movie.MovieID); err == nil {
+		if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
+			movie.Tags = uniqueTags
+		}
+		movie.TagCount, _ = tagsData["tagCount"].(int)
+	}
+
+	// 添加链接数据（使用通用函数）
+	if linksData, err := utils.GetMovieLinks(ctx, movie.MovieID); err == nil {
+		movie.Links = newLinks(linksData)
+	}
 }
 
 // generateRandomIDs 生成不重复的随机ID列表
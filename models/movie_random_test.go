@@ -0,0 +1,69 @@
+package models
+
+import (
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// sparseFixture ID不连续的电影，与MovieLens一样最大ID远大于电影数
+var sparseFixture = []hbasetest.Movie{
+	{ID: "1", Title: "Toy Story (1995)", Genres: "Animation|Comedy", Stats: map[string]string{"avg_rating": "3.9", "rating_count": "10"}},
+	{ID: "50", Title: "Usual Suspects, The (1995)", Genres: "Crime|Thriller", Stats: map[string]string{"avg_rating": "4.2", "rating_count": "10"}},
+	{ID: "318", Title: "Shawshank Redemption, The (1994)", Genres: "Crime|Drama", Stats: map[string]string{"avg_rating": "4.4", "rating_count": "10"}},
+	{ID: "2571", Title: "Matrix, The (1999)", Genres: "Action|Sci-Fi", Stats: map[string]string{"avg_rating": "4.1", "rating_count": "10"}},
+	{ID: "4306", Title: "Shrek (2001)", Genres: "Animation|Comedy", Stats: map[string]string{"avg_rating": "3.8", "rating_count": "10"}},
+	{ID: "58559", Title: "Dark Knight, The (2008)", Genres: "Action|Crime", Stats: map[string]string{"avg_rating": "4.2", "rating_count": "10"}},
+	{ID: "79132", Title: "Inception (2010)", Genres: "Action|Sci-Fi", Stats: map[string]string{"avg_rating": "4.1", "rating_count": "10"}},
+	{ID: "122904", Title: "Deadpool (2016)", Genres: "Action|Comedy", Stats: map[string]string{"avg_rating": "3.8", "rating_count": "10"}},
+	{ID: "170875", Title: "Fate of the Furious, The (2017)", Genres: "Action|Crime", Stats: map[string]string{"avg_rating": "2.9", "rating_count": "10"}},
+	{ID: "193609", Title: "Andrew Dice Clay: Dice Rules (1991)", Genres: "Comedy"},
+}
+
+// TestRandomMoviesSparseIDs ID不连续时仍返回count部不重复的电影，过滤条件不足时返回全部满足条件的电影
+func TestRandomMoviesSparseIDs(t *testing.T) {
+	newTestIndex(t, sparseFixture)
+	exists := make(map[string]bool)
+	for _, movie := range sparseFixture {
+		exists[movie.ID] = true
+	}
+
+	tests := []struct {
+		name        string
+		count       int
+		filter      RandomMoviesFilter
+		wantCount   int
+		wantPartial bool
+	}{
+		{"一部", 1, RandomMoviesFilter{}, 1, false},
+		{"多部", 5, RandomMoviesFilter{}, 5, false},
+		{"全部", 10, RandomMoviesFilter{}, 10, false},
+		{"超过电影数", 20, RandomMoviesFilter{}, 10, true},
+		{"按类型", 3, RandomMoviesFilter{Genre: "action"}, 3, false},
+		{"按类型不足", 10, RandomMoviesFilter{Genre: "Sci-Fi"}, 2, true},
+		{"按评分", 10, RandomMoviesFilter{MinRating: 4.1}, 5, true},
+		{"类型和评分", 2, RandomMoviesFilter{Genre: "Crime", MinRating: 4}, 2, false},
+		{"没有满足条件的电影", 3, RandomMoviesFilter{Genre: "Western"}, 0, true},
+	}
+	for _, tt := range tests {
+		movies, partial, err := GetRandomMoviesFiltered(tt.count, tt.filter)
+		if err != nil {
+			t.Fatalf("%s: GetRandomMoviesFiltered失败: %v", tt.name, err)
+		}
+		if len(movies) != tt.wantCount || partial != tt.wantPartial {
+			t.Errorf("%s: 返回%d部电影 (partial=%v), want %d (partial=%v)", tt.name, len(movies), partial, tt.wantCount, tt.wantPartial)
+		}
+		seen := make(map[string]bool)
+		for _, movie := range movies {
+			if !exists[movie.MovieID] || movie.Title == "" {
+				t.Errorf("%s: 返回了不存在的电影 %+v", tt.name, movie)
+			}
+			if seen[movie.MovieID] {
+				t.Errorf("%s: 电影%s重复", tt.name, movie.MovieID)
+			}
+			seen[movie.MovieID] = true
+			if !tt.filter.matches(movie) {
+				t.Errorf("%s: 电影%s不满足过滤条件 %+v", tt.name, movie.MovieID, tt.filter)
+			}
+		}
+	}
+}
@@ -2,7 +2,17 @@ package models
 
 import (
 	"context"
+	"errors"
 	"gohbase/utils"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 评分列表分页
+const (
+	DefaultRatingsPerPage = 50
+	MaxRatingsPerPage     = 200
 )
 
 // GetMovieRatings 获取电影评分
@@ -10,3 +20,141 @@ func GetMovieRatings(movieID string) (map[string]interface{}, error) {
 	ctx := context.Background()
 	return utils.GetMovieRatings(ctx, movieID)
 }
+
+// 评分列表排序字段（sort参数）
+const (
+	RatingsSortTimestamp = "timestamp"
+	RatingsSortRating    = "rating"
+)
+
+var (
+	// ErrInvalidRatingsSort 不支持的评分排序方式
+	ErrInvalidRatingsSort = errors.New("sort必须是timestamp或rating，order必须是asc或desc")
+	// ErrInvalidRatingRange 评分过滤范围无效
+	ErrInvalidRatingRange = errors.New("min_rating和max_rating必须是0到5之间的数字，且min_rating不大于max_rating")
+)
+
+// RatingsQuery 评分列表的分页、排序和过滤参数
+type RatingsQuery struct {
+	Page      int
+	PerPage   int
+	Sort      string  // timestamp或rating
+	Desc      bool    // 是否倒序
+	MinRating float64 // 只返回评分在[MinRating, MaxRating]内的评分
+	MaxRating float64
+}
+
+// DefaultRatingsQuery 默认参数：第一页，按评分时间倒序，不过滤
+func DefaultRatingsQuery() RatingsQuery {
+	return RatingsQuery{Page: 1, PerPage: DefaultRatingsPerPage, Sort: RatingsSortTimestamp, Desc: true, MaxRating: 5}
+}
+
+// ParseRatingsSort 解析sort和order参数，sort为空时按评分时间，order为空时倒序
+func ParseRatingsSort(sortBy, order string) (string, bool, error) {
+	sortBy = strings.ToLower(strings.TrimSpace(sortBy))
+	switch sortBy {
+	case "":
+		sortBy = RatingsSortTimestamp
+	case RatingsSortTimestamp, RatingsSortRating:
+	default:
+		return "", false, ErrInvalidRatingsSort
+	}
+
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", "desc":
+		return sortBy, true, nil
+	case "asc":
+		return sortBy, false, nil
+	}
+	return "", false, ErrInvalidRatingsSort
+}
+
+// ParseRatingRange 解析min_rating和max_rating参数，为空时分别为0和5
+func ParseRatingRange(minStr, maxStr string) (float64, float64, error) {
+	parse := func(s string, def float64) (float64, error) {
+		if s = strings.TrimSpace(s); s == "" {
+			return def, nil
+		}
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil || value < 0 || value > 5 {
+			return 0, ErrInvalidRatingRange
+		}
+		return value, nil
+	}
+
+	minRating, err := parse(minStr, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxRating, err := parse(maxStr, 5)
+	if err != nil {
+		return 0, 0, err
+	}
+	if minRating > maxRating {
+		return 0, 0, ErrInvalidRatingRange
+	}
+	return minRating, maxRating, nil
+}
+
+// GetMovieRatingsPage 分页获取电影评分，按query排序并过滤评分范围，相同时按评分时间倒序、用户ID排序。
+// count、avgRating、minRating、maxRating基于全部评分计算，不受过滤和分页影响；
+// filteredCount为过滤后的评分数，分页基于过滤后的评分。
+func GetMovieRatingsPage(movieID string, query RatingsQuery) (map[string]interface{}, error) {
+	page, perPage := query.Page, query.PerPage
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultRatingsPerPage
+	}
+
+	data, err := GetMovieRatings(movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings, _ := data["ratings"].([]map[string]interface{})
+	filtered := make([]map[string]interface{}, 0, len(ratings))
+	for _, rating := range ratings {
+		value, _ := rating["rating"].(float64)
+		if value >= query.MinRating && value <= query.MaxRating {
+			filtered = append(filtered, rating)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if query.Sort == RatingsSortRating {
+			ri, _ := filtered[i]["rating"].(float64)
+			rj, _ := filtered[j]["rating"].(float64)
+			if ri != rj {
+				return (ri > rj) == query.Desc
+			}
+		}
+		ti, _ := filtered[i]["timestamp"].(int64)
+		tj, _ := filtered[j]["timestamp"].(int64)
+		if ti != tj {
+			if query.Sort == RatingsSortTimestamp {
+				return (ti > tj) == query.Desc
+			}
+			return ti > tj
+		}
+		ui, _ := filtered[i]["userId"].(string)
+		uj, _ := filtered[j]["userId"].(string)
+		return ui < uj
+	})
+
+	total := len(filtered)
+	start := min((page-1)*perPage, total)
+	end := min(start+perPage, total)
+
+	result := make(map[string]interface{}, len(data)+4)
+	for key, value := range data {
+		result[key] = value
+	}
+	result["ratings"] = filtered[start:end]
+	result["filteredCount"] = total
+	result["page"] = page
+	result["perPage"] = perPage
+	result["totalPages"] = (total + perPage - 1) / perPage
+	return result, nil
+}
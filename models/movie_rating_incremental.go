@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// maxIncrementalRetries 增量更新_stats时CheckAndPut冲突的最大重试次数
+const maxIncrementalRetries = 5
+
+// IncrementalRatingUpdate 把一条新评分计入电影的_stats行，只读写_stats行，不读取全部评分。
+// 以rating_sum列做CheckAndPut，并发写入冲突时重新读取后重试。
+// _stats行不存在时（尚未计算过）退回完整计算一次。
+func IncrementalRatingUpdate(ctx context.Context, movieID string, newRating float64) error {
+	return applyRatingDelta(ctx, movieID, newRating, 1)
+}
+
+// IncrementalRatingReplace 用户重新评分时把评分从oldRating改为newRating，评分数不变
+func IncrementalRatingReplace(ctx context.Context, movieID string, oldRating, newRating float64) error {
+	return applyRatingDelta(ctx, movieID, newRating-oldRating, 0)
+}
+
+// GetUserMovieRating 读取用户对电影的评分，没有评分时ok为false
+func GetUserMovieRating(ctx context.Context, movieID, userID string) (rating float64, ok bool, err error) {
+	value, err := getSingleCell(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(movieID), "ratings", userID)
+	if err != nil || value == nil {
+		return 0, false, err
+	}
+	parsed, ok := utils.ParseRatingValue(string(value))
+	return parsed.Rating, ok, nil
+}
+
+// applyRatingDelta 按评分总和与评分数的变化量更新_stats行
+func applyRatingDelta(ctx context.Context, movieID string, sumDelta float64, countDelta int) error {
+	client := utils.GetClient().(gohbase.Client)
+	statsKey := rowkey.MovieStatsKey(movieID)
+
+	for attempt := 0; attempt < maxIncrementalRetries; attempt++ {
+		get, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), statsKey,
+			hrpc.Families(map[string][]string{"info": {"avg_rating", "rating_count", "rating_sum"}}))
+		if err != nil {
+			return err
+		}
+		result, err := client.Get(get)
+		if err != nil {
+			return fmt.Errorf("读取stats失败: %w", err)
+		}
+		cells := make(map[string][]byte)
+		for _, cell := range result.Cells {
+			cells[string(cell.Qualifier)] = cell.Value
+		}
+		if cells["rating_count"] == nil {
+			_, _, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
+			return err
+		}
+
+		count, _ := strconv.Atoi(string(cells["rating_count"]))
+		sum, err := strconv.ParseFloat(string(cells["rating_sum"]), 64)
+		if err != nil {
+			// 旧的_stats行没有rating_sum，由平均评分还原
+			avg, _ := strconv.ParseFloat(string(cells["avg_rating"]), 64)
+			sum = avg * float64(count)
+		}
+
+		count += countDelta
+		sum += sumDelta
+		avg := 0.0
+		if count > 0 {
+			avg = sum / float64(count)
+		}
+
+		put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), statsKey, statsValues(avg, count, sum))
+		if err != nil {
+			return err
+		}
+		// 期望值为读到的rating_sum（没有该列时为nil），期间有其他写入时不覆盖
+		applied, err := client.CheckAndPut(put, "info", "rating_sum", cells["rating_sum"])
+		if err != nil {
+			return fmt.Errorf("写入stats失败: %w", err)
+		}
+		if !applied {
+			continue
+		}
+
+		if err := GetSearchIndex().UpdateMovieStats(ctx, movieID, avg, count); err != nil {
+			logrus.Warnf("更新电影 %s 的索引评分统计失败: %v", movieID, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("电影 %s 的stats并发更新冲突，已重试%d次", movieID, maxIncrementalRetries)
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// maxPurgeErrors 结果中最多保留的错误信息条数
+const maxPurgeErrors = 20
+
+// RatingPurgeResult 删除旧评分的结果
+type RatingPurgeResult struct {
+	Before         int64    `json:"before"`         // 删除评分时间早于该时间戳（Unix秒）的评分
+	DryRun         bool     `json:"dryRun"`         // 只统计不删除
+	DeletedRatings int      `json:"deletedRatings"` // 删除（dryRun时为将删除）的评分数
+	AffectedMovies int      `json:"affectedMovies"`
+	AffectedUsers  int      `json:"affectedUsers"`
+	FailedMovies   int      `json:"failedMovies"` // 删除或重新计算统计失败的电影数
+	FailedUsers    int      `json:"failedUsers"`  // 删除users表评分失败的用户数
+	Errors         []string `json:"errors,omitempty"`
+}
+
+func (r *RatingPurgeResult) addError(format string, args ...interface{}) {
+	if len(r.Errors) < maxPurgeErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// ratingTime 评分时间（Unix秒）：优先取值中记录的时间戳，没有时取单元格的写入时间
+func ratingTime(cell *hrpc.Cell) int64 {
+	if parsed, ok := utils.ParseRatingValue(string(cell.Value)); ok && parsed.HasTimestamp {
+		return parsed.Timestamp
+	}
+	if cell.Timestamp != nil {
+		return int64(*cell.Timestamp / 1000)
+	}
+	return 0
+}
+
+// PurgeRatingsOlderThan 删除评分时间早于before的评分（电影_ratings行和users表），
+// 然后重新计算受影响电影的_stats和索引中的评分统计。
+// 删除只作用于扫描开始前写入的单元格版本，扫描期间用户重新评分的新值不会被删除。
+func PurgeRatingsOlderThan(ctx context.Context, before time.Time, dryRun bool) (*RatingPurgeResult, error) {
+	result := &RatingPurgeResult{Before: before.Unix(), DryRun: dryRun}
+	cutoff := before.Unix()
+	scanStart := time.Now()
+	client := utils.GetClient().(gohbase.Client)
+
+	// 用户ID -> 需要从users表删除的电影ID
+	userMovies := make(map[string][]string)
+	var movieIDs []string
+
+	err := utils.ScanRatingRows(ctx, func(movieID string, cells []*hrpc.Cell) error {
+		stale := make(map[string][]byte)
+		for _, cell := range cells {
+			if ratingTime(cell) < cutoff {
+				userID := string(cell.Qualifier)
+				stale[userID] = nil
+				userMovies[userID] = append(userMovies[userID], movieID)
+			}
+		}
+		if len(stale) == 0 {
+			return nil
+		}
+		result.DeletedRatings += len(stale)
+		movieIDs = append(movieIDs, movieID)
+		if dryRun {
+			return nil
+		}
+
+		del, err := hrpc.NewDelStr(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(movieID),
+			map[string]map[string][]byte{"ratings": stale}, hrpc.Timestamp(scanStart))
+		if err == nil {
+			_, err = client.Delete(del)
+		}
+		if err != nil {
+			result.FailedMovies++
+			result.addError("删除电影 %s 的评分失败: %v", movieID, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描评分失败: %w", err)
+	}
+
+	result.AffectedMovies = len(movieIDs)
+	result.AffectedUsers = len(userMovies)
+	if dryRun || result.DeletedRatings == 0 {
+		return result, nil
+	}
+
+	userIDs := make([]string, 0, len(userMovies))
+	for userID := range userMovies {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	for _, userID := range userIDs {
+		if err := deleteUserRatings(ctx, client, userID, userMovies[userID], scanStart); err != nil {
+			result.FailedUsers++
+			result.addError("删除用户 %s 的评分失败: %v", userID, err)
+		}
+	}
+
+	for _, movieID := range movieIDs {
+		if err := recomputeStatsFromRatings(ctx, movieID); err != nil {
+			result.FailedMovies++
+			result.addError("重新计算电影 %s 的评分统计失败: %v", movieID, err)
+		}
+		utils.Cache.Delete(fmt.Sprintf("movie_detail:%s", movieID))
+	}
+	utils.Cache.DeletePrefix("search:")
+	utils.Cache.DeletePrefix("random_movies:")
+	utils.Cache.DeletePrefix("movies_by_year:")
+
+	logrus.Infof("删除 %s 之前的评分: %d 条, 涉及 %d 部电影、%d 个用户, 失败电影 %d 部、用户 %d 个",
+		before.Format(time.RFC3339), result.DeletedRatings, result.AffectedMovies, result.AffectedUsers,
+		result.FailedMovies, result.FailedUsers)
+	return result, nil
+}
+
+// deleteUserRatings 删除users表中用户对指定电影的评分，只删除不晚于notAfter写入的版本
+func deleteUserRatings(ctx context.Context, client gohbase.Client, userID string, movieIDs []string, notAfter time.Time) error {
+	columns := make(map[string][]byte, len(movieIDs))
+	for _, movieID := range movieIDs {
+		columns[movieID] = nil
+	}
+	del, err := hrpc.NewDelStr(ctx, utils.UsersTable(), userID,
+		map[string]map[string][]byte{"movies": columns}, hrpc.Timestamp(notAfter))
+	if err != nil {
+		return err
+	}
+	_, err = client.Delete(del)
+	return err
+}
+
+// recomputeStatsFromRatings 按剩余的评分重新计算电影的_stats行，没有评分时写入0
+func recomputeStatsFromRatings(ctx context.Context, movieID string) error {
+	ratings, err := loadRatingsRow(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	var sum float64
+	for _, entry := range ratings {
+		sum += entry.Rating
+	}
+	avg := 0.0
+	if len(ratings) > 0 {
+		avg = sum / float64(len(ratings))
+	}
+	return StoreMovieAvgRatingToStats(ctx, movieID, avg, len(ratings))
+}
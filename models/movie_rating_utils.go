@@ -4,17 +4,19 @@ import (
 	"context"
 	"fmt"
 	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/tsuna/gohbase/hrpc"
 )
 
 // CalculateAndStoreMovieAvgRating 计算并存储电影平均评分（通用函数）
 func CalculateAndStoreMovieAvgRating(ctx context.Context, movieID string) (float64, int, error) {
 	// 获取电影的所有评分数据
-	ratingsGet, err := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_ratings", movieID))
+	ratingsGet, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieRatingsKey(movieID))
 	if err != nil {
 		return 0.0, 0, err
 	}
@@ -66,24 +68,12 @@ func CalculateAndStoreMovieAvgRating(ctx context.Context, movieID string) (float
 // StoreMovieAvgRatingToStats 存储电影平均评分到stats行（通用函数）
 func StoreMovieAvgRatingToStats(ctx context.Context, movieID string, avgRating float64, ratingCount int) error {
 	// 创建Put请求到stats行
-	rowKey := fmt.Sprintf("%s_stats", movieID)
+	rowKey := rowkey.MovieStatsKey(movieID)
 
-	// 准备数据
-	avgRatingStr := fmt.Sprintf("%.6f", avgRating)
-	ratingCountStr := fmt.Sprintf("%d", ratingCount)
-	currentTime := time.Now().Unix()
-	updatedTimeStr := fmt.Sprintf("%d", currentTime)
+	// rating_sum供增量更新使用
+	values := statsValues(avgRating, ratingCount, avgRating*float64(ratingCount))
 
-	// 创建values映射
-	values := map[string]map[string][]byte{
-		"info": {
-			"avg_rating":   []byte(avgRatingStr),
-			"rating_count": []byte(ratingCountStr),
-			"updated_time": []byte(updatedTimeStr),
-		},
-	}
-
-	put, err := hrpc.NewPutStr(ctx, "movies", rowKey, values)
+	put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowKey, values)
 	if err != nil {
 		return err
 	}
@@ -97,5 +87,22 @@ func StoreMovieAvgRatingToStats(ctx context.Context, movieID string, avgRating f
 		return fmt.Errorf("写入stats失败: %v", err)
 	}
 
+	// 同步索引中的评分统计（回填、重新计算和一致性修复都经过这里），供搜索按热度或评分排序
+	if err := GetSearchIndex().UpdateMovieStats(ctx, movieID, avgRating, ratingCount); err != nil {
+		logrus.Warnf("更新电影 %s 的索引评分统计失败: %v", movieID, err)
+	}
+
 	return nil
 }
+
+// statsValues 构造_stats行的写入值
+func statsValues(avgRating float64, ratingCount int, ratingSum float64) map[string]map[string][]byte {
+	return map[string]map[string][]byte{
+		"info": {
+			"avg_rating":   []byte(fmt.Sprintf("%.6f", avgRating)),
+			"rating_count": []byte(fmt.Sprintf("%d", ratingCount)),
+			"rating_sum":   []byte(fmt.Sprintf("%.6f", ratingSum)),
+			"updated_time": []byte(fmt.Sprintf("%d", time.Now().Unix())),
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// 最近添加的电影
+const (
+	DefaultRecentMovies = 12
+	MaxRecentMovies     = 50
+	recentExpiration    = time.Minute
+)
+
+// RecentMovie 最近添加的电影及其添加时间
+type RecentMovie struct {
+	Movie
+	AddedTime int64 `json:"addedTime"` // Unix秒
+}
+
+// addedTimeValue _info行info:added_time列的值（Unix秒）
+func addedTimeValue(t time.Time) []byte {
+	return []byte(strconv.FormatInt(t.Unix(), 10))
+}
+
+// GetRecentlyAddedMovies 按添加时间（_info行的info:added_time）倒序返回最近添加的电影，只查询SQLite索引（缓存1分钟）。
+// 没有添加时间的旧电影在首次访问详情时按最早的评分时间补写，之前不会出现在列表中；索引未就绪时返回空列表。
+func GetRecentlyAddedMovies(ctx context.Context, limit int) ([]RecentMovie, error) {
+	if limit <= 0 {
+		limit = DefaultRecentMovies
+	}
+	limit = min(limit, MaxRecentMovies)
+
+	cacheKey := fmt.Sprintf("recent_movies:%d", limit)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if movies, ok := cached.([]RecentMovie); ok {
+			return movies, nil
+		}
+	}
+
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return []RecentMovie{}, nil
+	}
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := db.QueryContext(ctx, `SELECT movie_id, COALESCE(title, ''), COALESCE(genres, ''), COALESCE(avg_rating, 0), added_time
+		FROM movie_index WHERE added_time IS NOT NULL ORDER BY added_time DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询最近添加的电影失败: %w", err)
+	}
+	defer rows.Close()
+
+	movies := []RecentMovie{}
+	for rows.Next() {
+		var movie RecentMovie
+		var title, genres string
+		if err := rows.Scan(&movie.MovieID, &title, &genres, &movie.AvgRating, &movie.AddedTime); err != nil {
+			return nil, err
+		}
+		movie.setTitle(title)
+		movie.Genres = utils.ParseGenres(genres)
+		movies = append(movies, movie)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	utils.Cache.SetWithExpiration(cacheKey, movies, recentExpiration)
+	return movies, nil
+}
+
+// SetMovieAddedTime 更新索引中电影的添加时间。索引尚未构建时不做任何操作。
+func (si *SearchIndex) SetMovieAddedTime(ctx context.Context, movieID string, addedTime int64) error {
+	return si.applyWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		if _, err := db.ExecContext(ctx, "UPDATE movie_index SET added_time = ? WHERE movie_id = ?", addedTime, movieID); err != nil {
+			return fmt.Errorf("更新索引添加时间失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// recordAddedTime 新建电影后把添加时间同步到索引，并使最近添加列表的缓存失效
+func recordAddedTime(ctx context.Context, movieID string, addedTime []byte) {
+	seconds, err := strconv.ParseInt(string(addedTime), 10, 64)
+	if err != nil {
+		return
+	}
+	if err := GetSearchIndex().SetMovieAddedTime(ctx, movieID, seconds); err != nil {
+		logrus.Warnf("更新电影 %s 的索引添加时间失败: %v", movieID, err)
+	}
+	utils.Cache.DeletePrefix("recent_movies:")
+}
+
+// backfillAddedTime 没有添加时间的电影以最早的评分时间补写info:added_time（已有值时不覆盖），没有评分时不补写
+func backfillAddedTime(ctx context.Context, movieID string, ratings []map[string]interface{}) {
+	var earliest int64
+	for _, rating := range ratings {
+		if timestamp, ok := rating["timestamp"].(int64); ok && timestamp > 0 && (earliest == 0 || timestamp < earliest) {
+			earliest = timestamp
+		}
+	}
+	if earliest == 0 {
+		return
+	}
+
+	value := addedTimeValue(time.Unix(earliest, 0))
+	put, err := hrpc.NewPutStr(ctx, utils.MoviesTable(), rowkey.MovieInfoKey(movieID),
+		map[string]map[string][]byte{"info": {"added_time": value}})
+	if err == nil {
+		var stored bool
+		stored, err = utils.GetClient().(gohbase.Client).CheckAndPut(put, "info", "added_time", nil)
+		if err == nil && !stored {
+			// 期间已被写入添加时间
+			return
+		}
+	}
+	if err != nil {
+		logrus.Warnf("补写电影 %s 的添加时间失败: %v", movieID, err)
+		return
+	}
+
+	logrus.Debugf("按最早的评分时间补写电影 %s 的添加时间: %s", movieID, value)
+	recordAddedTime(ctx, movieID, value)
+}
@@ -2,55 +2,145 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"gohbase/config"
 	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"io"
 	"strconv"
 	"strings"
 
 	"github.com/tsuna/gohbase/hrpc"
 )
 
-// SearchMovies 搜索电影
-func SearchMovies(query string, page, perPage int) (*MovieList, error) {
+// 搜索字段（search_type参数）
+const (
+	SearchTypeAll   = "all"
+	SearchTypeTitle = "title"
+	SearchTypeGenre = "genre"
+	SearchTypeTag   = "tag"
+)
+
+// ErrInvalidSearchType 不支持的搜索字段
+var ErrInvalidSearchType = errors.New("search_type必须是title、genre、tag或all")
+
+// 搜索结果排序方式（rank参数）
+const (
+	RankRelevance  = "relevance"  // 只按匹配度
+	RankPopularity = "popularity" // 匹配度加评分人数
+	RankRating     = "rating"     // 匹配度加贝叶斯平均评分
+)
+
+// ErrInvalidRankMode 不支持的排序方式
+var ErrInvalidRankMode = errors.New("rank必须是relevance、popularity或rating")
+
+// ParseRankMode 解析rank参数，为空时按匹配度排序
+func ParseRankMode(s string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return RankRelevance, nil
+	case RankRelevance, RankPopularity, RankRating:
+		return s, nil
+	}
+	return "", ErrInvalidRankMode
+}
+
+// ParseSearchType 解析search_type参数，为空时默认搜索全部字段
+func ParseSearchType(s string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(s)); s {
+	case "":
+		return SearchTypeAll, nil
+	case SearchTypeAll, SearchTypeTitle, SearchTypeGenre, SearchTypeTag:
+		return s, nil
+	}
+	return "", ErrInvalidSearchType
+}
+
+// searchTypeIncludes 搜索类型是否包含指定字段
+func searchTypeIncludes(searchType, field string) bool {
+	return searchType == SearchTypeAll || searchType == field
+}
+
+// SearchMovies 按searchType指定的字段（标题、类型、标签）搜索电影。
+// 查询为IMDB/TMDB ID（见ParseExternalID）时先在索引中精确查找，索引不可用时按普通文本搜索。
+// rank只在使用SQLite索引时生效，HBase扫描回退时保持扫描顺序。
+// fields不为nil时索引搜索只读取所需字段对应的数据，未选择的字段可能为空值，由调用方裁剪。
+func SearchMovies(query, searchType, rank string, page, perPage int, fields MovieFields) (*MovieList, error) {
 	// 构建缓存键
-	cacheKey := fmt.Sprintf("search:%s:%d:%d", query, page, perPage)
+	cacheKey := fmt.Sprintf("search:%s:%s:%s:%d:%d:%s", searchType, rank, query, page, perPage, fields.key())
 
 	// 检查缓存
 	if cachedResults, found := utils.Cache.Get(cacheKey); found {
-		return cachedResults.(*MovieList), nil
+		if result, ok := cachedResults.(*MovieList); ok {
+			return result, nil
+		}
 	}
 
 	ctx := context.Background()
+	searchCfg := config.GetConfig().GetSearchConfig()
+
+	// tt0111161、imdb:0111161、tmdb:278等外部ID在索引中精确查找
+	if source, externalID, ok := ParseExternalID(query); ok && searchType != SearchTypeTag {
+		if result, ok := searchByExternalID(ctx, source, externalID, page, perPage); ok {
+			utils.Cache.Set(cacheKey, result)
+			return result, nil
+		}
+	}
 
 	// 优先使用索引搜索（如果索引已建立）
 	searchIndex := GetSearchIndex()
 	if searchIndex.IsIndexReady() {
-		result, err := searchIndex.SearchMoviesWithIndex(ctx, query, page, perPage)
+		result, err := searchIndex.SearchMoviesWithIndex(ctx, query, searchType, rank, page, perPage, fields)
 		if err == nil {
 			// 缓存搜索结果
 			utils.Cache.Set(cacheKey, result)
 			return result, nil
 		}
+		if !*searchCfg.EnableIndexFallback {
+			return nil, err
+		}
 		// 如果索引搜索失败，继续使用原有方法
 		fmt.Printf("索引搜索失败，使用原有方法: %v\n", err)
+	} else if !*searchCfg.EnableIndexFallback {
+		return nil, fmt.Errorf("搜索索引未就绪，且已禁用HBase扫描回退")
 	}
 
 	// Fallback: 智能搜索策略
 	var matchedMovies []Movie
 	var err error
+	truncated := false
 
 	// 1. 检查是否为电影ID搜索
-	if movieID, parseErr := strconv.Atoi(query); parseErr == nil {
+	if movieID, parseErr := strconv.Atoi(query); parseErr == nil && searchType != SearchTypeTag {
 		matchedMovies, err = searchByMovieID(ctx, movieID)
-	} else {
+	} else if searchType != SearchTypeTag {
 		// 2. 文本搜索：限制扫描范围
-		matchedMovies, err = searchByTextOptimized(ctx, query)
+		matchedMovies, err = searchByTextOptimized(ctx, query, searchType, searchCfg.MaxScanRows, searchCfg.MaxResults)
+		truncated = len(matchedMovies) >= searchCfg.MaxResults
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
+	// 3. 标签搜索：标签扫描需要遍历整张表，search_type=all时只在索引确认是已知标签时执行
+	if searchType == SearchTypeTag {
+		matchedMovies, err = searchByTagScan(ctx, query, searchCfg.MaxResults)
+		if err != nil {
+			return nil, err
+		}
+		truncated = len(matchedMovies) >= searchCfg.MaxResults
+	} else if searchType == SearchTypeAll {
+		if known, _ := searchIndex.IsKnownTag(ctx, query); known {
+			tagMovies, err := searchByTagScan(ctx, query, searchCfg.MaxResults)
+			if err != nil {
+				return nil, err
+			}
+			matchedMovies = mergeMovies(matchedMovies, tagMovies)
+		}
+	}
+
 	// 批量获取评分数据（如果需要）
 	if len(matchedMovies) > 0 {
 		err = enrichMoviesWithRatings(ctx, matchedMovies)
@@ -84,6 +174,7 @@ func SearchMovies(query string, page, perPage int) (*MovieList, error) {
 		Page:        page,
 		PerPage:     perPage,
 		TotalPages:  totalPages,
+		Truncated:   truncated,
 	}
 
 	// 缓存搜索结果
@@ -98,10 +189,9 @@ func searchByMovieID(ctx context.Context, movieID int) ([]Movie, error) {
 
 	// 使用前缀扫描获取该电影的所有信息
 	// 行键格式: {movieId}_info, {movieId}_stats 等
-	startRow := fmt.Sprintf("%s_", movieIDStr)
-	endRow := fmt.Sprintf("%s`", movieIDStr) // ` 是 _ 的下一个ASCII字符
+	startRow, endRow := rowkey.ScanRangeForMovie(movieIDStr)
 
-	scan, err := hrpc.NewScanRangeStr(ctx, "movies", startRow, endRow)
+	scan, err := hrpc.NewScanRangeStr(ctx, utils.MoviesTable(), startRow, endRow)
 	if err != nil {
 		return nil, err
 	}
@@ -109,57 +199,47 @@ func searchByMovieID(ctx context.Context, movieID int) ([]Movie, error) {
 	scanner := utils.GetClient().(interface {
 		Scan(request *hrpc.Scan) hrpc.Scanner
 	}).Scan(scan)
+	defer scanner.Close()
 
-	// 收集该电影的所有数据
-	movieData := make(map[string]map[string][]byte)
+	// 收集该电影的所有数据（按行类型区分，避免stats与info列名冲突）
+	movieData := make(utils.MovieRows)
 
 	for {
 		res, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, fmt.Errorf("扫描HBase失败: %w", err)
+		}
 
 		if len(res.Cells) == 0 {
 			continue
 		}
 
-		rowKey := string(res.Cells[0].Row)
-
-		// 解析行键类型
-		parts := strings.Split(rowKey, "_")
-		if len(parts) < 2 {
+		// 解析行键类型，跳过前缀相同的其他电影
+		rowMovieID, rowType, err := rowkey.ParseMovieRowKey(string(res.Cells[0].Row))
+		if err != nil || rowMovieID != movieIDStr {
 			continue
 		}
 
-		rowType := parts[1]
-
-		// 构建结果映射
-		if _, ok := movieData[rowType]; !ok {
-			movieData[rowType] = make(map[string][]byte)
-		}
-
-		for _, cell := range res.Cells {
-			family := string(cell.Family)
-			qualifier := string(cell.Qualifier)
-			key := fmt.Sprintf("%s:%s", family, qualifier)
-			movieData[rowType][key] = cell.Value
-		}
+		movieData.AddCells(rowType, res.Cells)
 	}
 
 	// 如果找到info数据，构建Movie对象
 	if _, exists := movieData["info"]; exists {
-		parsedData := utils.ParseMovieData(movieIDStr, movieData)
-		movie := buildMovieFromData(movieIDStr, parsedData, movieData)
+		parsedData := utils.ParseMovieDataByRowType(movieIDStr, movieData)
+		movie := buildMovieFromData(movieIDStr, parsedData)
 		return []Movie{movie}, nil
 	}
 
 	return []Movie{}, nil
 }
 
-// searchByTextOptimized （只扫描_info行）
-func searchByTextOptimized(ctx context.Context, query string) ([]Movie, error) {
-	// 使用简化但高效的方案：限制扫描结果数量并快速匹配
-	scan, err := hrpc.NewScanStr(ctx, "movies")
+// searchByTextOptimized 按标题和/或类型匹配（只扫描_info行）
+func searchByTextOptimized(ctx context.Context, query, searchType string, maxRowsToProcess, maxResults int) ([]Movie, error) {
+	// 非_info行在服务端过滤，只传输标题和类型列
+	scan, err := hrpc.NewScanStr(ctx, utils.MoviesTable(), utils.ListScanOptions(int64(maxRowsToProcess))...)
 	if err != nil {
 		return nil, err
 	}
@@ -167,19 +247,22 @@ func searchByTextOptimized(ctx context.Context, query string) ([]Movie, error) {
 	scanner := utils.GetClient().(interface {
 		Scan(request *hrpc.Scan) hrpc.Scanner
 	}).Scan(scan)
+	defer scanner.Close()
 
 	queryLower := strings.ToLower(query)
 	var matchedMovies []Movie
 
 	// 限制处理的行数，避免无限扫描
-	maxRowsToProcess := 10000
 	processedInfoRows := 0
 
 	for processedInfoRows < maxRowsToProcess {
 		res, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, fmt.Errorf("扫描HBase失败: %w", err)
+		}
 
 		if len(res.Cells) == 0 {
 			continue
@@ -187,24 +270,22 @@ func searchByTextOptimized(ctx context.Context, query string) ([]Movie, error) {
 
 		rowKey := string(res.Cells[0].Row)
 
-		// 只处理_info行
-		if !strings.HasSuffix(rowKey, "_info") {
+		// 只处理_info行，提取电影ID
+		movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeInfo)
+		if !ok {
 			continue
 		}
 
 		processedInfoRows++
 
-		// 提取电影ID
-		movieID := strings.TrimSuffix(rowKey, "_info")
-
 		// 快速匹配并构建电影对象
-		movie := quickMatchAndBuildWithContext(ctx, movieID, res.Cells, queryLower)
+		movie := quickMatchAndBuildWithContext(ctx, movieID, res.Cells, queryLower, searchType)
 		if movie != nil {
 			matchedMovies = append(matchedMovies, *movie)
 		}
 
 		// 如果已经找到足够的结果，可以提前退出
-		if len(matchedMovies) >= 1000 { // 限制最大返回结果
+		if len(matchedMovies) >= maxResults { // 限制最大返回结果
 			break
 		}
 	}
@@ -214,11 +295,11 @@ func searchByTextOptimized(ctx context.Context, query string) ([]Movie, error) {
 
 // quickMatchAndBuild 快速匹配并构建电影对象
 func quickMatchAndBuild(movieID string, cells []*hrpc.Cell, query string) *Movie {
-	return quickMatchAndBuildWithContext(context.Background(), movieID, cells, query)
+	return quickMatchAndBuildWithContext(context.Background(), movieID, cells, query, SearchTypeAll)
 }
 
-// quickMatchAndBuildWithContext 快速匹配并构建电影对象（支持context）
-func quickMatchAndBuildWithContext(ctx context.Context, movieID string, cells []*hrpc.Cell, query string) *Movie {
+// quickMatchAndBuildWithContext 快速匹配并构建电影对象（支持context），searchType限定匹配标题或类型
+func quickMatchAndBuildWithContext(ctx context.Context, movieID string, cells []*hrpc.Cell, query, searchType string) *Movie {
 	// 快速提取标题和类型进行匹配
 	var title string
 	var genres []string
@@ -233,17 +314,17 @@ func quickMatchAndBuildWithContext(ctx context.Context, movieID string, cells []
 				title = string(cell.Value)
 			case "genres":
 				if len(cell.Value) > 0 {
-					genres = strings.Split(string(cell.Value), "|")
+					genres = utils.ParseGenres(string(cell.Value))
 				}
 			}
 		}
 	}
 
 	// 快速匹配检查
-	titleMatch := title != "" && strings.Contains(strings.ToLower(title), query)
+	titleMatch := searchTypeIncludes(searchType, SearchTypeTitle) && title != "" && strings.Contains(strings.ToLower(title), query)
 	genreMatch := false
 
-	if !titleMatch && len(genres) > 0 {
+	if !titleMatch && searchTypeIncludes(searchType, SearchTypeGenre) && len(genres) > 0 {
 		for _, genre := range genres {
 			if strings.Contains(strings.ToLower(genre), query) {
 				genreMatch = true
@@ -271,8 +352,9 @@ func quickMatchAndBuildWithContext(ctx context.Context, movieID string, cells []
 		}
 	}
 
-	// 尝试读取stats数据
-	statsGet, err := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_stats", movieID))
+	// 尝试读取stats行的评分列
+	statsGet, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieStatsKey(movieID),
+		hrpc.Families(utils.StatsColumns()))
 	if err == nil {
 		client := utils.GetClient().(interface {
 			Get(request *hrpc.Get) (*hrpc.Result, error)
@@ -297,6 +379,87 @@ func quickMatchAndBuildWithContext(ctx context.Context, movieID string, cells []
 	return buildMovieFromParsedDataWithRatingCheck(ctx, movieID, movieData)
 }
 
+// scanMoviesByTagResults 通过HBase扫描查找标签包含tag的电影，返回_info行数据
+func scanMoviesByTagResults(ctx context.Context, tag string, limit int) ([]*hrpc.Result, error) {
+	results, err := utils.ScanMoviesByTag(ctx, tag, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("按标签扫描HBase失败: %w", err)
+	}
+	return results, nil
+}
+
+// scanMoviesByTag 通过HBase扫描按标签搜索，返回电影ID和标题
+func scanMoviesByTag(ctx context.Context, tag string, limit int) ([]MovieIdWithTitle, error) {
+	results, err := scanMoviesByTagResults(ctx, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	movies := make([]MovieIdWithTitle, 0, len(results))
+	for _, result := range results {
+		movieID, ok := movieIDFromInfoResult(result)
+		if !ok {
+			continue
+		}
+		movie := MovieIdWithTitle{ID: movieID}
+		for _, cell := range result.Cells {
+			if string(cell.Family) == "info" && string(cell.Qualifier) == "title" {
+				movie.Title = string(cell.Value)
+			}
+		}
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+// searchByTagScan 通过HBase扫描按标签搜索并构建电影对象（索引不可用时使用）
+func searchByTagScan(ctx context.Context, tag string, limit int) ([]Movie, error) {
+	results, err := scanMoviesByTagResults(ctx, tag, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	movies := make([]Movie, 0, len(results))
+	for _, result := range results {
+		movieID, ok := movieIDFromInfoResult(result)
+		if !ok {
+			continue
+		}
+		resultMap := map[string]map[string][]byte{"info": {}}
+		for _, cell := range result.Cells {
+			if string(cell.Family) == "info" {
+				resultMap["info"][string(cell.Qualifier)] = cell.Value
+			}
+		}
+		movieData := utils.ParseMovieData(movieID, resultMap)
+		movies = append(movies, *buildMovieFromParsedDataWithRatingCheck(ctx, movieID, movieData))
+	}
+	return movies, nil
+}
+
+// mergeMovies 合并两组搜索结果，按电影ID去重并保持顺序
+func mergeMovies(movies, extra []Movie) []Movie {
+	seen := make(map[string]bool, len(movies))
+	for _, movie := range movies {
+		seen[movie.MovieID] = true
+	}
+	for _, movie := range extra {
+		if !seen[movie.MovieID] {
+			seen[movie.MovieID] = true
+			movies = append(movies, movie)
+		}
+	}
+	return movies
+}
+
+// movieIDFromInfoResult 从_info行结果中提取电影ID
+func movieIDFromInfoResult(result *hrpc.Result) (string, bool) {
+	if len(result.Cells) == 0 {
+		return "", false
+	}
+	return rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeInfo)
+}
+
 // buildMovieFromParsedData 从解析的数据构建Movie对象
 func buildMovieFromParsedData(movieID string, movieData map[string]interface{}) *Movie {
 	movie := &Movie{
@@ -304,15 +467,7 @@ func buildMovieFromParsedData(movieID string, movieData map[string]interface{})
 	}
 
 	if title, ok := movieData["title"].(string); ok {
-		movie.Title = title
-
-		// 从标题提取年份
-		if matches := strings.Split(title, " ("); len(matches) > 1 {
-			yearStr := strings.TrimSuffix(matches[len(matches)-1], ")")
-			if year, err := strconv.Atoi(yearStr); err == nil {
-				movie.Year = year
-			}
-		}
+		movie.setTitle(title)
 	}
 
 	if genres, ok := movieData["genres"].([]string); ok {
@@ -322,6 +477,7 @@ func buildMovieFromParsedData(movieID string, movieData map[string]interface{})
 	if avgRating, ok := movieData["avgRating"].(float64); ok {
 		movie.AvgRating = avgRating
 	}
+	movie.RatingCount, _ = movieData["ratingCount"].(int)
 
 	// 添加标签数据（使用通用函数）
 	ctx := context.Background()
@@ -329,26 +485,12 @@ func buildMovieFromParsedData(movieID string, movieData map[string]interface{})
 		if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
 			movie.Tags = uniqueTags
 		}
+		movie.TagCount, _ = tagsData["tagCount"].(int)
 	}
 
-	// 添加链接数据（使用通用函数）
-	if linksData, err := utils.GetMovieLinks(ctx, movieID); err == nil {
-		linkObj := Links{}
-
-		if imdbId, ok := linksData["imdbId"].(string); ok {
-			linkObj.ImdbID = imdbId
-		}
-		if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
-			linkObj.ImdbURL = imdbUrl
-		}
-		if tmdbId, ok := linksData["tmdbId"].(string); ok {
-			linkObj.TmdbID = tmdbId
-		}
-		if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
-			linkObj.TmdbURL = tmdbUrl
-		}
-
-		movie.Links = linkObj
+	// 添加链接数据（使用通用函数），只读取外部ID列
+	if linksData, err := utils.GetMovieLinks(ctx, movieID, hrpc.Families(utils.LinksColumns())); err == nil {
+		movie.Links = newLinks(linksData)
 	}
 
 	return movie
@@ -358,51 +500,40 @@ func buildMovieFromParsedData(movieID string, movieData map[string]interface{})
 func buildMovieFromParsedDataWithRatingCheck(ctx context.Context, movieID string, movieData map[string]interface{}) *Movie {
 	movie := buildMovieFromParsedData(movieID, movieData)
 
-	// 如果没有平均评分，尝试计算并存储
+	// 如果没有平均评分，按配置决定是否现场计算
 	if movie.AvgRating == 0.0 {
-		avgRating, ratingCount, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
-		if err == nil && avgRating > 0.0 {
-			movie.AvgRating = avgRating
-			fmt.Printf("✅ Fallback搜索: 成功计算并存储电影 %s 的平均评分: %.2f (基于 %d 个评分)\n",
-				movieID, avgRating, ratingCount)
+		if avgRating, ratingCount, ok := lazyAvgRating(ctx, movieID, "Fallback搜索"); ok {
+			movie.AvgRating, movie.RatingCount = avgRating, ratingCount
 		}
 	}
 
 	return movie
 }
 
-// buildMovieFromData 从完整数据构建Movie对象（用于ID搜索）
-func buildMovieFromData(movieID string, parsedData map[string]interface{}, allData map[string]map[string][]byte) Movie {
+// buildMovieFromData 从ParseMovieDataByRowType的解析结果构建Movie对象（用于ID搜索）
+func buildMovieFromData(movieID string, parsedData map[string]interface{}) Movie {
 	movie := Movie{
 		MovieID: movieID,
 	}
 
 	if title, ok := parsedData["title"].(string); ok {
-		movie.Title = title
-
-		// 从标题提取年份
-		if matches := strings.Split(title, " ("); len(matches) > 1 {
-			yearStr := strings.TrimSuffix(matches[len(matches)-1], ")")
-			if year, err := strconv.Atoi(yearStr); err == nil {
-				movie.Year = year
-			}
-		}
+		movie.setTitle(title)
 	}
 
 	if genres, ok := parsedData["genres"].([]string); ok {
 		movie.Genres = genres
 	}
 
-	// 优先使用stats中的预计算评分
-	if statsData, hasStats := allData["stats"]; hasStats {
-		if avgRatingBytes, ok := statsData["info:avg_rating"]; ok {
-			if avgRating, err := strconv.ParseFloat(string(avgRatingBytes), 64); err == nil {
-				movie.AvgRating = avgRating
-			}
-		}
-	} else if avgRating, ok := parsedData["avgRating"].(float64); ok {
+	// 解析时已优先使用stats中的预计算评分
+	if avgRating, ok := parsedData["avgRating"].(float64); ok {
 		movie.AvgRating = avgRating
 	}
+	// 没有stats行时平均分由ratings行实时计算，评分数取评分条数
+	if ratingCount, ok := parsedData["ratingCount"].(int); ok {
+		movie.RatingCount = ratingCount
+	} else if ratings, ok := parsedData["ratings"].([]map[string]interface{}); ok {
+		movie.RatingCount = len(ratings)
+	}
 
 	// 添加标签数据（使用通用函数）
 	ctx := context.Background()
@@ -410,26 +541,14 @@ func buildMovieFromData(movieID string, parsedData map[string]interface{}, allDa
 		if uniqueTags, ok := tagsData["uniqueTags"].([]string); ok {
 			movie.Tags = uniqueTags
 		}
+		movie.TagCount, _ = tagsData["tagCount"].(int)
 	}
 
-	// 添加链接数据（使用通用函数）
-	if linksData, err := utils.GetMovieLinks(ctx, movieID); err == nil {
-		linkObj := Links{}
-
-		if imdbId, ok := linksData["imdbId"].(string); ok {
-			linkObj.ImdbID = imdbId
-		}
-		if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
-			linkObj.ImdbURL = imdbUrl
-		}
-		if tmdbId, ok := linksData["tmdbId"].(string); ok {
-			linkObj.TmdbID = tmdbId
-		}
-		if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
-			linkObj.TmdbURL = tmdbUrl
-		}
-
-		movie.Links = linkObj
+	// 添加链接数据：已读取_links行时直接使用，否则通过通用函数获取
+	if linksData, ok := parsedData["links"].(map[string]interface{}); ok {
+		movie.Links = newLinks(linksData)
+	} else if linksData, err := utils.GetMovieLinks(ctx, movieID, hrpc.Families(utils.LinksColumns())); err == nil {
+		movie.Links = newLinks(linksData)
 	}
 
 	return movie
@@ -2,11 +2,17 @@ package models
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"gohbase/config"
 	"gohbase/utils"
-	"os"
+	"gohbase/utils/hbase/rowkey"
+	"io"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,15 +22,46 @@ import (
 
 // SearchIndex 搜索索引管理器。
 type SearchIndex struct {
-	mu sync.RWMutex
+	mu      sync.RWMutex
+	buildMu sync.Mutex // 同一时间只进行一次重建
+
+	// 重建期间的增量更新同时记入pending，切换到新索引前在新索引上重放。
+	// building在mu下修改，GetIndexStats不加锁读取
+	building atomic.Bool
+	pending  []indexWrite
+
+	// health 缓存的索引一致性检查结果，nil表示需要重新检查。
+	// 持有读锁的搜索可能同时写入，使用原子指针；切换索引和增量更新在写锁下清除
+	health atomic.Pointer[IndexHealth]
 }
 
+// indexWrite 对索引数据库的一次增量更新
+type indexWrite func(ctx context.Context, db *sql.DB) error
+
 // MovieIdWithTitle 用于存储电影ID和标题的简单结构体。
 type MovieIdWithTitle struct {
 	ID    string
 	Title string
 }
 
+// IndexStats 搜索索引统计信息。
+type IndexStats struct {
+	IndexedMovies int    `json:"indexed_movies"`
+	DBSizeBytes   int64  `json:"db_size_bytes"`
+	LastBuiltAt   string `json:"last_built_at"`
+	FTSReady      bool   `json:"fts_ready"`
+	FTSCount      int    `json:"fts_count"`
+	Consistent    bool   `json:"consistent"`
+	IndexReady    bool   `json:"index_ready"`
+	Rebuilding    bool   `json:"rebuilding"` // 正在重建，重建期间继续使用当前索引（首次构建时查询回退到HBase）
+}
+
+// 元数据表中的键
+const (
+	indexMetaLastBuiltAt = "last_built_at" // 最近构建时间
+	indexMetaTagsIndexed = "tags_indexed"  // 是否已索引标签（旧版本构建的索引没有标签数据）
+)
+
 var globalSearchIndex *SearchIndex
 var indexOnce sync.Once
 
@@ -37,26 +74,33 @@ func GetSearchIndex() *SearchIndex {
 }
 
 // BuildSearchIndex 扫描HBase并构建持久化的SQLite索引。
+// 新索引写入临时数据库，构建完成后原子地替换当前索引；构建期间当前索引继续提供查询。
 func (si *SearchIndex) BuildSearchIndex(ctx context.Context) error {
-	si.mu.Lock()
-	defer si.mu.Unlock()
+	si.buildMu.Lock()
+	defer si.buildMu.Unlock()
 
 	logrus.Info("开始构建SQLite搜索索引...")
 	start := time.Now()
 
-	if err := utils.ResetDatabase(); err != nil {
-		logrus.Warnf("无法重置数据库，但仍将继续: %v", err)
-	}
-	db, err := utils.InitDB()
+	db, err := utils.CreateStagingDB()
 	if err != nil {
-		return fmt.Errorf("初始化SQLite数据库失败: %w", err)
+		return fmt.Errorf("创建临时索引失败: %w", err)
 	}
+	si.setBuilding(true)
+	swapped := false
+	defer func() {
+		if !swapped {
+			si.setBuilding(false)
+			utils.DiscardStagingDB(db)
+		}
+	}()
 
-	scan, err := hrpc.NewScanStr(ctx, "movies")
+	scan, err := hrpc.NewScanStr(ctx, utils.MoviesTable())
 	if err != nil {
 		return fmt.Errorf("创建HBase扫描失败: %w", err)
 	}
 	scanner := utils.GetClient().(gohbase.Client).Scan(scan)
+	defer scanner.Close()
 
 	// 使用事务进行批量插入以提高性能
 	tx, err := db.Begin()
@@ -65,40 +109,121 @@ func (si *SearchIndex) BuildSearchIndex(ctx context.Context) error {
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare("INSERT INTO movie_index (movie_id, title) VALUES (?, ?)")
+	stmt, err := tx.Prepare("INSERT INTO movie_index (movie_id, title, title_norm, genres, year, added_time) VALUES (?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
+	trigramStmt, err := tx.Prepare("INSERT INTO movie_trigrams (trigram, movie_id) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer trigramStmt.Close()
+
+	genomeStmt, err := tx.Prepare("INSERT INTO movie_genome_topk (movie_id, tag_id, relevance) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer genomeStmt.Close()
+
+	tagStmt, err := tx.Prepare("INSERT INTO movie_tags (movie_id, tag, count) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer tagStmt.Close()
+
+	// _links和_stats行排在_info行之后，先收集，插入完成后再写入movie_index
+	movieStats := make(map[string]indexedStats)
+	movieLinks := make(map[string]externalIDs)
+
 	indexedCount := 0
 	for {
 		res, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return fmt.Errorf("扫描HBase失败: %w", err)
+		}
 		if len(res.Cells) == 0 {
 			continue
 		}
 
 		rowKey := string(res.Cells[0].Row)
-		if !strings.HasSuffix(rowKey, "_info") {
+
+		// _genome行：只保存相关度最高的Top-K标签，供相似度计算使用
+		if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeGenome); ok {
+			for _, tag := range topKGenomeTags(res.Cells, genomeTopK) {
+				if _, err := genomeStmt.Exec(movieID, tag.TagID, tag.Relevance); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		// _tags行：按标签（小写）统计次数
+		if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeTags); ok {
+			for tag, count := range countMovieTags(res.Cells) {
+				if _, err := tagStmt.Exec(movieID, tag, count); err != nil {
+					return err
+				}
+			}
 			continue
 		}
 
-		movieID := strings.TrimSuffix(rowKey, "_info")
-		var title string
+		// _stats行：评分统计，用于按热度或评分排序
+		if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeStats); ok {
+			if stats, ok := parseIndexedStats(res.Cells); ok {
+				movieStats[movieID] = stats
+			}
+			continue
+		}
+
+		// _links行：外部ID，用于按IMDB/TMDB ID查找
+		if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeLinks); ok {
+			if ids := parseExternalIDCells(res.Cells); !ids.empty() {
+				movieLinks[movieID] = ids
+			}
+			continue
+		}
+
+		movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeInfo)
+		if !ok {
+			continue
+		}
+		var title, genres string
+		var addedTime interface{}
 		for _, cell := range res.Cells {
-			qualifier := string(cell.Qualifier)
-			if string(cell.Family) == "info" && qualifier == "title" {
+			if string(cell.Family) != "info" {
+				continue
+			}
+			switch string(cell.Qualifier) {
+			case "title":
 				title = string(cell.Value)
+			case "genres":
+				genres = strings.Join(utils.ParseGenres(string(cell.Value)), "|")
+			case "added_time":
+				if seconds, err := strconv.ParseInt(string(cell.Value), 10, 64); err == nil {
+					addedTime = seconds
+				}
 			}
 		}
 
 		if title != "" {
-			if _, err := stmt.Exec(movieID, title); err != nil {
+			// 没有年份的电影year列为NULL
+			var year interface{}
+			if _, y, ok := utils.ExtractYearFromTitle(title); ok {
+				year = y
+			}
+			if _, err := stmt.Exec(movieID, title, utils.NormalizeTitle(title), genres, year, addedTime); err != nil {
 				return err
 			}
+			for _, trigram := range titleTrigrams(title) {
+				if _, err := trigramStmt.Exec(trigram, movieID); err != nil {
+					return err
+				}
+			}
 			indexedCount++
 			if indexedCount%1000 == 0 {
 				logrus.Infof("已索引 %d 部电影到SQLite...", indexedCount)
@@ -106,56 +231,131 @@ func (si *SearchIndex) BuildSearchIndex(ctx context.Context) error {
 		}
 	}
 
+	statsStmt, err := tx.Prepare("UPDATE movie_index SET avg_rating = ?, rating_count = ? WHERE movie_id = ?")
+	if err != nil {
+		return err
+	}
+	defer statsStmt.Close()
+	for movieID, stats := range movieStats {
+		if _, err := statsStmt.Exec(stats.AvgRating, stats.RatingCount, movieID); err != nil {
+			return err
+		}
+	}
+
+	linksStmt, err := tx.Prepare("UPDATE movie_index SET imdb_id = ?, tmdb_id = ? WHERE movie_id = ?")
+	if err != nil {
+		return err
+	}
+	defer linksStmt.Close()
+	for movieID, ids := range movieLinks {
+		if _, err := linksStmt.Exec(nullIfEmpty(ids.Imdb), nullIfEmpty(ids.Tmdb), movieID); err != nil {
+			return err
+		}
+	}
+
 	// 提交数据
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("提交事务失败: %w", err)
 	}
 
 	// 在数据插入后创建FTS表并重建索引
-	// UNINDEXED告诉FTS5不要为movie_id创建全文索引，以节省空间和提高效率
-	if _, err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS movie_fts USING fts5(movie_id UNINDEXED, title, content='movie_index', content_rowid='id')`); err != nil {
+	if _, err := db.Exec(ftsTableSQL); err != nil {
 		return fmt.Errorf("创建FTS表失败: %w", err)
 	}
 	if _, err := db.Exec(`INSERT INTO movie_fts(movie_fts) VALUES('rebuild')`); err != nil {
 		return fmt.Errorf("重建FTS索引失败: %w", err)
 	}
 
+	if err := utils.WriteIndexMeta(db, indexMetaLastBuiltAt, time.Now().Format(time.RFC3339)); err != nil {
+		logrus.Warnf("记录索引构建时间失败: %v", err)
+	}
+	if err := utils.WriteIndexMeta(db, indexMetaTagsIndexed, "true"); err != nil {
+		logrus.Warnf("记录标签索引状态失败: %v", err)
+	}
+
+	if err := si.swapIndex(ctx, db); err != nil {
+		return err
+	}
+	swapped = true
+
 	duration := time.Since(start)
 	logrus.Infof("SQLite搜索索引构建成功！共索引 %d 部电影，耗时 %v", indexedCount, duration)
 	return nil
 }
 
-// SearchMoviesWithIndex 使用SQLite索引进行快速搜索。
-func (si *SearchIndex) SearchMoviesWithIndex(ctx context.Context, query string, page, perPage int) (*MovieList, error) {
-	si.mu.RLock()
-	defer si.mu.RUnlock()
+// setBuilding 标记重建开始或结束，结束时丢弃记录的增量更新
+func (si *SearchIndex) setBuilding(building bool) {
+	si.mu.Lock()
+	si.building.Store(building)
+	si.pending = nil
+	si.mu.Unlock()
+}
+
+// swapIndex 在写锁下把构建期间的增量更新重放到新索引并复制用户数据，然后切换到新索引
+func (si *SearchIndex) swapIndex(ctx context.Context, staging *sql.DB) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
 
-	if !si.IsIndexReady() {
-		return nil, fmt.Errorf("搜索索引未就绪")
+	for _, write := range si.pending {
+		if err := write(ctx, staging); err != nil {
+			logrus.Warnf("重放构建期间的索引更新失败: %v", err)
+		}
+	}
+	if len(si.pending) > 0 {
+		logrus.Infof("已将构建期间的 %d 次索引更新应用到新索引", len(si.pending))
+	}
+	if err := carryOverUserData(ctx, staging); err != nil {
+		logrus.Warnf("复制用户活跃度到新索引失败，将在下次计算时恢复: %v", err)
 	}
 
-	db, err := utils.GetDB()
+	err := utils.SwapStagingDB(staging)
+	si.health.Store(nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("切换到新索引失败: %w", err)
 	}
+	si.building.Store(false)
+	si.pending = nil
+	utils.Cache.DeletePrefix(yearStatsCachePrefix)
+	return nil
+}
 
-	// 构造FTS5查询语句
-	sanitizedQuery := `"` + strings.ReplaceAll(query, `"`, `""`) + `*"`
+// applyWrite 在当前索引上执行增量更新，当前索引未就绪时不执行。
+// 重建进行中时同时记下该更新，切换前在新索引上重放，避免构建期间的修改丢失。
+func (si *SearchIndex) applyWrite(ctx context.Context, write indexWrite) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	if si.building.Load() {
+		si.pending = append(si.pending, write)
+	}
+	if !si.isIndexReadyLocked() {
+		return nil
+	}
 
-	// 查询FTS表 - 修改为同时获取标题
-	rows, err := db.QueryContext(ctx, "SELECT mi.movie_id, mi.title FROM movie_index mi JOIN movie_fts ft ON mi.id = ft.rowid WHERE ft.title MATCH ? ORDER BY ft.rank", sanitizedQuery)
+	db, release, err := utils.AcquireDB()
 	if err != nil {
-		return nil, fmt.Errorf("在SQLite FTS索引中搜索失败: %w", err)
+		return err
 	}
-	defer rows.Close()
+	defer release()
+	defer si.health.Store(nil)
+	return write(ctx, db)
+}
 
-	var matchedMovies []MovieIdWithTitle
-	for rows.Next() {
-		var movie MovieIdWithTitle
-		if err := rows.Scan(&movie.ID, &movie.Title); err != nil {
+// SearchMoviesWithIndex 使用SQLite索引进行快速搜索，按searchType选择匹配字段。
+// 结果依次为标题、类型、标签匹配，按电影ID去重；每组内部按rank排序（见rankBoostExpr）。
+// fields不为nil时只读取所需字段对应的数据，只需要movieId、title等索引中已有的字段时不访问HBase。
+// 只在查询SQLite期间持有读锁，HBase读取在锁外进行，HBase较慢时不会让等待切换的索引阻塞其他搜索。
+func (si *SearchIndex) SearchMoviesWithIndex(ctx context.Context, query, searchType, rank string, page, perPage int, fields MovieFields) (*MovieList, error) {
+	matchedMovies, didYouMean, scanTags, err := si.matchMovies(ctx, query, searchType, rank)
+	if err != nil {
+		return nil, err
+	}
+	if scanTags {
+		tag := strings.ToLower(strings.TrimSpace(query))
+		logrus.Debugf("索引中没有标签数据，使用HBase扫描搜索标签 %q", tag)
+		if matchedMovies, err = scanMoviesByTag(ctx, tag, config.GetConfig().GetSearchConfig().MaxResults); err != nil {
 			return nil, err
 		}
-		matchedMovies = append(matchedMovies, movie)
 	}
 
 	if len(matchedMovies) == 0 {
@@ -176,7 +376,7 @@ func (si *SearchIndex) SearchMoviesWithIndex(ctx context.Context, query string,
 	}
 
 	// 传递电影ID和标题给批量获取函数
-	movies, err := si.getMovieDetailsBatchWithTitles(ctx, pageMovies)
+	movies, err := si.getMovieDetailsBatchWithTitles(ctx, pageMovies, fields)
 	if err != nil {
 		return nil, err
 	}
@@ -187,131 +387,710 @@ func (si *SearchIndex) SearchMoviesWithIndex(ctx context.Context, query string,
 		Page:        page,
 		PerPage:     perPage,
 		TotalPages:  totalPages,
+		DidYouMean:  didYouMean,
 	}, nil
 }
 
-// IsIndexReady 检查SQLite索引是否可用。
+// matchMovies 在读锁下查询索引，返回按searchType匹配的电影ID和标题，没有结果时按标题做拼写纠错（didYouMean）。
+// 只搜索标签而索引构建时未包含标签数据时，scanTags为true，由调用方在锁外回退到HBase扫描
+func (si *SearchIndex) matchMovies(ctx context.Context, query, searchType, rank string) (matchedMovies []MovieIdWithTitle, didYouMean, scanTags bool, err error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, false, false, fmt.Errorf("搜索索引未就绪")
+	}
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer release()
+
+	seen := make(map[string]bool)
+	appendMatches := func(matches []MovieIdWithTitle) {
+		for _, movie := range matches {
+			if !seen[movie.ID] {
+				seen[movie.ID] = true
+				matchedMovies = append(matchedMovies, movie)
+			}
+		}
+	}
+
+	if searchTypeIncludes(searchType, SearchTypeTitle) {
+		// 构造FTS5查询语句，同时匹配原标题和规范化标题
+		sanitizedQuery := titleMatchQuery(query, func(text string) string {
+			return `"` + strings.ReplaceAll(text, `"`, `""`) + `*"`
+		})
+
+		// FTS5的rank（bm25）越小越相关，非relevance模式时减去加分项
+		orderBy := "ft.rank"
+		if boost := rankBoostExpr(rank); boost != "" {
+			orderBy = fmt.Sprintf("ft.rank - %g * %s, mi.id", rankBoostWeight, boost)
+		}
+
+		// 查询FTS表 - 修改为同时获取标题
+		matches, err := queryMoviesWithTitles(ctx, db, "SELECT mi.movie_id, mi.title FROM movie_index mi JOIN movie_fts ft ON mi.id = ft.rowid WHERE movie_fts MATCH ? ORDER BY "+orderBy, sanitizedQuery)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("在SQLite FTS索引中搜索失败: %w", err)
+		}
+		appendMatches(matches)
+	}
+
+	if searchTypeIncludes(searchType, SearchTypeGenre) {
+		// genres以"|"分隔，两端补分隔符后整词匹配
+		orderBy := "mi.id"
+		if boost := rankBoostExpr(rank); boost != "" {
+			orderBy = boost + " DESC, mi.id"
+		}
+		matches, err := queryMoviesWithTitles(ctx, db, `SELECT mi.movie_id, mi.title FROM movie_index mi WHERE '|' || lower(mi.genres) || '|' LIKE ? ESCAPE '\' ORDER BY `+orderBy,
+			"%|"+escapeLike(strings.ToLower(strings.TrimSpace(query)))+"|%")
+		if err != nil {
+			return nil, false, false, fmt.Errorf("在SQLite索引中按类型搜索失败: %w", err)
+		}
+		appendMatches(matches)
+	}
+
+	if searchTypeIncludes(searchType, SearchTypeTag) {
+		matches, indexed, err := searchIndexedTags(ctx, db, query, rank)
+		if err != nil {
+			return nil, false, false, err
+		}
+		// 索引中没有标签数据时，只搜索标签则由调用方回退到HBase扫描，
+		// 同时搜索其他字段时不为旧索引做全表扫描
+		if !indexed && searchType == SearchTypeTag {
+			return nil, false, true, nil
+		}
+		appendMatches(matches)
+	}
+
+	// 没有任何结果时按标题做拼写纠错
+	if len(matchedMovies) == 0 && searchTypeIncludes(searchType, SearchTypeTitle) {
+		matches, err := fuzzyTitleMatches(ctx, db, query)
+		if err != nil {
+			return nil, false, false, err
+		}
+		appendMatches(matches)
+		didYouMean = len(matches) > 0
+	}
+	return matchedMovies, didYouMean, false, nil
+}
+
+// 搜索排序参数
+const (
+	// rankBoostWeight 标题匹配时加分项相对bm25的权重
+	rankBoostWeight = 1.0
+	// ratingPriorCount、ratingPriorMean 贝叶斯平均的先验：评分人数少的电影的平均分向全局平均分收缩，
+	// 避免只有一条5分评价的冷门电影排在前面；全局平均分尚未计算时使用3.0
+	ratingPriorCount = 10
+	ratingPriorMean  = 3.0
+)
+
+// rankBoostExpr 返回rank对应的加分SQL表达式（越大越靠前，引用movie_index别名mi），relevance时返回空字符串。
+// popularity使用ln(1+评分人数)，rating使用贝叶斯平均评分。
+func rankBoostExpr(rank string) string {
+	switch rank {
+	case RankPopularity:
+		return "ln(1 + COALESCE(mi.rating_count, 0))"
+	case RankRating:
+		priorMean := ratingPriorMean
+		if globalAvg, ok := cachedGlobalAverage(); ok {
+			priorMean = globalAvg
+		}
+		return fmt.Sprintf("((%d * %g + COALESCE(mi.avg_rating, 0) * COALESCE(mi.rating_count, 0)) / (%d + COALESCE(mi.rating_count, 0)))",
+			ratingPriorCount, priorMean, ratingPriorCount)
+	}
+	return ""
+}
+
+// searchIndexedTags 按标签（不区分大小写、精确匹配）搜索，标签出现次数多的电影排在前面，
+// 非relevance模式时先按rank加分项排序。索引构建时未包含标签数据时indexed为false。调用方持有读锁。
+func searchIndexedTags(ctx context.Context, db *sql.DB, query, rank string) (matches []MovieIdWithTitle, indexed bool, err error) {
+	tag := strings.ToLower(strings.TrimSpace(query))
+
+	tagsIndexed, err := utils.ReadIndexMeta(db, indexMetaTagsIndexed)
+	if err != nil {
+		return nil, false, fmt.Errorf("读取标签索引状态失败: %w", err)
+	}
+	if tagsIndexed != "true" {
+		return nil, false, nil
+	}
+
+	orderBy := "mt.count DESC, mi.id"
+	if boost := rankBoostExpr(rank); boost != "" {
+		orderBy = boost + " DESC, " + orderBy
+	}
+	matches, err = queryMoviesWithTitles(ctx, db, `SELECT mi.movie_id, mi.title FROM movie_tags mt JOIN movie_index mi ON mi.movie_id = mt.movie_id
+		WHERE mt.tag = ? ORDER BY `+orderBy, tag)
+	if err != nil {
+		return nil, true, fmt.Errorf("在SQLite索引中按标签搜索失败: %w", err)
+	}
+	return matches, true, nil
+}
+
+// IsKnownTag 索引中是否存在该标签（不区分大小写）。索引未就绪或没有标签数据时返回false。
+func (si *SearchIndex) IsKnownTag(ctx context.Context, tag string) (bool, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return false, nil
+	}
+	if indexed, err := utils.GetIndexMeta(indexMetaTagsIndexed); err != nil || indexed != "true" {
+		return false, err
+	}
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	var exists int
+	err = db.QueryRowContext(ctx, "SELECT 1 FROM movie_tags WHERE tag = ? LIMIT 1", strings.ToLower(strings.TrimSpace(tag))).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// MovieIDsByTag 从索引中获取带有该标签（不区分大小写）的全部电影ID，标签出现次数多的排在前面。
+// 索引未就绪或没有标签数据时ok为false。
+func (si *SearchIndex) MovieIDsByTag(ctx context.Context, tag string) (movieIDs []string, ok bool, err error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, false, nil
+	}
+	if indexed, err := utils.GetIndexMeta(indexMetaTagsIndexed); err != nil || indexed != "true" {
+		return nil, false, err
+	}
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+	rows, err := db.QueryContext(ctx, `SELECT mt.movie_id FROM movie_tags mt JOIN movie_index mi ON mi.movie_id = mt.movie_id
+		WHERE mt.tag = ? ORDER BY mt.count DESC, mi.id`, strings.ToLower(strings.TrimSpace(tag)))
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	movieIDs = []string{}
+	for rows.Next() {
+		var movieID string
+		if err := rows.Scan(&movieID); err != nil {
+			return nil, false, err
+		}
+		movieIDs = append(movieIDs, movieID)
+	}
+	return movieIDs, true, rows.Err()
+}
+
+// RandomMovieIDs 从索引中随机取最多limit部电影ID，genre非空时只取该类型（不区分大小写），
+// minRating>0时只取索引中平均评分不低于minRating的电影。索引未就绪时ok为false。
+func (si *SearchIndex) RandomMovieIDs(ctx context.Context, genre string, minRating float64, limit int) (movieIDs []string, ok bool, err error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, false, nil
+	}
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
+	query := "SELECT movie_id FROM movie_index WHERE COALESCE(avg_rating, 0) >= ?"
+	args := []interface{}{minRating}
+	if genre = strings.ToLower(strings.TrimSpace(genre)); genre != "" {
+		query += ` AND '|' || lower(genres) || '|' LIKE ? ESCAPE '\'`
+		args = append(args, "%|"+escapeLike(genre)+"|%")
+	}
+	query += " ORDER BY random() LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	movieIDs = []string{}
+	for rows.Next() {
+		var movieID string
+		if err := rows.Scan(&movieID); err != nil {
+			return nil, false, err
+		}
+		movieIDs = append(movieIDs, movieID)
+	}
+	return movieIDs, true, rows.Err()
+}
+
+// queryMoviesWithTitles 执行返回(movie_id, title)的查询
+func queryMoviesWithTitles(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]MovieIdWithTitle, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movies []MovieIdWithTitle
+	for rows.Next() {
+		var movie MovieIdWithTitle
+		var title sql.NullString
+		if err := rows.Scan(&movie.ID, &title); err != nil {
+			return nil, err
+		}
+		movie.Title = title.String
+		movies = append(movies, movie)
+	}
+	return movies, rows.Err()
+}
+
+// escapeLike 转义LIKE模式中的通配符（ESCAPE '\'）
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// countMovieTags 统计_tags行中每个标签（小写）的出现次数
+func countMovieTags(cells []*hrpc.Cell) map[string]int {
+	counts := make(map[string]int)
+	for _, cell := range cells {
+		if string(cell.Family) != "tags" {
+			continue
+		}
+		// 标签数据格式: "{tag}:{userId}:{timestamp}"
+		tag := strings.ToLower(strings.TrimSpace(strings.Split(string(cell.Value), ":")[0]))
+		if tag != "" {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// IndexHealth 索引一致性检查结果。
+type IndexHealth struct {
+	IndexCount  int  `json:"index_count"`
+	FTSCount    int  `json:"fts_count"`
+	FTSOutdated bool `json:"fts_outdated"` // FTS表由旧版本创建（没有title_norm列或使用默认分词器）
+	Consistent  bool `json:"consistent"`
+}
+
+// validateIndexLocked 比较movie_index与FTS表的行数，不一致说明FTS重建未完成；FTS表结构过旧时同样视为不一致。
+// 外部内容FTS表的COUNT(*)实际读取的是movie_index，因此FTS行数取自movie_fts_docsize影子表。
+// 结果缓存到索引切换或增量更新为止，查询出错时不缓存。调用方持有mu
+func (si *SearchIndex) validateIndexLocked() (IndexHealth, error) {
+	if cached := si.health.Load(); cached != nil {
+		return *cached, nil
+	}
+
+	var health IndexHealth
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return health, err
+	}
+	defer release()
+
+	if err := db.QueryRow("SELECT COUNT(*) FROM movie_index").Scan(&health.IndexCount); err != nil {
+		return health, fmt.Errorf("查询索引计数失败: %w", err)
+	}
+
+	hasFTS, err := ftsTableExists(db)
+	if err != nil {
+		return health, fmt.Errorf("检查FTS表失败: %w", err)
+	}
+	if hasFTS {
+		if err := db.QueryRow("SELECT COUNT(*) FROM movie_fts_docsize").Scan(&health.FTSCount); err != nil {
+			return health, fmt.Errorf("查询FTS计数失败: %w", err)
+		}
+		var ftsSQL string
+		if err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'movie_fts'").Scan(&ftsSQL); err != nil {
+			return health, fmt.Errorf("读取FTS表结构失败: %w", err)
+		}
+		health.FTSOutdated = ftsSQL != ftsTableSQL
+	}
+
+	health.Consistent = hasFTS && !health.FTSOutdated && health.IndexCount == health.FTSCount
+	si.health.Store(&health)
+	return health, nil
+}
+
+// IsIndexReady 检查当前使用的SQLite索引是否可用：有数据且FTS表与movie_index一致。
+// 持有读锁检查，重建切换索引时等待切换完成，不会读到正在关闭的连接。
+// 已持有mu的方法应调用isIndexReadyLocked，重复获取读锁在有写者等待时会死锁
 func (si *SearchIndex) IsIndexReady() bool {
-	if _, err := os.Stat("./movie_index.db"); os.IsNotExist(err) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+	return si.isIndexReadyLocked()
+}
+
+// isIndexReadyLocked 同IsIndexReady，调用方持有mu
+func (si *SearchIndex) isIndexReadyLocked() bool {
+	health, err := si.validateIndexLocked()
+	if err != nil {
+		logrus.Warnf("无法检查索引就绪状态: %v", err)
+		return false
+	}
+	if health.FTSOutdated {
+		logrus.Warn("搜索索引的FTS表由旧版本创建，需要重建索引")
+		return false
+	}
+	if !health.Consistent {
+		if health.IndexCount > 0 {
+			logrus.Warnf("搜索索引不一致: movie_index %d 行, FTS %d 行，请重建索引", health.IndexCount, health.FTSCount)
+		}
 		return false
 	}
 
-	db, err := utils.GetDB()
+	return health.IndexCount > 0
+}
+
+// IndexedCount 返回索引中的电影数量。
+func (si *SearchIndex) IndexedCount() (int, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
 	if err != nil {
-		logrus.Warnf("无法检查索引就绪状态，获取数据库失败: %v", err)
-		return false
+		return 0, err
 	}
+	defer release()
 
 	var count int
 	err = db.QueryRow("SELECT COUNT(*) FROM movie_index").Scan(&count)
+	return count, err
+}
+
+// GetIndexStats 返回索引的电影数量、文件大小、最近构建时间、就绪状态和是否正在重建。
+// 切换索引或增量更新持有写锁时不等待，只返回文件大小和重建状态。
+func (si *SearchIndex) GetIndexStats() (*IndexStats, error) {
+	stats := &IndexStats{DBSizeBytes: utils.DBFileSize(), Rebuilding: si.building.Load()}
+
+	if !si.mu.TryRLock() {
+		return stats, nil
+	}
+	defer si.mu.RUnlock()
+
+	if stats.DBSizeBytes == 0 {
+		return stats, nil
+	}
+
+	health, err := si.validateIndexLocked()
 	if err != nil {
-		logrus.Warnf("无法查询索引计数: %v", err)
-		return false
+		return nil, err
+	}
+	stats.IndexedMovies = health.IndexCount
+	stats.FTSCount = health.FTSCount
+	stats.Consistent = health.Consistent
+	stats.FTSReady = health.FTSCount > 0
+	stats.IndexReady = health.IndexCount > 0 && health.Consistent
+
+	if stats.LastBuiltAt, err = utils.GetIndexMeta(indexMetaLastBuiltAt); err != nil {
+		return nil, fmt.Errorf("读取索引构建时间失败: %w", err)
 	}
 
-	return count > 0
+	return stats, nil
 }
 
-// getMovieDetailsBatchWithTitles 批量获取电影详情，使用SQLite中的标题。
-func (si *SearchIndex) getMovieDetailsBatchWithTitles(ctx context.Context, moviesWithTitles []MovieIdWithTitle) ([]Movie, error) {
-	var movies []Movie
-	var getReqs []*hrpc.Get
+// ftsTableSQL FTS表的建表语句。movie_id不建全文索引（UNINDEXED）；
+// unicode61分词器去掉变音符号，title_norm列保存NormalizeTitle的结果，使重音和中日韩标题可以按词查找。
+// 修改后旧索引的FTS表被视为过旧，启动时会重建。
+const ftsTableSQL = `CREATE VIRTUAL TABLE movie_fts USING fts5(movie_id UNINDEXED, title, title_norm, content='movie_index', content_rowid='id', tokenize='unicode61 remove_diacritics 2')`
 
-	// 映射，用于快速查找每个ID对应的标题
-	titleMap := make(map[string]string)
+// titleMatchQuery 构造同时匹配title和title_norm列的FTS5查询，build把文本转换为查询表达式。
+// 规范化后为空（如查询只有标点）时只匹配title列。
+func titleMatchQuery(query string, build func(text string) string) string {
+	match := "title : (" + build(query) + ")"
+	if normalized := utils.NormalizeTitle(query); normalized != "" {
+		match += " OR title_norm : (" + build(normalized) + ")"
+	}
+	return match
+}
 
-	for _, movie := range moviesWithTitles {
-		// 存储ID->标题的映射
-		titleMap[movie.ID] = movie.Title
+// ftsTableExists 检查FTS表是否已创建
+func ftsTableExists(q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}) (bool, error) {
+	var ftsTables int
+	err := q.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'movie_fts'").Scan(&ftsTables)
+	return ftsTables > 0, err
+}
 
-		// 只获取电影的stats（评分、统计信息）
-		statsGet, _ := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_stats", movie.ID))
-		// 以及其他可能需要的数据，如links等
-		linksGet, _ := hrpc.NewGetStr(ctx, "movies", fmt.Sprintf("%s_links", movie.ID))
+// UpsertMovie 新增或更新索引中的单部电影，同步维护FTS表，外部ID从_links行读取。
+// 索引尚未构建时不做任何操作；读取_links行失败时保留索引中原有的外部ID。
+func (si *SearchIndex) UpsertMovie(ctx context.Context, movieID, title string, genres []string) error {
+	// 在持有写锁前读取HBase，避免阻塞搜索
+	ids, linksErr := fetchExternalIDs(ctx, movieID)
+	if linksErr != nil {
+		logrus.Warnf("读取电影 %s 的_links行失败，保留索引中的外部ID: %v", movieID, linksErr)
+	}
 
-		getReqs = append(getReqs, statsGet, linksGet)
+	return si.applyWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		return upsertIndexedMovie(ctx, db, movieID, title, genres, ids, linksErr == nil)
+	})
+}
+
+// upsertIndexedMovie 在db中新增或更新电影，updateIDs为false时不修改已有条目的外部ID
+func upsertIndexedMovie(ctx context.Context, db *sql.DB, movieID, title string, genres []string, ids externalIDs, updateIDs bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
 	}
+	defer tx.Rollback()
 
-	// TODO: 可使用goroutine并发获取以提升性能
-	movieDataMap := make(map[string]map[string]map[string][]byte)
-	client := utils.GetClient().(gohbase.Client)
+	hasFTS, err := ftsTableExists(tx)
+	if err != nil {
+		return fmt.Errorf("检查FTS表失败: %w", err)
+	}
 
-	for _, req := range getReqs {
-		res, err := client.Get(req)
-		if err != nil || res == nil || len(res.Cells) == 0 {
-			continue
-		}
+	var year interface{}
+	if _, y, ok := utils.ExtractYearFromTitle(title); ok {
+		year = y
+	}
+	genresStr := strings.Join(genres, "|")
 
-		rowKey := string(res.Cells[0].Row)
-		parts := strings.Split(rowKey, "_")
-		movieID := parts[0]
-		rowType := parts[1]
+	titleNorm := utils.NormalizeTitle(title)
 
-		if _, ok := movieDataMap[movieID]; !ok {
-			movieDataMap[movieID] = make(map[string]map[string][]byte)
+	var rowID int64
+	var oldTitle, oldTitleNorm string
+	err = tx.QueryRowContext(ctx, "SELECT id, COALESCE(title, ''), COALESCE(title_norm, '') FROM movie_index WHERE movie_id = ?", movieID).Scan(&rowID, &oldTitle, &oldTitleNorm)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		res, err := tx.ExecContext(ctx, "INSERT INTO movie_index (movie_id, title, title_norm, genres, year, imdb_id, tmdb_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			movieID, title, titleNorm, genresStr, year, nullIfEmpty(ids.Imdb), nullIfEmpty(ids.Tmdb))
+		if err != nil {
+			return fmt.Errorf("写入索引失败: %w", err)
+		}
+		if rowID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("查询索引失败: %w", err)
+	default:
+		// 外部内容FTS表需要先用旧值删除，再插入新值
+		if hasFTS {
+			if _, err := tx.ExecContext(ctx, "INSERT INTO movie_fts(movie_fts, rowid, movie_id, title, title_norm) VALUES('delete', ?, ?, ?, ?)",
+				rowID, movieID, oldTitle, oldTitleNorm); err != nil {
+				return fmt.Errorf("删除旧FTS条目失败: %w", err)
+			}
 		}
-		if _, ok := movieDataMap[movieID][rowType]; !ok {
-			movieDataMap[movieID][rowType] = make(map[string][]byte)
+		if _, err := tx.ExecContext(ctx, "UPDATE movie_index SET title = ?, title_norm = ?, genres = ?, year = ? WHERE id = ?",
+			title, titleNorm, genresStr, year, rowID); err != nil {
+			return fmt.Errorf("更新索引失败: %w", err)
 		}
+		if updateIDs {
+			if _, err := tx.ExecContext(ctx, "UPDATE movie_index SET imdb_id = ?, tmdb_id = ? WHERE id = ?",
+				nullIfEmpty(ids.Imdb), nullIfEmpty(ids.Tmdb), rowID); err != nil {
+				return fmt.Errorf("更新外部ID失败: %w", err)
+			}
+		}
+	}
 
-		for _, cell := range res.Cells {
-			key := fmt.Sprintf("%s:%s", string(cell.Family), string(cell.Qualifier))
-			movieDataMap[movieID][rowType][key] = cell.Value
+	if hasFTS {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO movie_fts(rowid, movie_id, title, title_norm) VALUES(?, ?, ?, ?)",
+			rowID, movieID, title, titleNorm); err != nil {
+			return fmt.Errorf("写入FTS条目失败: %w", err)
 		}
 	}
+	if err := replaceTitleTrigrams(ctx, tx, movieID, title); err != nil {
+		return err
+	}
 
-	// 为每个找到的movieID构建完整的Movie对象
-	for _, movieWithTitle := range moviesWithTitles {
-		movieID := movieWithTitle.ID
+	return tx.Commit()
+}
+
+// DeleteMovie 从索引中删除单部电影及其基因向量、标签。索引尚未构建时不做任何操作。
+func (si *SearchIndex) DeleteMovie(ctx context.Context, movieID string) error {
+	return si.applyWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		return deleteIndexedMovie(ctx, db, movieID)
+	})
+}
 
-		// 获取从SQLite中读取的标题
-		title := movieWithTitle.Title
+// deleteIndexedMovie 从db中删除电影及其基因向量、标签
+func deleteIndexedMovie(ctx context.Context, db *sql.DB, movieID string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
 
-		// 创建基本的Movie对象
-		movie := Movie{
-			MovieID: movieID,
-			Title:   title, // 直接设置标题
+	var rowID int64
+	var title, titleNorm string
+	err = tx.QueryRowContext(ctx, "SELECT id, COALESCE(title, ''), COALESCE(title_norm, '') FROM movie_index WHERE movie_id = ?", movieID).Scan(&rowID, &title, &titleNorm)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("查询索引失败: %w", err)
+	}
+
+	hasFTS, err := ftsTableExists(tx)
+	if err != nil {
+		return fmt.Errorf("检查FTS表失败: %w", err)
+	}
+	if hasFTS {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO movie_fts(movie_fts, rowid, movie_id, title, title_norm) VALUES('delete', ?, ?, ?, ?)",
+			rowID, movieID, title, titleNorm); err != nil {
+			return fmt.Errorf("删除FTS条目失败: %w", err)
 		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_index WHERE id = ?", rowID); err != nil {
+		return fmt.Errorf("删除索引条目失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_genome_topk WHERE movie_id = ?", movieID); err != nil {
+		return fmt.Errorf("删除基因向量失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_tags WHERE movie_id = ?", movieID); err != nil {
+		return fmt.Errorf("删除标签失败: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM movie_trigrams WHERE movie_id = ?", movieID); err != nil {
+		return fmt.Errorf("删除标题三元组失败: %w", err)
+	}
 
-		// 如果有HBase数据，填充其他详情
-		if data, ok := movieDataMap[movieID]; ok {
-			// 从HBase解析数据
-			parsedData := utils.ParseMovieData(movieID, data)
+	return tx.Commit()
+}
 
-			// 使用buildMovieFromData填充其他字段
-			fullMovie := buildMovieFromData(movieID, parsedData, data)
+// AddTag 为索引中的电影的标签计数加一。索引尚未构建或没有标签数据时不做任何操作。
+func (si *SearchIndex) AddTag(ctx context.Context, movieID, tag string) error {
+	return si.applyWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		if indexed, err := utils.ReadIndexMeta(db, indexMetaTagsIndexed); err != nil || indexed != "true" {
+			return err
+		}
+		_, err := db.ExecContext(ctx, `INSERT INTO movie_tags (movie_id, tag, count) VALUES (?, ?, 1)
+			ON CONFLICT(movie_id, tag) DO UPDATE SET count = count + 1`, movieID, strings.ToLower(tag))
+		if err != nil {
+			return fmt.Errorf("更新标签计数失败: %w", err)
+		}
+		return nil
+	})
+}
 
-			// 复制所有非空字段，但保留我们已经设置的标题
-			if fullMovie.Genres != nil {
-				movie.Genres = fullMovie.Genres
-			}
-			if fullMovie.AvgRating != 0 {
-				movie.AvgRating = fullMovie.AvgRating
-			}
-			if fullMovie.Links.ImdbID != "" {
-				movie.Links = fullMovie.Links
+// indexedStats 索引中保存的评分统计
+type indexedStats struct {
+	AvgRating   float64
+	RatingCount int
+}
+
+// parseIndexedStats 从_stats行的info:avg_rating和info:rating_count解析评分统计
+func parseIndexedStats(cells []*hrpc.Cell) (indexedStats, bool) {
+	var stats indexedStats
+	found := false
+	for _, cell := range cells {
+		if string(cell.Family) != "info" {
+			continue
+		}
+		switch string(cell.Qualifier) {
+		case "avg_rating":
+			if v, err := strconv.ParseFloat(string(cell.Value), 64); err == nil {
+				stats.AvgRating = v
+				found = true
 			}
-			if fullMovie.Tags != nil {
-				movie.Tags = fullMovie.Tags
+		case "rating_count":
+			if v, err := strconv.Atoi(string(cell.Value)); err == nil {
+				stats.RatingCount = v
+				found = true
 			}
 		}
+	}
+	return stats, found
+}
 
-		// 如果平均分为0，尝试计算它
-		if movie.AvgRating == 0.0 {
-			avgRating, ratingCount, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
-			if err == nil && avgRating > 0.0 {
-				movie.AvgRating = avgRating
-				logrus.Infof("✅ 成功计算并存储电影 %s 的平均评分: %.2f (基于 %d 个评分)",
-					movieID, avgRating, ratingCount)
-			}
+// UpdateMovieStats 更新索引中电影的评分统计。索引尚未构建时不做任何操作。
+func (si *SearchIndex) UpdateMovieStats(ctx context.Context, movieID string, avgRating float64, ratingCount int) error {
+	return si.applyWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		if _, err := db.ExecContext(ctx, "UPDATE movie_index SET avg_rating = ?, rating_count = ? WHERE movie_id = ?",
+			avgRating, ratingCount, movieID); err != nil {
+			return fmt.Errorf("更新索引评分统计失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// getMovieDetailsBatchWithTitles 批量获取电影详情，使用SQLite中的标题和类型。
+// 其他字段与电影列表一样由fillMovieListDetails并发、按列投影读取，只读取fields所需的行；
+// tags只在fields中明确选择时返回（未指定fields时保持不返回标签，tagCount照常返回）。
+// 调用方不能持有读锁：类型只在读取索引期间短暂加锁，HBase读取在锁外进行。
+func (si *SearchIndex) getMovieDetailsBatchWithTitles(ctx context.Context, moviesWithTitles []MovieIdWithTitle, fields MovieFields) ([]Movie, error) {
+	movieIDs := make([]string, 0, len(moviesWithTitles))
+	for _, movie := range moviesWithTitles {
+		movieIDs = append(movieIDs, movie.ID)
+	}
+
+	var genresMap map[string][]string
+	if fields.Has("genres") {
+		var err error
+		if genresMap, err = si.lookupIndexedGenres(ctx, movieIDs); err != nil {
+			return nil, err
 		}
+	}
 
+	// 直接使用索引中的标题和类型
+	movies := make([]Movie, 0, len(moviesWithTitles))
+	for _, movieWithTitle := range moviesWithTitles {
+		movie := Movie{MovieID: movieWithTitle.ID, Genres: genresMap[movieWithTitle.ID]}
+		movie.setTitle(movieWithTitle.Title)
 		movies = append(movies, movie)
 	}
 
+	fillMovieListDetails(ctx, movies, fields, "索引搜索")
+	if !fields["tags"] {
+		for i := range movies {
+			movies[i].Tags = nil
+		}
+	}
+
 	return movies, nil
 }
 
+// lookupIndexedGenres 在读锁下从当前索引读取电影的类型
+func (si *SearchIndex) lookupIndexedGenres(ctx context.Context, movieIDs []string) (map[string][]string, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return indexedGenres(ctx, db, movieIDs)
+}
+
+// indexedGenres 从索引读取电影的类型，调用方持有读锁
+func indexedGenres(ctx context.Context, db *sql.DB, movieIDs []string) (map[string][]string, error) {
+	genres := make(map[string][]string, len(movieIDs))
+	if len(movieIDs) == 0 {
+		return genres, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(movieIDs)), ",")
+	args := make([]interface{}, len(movieIDs))
+	for i, movieID := range movieIDs {
+		args[i] = movieID
+	}
+	rows, err := db.QueryContext(ctx, "SELECT movie_id, genres FROM movie_index WHERE movie_id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("从索引读取电影类型失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var movieID string
+		var genresStr sql.NullString
+		if err := rows.Scan(&movieID, &genresStr); err != nil {
+			return nil, err
+		}
+		if genresStr.String != "" {
+			genres[movieID] = strings.Split(genresStr.String, "|")
+		}
+	}
+	return genres, rows.Err()
+}
+
 func indexOf(slice []string, item string) int {
 	for i, v := range slice {
 		if v == item {
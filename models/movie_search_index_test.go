@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/hbasetest"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// indexFixture 生成n部标题都包含"Movie"的电影
+func indexFixture(n int) []hbasetest.Movie {
+	movies := make([]hbasetest.Movie, n)
+	for i := range movies {
+		movies[i] = hbasetest.Movie{
+			ID:     strconv.Itoa(i + 1),
+			Title:  fmt.Sprintf("Movie %d (%d)", i+1, 1950+i%70),
+			Genres: "Drama|Comedy",
+			Stats:  map[string]string{"avg_rating": "3.5", "rating_count": "10"},
+		}
+	}
+	return movies
+}
+
+// TestSearchDuringRebuild 重建索引期间并发搜索不出错，也不会读到构建中的索引
+func TestSearchDuringRebuild(t *testing.T) {
+	const movieCount = 100
+	newTestIndex(t, indexFixture(movieCount))
+	si := GetSearchIndex()
+	ctx := context.Background()
+
+	var (
+		wg       sync.WaitGroup
+		stop     atomic.Bool
+		searches atomic.Int64
+		errMu    sync.Mutex
+		errs     []error
+	)
+	report := func(err error) {
+		errMu.Lock()
+		errs = append(errs, err)
+		errMu.Unlock()
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				if !si.IsIndexReady() {
+					report(fmt.Errorf("重建期间索引未就绪"))
+					continue
+				}
+				result, err := si.SearchMoviesWithIndex(ctx, "movie", SearchTypeTitle, RankRelevance, 1, 20, MovieFields{"movieId": true})
+				if err != nil {
+					report(err)
+					continue
+				}
+				if result.TotalMovies != movieCount {
+					report(fmt.Errorf("搜索到%d部电影, want %d", result.TotalMovies, movieCount))
+				}
+				if _, err := si.FindSimilarMovies(ctx, "1", 5); err != nil {
+					report(err)
+				}
+				searches.Add(1)
+			}
+		}()
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := si.BuildSearchIndex(ctx); err != nil {
+			t.Errorf("第%d次重建失败: %v", i+1, err)
+		}
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	for _, err := range errs {
+		t.Error(err)
+	}
+	if searches.Load() == 0 {
+		t.Error("重建期间没有完成任何搜索")
+	}
+}
+
+// TestRebuildReplaysWritesDuringBuild 构建期间的增量更新在切换前重放到新索引
+func TestRebuildReplaysWritesDuringBuild(t *testing.T) {
+	client := newTestIndex(t, indexFixture(50))
+	client.Latency = time.Millisecond // 每行1ms，构建过程持续足够长
+	si := GetSearchIndex()
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() { done <- si.BuildSearchIndex(ctx) }()
+	for !si.building.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 只写入索引、HBase中没有的电影，重建后仍在索引中说明已重放
+	if err := si.UpsertMovie(ctx, "9001", "Written During Rebuild (2024)", []string{"Drama"}); err != nil {
+		t.Fatalf("UpsertMovie失败: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("重建失败: %v", err)
+	}
+
+	result, err := si.SearchMoviesWithIndex(ctx, "written during rebuild", SearchTypeTitle, RankRelevance, 1, 10, MovieFields{"movieId": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Movies) != 1 || result.Movies[0].MovieID != "9001" {
+		t.Errorf("重建后搜索结果 = %+v, want 电影9001", result.Movies)
+	}
+}
+
+// TestSearchAccentedAndCJKTitles 不带重音的查询能找到带重音的标题，中日韩标题可以按其中的字词查找
+func TestSearchAccentedAndCJKTitles(t *testing.T) {
+	newTestIndex(t, []hbasetest.Movie{
+		{ID: "1", Title: "Amélie (Fabuleux destin d'Amélie Poulain, Le) (2001)", Genres: "Comedy|Romance"},
+		{ID: "2", Title: "Crème Brûlée (2005)", Genres: "Drama"},
+		{ID: "3", Title: "千与千寻 (2001)", Genres: "Animation"},
+		{ID: "4", Title: "Spirited Away (Sen to Chihiro no kamikakushi) 千と千尋の神隠し (2001)", Genres: "Animation"},
+		{ID: "5", Title: "기생충 (2019)", Genres: "Thriller"},
+		{ID: "6", Title: "Ｔｏｋｙｏ Ｓｔｏｒｙ (1953)", Genres: "Drama"},
+		{ID: "7", Title: "Amelia (2009)", Genres: "Drama"},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		query   string
+		wantIDs string
+	}{
+		{"amelie", "[1]"},
+		{"Amélie", "[1]"},
+		{"AMELIE poulain", "[1]"},
+		{"creme brulee", "[2]"},
+		{"brûlée", "[2]"},
+		{"千寻", "[3]"},
+		{"千", "[3 4]"},
+		{"神隠し", "[4]"},
+		{"기생충", "[5]"},
+		{"tokyo story", "[6]"},
+		{"ｔｏｋｙｏ", "[6]"},
+		{"ameli", "[1 7]"},
+	}
+	for _, tt := range tests {
+		result, err := GetSearchIndex().SearchMoviesWithIndex(ctx, tt.query, SearchTypeTitle, RankRelevance, 1, 20, MovieFields{"movieId": true})
+		if err != nil {
+			t.Fatalf("搜索%q失败: %v", tt.query, err)
+		}
+		ids := make([]string, 0, len(result.Movies))
+		for _, movie := range result.Movies {
+			ids = append(ids, movie.MovieID)
+		}
+		sort.Strings(ids)
+		if got := fmt.Sprint(ids); got != tt.wantIDs {
+			t.Errorf("搜索%q = %s, want %s", tt.query, got, tt.wantIDs)
+		}
+	}
+}
+
+// TestSearchRankModes 评分低、评分人数少但标题最匹配的电影只在relevance模式下排在最前
+func TestSearchRankModes(t *testing.T) {
+	newTestIndex(t, []hbasetest.Movie{
+		{ID: "2", Title: "Alien: Resurrection, The Director's Extended Special Edition (1997)", Genres: "Horror|Sci-Fi",
+			Stats: map[string]string{"avg_rating": "4.6", "rating_count": "500"}},
+		{ID: "3", Title: "Alien Nation: The Long Forgotten Ultimate Collector's Cut (1988)", Genres: "Horror|Sci-Fi",
+			Stats: map[string]string{"avg_rating": "3.0", "rating_count": "20000"}},
+		{ID: "9", Title: "Alien (1979)", Genres: "Horror|Sci-Fi",
+			Stats: map[string]string{"avg_rating": "2.0", "rating_count": "3"}},
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		searchType string
+		query      string
+		rank       string
+		wantIDs    string
+	}{
+		// 标题最短、最匹配的电影9按bm25排在最前
+		{SearchTypeTitle, "alien", RankRelevance, "[9 2 3]"},
+		{SearchTypeTitle, "alien", RankPopularity, "[3 2 9]"},
+		{SearchTypeTitle, "alien", RankRating, "[2 3 9]"},
+		// 类型匹配没有相关度，relevance按索引顺序
+		{SearchTypeGenre, "horror", RankRelevance, "[2 3 9]"},
+		{SearchTypeGenre, "horror", RankPopularity, "[3 2 9]"},
+		{SearchTypeGenre, "horror", RankRating, "[2 3 9]"},
+	}
+	for _, tt := range tests {
+		result, err := GetSearchIndex().SearchMoviesWithIndex(ctx, tt.query, tt.searchType, tt.rank, 1, 20, MovieFields{"movieId": true})
+		if err != nil {
+			t.Fatalf("search_type=%s rank=%s: 搜索失败: %v", tt.searchType, tt.rank, err)
+		}
+		ids := make([]string, 0, len(result.Movies))
+		for _, movie := range result.Movies {
+			ids = append(ids, movie.MovieID)
+		}
+		if got := fmt.Sprint(ids); got != tt.wantIDs {
+			t.Errorf("search_type=%s rank=%s: 结果 = %s, want %s", tt.searchType, tt.rank, got, tt.wantIDs)
+		}
+	}
+}
+
+// TestIndexHealthCached 就绪检查的结果被缓存，绕过SearchIndex直接修改索引不会被发现；
+// 增量更新和重建切换后重新检查
+func TestIndexHealthCached(t *testing.T) {
+	newTestIndex(t, indexFixture(5))
+	si := GetSearchIndex()
+	ctx := context.Background()
+
+	if !si.IsIndexReady() {
+		t.Fatal("构建后索引未就绪")
+	}
+
+	// 只删除movie_index中的行，FTS表行数不变，实际已不一致
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM movie_index WHERE movie_id = '5'")
+	release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !si.IsIndexReady() {
+		t.Error("就绪检查没有使用缓存的结果")
+	}
+
+	if err := si.SetMovieAddedTime(ctx, "1", 1000); err != nil {
+		t.Fatalf("增量更新失败: %v", err)
+	}
+	if si.IsIndexReady() {
+		t.Error("增量更新后没有重新检查，仍报告不一致的索引已就绪")
+	}
+
+	if err := si.BuildSearchIndex(ctx); err != nil {
+		t.Fatalf("重建索引失败: %v", err)
+	}
+	if !si.IsIndexReady() {
+		t.Error("重建切换后索引未就绪")
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// TestStatsOnlyMovieAverage 只有_stats行（没有逐用户评分）的电影，ID搜索和索引搜索都返回存储的平均分
+func TestStatsOnlyMovieAverage(t *testing.T) {
+	newTestIndex(t, []hbasetest.Movie{
+		{ID: "42", Title: "Stats Only (2004)", Genres: "Drama", Stats: map[string]string{"avg_rating": "4.25", "rating_count": "12"}},
+		{ID: "43", Title: "Unrated (2005)", Genres: "Drama"},
+	})
+	ctx := context.Background()
+
+	movies, err := searchByMovieID(ctx, 42)
+	if err != nil {
+		t.Fatalf("ID搜索失败: %v", err)
+	}
+	if len(movies) != 1 || movies[0].AvgRating != 4.25 {
+		t.Errorf("ID搜索结果 = %+v, want avgRating 4.25", movies)
+	}
+
+	result, err := GetSearchIndex().SearchMoviesWithIndex(ctx, "stats only", SearchTypeTitle, RankRelevance, 1, 10, nil)
+	if err != nil {
+		t.Fatalf("索引搜索失败: %v", err)
+	}
+	if len(result.Movies) != 1 || result.Movies[0].MovieID != "42" || result.Movies[0].AvgRating != 4.25 {
+		t.Errorf("索引搜索结果 = %+v, want 电影42 avgRating 4.25", result.Movies)
+	}
+
+	// 只选择avgRating字段时同样读取stats行
+	result, err = GetSearchIndex().SearchMoviesWithIndex(ctx, "stats only", SearchTypeTitle, RankRelevance, 1, 10, MovieFields{"avgRating": true})
+	if err != nil {
+		t.Fatalf("索引搜索失败: %v", err)
+	}
+	if len(result.Movies) != 1 || result.Movies[0].AvgRating != 4.25 {
+		t.Errorf("按字段索引搜索结果 = %+v, want avgRating 4.25", result.Movies)
+	}
+}
+
+// TestScanErrorsReachSearchCallers 扫描中途失败时搜索和索引重建返回错误，重建失败时保留当前索引
+func TestScanErrorsReachSearchCallers(t *testing.T) {
+	movies := []hbasetest.Movie{
+		{ID: "1", Title: "Alpha (2001)", Genres: "Drama", Stats: map[string]string{"avg_rating": "4.0", "rating_count": "3"}},
+		{ID: "2", Title: "Beta (2002)", Genres: "Drama"},
+		{ID: "3", Title: "Gamma (2003)", Genres: "Drama"},
+	}
+	client := newTestIndex(t, movies)
+	errRegionDown := errors.New("region server不可用")
+	client.ScanErr = errRegionDown
+	client.ScanErrAfter = 1
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"searchByMovieID", func() error { _, err := searchByMovieID(ctx, 1); return err }},
+		{"searchByTextOptimized", func() error { _, err := searchByTextOptimized(ctx, "gamma", SearchTypeAll, 100, 100); return err }},
+		{"BuildSearchIndex", func() error { return GetSearchIndex().BuildSearchIndex(ctx) }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, errRegionDown) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, errRegionDown)
+		}
+	}
+
+	if count, err := GetSearchIndex().IndexedCount(); err != nil || count != len(movies) {
+		t.Errorf("重建失败后索引电影数 = %d, %v, want %d", count, err, len(movies))
+	}
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// TestMovieResponseShape 列表、搜索（索引和HBase回退）和详情返回的电影都包含评分数、平均分、标签数和外部链接
+func TestMovieResponseShape(t *testing.T) {
+	newTestIndex(t, []hbasetest.Movie{{
+		ID:      "7",
+		Title:   "Shape Test (1999)",
+		Genres:  "Drama",
+		Stats:   map[string]string{"avg_rating": "4.5", "rating_count": "2"},
+		Links:   map[string]string{"imdbId": "0113277", "tmdbId": "949"},
+		Ratings: map[string]string{"1": "4.0:1:1000", "2": "5.0:2:1000"},
+		Tags:    map[string]string{"1_1000": "funny:1:1000", "2_1000": "dark:2:1000", "3_1000": "funny:3:1000"},
+	}})
+	ctx := context.Background()
+
+	firstMovie := func(list *MovieList, err error) (Movie, error) {
+		if err != nil || len(list.Movies) != 1 {
+			return Movie{}, err
+		}
+		return list.Movies[0], nil
+	}
+	firstOf := func(movies []Movie, err error) (Movie, error) {
+		if err != nil || len(movies) != 1 {
+			return Movie{}, err
+		}
+		return movies[0], nil
+	}
+
+	tests := []struct {
+		name string
+		get  func() (Movie, error)
+	}{
+		{"列表", func() (Movie, error) { return firstMovie(GetMoviesList(1, 10, nil)) }},
+		{"游标列表", func() (Movie, error) { return firstMovie(GetMoviesListAfter("", 10, nil)) }},
+		{"索引搜索", func() (Movie, error) {
+			return firstMovie(GetSearchIndex().SearchMoviesWithIndex(ctx, "shape", SearchTypeTitle, RankRelevance, 1, 10, nil))
+		}},
+		{"回退文本搜索", func() (Movie, error) { return firstOf(searchByTextOptimized(ctx, "shape", SearchTypeTitle, 100, 10)) }},
+		{"回退ID搜索", func() (Movie, error) { return firstOf(searchByMovieID(ctx, 7)) }},
+		{"详情", func() (Movie, error) {
+			detail, err := GetMovieByID("7")
+			if err != nil || detail == nil {
+				return Movie{}, err
+			}
+			return detail.Movie, nil
+		}},
+	}
+
+	for _, tt := range tests {
+		movie, err := tt.get()
+		if err != nil {
+			t.Fatalf("%s: 获取失败: %v", tt.name, err)
+		}
+		if movie.MovieID != "7" {
+			t.Errorf("%s: 没有返回电影7: %+v", tt.name, movie)
+			continue
+		}
+
+		data, err := json.Marshal(movie)
+		if err != nil {
+			t.Fatalf("%s: 序列化失败: %v", tt.name, err)
+		}
+		var shape struct {
+			AvgRating   *float64          `json:"avgRating"`
+			RatingCount *int              `json:"ratingCount"`
+			TagCount    *int              `json:"tagCount"`
+			Links       map[string]string `json:"links"`
+		}
+		if err := json.Unmarshal(data, &shape); err != nil {
+			t.Fatalf("%s: 解析失败: %v", tt.name, err)
+		}
+
+		if shape.AvgRating == nil || *shape.AvgRating != 4.5 {
+			t.Errorf("%s: avgRating = %v, want 4.5 (%s)", tt.name, shape.AvgRating, data)
+		}
+		if shape.RatingCount == nil || *shape.RatingCount != 2 {
+			t.Errorf("%s: ratingCount = %v, want 2 (%s)", tt.name, shape.RatingCount, data)
+		}
+		if shape.TagCount == nil || *shape.TagCount != 2 {
+			t.Errorf("%s: tagCount = %v, want 2 (%s)", tt.name, shape.TagCount, data)
+		}
+		wantLinks := map[string]string{
+			"imdbId":  "0113277",
+			"imdbUrl": "https://www.imdb.com/title/tt0113277/",
+			"tmdbId":  "949",
+			"tmdbUrl": "https://www.themoviedb.org/movie/949",
+		}
+		for key, want := range wantLinks {
+			if shape.Links[key] != want {
+				t.Errorf("%s: links.%s = %q, want %q", tt.name, key, shape.Links[key], want)
+			}
+		}
+	}
+}
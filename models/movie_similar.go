@@ -0,0 +1,342 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// genomeTopK 每部电影在索引中保留的基因标签数量
+const genomeTopK = 20
+
+// ErrNoGenomeData 电影没有基因数据
+var ErrNoGenomeData = errors.New("no_genome_data")
+
+// SimilarMovie 相似电影
+type SimilarMovie struct {
+	MovieID string  `json:"movieId"`
+	Title   string  `json:"title"`
+	Score   float64 `json:"score"`
+	Method  string  `json:"method"` // "genome" 或 "genre"
+}
+
+// GenomeTag 基因标签及相关度
+type GenomeTag struct {
+	TagID     string  `json:"tagId"`
+	Relevance float64 `json:"relevance"`
+}
+
+// topKGenomeTags 从_genome行的单元格中选出相关度最高的k个标签
+func topKGenomeTags(cells []*hrpc.Cell, k int) []GenomeTag {
+	tags := make([]GenomeTag, 0, len(cells))
+	for _, cell := range cells {
+		if string(cell.Family) != "genome" {
+			continue
+		}
+		relevance, err := strconv.ParseFloat(string(cell.Value), 64)
+		if err != nil || relevance <= 0 {
+			continue
+		}
+		tags = append(tags, GenomeTag{TagID: string(cell.Qualifier), Relevance: relevance})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Relevance != tags[j].Relevance {
+			return tags[i].Relevance > tags[j].Relevance
+		}
+		return tags[i].TagID < tags[j].TagID
+	})
+
+	if len(tags) > k {
+		tags = tags[:k]
+	}
+	return tags
+}
+
+// GetSimilarMovies 获取与指定电影最相似的电影（带缓存）
+func GetSimilarMovies(movieID string, limit int) ([]SimilarMovie, error) {
+	cacheKey := fmt.Sprintf("similar_movies:%s:%d", movieID, limit)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if similar, ok := cached.([]SimilarMovie); ok {
+			return similar, nil
+		}
+	}
+
+	similar, err := GetSearchIndex().FindSimilarMovies(context.Background(), movieID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.Cache.Set(cacheKey, similar)
+	return similar, nil
+}
+
+// GenomeCosineSimilarity 基于完整基因向量计算两部电影的余弦相似度（带缓存）。
+// 任一电影没有基因数据时返回-1和ErrNoGenomeData。
+func GenomeCosineSimilarity(ctx context.Context, movieID1, movieID2 string) (float64, error) {
+	ids := []string{movieID1, movieID2}
+	sort.Strings(ids)
+	cacheKey := fmt.Sprintf("genome_sim:%s:%s", ids[0], ids[1])
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if similarity, ok := cached.(float64); ok {
+			return similarity, nil
+		}
+	}
+
+	vector1, err := loadFullGenomeVector(ctx, movieID1)
+	if err != nil {
+		return -1, err
+	}
+	vector2, err := loadFullGenomeVector(ctx, movieID2)
+	if err != nil {
+		return -1, err
+	}
+	if len(vector1) == 0 || len(vector2) == 0 {
+		return -1, ErrNoGenomeData
+	}
+
+	similarity := cosineSimilarity(vector1, vector2)
+	utils.Cache.Set(cacheKey, similarity)
+	return similarity, nil
+}
+
+// loadFullGenomeVector 从HBase读取电影_genome行的完整基因向量
+func loadFullGenomeVector(ctx context.Context, movieID string) (map[string]float64, error) {
+	get, err := hrpc.NewGetStr(ctx, utils.MoviesTable(), rowkey.MovieGenomeKey(movieID),
+		hrpc.Families(map[string][]string{"genome": nil}))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := utils.GetClient().(interface {
+		Get(request *hrpc.Get) (*hrpc.Result, error)
+	}).Get(get)
+	if err != nil {
+		return nil, fmt.Errorf("读取电影 %s 基因数据失败: %w", movieID, err)
+	}
+
+	vector := make(map[string]float64, len(result.Cells))
+	for _, cell := range result.Cells {
+		relevance, err := strconv.ParseFloat(string(cell.Value), 64)
+		if err != nil || relevance <= 0 {
+			continue
+		}
+		vector[string(cell.Qualifier)] = relevance
+	}
+	return vector, nil
+}
+
+// FindSimilarMovies 基于索引中的Top-K基因向量计算余弦相似度，
+// 没有基因数据的电影回退到类型重合度。
+func (si *SearchIndex) FindSimilarMovies(ctx context.Context, movieID string, limit int) ([]SimilarMovie, error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, fmt.Errorf("搜索索引未就绪")
+	}
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// 读取目标电影的Top-K向量
+	target, err := loadGenomeVector(ctx, db, movieID)
+	if err != nil {
+		return nil, err
+	}
+	if len(target) == 0 {
+		return findSimilarByGenre(ctx, db, movieID, limit)
+	}
+
+	// 候选集：与目标电影至少共享一个Top-K标签的电影
+	tagIDs := make([]interface{}, 0, len(target))
+	placeholders := make([]string, 0, len(target))
+	for tagID := range target {
+		tagIDs = append(tagIDs, tagID)
+		placeholders = append(placeholders, "?")
+	}
+	args := append(tagIDs, movieID)
+
+	query := fmt.Sprintf(`SELECT movie_id, tag_id, relevance FROM movie_genome_topk
+        WHERE movie_id IN (SELECT DISTINCT movie_id FROM movie_genome_topk WHERE tag_id IN (%s))
+        AND movie_id != ?`, strings.Join(placeholders, ","))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询候选基因向量失败: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := make(map[string]map[string]float64)
+	for rows.Next() {
+		var id, tagID string
+		var relevance float64
+		if err := rows.Scan(&id, &tagID, &relevance); err != nil {
+			return nil, err
+		}
+		if candidates[id] == nil {
+			candidates[id] = make(map[string]float64)
+		}
+		candidates[id][tagID] = relevance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var similar []SimilarMovie
+	for id, vector := range candidates {
+		similar = append(similar, SimilarMovie{
+			MovieID: id,
+			Score:   cosineSimilarity(target, vector),
+			Method:  "genome",
+		})
+	}
+
+	similar = rankSimilarMovies(similar, limit)
+	fillSimilarTitles(ctx, db, similar)
+	return similar, nil
+}
+
+// findSimilarByGenre 按类型重合度（Jaccard系数）查找相似电影
+func findSimilarByGenre(ctx context.Context, db *sql.DB, movieID string, limit int) ([]SimilarMovie, error) {
+	var targetGenres string
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(genres, '') FROM movie_index WHERE movie_id = ?", movieID).Scan(&targetGenres)
+	if err != nil {
+		return []SimilarMovie{}, nil
+	}
+
+	target := genreSet(targetGenres)
+	if len(target) == 0 {
+		return []SimilarMovie{}, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT movie_id, title, COALESCE(genres, '') FROM movie_index WHERE movie_id != ?", movieID)
+	if err != nil {
+		return nil, fmt.Errorf("查询候选电影失败: %w", err)
+	}
+	defer rows.Close()
+
+	var similar []SimilarMovie
+	for rows.Next() {
+		var id, title, genres string
+		if err := rows.Scan(&id, &title, &genres); err != nil {
+			return nil, err
+		}
+
+		candidate := genreSet(genres)
+		var shared int
+		for genre := range candidate {
+			if target[genre] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+
+		union := len(target) + len(candidate) - shared
+		similar = append(similar, SimilarMovie{
+			MovieID: id,
+			Title:   title,
+			Score:   float64(shared) / float64(union),
+			Method:  "genre",
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rankSimilarMovies(similar, limit), nil
+}
+
+// loadGenomeVector 从索引读取电影的Top-K基因向量
+func loadGenomeVector(ctx context.Context, db *sql.DB, movieID string) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT tag_id, relevance FROM movie_genome_topk WHERE movie_id = ?", movieID)
+	if err != nil {
+		return nil, fmt.Errorf("查询基因向量失败: %w", err)
+	}
+	defer rows.Close()
+
+	vector := make(map[string]float64)
+	for rows.Next() {
+		var tagID string
+		var relevance float64
+		if err := rows.Scan(&tagID, &relevance); err != nil {
+			return nil, err
+		}
+		vector[tagID] = relevance
+	}
+	return vector, rows.Err()
+}
+
+// fillSimilarTitles 从索引中补充相似电影的标题
+func fillSimilarTitles(ctx context.Context, db *sql.DB, similar []SimilarMovie) {
+	for i := range similar {
+		var title string
+		err := db.QueryRowContext(ctx, "SELECT title FROM movie_index WHERE movie_id = ?", similar[i].MovieID).Scan(&title)
+		if err == nil {
+			similar[i].Title = title
+		}
+	}
+}
+
+// cosineSimilarity 计算两个稀疏向量的余弦相似度
+func cosineSimilarity(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for tagID, va := range a {
+		normA += va * va
+		if vb, ok := b[tagID]; ok {
+			dot += va * vb
+		}
+	}
+	for _, vb := range b {
+		normB += vb * vb
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// rankSimilarMovies 按相似度降序排序并截取前limit个
+func rankSimilarMovies(similar []SimilarMovie, limit int) []SimilarMovie {
+	sort.Slice(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].MovieID < similar[j].MovieID
+	})
+
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+	if similar == nil {
+		similar = []SimilarMovie{}
+	}
+	return similar
+}
+
+// genreSet 将"A|B|C"格式的类型字符串转换为小写集合
+func genreSet(genres string) map[string]bool {
+	set := make(map[string]bool)
+	for _, genre := range strings.Split(genres, "|") {
+		genre = strings.ToLower(strings.TrimSpace(genre))
+		if genre != "" && genre != "(no genres listed)" {
+			set[genre] = true
+		}
+	}
+	return set
+}
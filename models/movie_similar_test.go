@@ -0,0 +1,122 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"math"
+	"testing"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// similarFixture 基因数据：电影2与电影1几乎相同，电影3只共享一个高相关度标签，
+// 电影4与电影1没有共同标签；电影5-8没有基因数据，只能按类型比较
+var similarFixture = []hbasetest.Movie{
+	{ID: "1", Title: "Target (1999)", Genres: "Sci-Fi", Genome: map[string]string{"1": "0.9", "2": "0.8", "3": "0.1"}},
+	{ID: "2", Title: "Twin (2000)", Genres: "Sci-Fi", Genome: map[string]string{"1": "0.85", "2": "0.75", "3": "0.15"}},
+	{ID: "3", Title: "Cousin (2001)", Genres: "Sci-Fi", Genome: map[string]string{"1": "0.9", "4": "0.9"}},
+	{ID: "4", Title: "Stranger (2002)", Genres: "Sci-Fi", Genome: map[string]string{"4": "1.0"}},
+	{ID: "5", Title: "Plain (1990)", Genres: "Comedy|Drama"},
+	{ID: "6", Title: "Same Genres (1991)", Genres: "Drama|Comedy"},
+	{ID: "7", Title: "Half Genres (1992)", Genres: "Comedy|Romance"},
+	{ID: "8", Title: "Other Genres (1993)", Genres: "Horror"},
+}
+
+func TestFindSimilarMoviesRanking(t *testing.T) {
+	newTestIndex(t, similarFixture)
+	ctx := context.Background()
+
+	tests := []struct {
+		movieID    string
+		limit      int
+		wantIDs    string
+		wantMethod string
+	}{
+		{"1", 10, "[2 3]", "genome"},
+		{"1", 1, "[2]", "genome"},
+		{"4", 10, "[3]", "genome"},
+		{"5", 10, "[6 7]", "genre"},
+		{"8", 10, "[]", ""},
+		{"999", 10, "[]", ""},
+	}
+	for _, tt := range tests {
+		similar, err := GetSearchIndex().FindSimilarMovies(ctx, tt.movieID, tt.limit)
+		if err != nil {
+			t.Fatalf("FindSimilarMovies(%s) 失败: %v", tt.movieID, err)
+		}
+		ids := make([]string, 0, len(similar))
+		for i, movie := range similar {
+			ids = append(ids, movie.MovieID)
+			if movie.Method != tt.wantMethod {
+				t.Errorf("FindSimilarMovies(%s)[%d].Method = %q, want %q", tt.movieID, i, movie.Method, tt.wantMethod)
+			}
+			if movie.Title == "" {
+				t.Errorf("FindSimilarMovies(%s)[%d] 缺少标题", tt.movieID, i)
+			}
+			if i > 0 && movie.Score > similar[i-1].Score {
+				t.Errorf("FindSimilarMovies(%s) 未按相似度降序: %v", tt.movieID, similar)
+			}
+		}
+		if got := fmt.Sprint(ids); got != tt.wantIDs {
+			t.Errorf("FindSimilarMovies(%s, %d) = %s, want %s", tt.movieID, tt.limit, got, tt.wantIDs)
+		}
+	}
+
+	// 类型完全相同的Jaccard系数为1，共享一半为1/3
+	similar, err := GetSearchIndex().FindSimilarMovies(ctx, "5", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if similar[0].Score != 1 || math.Abs(similar[1].Score-1.0/3) > 1e-9 {
+		t.Errorf("类型相似度 = %v, want [1 0.333]", similar)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]float64
+		want float64
+	}{
+		{"相同向量", map[string]float64{"1": 0.5, "2": 0.5}, map[string]float64{"1": 0.5, "2": 0.5}, 1},
+		{"成比例", map[string]float64{"1": 1, "2": 2}, map[string]float64{"1": 2, "2": 4}, 1},
+		{"正交", map[string]float64{"1": 1}, map[string]float64{"2": 1}, 0},
+		{"部分重合", map[string]float64{"1": 1, "2": 1}, map[string]float64{"1": 1}, 1 / math.Sqrt2},
+		{"空向量", map[string]float64{}, map[string]float64{"1": 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: cosineSimilarity = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTopKGenomeTags(t *testing.T) {
+	cell := func(family, qualifier, value string) *hrpc.Cell {
+		return &hrpc.Cell{Family: []byte(family), Qualifier: []byte(qualifier), Value: []byte(value)}
+	}
+	cells := []*hrpc.Cell{
+		cell("genome", "1", "0.2"),
+		cell("genome", "2", "0.9"),
+		cell("genome", "3", "0.9"),
+		cell("genome", "4", "0"),
+		cell("genome", "5", "abc"),
+		cell("info", "6", "1.0"),
+		cell("genome", "7", "0.5"),
+	}
+
+	tests := []struct {
+		k    int
+		want string
+	}{
+		{2, "[{2 0.9} {3 0.9}]"},
+		{3, "[{2 0.9} {3 0.9} {7 0.5}]"},
+		{10, "[{2 0.9} {3 0.9} {7 0.5} {1 0.2}]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(topKGenomeTags(cells, tt.k)); got != tt.want {
+			t.Errorf("topKGenomeTags(k=%d) = %s, want %s", tt.k, got, tt.want)
+		}
+	}
+}
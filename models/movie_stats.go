@@ -0,0 +1,113 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/config"
+	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ListAllMovieIDs 获取全部电影ID，按数字顺序排序。
+// 优先读取SQLite索引，索引不可用时回退到HBase _info行扫描。
+func ListAllMovieIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+
+	if GetSearchIndex().IsIndexReady() {
+		var err error
+		ids, err = listMovieIDsFromIndex()
+		if err != nil {
+			logrus.Warnf("从索引读取电影ID失败，回退到HBase扫描: %v", err)
+			ids = nil
+		}
+	}
+
+	if ids == nil {
+		results, err := utils.ScanMovies(ctx, "", "", math.MaxInt64)
+		if err != nil {
+			return nil, fmt.Errorf("扫描电影ID失败: %w", err)
+		}
+		ids = make([]string, 0, len(results))
+		for _, result := range results {
+			if len(result.Cells) == 0 {
+				continue
+			}
+			if movieID, ok := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeInfo); ok {
+				ids = append(ids, movieID)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return CompareMovieIDs(ids[i], ids[j]) < 0
+	})
+	return ids, nil
+}
+
+// listMovieIDsFromIndex 从SQLite索引读取全部电影ID
+func listMovieIDsFromIndex() ([]string, error) {
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := db.Query("SELECT movie_id FROM movie_index")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CompareMovieIDs 比较两个电影ID：都是数字时按数值比较，否则按字符串比较
+func CompareMovieIDs(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// lazyAvgRating 在请求路径上按需计算并存储缺失的平均评分，同时返回评分数。
+// 默认关闭（见config rating.lazy_stats_recompute），应使用 /api/system/stats/recompute 预先回填。
+func lazyAvgRating(ctx context.Context, movieID, source string) (float64, int, bool) {
+	if !config.GetConfig().LazyStatsRecomputeEnabled() {
+		return 0, 0, false
+	}
+
+	avgRating, ratingCount, err := CalculateAndStoreMovieAvgRating(ctx, movieID)
+	if err != nil || avgRating <= 0.0 {
+		return 0, 0, false
+	}
+
+	logrus.Debugf("%s: 按需计算并存储电影 %s 的平均评分: %.2f (基于 %d 个评分)",
+		source, movieID, avgRating, ratingCount)
+	return avgRating, ratingCount, true
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultSuggestLimit 默认返回的建议数
+	DefaultSuggestLimit = 8
+	// MaxSuggestLimit 最多返回的建议数
+	MaxSuggestLimit = 20
+	// suggestTimeout 单次建议查询的时间预算，超时返回空列表
+	suggestTimeout = 20 * time.Millisecond
+	// suggestExpiration 建议结果的缓存时间
+	suggestExpiration = 30 * time.Minute
+)
+
+// MovieSuggestion 输入联想结果，只包含索引中的字段
+type MovieSuggestion struct {
+	MovieID string `json:"movieId"`
+	Title   string `json:"title"`
+	Year    int    `json:"year,omitempty"`
+}
+
+// SuggestMovies 按标题前缀返回输入联想（带缓存），评分人数多的电影排在前面。
+// 只查询SQLite索引，不访问HBase；索引未就绪、正在重建或查询超时时返回空列表。
+func SuggestMovies(ctx context.Context, query string, limit int) ([]MovieSuggestion, error) {
+	prefix := normalizeSuggestPrefix(query)
+	if prefix == "" {
+		return []MovieSuggestion{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+	if limit > MaxSuggestLimit {
+		limit = MaxSuggestLimit
+	}
+
+	cacheKey := fmt.Sprintf("suggest:%d:%s", limit, prefix)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if suggestions, ok := cached.([]MovieSuggestion); ok {
+			return suggestions, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, suggestTimeout)
+	defer cancel()
+
+	suggestions, ok, err := GetSearchIndex().suggestByPrefix(ctx, prefix, limit)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logrus.Debugf("输入联想 %q 超出时间预算 %v", prefix, suggestTimeout)
+		return []MovieSuggestion{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// 索引未就绪时不缓存，构建完成后即可返回结果
+		return []MovieSuggestion{}, nil
+	}
+
+	utils.Cache.SetWithExpiration(cacheKey, suggestions, suggestExpiration)
+	return suggestions, nil
+}
+
+// normalizeSuggestPrefix 转为小写并合并空白，作为缓存键和查询前缀
+func normalizeSuggestPrefix(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// suggestByPrefix 对movie_fts做前缀查询，最后一个词按前缀匹配、其余词整词匹配。
+// 正在构建索引（持有写锁）或FTS表不存在时ok为false。
+func (si *SearchIndex) suggestByPrefix(ctx context.Context, prefix string, limit int) ([]MovieSuggestion, bool, error) {
+	// 构建期间不等待写锁，直接返回空结果
+	if !si.mu.TryRLock() {
+		return nil, false, nil
+	}
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+	if hasFTS, err := ftsTableExists(db); err != nil || !hasFTS {
+		return nil, false, err
+	}
+
+	match := titleMatchQuery(prefix, func(text string) string {
+		words := strings.Fields(text)
+		terms := make([]string, len(words))
+		for i, word := range words {
+			terms[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		}
+		terms[len(terms)-1] += "*"
+		return strings.Join(terms, " ")
+	})
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT mi.movie_id, mi.title, COALESCE(mi.year, 0) FROM movie_index mi JOIN movie_fts ft ON mi.id = ft.rowid
+		WHERE movie_fts MATCH ? ORDER BY ft.rank - %g * %s, mi.id LIMIT ?`, rankBoostWeight, rankBoostExpr(RankPopularity)),
+		match, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	suggestions := make([]MovieSuggestion, 0, limit)
+	for rows.Next() {
+		var s MovieSuggestion
+		if err := rows.Scan(&s.MovieID, &s.Title, &s.Year); err != nil {
+			return nil, false, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	return suggestions, true, nil
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/config"
+	"gohbase/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// popularTagsCacheKey 热门标签的缓存键
+	popularTagsCacheKey = "popular_tags"
+	// popularTagsExpiration 热门标签的缓存时间，应长于后台刷新间隔
+	popularTagsExpiration = 2 * time.Hour
+	// PopularTagsRefreshInterval 后台刷新热门标签的间隔（需要全表扫描_tags行）
+	PopularTagsRefreshInterval = time.Hour
+	// maxPopularTags 缓存的热门标签数上限
+	maxPopularTags = 1000
+	// maxTagLength 用户提交标签的最大字符数
+	maxTagLength = 50
+)
+
+// ErrInvalidTag 标签为空、过长或包含分隔符
+var ErrInvalidTag = errors.New("标签无效")
+
+// TagCount 标签及其使用次数，用于标签云
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// popularTagsMu 避免缓存失效时多个请求同时扫描
+var popularTagsMu sync.Mutex
+
+// GetPopularTags 获取使用次数最多的limit个标签（带缓存）
+func GetPopularTags(ctx context.Context, limit int) ([]TagCount, error) {
+	tags, err := getCachedPopularTags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && limit < len(tags) {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// getCachedPopularTags 读取缓存的热门标签，缓存失效时重新统计
+func getCachedPopularTags(ctx context.Context) ([]TagCount, error) {
+	if cached, found := utils.Cache.Get(popularTagsCacheKey); found {
+		if tags, ok := cached.([]TagCount); ok {
+			return tags, nil
+		}
+	}
+
+	popularTagsMu.Lock()
+	defer popularTagsMu.Unlock()
+
+	// 等待锁期间可能已被其他请求计算
+	if cached, found := utils.Cache.Get(popularTagsCacheKey); found {
+		if tags, ok := cached.([]TagCount); ok {
+			return tags, nil
+		}
+	}
+	return RefreshPopularTags(ctx)
+}
+
+// RefreshPopularTags 重新统计标签使用次数并写入缓存。
+// 索引包含标签数据时读取SQLite，否则扫描HBase _tags行。
+func RefreshPopularTags(ctx context.Context) ([]TagCount, error) {
+	var counts map[string]int
+
+	if GetSearchIndex().IsIndexReady() {
+		var err error
+		counts, err = tagCountsFromIndex(ctx)
+		if err != nil {
+			logrus.Warnf("从索引读取标签统计失败，回退到HBase扫描: %v", err)
+			counts = nil
+		}
+	}
+
+	if counts == nil {
+		var err error
+		counts, err = utils.ScanTagCounts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("扫描标签失败: %w", err)
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	if len(tags) > maxPopularTags {
+		tags = tags[:maxPopularTags]
+	}
+
+	utils.Cache.SetWithExpiration(popularTagsCacheKey, tags, popularTagsExpiration)
+	return tags, nil
+}
+
+// StartPopularTagsRefresher 在后台定期刷新热门标签，ctx取消时退出
+func StartPopularTagsRefresher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := RefreshPopularTags(ctx); err != nil {
+					logrus.Warnf("刷新热门标签失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// tagCountsFromIndex 从SQLite标签表汇总使用次数，索引没有标签数据时返回nil
+func tagCountsFromIndex(ctx context.Context) (map[string]int, error) {
+	// 持有读锁，避免重建索引切换连接时查询到已关闭的旧连接
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	indexed, err := utils.GetIndexMeta(indexMetaTagsIndexed)
+	if err != nil || indexed != "true" {
+		return nil, err
+	}
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := db.QueryContext(ctx, "SELECT tag, SUM(count) FROM movie_tags GROUP BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tag string
+		var count int
+		if err := rows.Scan(&tag, &count); err != nil {
+			return nil, err
+		}
+		counts[tag] = count
+	}
+	return counts, rows.Err()
+}
+
+// moviesByTagExpiration 标签电影ID列表的缓存时间
+const moviesByTagExpiration = 10 * time.Minute
+
+// taggedMovieIDs 带有某标签的电影ID列表及是否因达到扫描上限而不完整
+type taggedMovieIDs struct {
+	IDs       []string
+	Truncated bool
+}
+
+// GetMoviesByTag 分页获取带有指定标签（不区分大小写、精确匹配）的电影，总数为过滤后的电影数。
+// fields不为nil时只读取所需字段对应的行
+func GetMoviesByTag(ctx context.Context, tag string, page, perPage int, fields MovieFields) (*MovieList, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 12
+	}
+
+	tagged, err := getTaggedMovieIDs(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(tagged.IDs)
+	start := (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end := start + perPage
+	if end > total {
+		end = total
+	}
+	pageIDs := tagged.IDs[start:end]
+
+	// 批量获取本页电影的_info行
+	movies, err := moviesByIDs(ctx, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	fillMovieListDetails(ctx, movies, fields, "标签电影列表")
+
+	return &MovieList{
+		Movies:      movies,
+		TotalMovies: total,
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  (total + perPage - 1) / perPage,
+		Truncated:   tagged.Truncated,
+	}, nil
+}
+
+// getTaggedMovieIDs 获取带有标签的电影ID（带缓存），优先读取SQLite索引，否则扫描HBase _tags行
+func getTaggedMovieIDs(ctx context.Context, tag string) (*taggedMovieIDs, error) {
+	cacheKey := "movies_by_tag:" + tag
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if tagged, ok := cached.(*taggedMovieIDs); ok {
+			return tagged, nil
+		}
+	}
+
+	movieIDs, ok, err := GetSearchIndex().MovieIDsByTag(ctx, tag)
+	if err != nil {
+		logrus.Warnf("从索引读取标签 %q 的电影失败，回退到HBase扫描: %v", tag, err)
+	}
+
+	tagged := &taggedMovieIDs{IDs: movieIDs}
+	if !ok || err != nil {
+		maxResults := config.GetConfig().GetSearchConfig().MaxResults
+		movieIDs, err = utils.ScanMovieIDsByTag(ctx, tag, int64(maxResults)+1)
+		if err != nil {
+			return nil, fmt.Errorf("按标签扫描电影失败: %w", err)
+		}
+		if len(movieIDs) > maxResults {
+			movieIDs = movieIDs[:maxResults]
+			tagged.Truncated = true
+		}
+		tagged.IDs = movieIDs
+	}
+
+	utils.Cache.SetWithExpiration(cacheKey, tagged, moviesByTagExpiration)
+	return tagged, nil
+}
+
+// NormalizeTag 去除首尾空白并转为小写，校验长度为1-50个字符且不含存储格式使用的冒号
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if n := utf8.RuneCountInString(tag); n == 0 || n > maxTagLength {
+		return "", fmt.Errorf("%w: 长度必须为1到%d个字符", ErrInvalidTag, maxTagLength)
+	}
+	if strings.Contains(tag, ":") {
+		return "", fmt.Errorf("%w: 不能包含冒号", ErrInvalidTag)
+	}
+	return tag, nil
+}
+
+// AddMovieTag 记录用户为电影添加的标签：写入电影_tags行和users表，更新索引中的标签计数并清除相关缓存。
+// 电影行是标签的权威数据，users表写入失败只记录日志。
+func AddMovieTag(ctx context.Context, movieID, userID, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+
+	movie, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return err
+	}
+	if movie == nil {
+		return ErrMovieNotFound
+	}
+
+	timestamp := time.Now().Unix()
+	if err := utils.PutMovieTag(ctx, movieID, userID, tag, timestamp); err != nil {
+		return fmt.Errorf("写入电影标签失败: %w", err)
+	}
+	if err := utils.PutUserTag(ctx, userID, movieID, tag, timestamp); err != nil {
+		logrus.Warnf("写入用户 %s 的标签到users表失败（电影 %s）: %v", userID, movieID, err)
+	}
+
+	if err := GetSearchIndex().AddTag(ctx, movieID, tag); err != nil {
+		logrus.Warnf("更新电影 %s 的标签索引失败: %v", movieID, err)
+	}
+	utils.Cache.Delete(fmt.Sprintf("movie_detail:%s", movieID))
+	utils.Cache.Delete("movies_by_tag:" + tag)
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/config"
+	"gohbase/utils"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MinMovieYear 最早的电影年份（1888年的《朗德海花园场景》）
+const MinMovieYear = 1888
+
+// ErrInvalidYear 年份不是数字或超出合理范围
+var ErrInvalidYear = errors.New("无效的年份")
+
+// ParseMovieYear 解析年份参数，要求在MinMovieYear到今年之间
+func ParseMovieYear(s string) (int, error) {
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q不是数字", ErrInvalidYear, s)
+	}
+	if current := time.Now().Year(); year < MinMovieYear || year > current {
+		return 0, fmt.Errorf("%w: 年份应在%d到%d之间", ErrInvalidYear, MinMovieYear, current)
+	}
+	return year, nil
+}
+
+// GetMoviesByYear 分页获取指定年份的电影（带缓存），按电影ID排序。
+// 优先按索引的year列查询，索引不可用时扫描标题中带有该年份的电影。
+func GetMoviesByYear(year, page, perPage int) (*MovieList, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = 12
+	}
+
+	cacheKey := fmt.Sprintf("movies_by_year:%d:%d:%d", year, page, perPage)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if result, ok := cached.(*MovieList); ok {
+			return result, nil
+		}
+	}
+
+	ctx := context.Background()
+	var result *MovieList
+
+	searchIndex := GetSearchIndex()
+	if searchIndex.IsIndexReady() {
+		movieIDs, total, err := searchIndex.MovieIDsByYear(ctx, year, (page-1)*perPage, perPage)
+		if err == nil {
+			movies, err := moviesByIDs(ctx, movieIDs)
+			if err != nil {
+				return nil, err
+			}
+			fillMovieListDetails(ctx, movies, nil, "年份电影列表")
+			result = &MovieList{
+				Movies:      movies,
+				TotalMovies: total,
+				Page:        page,
+				PerPage:     perPage,
+				TotalPages:  (total + perPage - 1) / perPage,
+			}
+		} else {
+			logrus.Warnf("从索引读取 %d 年的电影失败，回退到HBase扫描: %v", year, err)
+		}
+	}
+
+	if result == nil {
+		var err error
+		if result, err = scanMoviesByYear(ctx, year, page, perPage); err != nil {
+			return nil, err
+		}
+	}
+
+	utils.Cache.Set(cacheKey, result)
+	return result, nil
+}
+
+// scanMoviesByYear 扫描标题中包含年份的电影，只保留标题末尾年份相符的
+func scanMoviesByYear(ctx context.Context, year, page, perPage int) (*MovieList, error) {
+	searchCfg := config.GetConfig().GetSearchConfig()
+	matched, err := searchByTextOptimized(ctx, strconv.Itoa(year), SearchTypeTitle, searchCfg.MaxScanRows, searchCfg.MaxResults)
+	if err != nil {
+		return nil, fmt.Errorf("按年份扫描电影失败: %w", err)
+	}
+	truncated := len(matched) >= searchCfg.MaxResults
+
+	// 标题中其他位置出现的数字（如"2001: A Space Odyssey (1968)"）不算
+	movies := matched[:0]
+	for _, movie := range matched {
+		if movie.Year == year {
+			movies = append(movies, movie)
+		}
+	}
+
+	total := len(movies)
+	start := min((page-1)*perPage, total)
+	end := min(start+perPage, total)
+	pageMovies := append([]Movie{}, movies[start:end]...)
+	if err := enrichMoviesWithRatings(ctx, pageMovies); err != nil {
+		logrus.Warnf("获取年份电影评分失败: %v", err)
+	}
+
+	return &MovieList{
+		Movies:      pageMovies,
+		TotalMovies: total,
+		Page:        page,
+		PerPage:     perPage,
+		TotalPages:  (total + perPage - 1) / perPage,
+		Truncated:   truncated,
+	}, nil
+}
+
+// moviesByIDs 批量读取电影的_info行，按ids的顺序返回，HBase中已不存在的电影跳过
+func moviesByIDs(ctx context.Context, ids []string) ([]Movie, error) {
+	infos, err := utils.GetMoviesMultiple(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("获取电影信息失败: %w", err)
+	}
+
+	movies := make([]Movie, 0, len(ids))
+	for _, movieID := range ids {
+		if info, ok := infos[movieID]; ok {
+			movies = append(movies, movieFromInfo(movieID, info["info"]))
+		}
+	}
+	return movies, nil
+}
+
+// MovieIDsByYear 从索引中分页读取指定年份的电影ID（按电影ID排序）和该年份的电影总数
+func (si *SearchIndex) MovieIDsByYear(ctx context.Context, year, offset, limit int) (movieIDs []string, total int, err error) {
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
+
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM movie_index WHERE year = ?", year).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("统计年份电影数失败: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		"SELECT movie_id FROM movie_index WHERE year = ? ORDER BY CAST(movie_id AS INTEGER) LIMIT ? OFFSET ?",
+		year, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询年份电影失败: %w", err)
+	}
+	defer rows.Close()
+
+	movieIDs = []string{}
+	for rows.Next() {
+		var movieID string
+		if err := rows.Scan(&movieID); err != nil {
+			return nil, 0, err
+		}
+		movieIDs = append(movieIDs, movieID)
+	}
+	return movieIDs, total, rows.Err()
+}
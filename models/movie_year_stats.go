@@ -0,0 +1,129 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"strconv"
+	"time"
+)
+
+const (
+	// yearStatsCachePrefix 年份统计的缓存键前缀，重建索引后按前缀清除
+	yearStatsCachePrefix = "year_stats:"
+	// yearStatsExpiration 年份统计的缓存时间，期间的评分变化不会反映在结果中
+	yearStatsExpiration = 10 * time.Minute
+	// UnknownYear 标题中没有可解析年份的电影的分组
+	UnknownYear = "unknown"
+)
+
+var (
+	// ErrYearStatsUnavailable 搜索索引未就绪，无法统计
+	ErrYearStatsUnavailable = errors.New("搜索索引未就绪，暂时无法统计年份")
+	// ErrInvalidYearRange from晚于to
+	ErrInvalidYearRange = errors.New("无效的年份范围")
+)
+
+// YearStats 一个上映年份的电影数和评分统计
+type YearStats struct {
+	Year        string  `json:"year"` // 年份，没有可解析年份的电影为"unknown"
+	MovieCount  int     `json:"movieCount"`
+	AvgRating   float64 `json:"avgRating"` // 按评分数加权的平均分
+	RatingCount int     `json:"ratingCount"`
+}
+
+// ParseYearRange 解析from、to参数，为空时返回0表示不限制
+func ParseYearRange(from, to string) (int, int, error) {
+	var fromYear, toYear int
+	var err error
+	if from != "" {
+		if fromYear, err = ParseMovieYear(from); err != nil {
+			return 0, 0, err
+		}
+	}
+	if to != "" {
+		if toYear, err = ParseMovieYear(to); err != nil {
+			return 0, 0, err
+		}
+	}
+	if fromYear != 0 && toYear != 0 && fromYear > toYear {
+		return 0, 0, fmt.Errorf("%w: from不能晚于to", ErrInvalidYearRange)
+	}
+	return fromYear, toYear, nil
+}
+
+// GetYearStats 按上映年份统计电影数、平均评分和评分数（带缓存），按年份升序，"unknown"排在最后。
+// from、to不为0时只统计该范围内的年份（含两端），此时不包含"unknown"。数据取自SQLite索引的year列和评分统计。
+func GetYearStats(ctx context.Context, from, to int) ([]YearStats, error) {
+	cacheKey := fmt.Sprintf("%s%d:%d", yearStatsCachePrefix, from, to)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if stats, ok := cached.([]YearStats); ok {
+			return stats, nil
+		}
+	}
+
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	if !si.isIndexReadyLocked() {
+		return nil, ErrYearStatsUnavailable
+	}
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	query := `SELECT year, COUNT(*), SUM(COALESCE(avg_rating, 0) * COALESCE(rating_count, 0)), SUM(COALESCE(rating_count, 0))
+		FROM movie_index WHERE 1 = 1`
+	var args []interface{}
+	if from != 0 {
+		query += " AND year >= ?"
+		args = append(args, from)
+	}
+	if to != 0 {
+		query += " AND year <= ?"
+		args = append(args, to)
+	}
+	// NULL排在最前，移到结果末尾
+	query += " GROUP BY year ORDER BY year"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("按年份统计失败: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []YearStats{}
+	var unknown *YearStats
+	for rows.Next() {
+		var year sql.NullInt64
+		var ratingSum float64
+		var entry YearStats
+		if err := rows.Scan(&year, &entry.MovieCount, &ratingSum, &entry.RatingCount); err != nil {
+			return nil, err
+		}
+		if entry.RatingCount > 0 {
+			entry.AvgRating = ratingSum / float64(entry.RatingCount)
+		}
+		if !year.Valid {
+			entry.Year = UnknownYear
+			unknown = &entry
+			continue
+		}
+		entry.Year = strconv.FormatInt(year.Int64, 10)
+		stats = append(stats, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if unknown != nil {
+		stats = append(stats, *unknown)
+	}
+
+	utils.Cache.SetWithExpiration(cacheKey, stats, yearStatsExpiration)
+	return stats, nil
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// yearStatsFixture 跨越几十年的电影，其中两部标题没有年份，一部没有评分
+var yearStatsFixture = []hbasetest.Movie{
+	{ID: "1", Title: "Seven Samurai (Shichinin no samurai) (1954)", Genres: "Action|Drama", Stats: map[string]string{"avg_rating": "4.5", "rating_count": "10"}},
+	{ID: "2", Title: "Rear Window (1954)", Genres: "Mystery|Thriller", Stats: map[string]string{"avg_rating": "4.0", "rating_count": "30"}},
+	{ID: "3", Title: "Godfather, The (1972)", Genres: "Crime|Drama", Stats: map[string]string{"avg_rating": "4.3", "rating_count": "20"}},
+	{ID: "4", Title: "Matrix, The (1999)", Genres: "Action|Sci-Fi", Stats: map[string]string{"avg_rating": "4.1", "rating_count": "40"}},
+	{ID: "5", Title: "Toy Story (1995)", Genres: "Animation|Comedy"},
+	{ID: "6", Title: "Deadpool (2016)", Genres: "Action|Comedy", Stats: map[string]string{"avg_rating": "3.8", "rating_count": "5"}},
+	{ID: "7", Title: "Hyena Road", Genres: "Drama|War", Stats: map[string]string{"avg_rating": "3.0", "rating_count": "2"}},
+	{ID: "8", Title: "Cosmos (2014-)", Genres: "Documentary"},
+}
+
+// formatYearStats 把统计结果格式化为"年份:电影数/评分数/平均分"，便于比较
+func formatYearStats(stats []YearStats) string {
+	parts := make([]string, len(stats))
+	for i, entry := range stats {
+		parts[i] = fmt.Sprintf("%s:%d/%d/%.3f", entry.Year, entry.MovieCount, entry.RatingCount, entry.AvgRating)
+	}
+	return fmt.Sprint(parts)
+}
+
+func TestGetYearStats(t *testing.T) {
+	newTestIndex(t, yearStatsFixture)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		from, to int
+		want     string
+	}{
+		{"全部", 0, 0, "[1954:2/40/4.125 1972:1/20/4.300 1995:1/0/0.000 1999:1/40/4.100 2016:1/5/3.800 unknown:2/2/3.000]"},
+		{"起止年份", 1960, 1999, "[1972:1/20/4.300 1995:1/0/0.000 1999:1/40/4.100]"},
+		{"只有起始年份", 1999, 0, "[1999:1/40/4.100 2016:1/5/3.800]"},
+		{"只有截止年份", 0, 1954, "[1954:2/40/4.125]"},
+		{"同一年", 1972, 1972, "[1972:1/20/4.300]"},
+		{"范围内没有电影", 1980, 1990, "[]"},
+	}
+	for _, tt := range tests {
+		stats, err := GetYearStats(ctx, tt.from, tt.to)
+		if err != nil {
+			t.Fatalf("%s: GetYearStats失败: %v", tt.name, err)
+		}
+		if got := formatYearStats(stats); got != tt.want {
+			t.Errorf("%s: GetYearStats(%d, %d) = %s, want %s", tt.name, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestParseYearRange(t *testing.T) {
+	tests := []struct {
+		from, to         string
+		wantFrom, wantTo int
+		wantErr          error
+	}{
+		{"", "", 0, 0, nil},
+		{"1990", "", 1990, 0, nil},
+		{"", "2000", 0, 2000, nil},
+		{"1990", "2000", 1990, 2000, nil},
+		{"1990", "1990", 1990, 1990, nil},
+		{"2000", "1990", 0, 0, ErrInvalidYearRange},
+		{"abc", "", 0, 0, ErrInvalidYear},
+		{"", "3000", 0, 0, ErrInvalidYear},
+		{"1000", "", 0, 0, ErrInvalidYear},
+	}
+	for _, tt := range tests {
+		from, to, err := ParseYearRange(tt.from, tt.to)
+		if from != tt.wantFrom || to != tt.wantTo || !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseYearRange(%q, %q) = (%d, %d, %v), want (%d, %d, %v)",
+				tt.from, tt.to, from, to, err, tt.wantFrom, tt.wantTo, tt.wantErr)
+		}
+	}
+}
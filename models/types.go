@@ -1,6 +1,8 @@
 package models
 
 import (
+	"gohbase/utils"
+	"gohbase/utils/cache"
 	"math/rand"
 	"time"
 )
@@ -10,13 +12,22 @@ var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 
 // Movie 电影模型
 type Movie struct {
-	MovieID   string   `json:"movieId"`
-	Title     string   `json:"title"`
-	Genres    []string `json:"genres"`
-	Year      int      `json:"year,omitempty"`
-	AvgRating float64  `json:"avgRating"`
-	Links     Links    `json:"links,omitempty"`
-	Tags      []string `json:"tags,omitempty"`
+	MovieID     string   `json:"movieId"`
+	Title       string   `json:"title"`
+	CleanTitle  string   `json:"cleanTitle,omitempty"` // 去掉末尾年份的标题
+	Genres      []string `json:"genres"`
+	Year        int      `json:"year,omitempty"`
+	AvgRating   float64  `json:"avgRating"`
+	RatingCount int      `json:"ratingCount"`
+	Links       Links    `json:"links,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	TagCount    int      `json:"tagCount"` // 不同标签数
+}
+
+// setTitle 设置标题，并从标题末尾的"(dddd)"提取年份和去年份标题
+func (m *Movie) setTitle(title string) {
+	m.Title = title
+	m.CleanTitle, m.Year, _ = utils.ExtractYearFromTitle(title)
 }
 
 // Links 外部链接
@@ -27,6 +38,26 @@ type Links struct {
 	TmdbURL string `json:"tmdbUrl,omitempty"`
 }
 
+// newLinks 从GetMovieLinks返回的数据构建Links
+func newLinks(linksData map[string]interface{}) Links {
+	links := Links{}
+
+	if imdbId, ok := linksData["imdbId"].(string); ok {
+		links.ImdbID = imdbId
+	}
+	if imdbUrl, ok := linksData["imdbUrl"].(string); ok {
+		links.ImdbURL = imdbUrl
+	}
+	if tmdbId, ok := linksData["tmdbId"].(string); ok {
+		links.TmdbID = tmdbId
+	}
+	if tmdbUrl, ok := linksData["tmdbUrl"].(string); ok {
+		links.TmdbURL = tmdbUrl
+	}
+
+	return links
+}
+
 // MovieList 电影列表响应
 type MovieList struct {
 	Movies      []Movie `json:"movies"`
@@ -34,14 +65,21 @@ type MovieList struct {
 	Page        int     `json:"page"`
 	PerPage     int     `json:"perPage"`
 	TotalPages  int     `json:"totalPages"`
+	Truncated   bool    `json:"truncated,omitempty"`  // 结果数达到搜索上限，可能不完整
+	DidYouMean  bool    `json:"didYouMean,omitempty"` // 没有精确匹配，结果为拼写相近的标题
+	NextCursor  string  `json:"nextCursor,omitempty"` // 下一页的游标（最后一部电影的行键），没有下一页时省略
+	// Links 分页导航URL（self、first、last、next、prev），不适用时为null
+	Links map[string]*string `json:"links,omitempty"`
 }
 
 // MovieDetail 电影详情响应
 type MovieDetail struct {
-	Movie       Movie               `json:"movie"`
-	Ratings     []Rating            `json:"ratings,omitempty"`
-	TaggedUsers []map[string]string `json:"taggedUsers,omitempty"`
-	Stats       map[string]float64  `json:"stats,omitempty"`
+	Movie              Movie               `json:"movie"`
+	Ratings            []Rating            `json:"ratings,omitempty"`
+	RatingDistribution map[string]int      `json:"ratingDistribution"` // 各分值（"0.5"到"5.0"）的评分数，没有评分的分值为0
+	TaggedUsers        []map[string]string `json:"taggedUsers"`
+	Genome             []GenomeTag         `json:"genome"` // 相关度最高的基因标签，按相关度降序
+	Stats              map[string]float64  `json:"stats"`  // avgRating、ratingCount、tagCount、userTagCount、uniqueUsers
 }
 
 // Rating 评分
@@ -49,3 +87,24 @@ type Rating struct {
 	UserID string  `json:"userId"`
 	Rating float64 `json:"rating"`
 }
+
+func init() {
+	// 使用Redis缓存时，其他实例写入的值需要按类型还原
+	cache.RegisterType(
+		&MovieDetail{},
+		&MovieList{},
+		[]Movie{},
+		[]SimilarMovie{},
+		[]TagCount{},
+		[]GenreCount{},
+		[]MovieSuggestion{},
+		&taggedMovieIDs{},
+		&randomMoviesResult{},
+		&MovieActivity{},
+		[]RecentMovie{},
+		&GlobalRating{},
+		[]YearStats{},
+		&UserProfile{},
+		&UserTasteProfile{},
+	)
+}
@@ -0,0 +1,369 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gohbase/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+const (
+	// UserActivityRefreshInterval 后台扫描users表重新计算用户活跃度的间隔
+	UserActivityRefreshInterval = 15 * time.Minute
+	// userActivityRetryInterval 计算失败时的重试间隔
+	userActivityRetryInterval = 30 * time.Second
+	// userActivityMetaKey 索引元数据中记录用户活跃度计算时间（Unix秒）的键
+	userActivityMetaKey = "user_activity_updated"
+
+	// DefaultTopUsersLimit 活跃用户排行每页默认返回的用户数
+	DefaultTopUsersLimit = 50
+	// MaxTopUsersLimit 活跃用户排行每页最多返回的用户数
+	MaxTopUsersLimit = 200
+)
+
+// 活跃用户排行的排序依据
+const (
+	TopUsersByRatings = "ratings"
+	TopUsersByTags    = "tags"
+)
+
+var (
+	// ErrUserActivityNotReady 后台任务尚未完成第一次计算
+	ErrUserActivityNotReady = errors.New("用户活跃度尚未计算完成，请稍后重试")
+	// ErrInvalidTopUsersBy by参数不是ratings或tags
+	ErrInvalidTopUsersBy = errors.New("by参数只能是ratings或tags")
+)
+
+// ActiveUser 活跃用户排行中的一个用户
+type ActiveUser struct {
+	Rank         int     `json:"rank"`
+	UserID       string  `json:"userId"`
+	RatingCount  int     `json:"ratingCount"`
+	TagCount     int     `json:"tagCount"`
+	AvgRating    float64 `json:"avgRating"`        // 用户给出评分的平均值，没有评分时为0
+	LastActivity int64   `json:"lastActivity"`     // 最近一次评分或添加标签的时间（Unix秒）
+	IsTest       bool    `json:"isTest,omitempty"` // 压力测试生成的用户
+}
+
+// TopUsers 按评分数或标签数排序的活跃用户排行
+type TopUsers struct {
+	By          string       `json:"by"`
+	Users       []ActiveUser `json:"users"`
+	TotalUsers  int          `json:"totalUsers"`
+	Page        int          `json:"page"`
+	PerPage     int          `json:"perPage"`
+	TotalPages  int          `json:"totalPages"`
+	IncludeTest bool         `json:"includeTest"`
+	UpdatedAt   time.Time    `json:"updatedAt"` // 统计的计算时间，之后的评分和标签尚未计入
+}
+
+// topUsersColumn 返回by对应的排序列
+func topUsersColumn(by string) (string, error) {
+	switch by {
+	case "", TopUsersByRatings:
+		return "rating_count", nil
+	case TopUsersByTags:
+		return "tag_count", nil
+	}
+	return "", ErrInvalidTopUsersBy
+}
+
+// GetTopUsers 返回按评分数（by=ratings）或标签数（by=tags）降序排列的第page页活跃用户，
+// 数量相同时按最近活动时间降序。数据来自后台任务最近一次计算，includeTest为false时排除压力测试生成的用户
+func GetTopUsers(ctx context.Context, by string, page, perPage int, includeTest bool) (*TopUsers, error) {
+	column, err := topUsersColumn(by)
+	if err != nil {
+		return nil, err
+	}
+	if by == "" {
+		by = TopUsersByRatings
+	}
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultTopUsersLimit
+	}
+	perPage = min(perPage, MaxTopUsersLimit)
+
+	si := GetSearchIndex()
+	si.mu.RLock()
+	defer si.mu.RUnlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	updated, err := utils.ReadIndexMeta(db, userActivityMetaKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取用户活跃度计算时间失败: %w", err)
+	}
+	updatedUnix, err := strconv.ParseInt(updated, 10, 64)
+	if err != nil {
+		return nil, ErrUserActivityNotReady
+	}
+
+	where := "ua." + column + " > 0"
+	if !includeTest {
+		where += " AND tu.user_id IS NULL"
+	}
+	from := " FROM user_activity ua LEFT JOIN test_users tu ON tu.user_id = ua.user_id WHERE " + where
+
+	result := &TopUsers{
+		By:          by,
+		Users:       []ActiveUser{},
+		Page:        page,
+		PerPage:     perPage,
+		IncludeTest: includeTest,
+		UpdatedAt:   time.Unix(updatedUnix, 0),
+	}
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*)"+from).Scan(&result.TotalUsers); err != nil {
+		return nil, fmt.Errorf("统计活跃用户数失败: %w", err)
+	}
+	result.TotalPages = (result.TotalUsers + perPage - 1) / perPage
+
+	offset := (page - 1) * perPage
+	rows, err := db.QueryContext(ctx, `SELECT ua.user_id, ua.rating_count, ua.rating_sum, ua.tag_count, ua.last_activity,
+			tu.user_id IS NOT NULL`+from+`
+			ORDER BY ua.`+column+` DESC, ua.last_activity DESC, ua.user_id LIMIT ? OFFSET ?`, perPage, offset)
+	if err != nil {
+		return nil, fmt.Errorf("查询活跃用户失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user ActiveUser
+		var ratingSum float64
+		if err := rows.Scan(&user.UserID, &user.RatingCount, &ratingSum, &user.TagCount, &user.LastActivity, &user.IsTest); err != nil {
+			return nil, err
+		}
+		if user.RatingCount > 0 {
+			user.AvgRating = ratingSum / float64(user.RatingCount)
+		}
+		user.Rank = offset + len(result.Users) + 1
+		result.Users = append(result.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userActivity 一个用户的评分和标签汇总
+type userActivity struct {
+	userID       string
+	ratingCount  int
+	ratingSum    float64
+	tagCount     int
+	lastActivity int64
+}
+
+// RefreshUserActivity 扫描users表重新计算每个用户的评分数、评分总和、标签数和最近活动时间，
+// 整体替换索引中的user_activity表
+func RefreshUserActivity(ctx context.Context) error {
+	start := time.Now()
+	var users []userActivity
+	err := utils.ScanUserRows(ctx, func(userID string, cells []*hrpc.Cell) error {
+		if activity, ok := summarizeUserCells(userID, cells); ok {
+			users = append(users, activity)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("扫描users表失败: %w", err)
+	}
+
+	err = GetSearchIndex().applyUserWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		return replaceUserActivity(ctx, db, users, start)
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("用户活跃度计算完成: %d 个用户, 耗时 %v", len(users), time.Since(start))
+	return nil
+}
+
+// summarizeUserCells 汇总users表一行中的评分和标签，没有有效数据时返回false
+func summarizeUserCells(userID string, cells []*hrpc.Cell) (userActivity, bool) {
+	activity := userActivity{userID: userID}
+	for _, cell := range cells {
+		switch string(cell.Family) {
+		case "movies":
+			parsed, ok := utils.ParseRatingValue(string(cell.Value))
+			if !ok {
+				continue
+			}
+			activity.ratingCount++
+			activity.ratingSum += parsed.Rating
+			activity.lastActivity = max(activity.lastActivity, ratingTime(cell))
+		case "tags":
+			activity.tagCount++
+			activity.lastActivity = max(activity.lastActivity, tagTime(cell))
+		}
+	}
+	return activity, activity.ratingCount > 0 || activity.tagCount > 0
+}
+
+// tagTime 标签时间（Unix秒）：优先取值"{tag}:{movieId}:{timestamp}"末尾的时间戳，没有时取单元格的写入时间
+func tagTime(cell *hrpc.Cell) int64 {
+	value := string(cell.Value)
+	if i := strings.LastIndex(value, ":"); i >= 0 {
+		if seconds, err := strconv.ParseInt(value[i+1:], 10, 64); err == nil {
+			return seconds
+		}
+	}
+	if cell.Timestamp != nil {
+		return int64(*cell.Timestamp / 1000)
+	}
+	return 0
+}
+
+// replaceUserActivity 在一个事务中用users替换user_activity表的内容，并记录计算时间
+func replaceUserActivity(ctx context.Context, db *sql.DB, users []userActivity, computedAt time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_activity"); err != nil {
+		return fmt.Errorf("清空用户活跃度失败: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO user_activity (user_id, rating_count, rating_sum, tag_count, last_activity)
+		VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, user := range users {
+		if _, err := stmt.ExecContext(ctx, user.userID, user.ratingCount, user.ratingSum, user.tagCount, user.lastActivity); err != nil {
+			return fmt.Errorf("写入用户活跃度失败: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT OR REPLACE INTO movie_index_meta (key, value) VALUES (?, ?)",
+		userActivityMetaKey, strconv.FormatInt(computedAt.Unix(), 10)); err != nil {
+		return fmt.Errorf("记录用户活跃度计算时间失败: %w", err)
+	}
+	return tx.Commit()
+}
+
+// StartUserActivityRefresher 在后台每隔interval重新计算用户活跃度，计算失败时按较短的间隔重试；
+// 索引中已有不超过interval的计算结果时（如重启后）等到其过期再计算。ctx取消时退出
+func StartUserActivityRefresher(ctx context.Context, interval time.Duration) {
+	go func() {
+		timer := time.NewTimer(untilUserActivityStale(interval))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				next := interval
+				if err := RefreshUserActivity(ctx); err != nil {
+					logrus.Debugf("计算用户活跃度失败，%v后重试: %v", userActivityRetryInterval, err)
+					next = userActivityRetryInterval
+				}
+				timer.Reset(next)
+			}
+		}
+	}()
+}
+
+// untilUserActivityStale 返回索引中的用户活跃度距离超过interval还有多久，没有计算结果时返回0
+func untilUserActivityStale(interval time.Duration) time.Duration {
+	si := GetSearchIndex()
+	si.mu.RLock()
+	updated, err := utils.GetIndexMeta(userActivityMetaKey)
+	si.mu.RUnlock()
+	if err != nil {
+		return 0
+	}
+	updatedUnix, err := strconv.ParseInt(updated, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return max(0, interval-time.Since(time.Unix(updatedUnix, 0)))
+}
+
+// MarkTestUsers 记录压力测试生成的用户，活跃用户排行默认排除这些用户
+func MarkTestUsers(ctx context.Context, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return GetSearchIndex().applyUserWrite(ctx, func(ctx context.Context, db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, userID := range userIDs {
+			if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO test_users (user_id) VALUES (?)", userID); err != nil {
+				return fmt.Errorf("记录测试用户失败: %w", err)
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// applyUserWrite 在当前索引上写入用户数据。用户数据不是由重建索引生成的，
+// 不要求索引就绪，重建切换时由carryOverUserData复制到新索引
+func (si *SearchIndex) applyUserWrite(ctx context.Context, write indexWrite) error {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+
+	db, release, err := utils.AcquireDB()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return write(ctx, db)
+}
+
+// carryOverUserData 把当前索引中的用户活跃度、测试用户和活跃度计算时间复制到重建的新索引。
+// 调用方持有写锁
+func carryOverUserData(ctx context.Context, staging *sql.DB) error {
+	current, release, err := utils.AcquireDB()
+	if err != nil {
+		return err
+	}
+	defer release()
+	updated, err := utils.ReadIndexMeta(current, userActivityMetaKey)
+	if err != nil {
+		return err
+	}
+
+	conn, err := staging.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS current_index", utils.IndexPath()); err != nil {
+		return fmt.Errorf("打开当前索引失败: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "DETACH DATABASE current_index")
+
+	for _, stmt := range []string{
+		"INSERT OR IGNORE INTO test_users SELECT user_id FROM current_index.test_users",
+		`INSERT OR REPLACE INTO user_activity (user_id, rating_count, rating_sum, tag_count, last_activity)
+			SELECT user_id, rating_count, rating_sum, tag_count, last_activity FROM current_index.user_activity`,
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("复制用户数据失败: %w", err)
+		}
+	}
+	if updated == "" {
+		return nil
+	}
+	_, err = conn.ExecContext(ctx, "INSERT OR REPLACE INTO movie_index_meta (key, value) VALUES (?, ?)", userActivityMetaKey, updated)
+	return err
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"sort"
+	"time"
+)
+
+const (
+	// userProfileListSize 用户资料中评分最高和最近评分的电影数
+	userProfileListSize = 5
+	// userProfileExpiration 用户资料的缓存时间
+	userProfileExpiration = 5 * time.Minute
+)
+
+// UserRatedMovie 用户评过分的一部电影
+type UserRatedMovie struct {
+	MovieID   string  `json:"movieId"`
+	Title     string  `json:"title"` // 电影已删除时为空
+	Rating    float64 `json:"rating"`
+	Timestamp int64   `json:"timestamp"` // 评分时间（Unix秒），旧数据没有时为0
+}
+
+// UserProfile 用户的评分概况
+type UserProfile struct {
+	UserID         string           `json:"userId"`
+	TotalRatings   int              `json:"totalRatings"`
+	AvgRating      float64          `json:"avgRating"`
+	FavoriteGenres []GenreCount     `json:"favoriteGenres"` // 评过分的电影的类型及电影数，按电影数降序
+	TopRatedMovies []UserRatedMovie `json:"topRatedMovies"` // 评分最高的电影，相同时较新的在前
+	RecentRatings  []UserRatedMovie `json:"recentRatings"`  // 最近的评分
+}
+
+// GetUserProfile 返回用户的评分数、平均评分、喜欢的类型、评分最高和最近评分的电影（缓存5分钟）。
+// 用户在users表中没有评分时返回nil
+func GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
+	cacheKey := fmt.Sprintf("user_profile:%s", userID)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if profile, ok := cached.(*UserProfile); ok {
+			return profile, nil
+		}
+	}
+
+	userRatings, err := utils.GetUserMovieRatings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户评分失败: %w", err)
+	}
+	ratings := userRatedMovies(userRatings)
+	if len(ratings) == 0 {
+		return nil, nil
+	}
+
+	genreCounts, err := utils.GetUserFavoriteGenres(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户喜欢的类型失败: %w", err)
+	}
+
+	profile := &UserProfile{
+		UserID:         userID,
+		TotalRatings:   len(ratings),
+		FavoriteGenres: sortedGenreCounts(genreCounts),
+	}
+	var sum float64
+	for _, rating := range ratings {
+		sum += rating.Rating
+	}
+	profile.AvgRating = sum / float64(len(ratings))
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Rating != ratings[j].Rating {
+			return ratings[i].Rating > ratings[j].Rating
+		}
+		if ratings[i].Timestamp != ratings[j].Timestamp {
+			return ratings[i].Timestamp > ratings[j].Timestamp
+		}
+		return ratings[i].MovieID < ratings[j].MovieID
+	})
+	profile.TopRatedMovies = append([]UserRatedMovie{}, ratings[:min(userProfileListSize, len(ratings))]...)
+
+	sort.Slice(ratings, func(i, j int) bool {
+		if ratings[i].Timestamp != ratings[j].Timestamp {
+			return ratings[i].Timestamp > ratings[j].Timestamp
+		}
+		return ratings[i].MovieID < ratings[j].MovieID
+	})
+	profile.RecentRatings = append([]UserRatedMovie{}, ratings[:min(userProfileListSize, len(ratings))]...)
+
+	fillUserRatedTitles(ctx, profile.TopRatedMovies, profile.RecentRatings)
+
+	utils.Cache.SetWithExpiration(cacheKey, profile, userProfileExpiration)
+	return profile, nil
+}
+
+// userRatedMovies 把GetUserMovieRatings的结果转换为UserRatedMovie列表
+func userRatedMovies(userRatings map[string]interface{}) []UserRatedMovie {
+	entries, _ := userRatings["ratings"].([]map[string]interface{})
+	ratings := make([]UserRatedMovie, 0, len(entries))
+	for _, entry := range entries {
+		movieID, _ := entry["movieId"].(string)
+		rating, ok := entry["rating"].(float64)
+		if movieID == "" || !ok {
+			continue
+		}
+		timestamp, _ := entry["timestamp"].(int64)
+		ratings = append(ratings, UserRatedMovie{MovieID: movieID, Rating: rating, Timestamp: timestamp})
+	}
+	return ratings
+}
+
+// sortedGenreCounts 按电影数降序、类型名称升序排列类型计数
+func sortedGenreCounts(counts map[string]int) []GenreCount {
+	genres := make([]GenreCount, 0, len(counts))
+	for genre, count := range counts {
+		genres = append(genres, GenreCount{Genre: genre, Count: count})
+	}
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Count != genres[j].Count {
+			return genres[i].Count > genres[j].Count
+		}
+		return genres[i].Genre < genres[j].Genre
+	})
+	return genres
+}
+
+// fillUserRatedTitles 批量读取各列表中电影的_info行补充标题
+func fillUserRatedTitles(ctx context.Context, lists ...[]UserRatedMovie) {
+	var movieIDs []string
+	for _, list := range lists {
+		for _, movie := range list {
+			movieIDs = append(movieIDs, movie.MovieID)
+		}
+	}
+	infos, err := utils.GetMoviesMultiple(ctx, dedupeMovieIDs(movieIDs))
+	if err != nil {
+		return
+	}
+	for _, list := range lists {
+		for i := range list {
+			if info, ok := infos[list[i].MovieID]["info"]; ok {
+				list[i].Title = string(info["title"])
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// userTasteTopN 口味概况中返回的类型数和标签数
+	userTasteTopN = 10
+	// userTasteExpiration 口味概况的缓存时间
+	userTasteExpiration = 5 * time.Minute
+	// userTendencyMinRatings 评分数达到该值才判断评分倾向
+	userTendencyMinRatings = 5
+	// userTendencyMargin 平均评分比全局平均分低（高）超过该值时视为严格（宽松）
+	userTendencyMargin = 0.5
+)
+
+// 评分倾向
+const (
+	UserTendencyHarsh    = "harsh"
+	UserTendencyBalanced = "balanced"
+	UserTendencyGenerous = "generous"
+)
+
+// UserTasteProfile 用户的口味概况。users表没有注册的概念，没有任何评分和标签的用户Exists为false
+type UserTasteProfile struct {
+	UserID       string  `json:"userId"`
+	Exists       bool    `json:"exists"`
+	TotalRatings int     `json:"totalRatings"`
+	AvgRating    float64 `json:"avgRating"`    // 用户给出评分的平均值
+	RatingStdDev float64 `json:"ratingStdDev"` // 评分的总体标准差，越小说明打分越集中
+	// Tendency 与全局平均分比较的评分倾向（harsh、balanced、generous），评分太少或全局平均分尚未计算时省略
+	Tendency      string       `json:"tendency,omitempty"`
+	TopGenres     []GenreCount `json:"topGenres"` // 评过分的电影的类型及电影数，最多10个
+	TopTags       []TagCount   `json:"topTags"`   // 用户添加最多的标签及次数，最多10个
+	TotalTags     int          `json:"totalTags"`
+	FirstActivity int64        `json:"firstActivity"` // 最早的评分或标签时间（Unix秒），没有时为0
+	LastActivity  int64        `json:"lastActivity"`  // 最近的评分或标签时间（Unix秒），没有时为0
+}
+
+// GetUserTasteProfile 汇总用户的评分数、平均评分、标准差、评分倾向、常看的类型、常用的标签和首末活动时间（缓存5分钟）。
+// 类型按用户评过分的电影分批读取_info行统计
+func GetUserTasteProfile(ctx context.Context, userID string) (*UserTasteProfile, error) {
+	cacheKey := fmt.Sprintf("user_taste:%s", userID)
+	if cached, found := utils.Cache.Get(cacheKey); found {
+		if profile, ok := cached.(*UserTasteProfile); ok {
+			return profile, nil
+		}
+	}
+
+	userRatings, err := utils.GetUserMovieRatings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户评分失败: %w", err)
+	}
+	tags, err := utils.GetUserTagEntries(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户标签失败: %w", err)
+	}
+	ratings := userRatedMovies(userRatings)
+
+	profile := &UserTasteProfile{
+		UserID:       userID,
+		Exists:       len(ratings) > 0 || len(tags) > 0,
+		TotalRatings: len(ratings),
+		TopGenres:    []GenreCount{},
+		TopTags:      []TagCount{},
+		TotalTags:    len(tags),
+	}
+
+	movieIDs := make([]string, len(ratings))
+	for i, rating := range ratings {
+		movieIDs[i] = rating.MovieID
+		profile.observeActivity(rating.Timestamp)
+	}
+	for _, tag := range tags {
+		profile.observeActivity(tag.Timestamp)
+	}
+
+	if len(ratings) > 0 {
+		profile.AvgRating, profile.RatingStdDev = ratingMeanStdDev(ratings)
+		if globalAvg, ok := cachedGlobalAverage(); ok && len(ratings) >= userTendencyMinRatings {
+			profile.Tendency = ratingTendency(profile.AvgRating, globalAvg)
+		}
+		genres := sortedGenreCounts(utils.CountMovieGenres(ctx, movieIDs))
+		profile.TopGenres = genres[:min(userTasteTopN, len(genres))]
+	}
+	profile.TopTags = topUserTags(tags, userTasteTopN)
+
+	utils.Cache.SetWithExpiration(cacheKey, profile, userTasteExpiration)
+	return profile, nil
+}
+
+// observeActivity 用一次评分或标签的时间更新首末活动时间，忽略没有时间的旧数据
+func (p *UserTasteProfile) observeActivity(timestamp int64) {
+	if timestamp <= 0 {
+		return
+	}
+	if p.FirstActivity == 0 || timestamp < p.FirstActivity {
+		p.FirstActivity = timestamp
+	}
+	p.LastActivity = max(p.LastActivity, timestamp)
+}
+
+// ratingMeanStdDev 返回评分的平均值和总体标准差
+func ratingMeanStdDev(ratings []UserRatedMovie) (float64, float64) {
+	var sum float64
+	for _, rating := range ratings {
+		sum += rating.Rating
+	}
+	mean := sum / float64(len(ratings))
+
+	var variance float64
+	for _, rating := range ratings {
+		variance += (rating.Rating - mean) * (rating.Rating - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(ratings)))
+}
+
+// ratingTendency 按用户平均分与全局平均分的差判断评分倾向
+func ratingTendency(avgRating, globalAvg float64) string {
+	switch {
+	case avgRating < globalAvg-userTendencyMargin:
+		return UserTendencyHarsh
+	case avgRating > globalAvg+userTendencyMargin:
+		return UserTendencyGenerous
+	}
+	return UserTendencyBalanced
+}
+
+// topUserTags 按使用次数降序、标签升序返回前limit个标签
+func topUserTags(tags []utils.UserTag, limit int) []TagCount {
+	counts := make(map[string]int)
+	for _, tag := range tags {
+		counts[tag.Tag]++
+	}
+	top := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		top = append(top, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Tag < top[j].Tag
+	})
+	return top[:min(limit, len(top))]
+}
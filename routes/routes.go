@@ -1,11 +1,16 @@
 package routes
 
 import (
+	"gohbase/config"
 	"gohbase/controllers"
+	_ "gohbase/docs" // 注册swag生成的Swagger文档
+	"gohbase/middleware"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // SetupRouter 设置路由
@@ -23,38 +28,80 @@ func SetupRouter() *gin.Engine {
 	})
 
 	// 添加CORS中间件
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Cache-Check", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "X-Cache-Hit"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	cfg := config.GetConfig()
+	router.Use(cors.New(corsConfig(cfg)))
+
+	// 限制请求体大小
+	router.Use(middleware.BodyLimit(cfg.GetMaxBodyBytes(), cfg.GetImportMaxBodyBytes()))
 
 	// 创建API路由组
 	api := router.Group("/api")
 
+	// 按Accept-Encoding压缩较大的响应（电影列表、评分等），小响应不压缩
+	api.Use(middleware.Compress(cfg.GetCompressionMinBytes()))
+
+	// 管理接口鉴权
+	adminAuth := middleware.AdminAuth(cfg.Server.AdminKey)
+
 	// 创建控制器实例
 	movieController := controllers.NewMovieController()
 	systemController := controllers.NewSystemController()
 	testController := controllers.NewTestController()
 	hotnessController := controllers.NewHotnessController()
+	adminController := controllers.NewAdminController()
 
 	// 电影相关路由
 	movies := api.Group("/movies")
 	{
 		movies.GET("", movieController.GetMovies)
 		movies.GET("/:id", movieController.GetMovie)
+		movies.GET("/:id/similar", movieController.GetSimilarMovies)
+		movies.GET("/:id/activity", movieController.GetMovieActivity)
+		movies.GET("/:id/similarity/:otherId", movieController.GetMovieSimilarity)
 		movies.GET("/random", movieController.GetRandomMovies)
+		movies.GET("/recent", movieController.GetRecentlyAddedMovies)
+		movies.GET("/year/:year", movieController.GetMoviesByYear)
 		movies.POST("/random", movieController.RandomMoviesPost)
+		movies.POST("/batch", movieController.GetMoviesBatch)
 		movies.GET("/search", movieController.SearchMovies)
+		movies.GET("/suggest", movieController.SuggestMovies)
+		movies.DELETE("/batch-delete", adminAuth, adminController.BatchDeleteMovies)
+		movies.PUT("/:id", adminAuth, adminController.PutMovie)
+		movies.POST("/:id", adminAuth, adminController.PostMovie)
+		movies.DELETE("/:id", adminAuth, adminController.DeleteMovie)
+		movies.POST("/:id/rate", middleware.Idempotency(), movieController.RateMovie)
+		movies.POST("/:id/tags", middleware.Idempotency(), movieController.AddMovieTag)
+	}
+
+	// Prometheus指标
+	router.GET("/metrics", systemController.GetMetrics)
+
+	// 类型列表（筛选用）
+	api.GET("/genres", movieController.GetGenres)
+	api.GET("/stats/genres", movieController.GetGenreStats)
+	api.GET("/stats/years", movieController.GetYearStats)
+	api.GET("/analytics/global-rating", movieController.GetGlobalRating)
+
+	// 全量导出（NDJSON）
+	api.GET("/export/movies", adminAuth, movieController.ExportMovies)
+
+	// 热门标签（标签云）
+	api.GET("/tags/popular", movieController.GetPopularTags)
+
+	// 用户相关路由
+	users := api.Group("/users")
+	{
+		users.GET("/top", movieController.GetTopUsers)
+		users.GET("/:id", movieController.GetUserProfile)
+		users.GET("/:id/profile", movieController.GetUserTasteProfile)
 	}
 
 	// 评分相关路由
 	ratings := api.Group("/ratings")
 	{
 		ratings.GET("/movie/:id", movieController.GetMovieRatings)
+		ratings.GET("/movie/:id/user/:userId", movieController.GetUserRating)
+		ratings.DELETE("/older-than", adminAuth, adminController.PurgeOldRatings)
 	}
 
 	// 系统相关路由
@@ -64,11 +111,31 @@ func SetupRouter() *gin.Engine {
 		system.GET("/cache", systemController.GetCacheStats)
 		system.POST("/search-index/build", systemController.BuildSearchIndex)
 		system.GET("/search-index/stats", systemController.GetSearchIndexStats)
+		system.POST("/stats/recompute", adminAuth, systemController.RecomputeStats)
+		system.GET("/stats/recompute/status", systemController.GetStatsRecomputeStatus)
+		system.POST("/verify", adminAuth, systemController.VerifyIntegrity)
+		system.GET("/verify/report", systemController.GetIntegrityReport)
+		system.POST("/verify/cancel", adminAuth, systemController.CancelIntegrityCheck)
 
 		// 性能监控和诊断
 		system.GET("/performance", systemController.GetHBasePerformanceStats)
 		system.GET("/diagnostics", systemController.GetHBaseDiagnostics)
+		system.GET("/pool-health", systemController.GetPoolHealth)
+		system.GET("/goroutines", systemController.GetGoroutines)
 		system.POST("/gc", systemController.ForceGC)
+
+		// 运行时配置
+		system.PUT("/log-level", adminAuth, systemController.SetLogLevel)
+		system.GET("/search-config", systemController.GetSearchConfig)
+		system.PATCH("/search-config", adminAuth, systemController.UpdateSearchConfig)
+	}
+
+	// 电影元数据管理路由
+	admin := api.Group("/admin", adminAuth)
+	{
+		admin.POST("/movies", adminController.CreateMovie)
+		admin.PATCH("/movies/:id", adminController.UpdateMovie)
+		admin.DELETE("/movies/:id", adminController.DeleteMovie)
 	}
 
 	// 测试相关路由
@@ -82,6 +149,7 @@ func SetupRouter() *gin.Engine {
 
 		// 单次操作
 		test.POST("/ratings/movie/:id", testController.GenerateRandomRatingsForMovie)
+		test.POST("/ratings/range", adminAuth, testController.GenerateRandomRatingsForRange)
 		test.DELETE("/ratings/movie/:id", testController.ClearMovieRatings)
 	}
 
@@ -91,11 +159,30 @@ func SetupRouter() *gin.Engine {
 		hotness.GET("/movies", hotnessController.GetHotMovies)
 		hotness.GET("/movie/:id", hotnessController.GetMovieHotness)
 		hotness.GET("/movie/:id/threshold", hotnessController.GetMovieRatingThreshold)
+		hotness.POST("/movie/:id/recalculate", adminAuth, hotnessController.RecalculateMovieRating)
+		hotness.GET("/thresholds", hotnessController.GetRatingThresholds)
 		hotness.GET("/stats", hotnessController.GetWriteStats)
 		hotness.GET("/writes", hotnessController.GetRecentWrites)
 		hotness.GET("/ranking", hotnessController.GetHotnessRanking)
 		hotness.GET("/trends", hotnessController.GetHotnessTrends)
+		hotness.GET("/export", hotnessController.ExportHotness)
 	}
 
+	// API文档：Swagger UI在/swagger/index.html，文档在/swagger/doc.json（由make swagger生成）
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
 	return router
 }
+
+// corsConfig 按server.cors_allow_origins和server.cors_allow_credentials构建CORS配置。
+// 启动时已用ValidateCORS拒绝通配符来源与凭据的组合，这里再次保证不会同时启用两者
+func corsConfig(cfg *config.Config) cors.Config {
+	return cors.Config{
+		AllowOrigins:     cfg.GetCORSAllowOrigins(),
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Cache-Check", "X-Requested-With", middleware.AdminKeyHeader, middleware.IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "X-Cache-Hit", "Idempotent-Replayed"},
+		AllowCredentials: cfg.Server.CORSAllowCredentials && !cfg.CORSAllowsAnyOrigin(),
+		MaxAge:           12 * time.Hour,
+	}
+}
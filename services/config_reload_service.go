@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"gohbase/config"
+	"gohbase/utils"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// configReloadDebounce 编辑器保存文件时常连续产生多个事件，合并为一次重新加载
+const configReloadDebounce = 200 * time.Millisecond
+
+// ReloadConfig 重新加载配置文件，校验通过后替换全局配置并应用可运行时生效的配置项。
+// trigger 为触发来源（file、SIGHUP），仅用于日志。
+func ReloadConfig(trigger string) error {
+	old, next, err := config.Reload()
+	if err != nil {
+		logrus.Errorf("重新加载配置失败（%s），继续使用当前配置: %v", trigger, err)
+		return err
+	}
+
+	changes := config.Diff(old, next)
+	if len(changes) == 0 {
+		logrus.Infof("配置已重新加载（%s），没有变化", trigger)
+		return nil
+	}
+
+	logrus.Infof("配置已重新加载（%s），%d项变化", trigger, len(changes))
+	ApplyConfig(next, changes)
+	return nil
+}
+
+// ApplyConfig 记录每项配置变化，并将可运行时生效的配置项应用到各组件。
+// HBase地址、端口等只在启动时读取的配置项只记录警告，需要重启后生效。
+func ApplyConfig(cfg *config.Config, changes []config.FieldChange) {
+	var searchChanged, levelChanged bool
+	for _, change := range changes {
+		if !change.Runtime {
+			logrus.Warnf("配置项 %s: %s -> %s（不支持运行时生效，需要重启）", change.Field, change.Old, change.New)
+			continue
+		}
+		logrus.Infof("配置项 %s: %s -> %s", change.Field, change.Old, change.New)
+		if strings.HasPrefix(change.Field, "search.") {
+			searchChanged = true
+		}
+		if change.Field == "logging.level" {
+			levelChanged = true
+		}
+	}
+
+	// 日志：只在logging.level变化时调整级别，避免覆盖通过PUT /api/system/log-level临时调整的级别
+	if levelChanged {
+		if level, err := logrus.ParseLevel(cfg.Logging.Level); err == nil {
+			logrus.SetLevel(level)
+		} else {
+			logrus.SetLevel(logrus.InfoLevel)
+		}
+	}
+	logrus.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: cfg.Logging.Timestamp,
+	})
+
+	// 缓存：只影响之后写入的缓存项
+	if utils.Cache != nil {
+		utils.Cache.SetDefaultExpiration(cfg.GetCacheDefaultExpiration())
+		utils.Cache.SetExpirationJitter(cfg.GetCacheExpirationJitterPct())
+		if searchChanged {
+			// 搜索结果受回退扫描上限影响
+			utils.Cache.DeletePrefix("search:")
+		}
+	}
+
+	// 评分追踪器（重新计算阈值每次写入时从配置读取，无需额外处理）
+	if err := GlobalRatingTracker.SetMaxRecords(cfg.GetTrackerMaxRecords()); err != nil {
+		logrus.Warnf("调整评分追踪器记录数失败: %v", err)
+	}
+
+	// HBase操作统计
+	utils.SetHBaseMetricsEnabled(cfg.HBase.IsMetricsEnabled())
+	utils.SetHBaseSlowOpsCapacity(cfg.HBase.GetSlowOpsCapacity())
+}
+
+// WatchConfig 监听配置文件变化和SIGHUP信号，触发时重新加载配置，直到ctx结束。
+// 监听的是配置文件所在目录，编辑器以重命名方式保存文件时也能收到事件。
+func WatchConfig(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	configPath, err := filepath.Abs(config.FilePath())
+	if err != nil {
+		configPath = config.FilePath()
+	}
+
+	var events <-chan fsnotify.Event
+	var watchErrors <-chan error
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = watcher.Add(filepath.Dir(configPath))
+	}
+	if err != nil {
+		logrus.Warnf("无法监听配置文件 %s，只能通过SIGHUP重新加载: %v", configPath, err)
+	} else {
+		defer watcher.Close()
+		events = watcher.Events
+		watchErrors = watcher.Errors
+		logrus.Infof("正在监听配置文件 %s 的变化", configPath)
+	}
+
+	// 防抖定时器，初始为停止状态
+	debounce := time.NewTimer(configReloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			ReloadConfig("SIGHUP")
+		case event := <-events:
+			if filepath.Clean(event.Name) != configPath {
+				continue
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				debounce.Reset(configReloadDebounce)
+			}
+		case err := <-watchErrors:
+			logrus.Warnf("监听配置文件出错: %v", err)
+		case <-debounce.C:
+			ReloadConfig("file")
+		}
+	}
+}
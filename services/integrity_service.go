@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gohbase/models"
+	"sync"
+)
+
+// ErrIntegrityCheckRunning 已有一致性检查在运行
+var ErrIntegrityCheckRunning = errors.New("一致性检查正在运行")
+
+// IntegrityService 评分数据一致性检查服务，保留最近一次检查的报告
+type IntegrityService struct {
+	mu     sync.Mutex
+	report *models.IntegrityReport
+	cancel context.CancelFunc
+}
+
+// NewIntegrityService 创建一致性检查服务
+func NewIntegrityService() *IntegrityService {
+	return &IntegrityService{}
+}
+
+// Start 在后台启动一致性检查，已有检查在运行时返回ErrIntegrityCheckRunning
+func (s *IntegrityService) Start(ctx context.Context, opts models.IntegrityOptions) (*models.IntegrityReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report != nil && s.report.IsRunning() {
+		return s.report.Snapshot(), ErrIntegrityCheckRunning
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	report := models.NewIntegrityReport(opts)
+	s.report = report
+	s.cancel = cancel
+
+	go func() {
+		defer cancel()
+		models.RunIntegrityCheck(ctx, opts, report)
+	}()
+
+	return report.Snapshot(), nil
+}
+
+// Cancel 取消正在运行的检查，没有运行中的检查时返回false
+func (s *IntegrityService) Cancel() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report == nil || !s.report.IsRunning() {
+		return false
+	}
+	s.cancel()
+	return true
+}
+
+// Report 获取最近一次检查的报告，从未运行时返回nil
+func (s *IntegrityService) Report() *models.IntegrityReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.report == nil {
+		return nil
+	}
+	return s.report.Snapshot()
+}
+
+// GlobalIntegrity 全局一致性检查服务实例
+var GlobalIntegrity = NewIntegrityService()
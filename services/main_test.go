@@ -0,0 +1,13 @@
+package services
+
+import (
+	"gohbase/config"
+	"gohbase/utils"
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// TestMain 把索引文件放到临时目录并初始化内存缓存，测试不读写工作目录下的索引
+func TestMain(m *testing.M) {
+	hbasetest.Main(m, func(string) { utils.InitCache(config.GetConfig()) })
+}
@@ -1,16 +1,43 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"gohbase/models"
+	"gohbase/utils"
+	"io"
+	"time"
 )
 
 // MovieService 电影服务接口
 type MovieService interface {
-	GetMoviesList(page, perPage int) (*models.MovieList, error)
+	GetMoviesList(page, perPage int, fields models.MovieFields) (*models.MovieList, error)
+	GetMoviesListAfter(cursor string, perPage int, fields models.MovieFields) (*models.MovieList, error)
+	GetMoviesByTag(tag string, page, perPage int, fields models.MovieFields) (*models.MovieList, error)
+	GetMoviesByYear(year, page, perPage int) (*models.MovieList, error)
+	GetMoviesBatch(ids []string) (*models.MovieBatch, error)
+	GetMovieActivity(movieID string, latest int) (*models.MovieActivity, error)
+	ExportMovies(ctx context.Context, w io.Writer, include models.ExportInclude, limit int) (int, error)
 	GetMovieByID(movieID string) (*models.MovieDetail, error)
-	GetRandomMovies(count int) ([]models.Movie, error)
-	SearchMovies(query string, page, perPage int) (*models.MovieList, error)
+	GetRandomMovies(count int, filter models.RandomMoviesFilter) ([]models.Movie, bool, error)
+	SearchMovies(query, searchType, rank string, page, perPage int, fields models.MovieFields) (*models.MovieList, error)
+	SuggestMovies(query string, limit int) ([]models.MovieSuggestion, error)
 	GetMovieRatings(movieID string) (map[string]interface{}, error)
+	GetMovieRatingsPage(movieID string, query models.RatingsQuery) (map[string]interface{}, error)
+	GetGenreCounts() ([]models.GenreCount, error)
+	GetYearStats(from, to int) ([]models.YearStats, error)
+	GetTopUsers(by string, page, limit int, includeTest bool) (*models.TopUsers, error)
+	GetUserProfile(userID string) (*models.UserProfile, error)
+	GetUserTasteProfile(userID string) (*models.UserTasteProfile, error)
+	GetGlobalRating() (*models.GlobalRating, error)
+	GetRecentlyAddedMovies(limit int) ([]models.RecentMovie, error)
+	GetGenreStats(window time.Duration) (*models.GenreStatsReport, error)
+	GetPopularTags(limit int) ([]models.TagCount, error)
+	GetUserRating(movieID, userID string) (map[string]interface{}, error)
+	GetSimilarMovies(movieID string, limit int) ([]models.SimilarMovie, error)
+	GetGenomeSimilarity(movieID, otherID string) (float64, error)
+	RateMovie(movieID, userID string, rating float64) (map[string]interface{}, error)
+	AddMovieTag(movieID, userID, tag string) (map[string]interface{}, error)
 }
 
 // movieService 电影服务实现
@@ -21,9 +48,47 @@ func NewMovieService() MovieService {
 	return &movieService{}
 }
 
-// GetMoviesList 获取电影列表
-func (s *movieService) GetMoviesList(page, perPage int) (*models.MovieList, error) {
-	return models.GetMoviesList(page, perPage)
+// GetMoviesList 获取电影列表，fields为nil时返回全部字段
+func (s *movieService) GetMoviesList(page, perPage int, fields models.MovieFields) (*models.MovieList, error) {
+	return models.GetMoviesList(page, perPage, fields)
+}
+
+// GetMoviesListAfter 游标分页获取电影列表，cursor为空时从第一部电影开始
+func (s *movieService) GetMoviesListAfter(cursor string, perPage int, fields models.MovieFields) (*models.MovieList, error) {
+	return models.GetMoviesListAfter(cursor, perPage, fields)
+}
+
+// GetMoviesByTag 分页获取带有指定标签的电影，fields为nil时返回全部字段
+func (s *movieService) GetMoviesByTag(tag string, page, perPage int, fields models.MovieFields) (*models.MovieList, error) {
+	return models.GetMoviesByTag(context.Background(), tag, page, perPage, fields)
+}
+
+// GetMoviesBatch 批量获取电影的完整信息
+func (s *movieService) GetMoviesBatch(ids []string) (*models.MovieBatch, error) {
+	return models.GetMoviesBatch(context.Background(), ids)
+}
+
+// GetMovieActivity 获取电影最近的评分动态，合并评分行和追踪器的写入记录；电影不存在时返回nil
+func (s *movieService) GetMovieActivity(movieID string, latest int) (*models.MovieActivity, error) {
+	var trackerEvents []models.RatingEvent
+	for _, record := range GlobalRatingTracker.GetMovieWrites(movieID) {
+		trackerEvents = append(trackerEvents, models.RatingEvent{
+			UserID:    record.UserID,
+			Rating:    record.Rating,
+			Timestamp: record.Timestamp.Unix(),
+		})
+	}
+	return models.GetMovieActivity(context.Background(), movieID, latest, trackerEvents)
+}
+
+// ExportMovies 以NDJSON流式导出电影数据，返回导出的电影数
+func (s *movieService) ExportMovies(ctx context.Context, w io.Writer, include models.ExportInclude, limit int) (int, error) {
+	return models.ExportMovies(ctx, w, include, limit)
+}
+
+// GetMoviesByYear 分页获取指定年份的电影
+func (s *movieService) GetMoviesByYear(year, page, perPage int) (*models.MovieList, error) {
+	return models.GetMoviesByYear(year, page, perPage)
 }
 
 // GetMovieByID 获取电影详情
@@ -31,17 +96,170 @@ func (s *movieService) GetMovieByID(movieID string) (*models.MovieDetail, error)
 	return models.GetMovieByID(movieID)
 }
 
-// GetRandomMovies 获取随机电影
-func (s *movieService) GetRandomMovies(count int) ([]models.Movie, error) {
-	return models.GetRandomMovies(count)
+// GetRandomMovies 获取满足过滤条件的随机电影，满足条件的电影不足count部时第二个返回值为true
+func (s *movieService) GetRandomMovies(count int, filter models.RandomMoviesFilter) ([]models.Movie, bool, error) {
+	return models.GetRandomMoviesFiltered(count, filter)
 }
 
 // SearchMovies 搜索电影
-func (s *movieService) SearchMovies(query string, page, perPage int) (*models.MovieList, error) {
-	return models.SearchMovies(query, page, perPage)
+func (s *movieService) SearchMovies(query, searchType, rank string, page, perPage int, fields models.MovieFields) (*models.MovieList, error) {
+	return models.SearchMovies(query, searchType, rank, page, perPage, fields)
+}
+
+// SuggestMovies 按标题前缀获取输入联想
+func (s *movieService) SuggestMovies(query string, limit int) ([]models.MovieSuggestion, error) {
+	return models.SuggestMovies(context.Background(), query, limit)
 }
 
 // GetMovieRatings 获取电影评分
 func (s *movieService) GetMovieRatings(movieID string) (map[string]interface{}, error) {
 	return models.GetMovieRatings(movieID)
 }
+
+// GetMovieRatingsPage 分页获取电影评分（可排序和按评分过滤），统计基于全部评分
+func (s *movieService) GetMovieRatingsPage(movieID string, query models.RatingsQuery) (map[string]interface{}, error) {
+	return models.GetMovieRatingsPage(movieID, query)
+}
+
+// GetSimilarMovies 获取相似电影
+func (s *movieService) GetSimilarMovies(movieID string, limit int) ([]models.SimilarMovie, error) {
+	return models.GetSimilarMovies(movieID, limit)
+}
+
+// GetGenreStats 获取各类型的评分统计。window为0时返回后台计算的全部评分统计，
+// 否则只汇总评分追踪器中最近window内的评分（受追踪器保留的记录数限制）
+func (s *movieService) GetGenreStats(window time.Duration) (*models.GenreStatsReport, error) {
+	if window == 0 {
+		return models.GetGenreStats()
+	}
+
+	since := time.Now().Add(-window)
+	ratings := make(map[string][]float64)
+	for _, record := range GlobalRatingTracker.GetRecentWrites(0) {
+		if !record.Timestamp.Before(since) {
+			ratings[record.MovieID] = append(ratings[record.MovieID], record.Rating)
+		}
+	}
+	return models.GetGenreStatsForRatings(ratings, since)
+}
+
+// GetRecentlyAddedMovies 获取最近添加的电影
+func (s *movieService) GetRecentlyAddedMovies(limit int) ([]models.RecentMovie, error) {
+	return models.GetRecentlyAddedMovies(context.Background(), limit)
+}
+
+// GetGlobalRating 获取全部电影按评分数加权的平均评分
+func (s *movieService) GetGlobalRating() (*models.GlobalRating, error) {
+	return models.GetGlobalRating(context.Background())
+}
+
+// GetYearStats 按上映年份统计电影数和评分
+func (s *movieService) GetYearStats(from, to int) ([]models.YearStats, error) {
+	return models.GetYearStats(context.Background(), from, to)
+}
+
+// GetTopUsers 获取按评分数或标签数排序的活跃用户排行
+func (s *movieService) GetTopUsers(by string, page, limit int, includeTest bool) (*models.TopUsers, error) {
+	return models.GetTopUsers(context.Background(), by, page, limit, includeTest)
+}
+
+// GetUserProfile 获取用户的评分概况
+func (s *movieService) GetUserProfile(userID string) (*models.UserProfile, error) {
+	return models.GetUserProfile(context.Background(), userID)
+}
+
+// GetUserTasteProfile 获取用户的口味概况
+func (s *movieService) GetUserTasteProfile(userID string) (*models.UserTasteProfile, error) {
+	return models.GetUserTasteProfile(context.Background(), userID)
+}
+
+// GetGenreCounts 获取全部类型及其电影数
+func (s *movieService) GetGenreCounts() ([]models.GenreCount, error) {
+	return models.GetGenreCounts(context.Background())
+}
+
+// GetPopularTags 获取使用次数最多的标签
+func (s *movieService) GetPopularTags(limit int) ([]models.TagCount, error) {
+	return models.GetPopularTags(context.Background(), limit)
+}
+
+// GetUserRating 获取用户对电影的评分，未评分时hasRated为false
+func (s *movieService) GetUserRating(movieID, userID string) (map[string]interface{}, error) {
+	rating, timestamp, err := utils.GetUserRating(context.Background(), movieID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 有效评分最低为0.5，评分为0表示未评分
+	return map[string]interface{}{
+		"movieId":   movieID,
+		"userId":    userID,
+		"rating":    rating,
+		"timestamp": timestamp,
+		"hasRated":  rating > 0,
+	}, nil
+}
+
+// GetGenomeSimilarity 获取两部电影基因向量的余弦相似度
+func (s *movieService) GetGenomeSimilarity(movieID, otherID string) (float64, error) {
+	return models.GenomeCosineSimilarity(context.Background(), movieID, otherID)
+}
+
+// RateMovie 提交用户评分，返回更新后的平均评分和评分数；电影不存在时返回nil
+func (s *movieService) RateMovie(movieID, userID string, rating float64) (map[string]interface{}, error) {
+	ctx := context.Background()
+
+	movie, err := utils.GetMovie(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+	if movie == nil {
+		return nil, nil
+	}
+
+	isUpdate, err := GlobalRatingTracker.WriteRatingToHBase(ctx, movieID, userID, rating, "api")
+	if err != nil {
+		return nil, err
+	}
+
+	action := "created"
+	if isUpdate {
+		action = "updated"
+	}
+
+	// 评分变化后详情缓存失效
+	utils.Cache.Delete(fmt.Sprintf("movie_detail:%s", movieID))
+
+	ratings, err := utils.GetMovieRatings(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"movieId":     movieID,
+		"userId":      userID,
+		"rating":      rating,
+		"action":      action,
+		"avgRating":   ratings["avgRating"],
+		"ratingCount": ratings["count"],
+	}, nil
+}
+
+// AddMovieTag 为电影添加用户标签，返回规范化后的标签
+func (s *movieService) AddMovieTag(movieID, userID, tag string) (map[string]interface{}, error) {
+	tag, err := models.NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.AddMovieTag(context.Background(), movieID, userID, tag); err != nil {
+		return nil, err
+	}
+
+	GlobalRatingTracker.RecordTagWrite(movieID, userID, tag, "api")
+
+	return map[string]interface{}{
+		"movieId": movieID,
+		"userId":  userID,
+		"tag":     tag,
+	}, nil
+}
@@ -1,24 +1,36 @@
 package services
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"gohbase/config"
 	"gohbase/models"
 	"gohbase/utils"
+	"gohbase/utils/hbase/rowkey"
+	"io"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"github.com/tsuna/gohbase/hrpc"
 )
 
+// ErrRecalculationQueued 电影已有重新计算在运行，强制重新计算将在其完成后执行
+var ErrRecalculationQueued = errors.New("该电影正在重新计算评分，完成后将再计算一次")
+
 // RatingWriteRecord 评分写入记录
 type RatingWriteRecord struct {
 	MovieID   string    `json:"movieId"`
 	UserID    string    `json:"userId"`
 	Rating    float64   `json:"rating"`
 	Timestamp time.Time `json:"timestamp"`
-	Source    string    `json:"source"` // "test", "api", "import" 等
+	Source    string    `json:"source"`   // "test", "api", "import" 等
+	IsUpdate  bool      `json:"isUpdate"` // 是否为用户对已评分电影的重新评分
 }
 
 // MovieHotness 电影热度信息
@@ -26,12 +38,13 @@ type MovieHotness struct {
 	MovieID      string    `json:"movieId"`
 	Title        string    `json:"title"`
 	WriteCount   int       `json:"writeCount"`
+	UpdateCount  int       `json:"updateCount"` // 其中重新评分的次数（不增加评分总数）
 	LastWrite    time.Time `json:"lastWrite"`
 	AvgRating    float64   `json:"avgRating"`
 	HotnessScore float64   `json:"hotnessScore"` // 综合热度分数
-	// 新增字段用于10%阈值检查
+	// 新增字段用于阈值检查（默认10%，见config rating）
 	LastRatingCount    int `json:"lastRatingCount"`    // 上次重新计算时的评分总数
-	NewWritesSinceCalc int `json:"newWritesSinceCalc"` // 自上次计算后的新增写入数
+	NewWritesSinceCalc int `json:"newWritesSinceCalc"` // 自上次计算后的新增评分数（不含重新评分）
 }
 
 // RatingTrackerService 评分追踪服务
@@ -39,20 +52,38 @@ type RatingTrackerService struct {
 	mu           sync.RWMutex
 	writeRecords []RatingWriteRecord
 	movieStats   map[string]*MovieHotness
-	maxRecords   int
+	maxRecords   int // 保留的写入记录上限，越大趋势统计可回看越久，内存占用约100字节/条
+	// 每部电影同时只运行一次重新计算，运行期间的新触发合并为一次后续计算
+	recalcInFlight map[string]bool
+	recalcPending  map[string]bool
+	// users表反向写入失败次数及最近一次错误（电影行已写入，users行缺失）
+	userWriteErrors    int64
+	lastUserWriteError string
+	// 通过接口写入的标签数（按来源），标签不参与热度和评分重新计算
+	tagWrites map[string]int64
+	surge     *SurgeDetector
 }
 
 // NewRatingTrackerService 创建评分追踪服务
 func NewRatingTrackerService() *RatingTrackerService {
 	return &RatingTrackerService{
-		writeRecords: make([]RatingWriteRecord, 0),
-		movieStats:   make(map[string]*MovieHotness),
-		maxRecords:   10000, // 最多保存10000条记录
+		writeRecords:   make([]RatingWriteRecord, 0),
+		movieStats:     make(map[string]*MovieHotness),
+		maxRecords:     config.GetConfig().GetTrackerMaxRecords(), // 默认最多保存10000条记录
+		recalcInFlight: make(map[string]bool),
+		recalcPending:  make(map[string]bool),
+		tagWrites:      make(map[string]int64),
+		surge:          NewSurgeDetector(),
 	}
 }
 
-// RecordRatingWrite 记录评分写入（通用函数）
-func (rts *RatingTrackerService) RecordRatingWrite(movieID, userID string, rating float64, source string) {
+// Surge 返回写入激增检测器
+func (rts *RatingTrackerService) Surge() *SurgeDetector {
+	return rts.surge
+}
+
+// RecordRatingWrite 记录评分写入（通用函数），isUpdate表示覆盖了该用户已有的评分
+func (rts *RatingTrackerService) RecordRatingWrite(movieID, userID string, rating float64, source string, isUpdate bool) {
 	rts.mu.Lock()
 	defer rts.mu.Unlock()
 
@@ -65,10 +96,12 @@ func (rts *RatingTrackerService) RecordRatingWrite(movieID, userID string, ratin
 		Rating:    rating,
 		Timestamp: now,
 		Source:    source,
+		IsUpdate:  isUpdate,
 	}
 
 	// 添加到记录列表
 	rts.writeRecords = append(rts.writeRecords, record)
+	rts.surge.Record(movieID, now)
 
 	// 保持记录数量限制
 	if len(rts.writeRecords) > rts.maxRecords {
@@ -78,30 +111,53 @@ func (rts *RatingTrackerService) RecordRatingWrite(movieID, userID string, ratin
 	// 更新电影统计
 	if hotness, exists := rts.movieStats[movieID]; exists {
 		hotness.WriteCount++
+		if isUpdate {
+			hotness.UpdateCount++
+		}
 		hotness.LastWrite = now
-		hotness.NewWritesSinceCalc++ // 增加新写入计数
+		if !isUpdate {
+			hotness.NewWritesSinceCalc++ // 重新评分不增加评分总数，不计入阈值
+		}
 		// 更新平均评分（简单移动平均）
 		hotness.AvgRating = (hotness.AvgRating + rating) / 2
 	} else {
 		// 初始化电影统计，获取当前评分总数
 		ctx := context.Background()
 		currentRatingCount := rts.getCurrentRatingCount(ctx, movieID)
-		
+
+		updateCount, newWrites := 0, 1
+		if isUpdate {
+			updateCount, newWrites = 1, 0
+		}
+
 		rts.movieStats[movieID] = &MovieHotness{
 			MovieID:            movieID,
 			WriteCount:         1,
+			UpdateCount:        updateCount,
 			LastWrite:          now,
 			AvgRating:          rating,
 			LastRatingCount:    currentRatingCount,
-			NewWritesSinceCalc: 1,
+			NewWritesSinceCalc: newWrites,
 		}
 	}
 
-	// 检查是否需要重新计算评分（10%阈值）
+	// 检查是否需要重新计算评分（百分比阈值）
 	rts.checkAndRecalculateRating(movieID)
 
-	// 重新计算热度分数
-	rts.calculateHotnessScore(movieID)
+	// 重新计算热度分数（持有写锁，可以更新追踪数据）
+	rts.movieStats[movieID].HotnessScore = rts.calculateHotnessScore(movieID, now)
+}
+
+// SetMaxRecords 调整保留的写入记录数，超出部分在下次写入时裁剪
+func (rts *RatingTrackerService) SetMaxRecords(maxRecords int) error {
+	if maxRecords <= 0 {
+		return fmt.Errorf("maxRecords必须大于0")
+	}
+
+	rts.mu.Lock()
+	rts.maxRecords = maxRecords
+	rts.mu.Unlock()
+	return nil
 }
 
 // getCurrentRatingCount 获取当前电影的评分总数
@@ -110,70 +166,121 @@ func (rts *RatingTrackerService) getCurrentRatingCount(ctx context.Context, movi
 	if err != nil {
 		return 0
 	}
-	
+
 	if ratingCount, ok := stats["ratingCount"].(int); ok {
 		return ratingCount
 	}
 	return 0
 }
 
-// checkAndRecalculateRating 检查并重新计算评分（10%阈值逻辑）
+// checkAndRecalculateRating 检查并重新计算评分（百分比阈值逻辑）
 func (rts *RatingTrackerService) checkAndRecalculateRating(movieID string) {
 	hotness := rts.movieStats[movieID]
 	if hotness == nil {
 		return
 	}
 
-	// 计算10%阈值
-	threshold := int(float64(hotness.LastRatingCount) * 0.1)
-	if threshold < 1 {
-		threshold = 1 // 至少1个新评分才触发重新计算
-	}
+	// 计算阈值
+	percent := config.GetConfig().GetRecalcThresholdPercent(hotness.LastRatingCount)
+	threshold := recalcThreshold(hotness.LastRatingCount)
 
 	// 检查是否达到阈值
 	if hotness.NewWritesSinceCalc >= threshold {
-		fmt.Printf("🔄 电影 %s 新增评分数 %d 达到阈值 %d (总评分数的10%%)，开始重新计算评分...\n", 
-			movieID, hotness.NewWritesSinceCalc, threshold)
-		
+		// 已有重新计算在运行时只标记待处理，避免并发扫描和写入stats行
+		if rts.recalcInFlight[movieID] {
+			rts.recalcPending[movieID] = true
+			return
+		}
+		rts.recalcInFlight[movieID] = true
+
+		logrus.Debugf("电影 %s 新增评分数 %d 达到阈值 %d (总评分数的%g%%)，开始重新计算评分",
+			movieID, hotness.NewWritesSinceCalc, threshold, percent)
+
 		// 异步重新计算评分
-		go rts.recalculateMovieRating(movieID)
+		go rts.runRecalculation(movieID)
+	}
+}
+
+// runRecalculation 执行重新计算，运行期间如有新的触发则再计算一次
+func (rts *RatingTrackerService) runRecalculation(movieID string) {
+	for {
+		rts.recalculateMovieRating(movieID)
+
+		rts.mu.Lock()
+		if !rts.recalcPending[movieID] {
+			delete(rts.recalcInFlight, movieID)
+			rts.mu.Unlock()
+			return
+		}
+		delete(rts.recalcPending, movieID)
+		rts.mu.Unlock()
 	}
 }
 
+// ForceRecalculate 立即重新计算电影评分，不检查阈值。
+// 该电影已有重新计算在运行时不重复执行，标记为完成后再计算一次并返回ErrRecalculationQueued。
+func (rts *RatingTrackerService) ForceRecalculate(movieID string) error {
+	rts.mu.Lock()
+	if rts.recalcInFlight[movieID] {
+		rts.recalcPending[movieID] = true
+		rts.mu.Unlock()
+		return ErrRecalculationQueued
+	}
+	rts.recalcInFlight[movieID] = true
+	rts.mu.Unlock()
+
+	err := rts.recalculateMovieRating(movieID)
+
+	// 计算期间有新的触发时交给后台再计算一次
+	rts.mu.Lock()
+	if rts.recalcPending[movieID] {
+		delete(rts.recalcPending, movieID)
+		rts.mu.Unlock()
+		go rts.runRecalculation(movieID)
+		return err
+	}
+	delete(rts.recalcInFlight, movieID)
+	rts.mu.Unlock()
+	return err
+}
+
 // recalculateMovieRating 重新计算电影评分
-func (rts *RatingTrackerService) recalculateMovieRating(movieID string) {
+func (rts *RatingTrackerService) recalculateMovieRating(movieID string) error {
 	ctx := context.Background()
-	
+
 	// 重新计算并存储评分
 	avgRating, ratingCount, err := models.CalculateAndStoreMovieAvgRating(ctx, movieID)
 	if err != nil {
-		fmt.Printf("❌ 重新计算电影 %s 评分失败: %v\n", movieID, err)
-		return
+		logrus.Warnf("重新计算电影 %s 评分失败: %v", movieID, err)
+		return err
 	}
-	
+
 	// 更新统计信息
 	rts.mu.Lock()
 	defer rts.mu.Unlock()
-	
+
 	if hotness, exists := rts.movieStats[movieID]; exists {
 		hotness.LastRatingCount = ratingCount
 		hotness.NewWritesSinceCalc = 0 // 重置新增计数
 		hotness.AvgRating = avgRating
 	}
-	
-	fmt.Printf("✅ 电影 %s 评分重新计算完成: 平均评分=%.2f, 总评分数=%d\n", 
+
+	logrus.Infof("电影 %s 评分重新计算完成: 平均评分=%.2f, 总评分数=%d",
 		movieID, avgRating, ratingCount)
+	return nil
 }
 
-// calculateHotnessScore 计算热度分数
-func (rts *RatingTrackerService) calculateHotnessScore(movieID string) {
+// calculateHotnessScore 返回电影在now时的热度分数，不修改追踪数据，调用方持有读锁即可
+func (rts *RatingTrackerService) calculateHotnessScore(movieID string, now time.Time) float64 {
 	hotness := rts.movieStats[movieID]
 	if hotness == nil {
-		return
+		return 0
 	}
+	return computeHotnessScore(hotness, now)
+}
 
-	now := time.Now()
-
+// computeHotnessScore 根据写入次数、最近写入时间和平均评分计算热度分数
+func computeHotnessScore(hotness *MovieHotness, now time.Time) float64 {
 	// 时间衰减因子（最近的写入权重更高）
 	timeDiff := now.Sub(hotness.LastWrite).Hours()
 	timeDecay := 1.0 / (1.0 + timeDiff/24.0) // 24小时衰减
@@ -185,32 +292,22 @@ func (rts *RatingTrackerService) calculateHotnessScore(movieID string) {
 	ratingScore := hotness.AvgRating / 5.0
 
 	// 综合热度分数
-	hotness.HotnessScore = writeScore * timeDecay * (0.7 + 0.3*ratingScore)
+	return writeScore * timeDecay * (0.7 + 0.3*ratingScore)
 }
 
-// GetHotMovies 获取热门电影列表
+// GetHotMovies 获取热门电影列表。返回的是带有当前热度分数的副本，
+// 读锁下只复制数据，补充标题在锁外进行
 func (rts *RatingTrackerService) GetHotMovies(limit int) ([]*MovieHotness, error) {
-	rts.mu.RLock()
-	defer rts.mu.RUnlock()
+	now := time.Now()
 
-	// 重新计算所有电影的热度分数
-	for movieID := range rts.movieStats {
-		rts.calculateHotnessScore(movieID)
-	}
-
-	// 转换为切片并排序
-	var hotMovies []*MovieHotness
-	for _, hotness := range rts.movieStats {
-		// 获取电影标题
-		if hotness.Title == "" {
-			if title, err := rts.getMovieTitle(hotness.MovieID); err == nil {
-				hotness.Title = title
-			} else {
-				hotness.Title = fmt.Sprintf("电影 %s", hotness.MovieID)
-			}
-		}
-		hotMovies = append(hotMovies, hotness)
+	rts.mu.RLock()
+	hotMovies := make([]*MovieHotness, 0, len(rts.movieStats))
+	for movieID, hotness := range rts.movieStats {
+		movie := *hotness
+		movie.HotnessScore = rts.calculateHotnessScore(movieID, now)
+		hotMovies = append(hotMovies, &movie)
 	}
+	rts.mu.RUnlock()
 
 	// 按热度分数排序
 	sort.Slice(hotMovies, func(i, j int) bool {
@@ -222,31 +319,45 @@ func (rts *RatingTrackerService) GetHotMovies(limit int) ([]*MovieHotness, error
 		hotMovies = hotMovies[:limit]
 	}
 
+	for _, movie := range hotMovies {
+		rts.fillTitle(movie)
+	}
 	return hotMovies, nil
 }
 
-// GetMovieHotness 获取指定电影的热度信息
+// GetMovieHotness 获取指定电影的热度信息（带有当前热度分数的副本）
 func (rts *RatingTrackerService) GetMovieHotness(movieID string) (*MovieHotness, error) {
 	rts.mu.RLock()
-	defer rts.mu.RUnlock()
+	hotness, exists := rts.movieStats[movieID]
+	if !exists {
+		rts.mu.RUnlock()
+		return nil, fmt.Errorf("电影 %s 没有热度数据", movieID)
+	}
+	movie := *hotness
+	movie.HotnessScore = rts.calculateHotnessScore(movieID, time.Now())
+	rts.mu.RUnlock()
 
-	if hotness, exists := rts.movieStats[movieID]; exists {
-		// 重新计算热度分数
-		rts.calculateHotnessScore(movieID)
-
-		// 获取电影标题
-		if hotness.Title == "" {
-			if title, err := rts.getMovieTitle(movieID); err == nil {
-				hotness.Title = title
-			} else {
-				hotness.Title = fmt.Sprintf("电影 %s", movieID)
-			}
-		}
+	rts.fillTitle(&movie)
+	return &movie, nil
+}
 
-		return hotness, nil
+// fillTitle 为没有标题的副本补充标题，查询成功时同时记入追踪数据，避免下次重复查询HBase
+func (rts *RatingTrackerService) fillTitle(movie *MovieHotness) {
+	if movie.Title != "" {
+		return
+	}
+	title, err := rts.getMovieTitle(movie.MovieID)
+	if err != nil {
+		movie.Title = fmt.Sprintf("电影 %s", movie.MovieID)
+		return
 	}
+	movie.Title = title
 
-	return nil, fmt.Errorf("电影 %s 没有热度数据", movieID)
+	rts.mu.Lock()
+	if hotness, exists := rts.movieStats[movie.MovieID]; exists && hotness.Title == "" {
+		hotness.Title = title
+	}
+	rts.mu.Unlock()
 }
 
 // GetRecentWrites 获取最近的写入记录
@@ -266,6 +377,39 @@ func (rts *RatingTrackerService) GetRecentWrites(limit int) []RatingWriteRecord
 	return result
 }
 
+// GetMovieWrites 获取追踪器保留的指定电影的写入记录
+func (rts *RatingTrackerService) GetMovieWrites(movieID string) []RatingWriteRecord {
+	rts.mu.RLock()
+	defer rts.mu.RUnlock()
+
+	var result []RatingWriteRecord
+	for _, record := range rts.writeRecords {
+		if record.MovieID == movieID {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// RecordUserWriteError 记录users表反向写入失败
+func (rts *RatingTrackerService) RecordUserWriteError(userID string, movieIDs []string, err error) {
+	rts.mu.Lock()
+	rts.userWriteErrors += int64(len(movieIDs))
+	rts.lastUserWriteError = fmt.Sprintf("用户 %s (电影 %s): %v", userID, strings.Join(movieIDs, ","), err)
+	rts.mu.Unlock()
+
+	logrus.Warnf("写入用户 %s 的评分到users表失败（电影 %s）: %v", userID, strings.Join(movieIDs, ","), err)
+}
+
+// RecordTagWrite 记录标签写入
+func (rts *RatingTrackerService) RecordTagWrite(movieID, userID, tag, source string) {
+	rts.mu.Lock()
+	rts.tagWrites[source]++
+	rts.mu.Unlock()
+
+	logrus.Debugf("用户 %s 为电影 %s 添加标签 %q（来源 %s）", userID, movieID, tag, source)
+}
+
 // GetWriteStats 获取写入统计信息
 func (rts *RatingTrackerService) GetWriteStats() map[string]interface{} {
 	rts.mu.RLock()
@@ -290,15 +434,138 @@ func (rts *RatingTrackerService) GetWriteStats() map[string]interface{} {
 		sourceStats[record.Source]++
 	}
 
+	tagWrites := make(map[string]int64, len(rts.tagWrites))
+	for source, count := range rts.tagWrites {
+		tagWrites[source] = count
+	}
+
 	return map[string]interface{}{
 		"totalWrites": len(rts.writeRecords),
 		"totalMovies": len(rts.movieStats),
 		"lastHour":    lastHour,
 		"lastDay":     lastDay,
 		"sourceStats": sourceStats,
+		// users表反向写入失败数，非0时可用 /api/system/verify 修复缺失的用户行
+		"userWriteErrors":    rts.userWriteErrors,
+		"lastUserWriteError": rts.lastUserWriteError,
+		"tagWrites":          tagWrites,
 	}
 }
 
+// hotnessExportChunkSize 导出热度快照时每批编码的电影数，每批补充一次标题并刷新输出
+const hotnessExportChunkSize = 200
+
+// ExportSnapshot 将追踪器状态以JSON流式写入w：所有电影的热度信息，以及可选的最近写入记录。
+// 持锁期间只记录电影ID和写入记录的切片（记录写入后不再修改，无需复制），
+// 之后每批hotnessExportChunkSize部电影短暂持锁复制热度信息、批量补充缺失的标题，
+// 编码后刷新w（实现了Flush时），不在内存中复制全部数据
+func (rts *RatingTrackerService) ExportSnapshot(ctx context.Context, w io.Writer, includeWrites bool) error {
+	now := time.Now()
+
+	rts.mu.RLock()
+	movieIDs := make([]string, 0, len(rts.movieStats))
+	for movieID := range rts.movieStats {
+		movieIDs = append(movieIDs, movieID)
+	}
+	records := rts.writeRecords
+	rts.mu.RUnlock()
+
+	sort.Strings(movieIDs)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	flusher, _ := w.(interface{ Flush() })
+	flush := func() error {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	fmt.Fprintf(bw, `{"exportedAt":%q,"totalMovies":%d,"totalRecords":%d,"movies":[`,
+		now.Format(time.RFC3339), len(movieIDs), len(records))
+	for start := 0; start < len(movieIDs); start += hotnessExportChunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		movies := rts.hotnessChunk(ctx, movieIDs[start:min(start+hotnessExportChunkSize, len(movieIDs))], now)
+		for i := range movies {
+			if start+i > 0 {
+				bw.WriteByte(',')
+			}
+			if err := enc.Encode(&movies[i]); err != nil {
+				return err
+			}
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	bw.WriteByte(']')
+
+	if includeWrites {
+		bw.WriteString(`,"writeRecords":[`)
+		for i := range records {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			if err := enc.Encode(&records[i]); err != nil {
+				return err
+			}
+			if (i+1)%hotnessExportChunkSize == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		bw.WriteByte(']')
+	}
+	bw.WriteString("}\n")
+
+	return flush()
+}
+
+// hotnessChunk 复制一批电影的热度信息并计算热度分数，缺失的标题用一次批量读取补充
+func (rts *RatingTrackerService) hotnessChunk(ctx context.Context, movieIDs []string, now time.Time) []MovieHotness {
+	movies := make([]MovieHotness, 0, len(movieIDs))
+	rts.mu.RLock()
+	for _, movieID := range movieIDs {
+		if hotness, exists := rts.movieStats[movieID]; exists {
+			movies = append(movies, *hotness)
+		}
+	}
+	rts.mu.RUnlock()
+
+	var missing []string
+	for i := range movies {
+		movies[i].HotnessScore = computeHotnessScore(&movies[i], now)
+		if movies[i].Title == "" {
+			missing = append(missing, movies[i].MovieID)
+		}
+	}
+	if len(missing) == 0 {
+		return movies
+	}
+
+	infos, err := utils.GetMoviesMultiple(ctx, missing)
+	if err != nil {
+		logrus.Warnf("导出热度快照时读取 %d 部电影的标题失败: %v", len(missing), err)
+		return movies
+	}
+	for i := range movies {
+		if movies[i].Title == "" {
+			movies[i].Title = string(infos[movies[i].MovieID]["info"]["title"])
+		}
+	}
+	return movies
+}
+
 // getMovieTitle 获取电影标题
 func (rts *RatingTrackerService) getMovieTitle(movieID string) (string, error) {
 	ctx := context.Background()
@@ -323,42 +590,84 @@ func (rts *RatingTrackerService) getMovieTitle(movieID string) (string, error) {
 }
 
 // WriteRatingToHBase 写入评分到HBase并记录追踪信息（通用函数）
-func (rts *RatingTrackerService) WriteRatingToHBase(ctx context.Context, movieID, userID string, rating float64, source string) error {
+// 返回isUpdate表示该用户此前已对电影评分、本次为覆盖更新
+func (rts *RatingTrackerService) WriteRatingToHBase(ctx context.Context, movieID, userID string, rating float64, source string) (bool, error) {
 	// 获取HBase客户端
 	client := utils.GetClient().(interface {
 		Put(request *hrpc.Mutate) (*hrpc.Result, error)
+		CheckAndPut(p *hrpc.Mutate, family string, qualifier string, expectedValue []byte) (bool, error)
 	})
 
 	// 生成时间戳
 	timestamp := time.Now().Unix()
 
-	// 构建评分数据值: "{rating}:{userId}:{timestamp}"
-	ratingValue := fmt.Sprintf("%.1f:%s:%d", rating, userID, timestamp)
+	// 构建评分数据值: "{rating}:{userId}:{timestamp}:{source}"
+	ratingValue := utils.RatingValue(rating, userID, timestamp, source)
 
 	// 构建行键: "{movieId}_ratings"
-	rowKey := fmt.Sprintf("%s_ratings", movieID)
+	rowKey := rowkey.MovieRatingsKey(movieID)
+
+	newPut := func() (*hrpc.Mutate, error) {
+		return hrpc.NewPutStr(ctx, utils.MoviesTable(), rowKey, map[string]map[string][]byte{
+			"ratings": {
+				userID: ratingValue,
+			},
+		})
+	}
 
 	// 创建Put请求
-	putRequest, err := hrpc.NewPutStr(ctx, "movies", rowKey, map[string]map[string][]byte{
-		"ratings": {
-			userID: []byte(ratingValue),
-		},
-	})
-
+	putRequest, err := newPut()
 	if err != nil {
-		return fmt.Errorf("创建Put请求失败: %v", err)
+		return false, fmt.Errorf("创建Put请求失败: %v", err)
 	}
 
-	// 执行Put操作
-	_, err = client.Put(putRequest)
+	// 仅当该用户尚无评分时写入（期望值为nil表示列不存在）
+	created, err := client.CheckAndPut(putRequest, "ratings", userID, nil)
 	if err != nil {
-		return fmt.Errorf("写入HBase失败: %v", err)
+		return false, fmt.Errorf("写入HBase失败: %v", err)
+	}
+
+	isUpdate := !created
+	var oldRating float64
+	hasOldRating := false
+	if isUpdate {
+		// 记下旧评分供增量更新_stats，读取失败时交给阈值触发的重新计算修正
+		oldRating, hasOldRating, err = models.GetUserMovieRating(ctx, movieID, userID)
+		if err != nil {
+			logrus.Warnf("读取用户 %s 对电影 %s 的旧评分失败: %v", userID, movieID, err)
+		}
+
+		// 用户已有评分，覆盖旧值
+		putRequest, err = newPut()
+		if err != nil {
+			return false, fmt.Errorf("创建Put请求失败: %v", err)
+		}
+		if _, err = client.Put(putRequest); err != nil {
+			return false, fmt.Errorf("写入HBase失败: %v", err)
+		}
+	}
+
+	// 同步写入users表的反向索引；电影行是评分的权威数据，这里失败只记录错误不回滚
+	userValues := map[string][]byte{movieID: utils.UserRatingValue(rating, movieID, timestamp)}
+	if err := utils.PutUserMovieRatings(ctx, userID, userValues); err != nil {
+		rts.RecordUserWriteError(userID, []string{movieID}, err)
+	}
+
+	// 增量更新_stats（只读写_stats行），完整重新计算只在达到阈值时进行
+	var statsErr error
+	if !isUpdate {
+		statsErr = models.IncrementalRatingUpdate(ctx, movieID, rating)
+	} else if hasOldRating {
+		statsErr = models.IncrementalRatingReplace(ctx, movieID, oldRating, rating)
+	}
+	if statsErr != nil {
+		logrus.Warnf("增量更新电影 %s 的评分统计失败: %v", movieID, statsErr)
 	}
 
 	// 记录追踪信息
-	rts.RecordRatingWrite(movieID, userID, rating, source)
+	rts.RecordRatingWrite(movieID, userID, rating, source, isUpdate)
 
-	return nil
+	return isUpdate, nil
 }
 
 // GetMovieRatingThresholdStatus 获取电影评分阈值状态
@@ -367,19 +676,17 @@ func (rts *RatingTrackerService) GetMovieRatingThresholdStatus(movieID string) m
 	defer rts.mu.RUnlock()
 
 	if hotness, exists := rts.movieStats[movieID]; exists {
-		threshold := int(float64(hotness.LastRatingCount) * 0.1)
-		if threshold < 1 {
-			threshold = 1
-		}
+		threshold := recalcThreshold(hotness.LastRatingCount)
+		percent := config.GetConfig().GetRecalcThresholdPercent(hotness.LastRatingCount)
 
 		return map[string]interface{}{
-			"movieId":              movieID,
-			"lastRatingCount":      hotness.LastRatingCount,
-			"newWritesSinceCalc":   hotness.NewWritesSinceCalc,
-			"threshold":            threshold,
-			"thresholdPercentage": "10%",
-			"needsRecalculation":   hotness.NewWritesSinceCalc >= threshold,
-			"progress":             fmt.Sprintf("%d/%d", hotness.NewWritesSinceCalc, threshold),
+			"movieId":             movieID,
+			"lastRatingCount":     hotness.LastRatingCount,
+			"newWritesSinceCalc":  hotness.NewWritesSinceCalc,
+			"threshold":           threshold,
+			"thresholdPercentage": fmt.Sprintf("%g%%", percent),
+			"needsRecalculation":  hotness.NewWritesSinceCalc >= threshold,
+			"progress":            fmt.Sprintf("%d/%d", hotness.NewWritesSinceCalc, threshold),
 		}
 	}
 
@@ -389,5 +696,204 @@ func (rts *RatingTrackerService) GetMovieRatingThresholdStatus(movieID string) m
 	}
 }
 
+// recalcThreshold 计算触发重新计算所需的新增评分数（按配置的百分比和最小值）
+func recalcThreshold(lastRatingCount int) int {
+	cfg := config.GetConfig()
+	return thresholdFor(lastRatingCount, cfg.GetRecalcThresholdPercent(lastRatingCount), cfg.GetRecalcMinWrites())
+}
+
+// thresholdFor 计算 lastRatingCount*percent% 向下取整，且不少于minWrites
+func thresholdFor(lastRatingCount int, percent float64, minWrites int) int {
+	threshold := int(float64(lastRatingCount) * percent / 100)
+	if threshold < minWrites {
+		threshold = minWrites
+	}
+	return threshold
+}
+
+// ThresholdStatus 电影的重新计算阈值进度
+type ThresholdStatus struct {
+	MovieID            string  `json:"movieId"`
+	Title              string  `json:"title"`
+	LastRatingCount    int     `json:"lastRatingCount"`
+	NewWritesSinceCalc int     `json:"newWritesSinceCalc"`
+	Threshold          int     `json:"threshold"`
+	ThresholdPercent   float64 `json:"thresholdPercent"`
+	ProgressRatio      float64 `json:"progressRatio"` // 新增写入数/阈值，>=1表示需要重新计算
+	NeedsRecalculation bool    `json:"needsRecalculation"`
+}
+
+// GetAllThresholdStatuses 获取所有追踪电影的阈值进度，按接近触发的程度降序排列
+// needsRecalcOnly为true时只返回已达到阈值的电影，limit<=0表示不限制数量
+func (rts *RatingTrackerService) GetAllThresholdStatuses(limit int, needsRecalcOnly bool) []ThresholdStatus {
+	rts.mu.RLock()
+	statuses := make([]ThresholdStatus, 0, len(rts.movieStats))
+	for movieID, hotness := range rts.movieStats {
+		threshold := recalcThreshold(hotness.LastRatingCount)
+		status := ThresholdStatus{
+			MovieID:            movieID,
+			Title:              hotness.Title,
+			LastRatingCount:    hotness.LastRatingCount,
+			NewWritesSinceCalc: hotness.NewWritesSinceCalc,
+			Threshold:          threshold,
+			ThresholdPercent:   config.GetConfig().GetRecalcThresholdPercent(hotness.LastRatingCount),
+			ProgressRatio:      float64(hotness.NewWritesSinceCalc) / float64(threshold),
+			NeedsRecalculation: hotness.NewWritesSinceCalc >= threshold,
+		}
+		if needsRecalcOnly && !status.NeedsRecalculation {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	rts.mu.RUnlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].ProgressRatio != statuses[j].ProgressRatio {
+			return statuses[i].ProgressRatio > statuses[j].ProgressRatio
+		}
+		return statuses[i].MovieID < statuses[j].MovieID
+	})
+
+	if limit > 0 && len(statuses) > limit {
+		statuses = statuses[:limit]
+	}
+	return statuses
+}
+
+const (
+	surgeWindow         = 5 * time.Minute // 比较的窗口长度
+	surgeCheckInterval  = time.Minute     // 检查间隔
+	surgeMinWrites      = 10              // 当前窗口写入数低于此值时不视为激增
+	surgeEventBuffer    = 100             // 事件通道缓冲，订阅者跟不上时丢弃事件
+	surgeRateMultiplier = 2.0             // 速率超过前一窗口的倍数即视为激增
+)
+
+// SurgeEvent 电影写入速率激增事件，速率单位为每分钟写入数
+type SurgeEvent struct {
+	MovieID      string    `json:"movieId"`
+	PreviousRate float64   `json:"previousRate"` // 前一个5分钟窗口的速率
+	CurrentRate  float64   `json:"currentRate"`  // 最近5分钟的速率
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// SurgeDetector 按分钟统计每部电影的写入数，定期比较最近5分钟与之前5分钟的写入速率
+type SurgeDetector struct {
+	mu        sync.Mutex
+	buckets   map[string]map[int64]int // 电影ID -> 分钟(Unix分钟数) -> 写入数
+	lastAlert map[string]time.Time     // 同一部电影在一个窗口内只告警一次
+	events    chan SurgeEvent
+	startOnce sync.Once
+}
+
+// NewSurgeDetector 创建激增检测器
+func NewSurgeDetector() *SurgeDetector {
+	return &SurgeDetector{
+		buckets:   make(map[string]map[int64]int),
+		lastAlert: make(map[string]time.Time),
+		events:    make(chan SurgeEvent, surgeEventBuffer),
+	}
+}
+
+// Events 激增事件通道，供通知系统订阅
+func (sd *SurgeDetector) Events() <-chan SurgeEvent {
+	return sd.events
+}
+
+// Record 记录一次写入
+func (sd *SurgeDetector) Record(movieID string, t time.Time) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	minutes, ok := sd.buckets[movieID]
+	if !ok {
+		minutes = make(map[int64]int)
+		sd.buckets[movieID] = minutes
+	}
+	minutes[t.Unix()/60]++
+}
+
+// Start 启动后台检查，每分钟比较一次，重复调用无效
+func (sd *SurgeDetector) Start(ctx context.Context) {
+	sd.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(surgeCheckInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					sd.Check(now)
+				}
+			}
+		}()
+	})
+}
+
+// Check 比较每部电影最近5分钟与之前5分钟的写入速率，速率翻倍以上时发出事件，并清理过期的分钟统计
+func (sd *SurgeDetector) Check(now time.Time) []SurgeEvent {
+	windowMinutes := int64(surgeWindow / time.Minute)
+	// 只统计已结束的分钟，避免当前分钟未写满导致速率偏低
+	current := now.Unix()/60 - 1
+	currentStart := current - windowMinutes + 1
+	previousStart := currentStart - windowMinutes
+
+	var events []SurgeEvent
+
+	sd.mu.Lock()
+	for movieID, minutes := range sd.buckets {
+		var currentWrites, previousWrites int
+		for minute, count := range minutes {
+			switch {
+			case minute < previousStart:
+				delete(minutes, minute)
+			case minute < currentStart:
+				previousWrites += count
+			case minute <= current:
+				currentWrites += count
+			}
+		}
+		if len(minutes) == 0 {
+			delete(sd.buckets, movieID)
+			delete(sd.lastAlert, movieID)
+			continue
+		}
+
+		// 前一窗口没有写入时无法计算倍数，新出现的电影由热度统计体现
+		if currentWrites < surgeMinWrites || previousWrites == 0 {
+			continue
+		}
+		previousRate := float64(previousWrites) / float64(windowMinutes)
+		currentRate := float64(currentWrites) / float64(windowMinutes)
+		if currentRate <= previousRate*surgeRateMultiplier {
+			continue
+		}
+		if last, ok := sd.lastAlert[movieID]; ok && now.Sub(last) < surgeWindow {
+			continue
+		}
+		sd.lastAlert[movieID] = now
+
+		events = append(events, SurgeEvent{
+			MovieID:      movieID,
+			PreviousRate: previousRate,
+			CurrentRate:  currentRate,
+			Timestamp:    now,
+		})
+	}
+	sd.mu.Unlock()
+
+	// 未配置通知时也以WARN级别记录
+	for _, event := range events {
+		logrus.Warnf("电影 %s 写入激增: %.1f/分钟 -> %.1f/分钟", event.MovieID, event.PreviousRate, event.CurrentRate)
+		select {
+		case sd.events <- event:
+		default:
+			logrus.Debugf("激增事件通道已满，丢弃电影 %s 的事件", event.MovieID)
+		}
+	}
+	return events
+}
+
 // 全局实例
 var GlobalRatingTracker = NewRatingTrackerService()
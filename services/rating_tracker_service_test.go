@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"gohbase/utils"
+	"gohbase/utils/hbase"
+	"gohbase/utils/hbase/hbasetest"
+	"gohbase/utils/hbase/rowkey"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+func TestThresholdFor(t *testing.T) {
+	tests := []struct {
+		lastRatingCount int
+		percent         float64
+		minWrites       int
+		want            int
+	}{
+		{1000, 10, 1, 100},
+		{1000, 5, 1, 50},
+		{1000, 2.5, 1, 25},
+		{1000, 25, 1, 250},
+		{999, 10, 1, 99}, // 向下取整
+		{5, 10, 1, 1},    // 不足1时取最小值
+		{0, 10, 1, 1},
+		{200, 5, 20, 20},
+		{1000, 5, 20, 50},
+		{1000, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		if got := thresholdFor(tt.lastRatingCount, tt.percent, tt.minWrites); got != tt.want {
+			t.Errorf("thresholdFor(%d, %g, %d) = %d, want %d",
+				tt.lastRatingCount, tt.percent, tt.minWrites, got, tt.want)
+		}
+	}
+}
+
+// recalcCountingClient 统计读取_ratings行（每次重新计算一次）的次数和最大并发数
+type recalcCountingClient struct {
+	*hbasetest.Client
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	calls     int
+}
+
+func (c *recalcCountingClient) Get(get *hrpc.Get) (*hrpc.Result, error) {
+	if string(get.Key()) != rowkey.MovieRatingsKey("1") {
+		return c.Client.Get(get)
+	}
+	c.mu.Lock()
+	c.calls++
+	c.active++
+	if c.active > c.maxActive {
+		c.maxActive = c.active
+	}
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		c.active--
+		c.mu.Unlock()
+	}()
+	return c.Client.Get(get)
+}
+
+// TestRecalculationBurstCoalesced 同一电影的一批写入每次都达到阈值，重新计算不并发执行，运行期间的触发合并
+func TestRecalculationBurstCoalesced(t *testing.T) {
+	fake := hbasetest.New()
+	fake.Latency = 20 * time.Millisecond
+	fake.SetRow(utils.MoviesTable(), rowkey.MovieRatingsKey("1"), map[string]map[string][]byte{
+		"ratings": {"1": []byte("3.0:1:1000"), "2": []byte("4.0:2:1000"), "3": []byte("5.0:3:1000")},
+	})
+	client := &recalcCountingClient{Client: fake}
+	hbase.SetClient(client)
+
+	// 没有_stats行时评分总数为0，阈值取最小值1，每次写入都触发重新计算
+	rts := NewRatingTrackerService()
+	const burst = 30
+	var wg sync.WaitGroup
+	for i := 0; i < burst; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rts.RecordRatingWrite("1", strconv.Itoa(100+i), 4.0, "test", false)
+		}(i)
+	}
+	wg.Wait()
+
+	waitRecalcIdle(t, rts)
+
+	client.mu.Lock()
+	calls, maxActive := client.calls, client.maxActive
+	client.mu.Unlock()
+	if maxActive != 1 {
+		t.Errorf("同一电影的重新计算最大并发数 = %d, want 1", maxActive)
+	}
+	if calls == 0 || calls > burst/3 {
+		t.Errorf("%d次触发执行了%d次重新计算，应合并为少数几次", burst, calls)
+	}
+
+	hotness, err := rts.GetMovieHotness("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hotness.LastRatingCount != 3 || hotness.WriteCount != burst {
+		t.Errorf("LastRatingCount = %d, WriteCount = %d, want 3, %d", hotness.LastRatingCount, hotness.WriteCount, burst)
+	}
+}
+
+// waitRecalcIdle 等待后台重新计算全部完成
+func waitRecalcIdle(t *testing.T, rts *RatingTrackerService) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rts.mu.RLock()
+		done := len(rts.recalcInFlight) == 0 && len(rts.recalcPending) == 0
+		rts.mu.RUnlock()
+		if done {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("重新计算未在5秒内完成")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestWriteRatingReRating 同一用户重新评分时评分数保持为1，平均分随新评分变化
+func TestWriteRatingReRating(t *testing.T) {
+	client := hbasetest.New()
+	hbase.SetClient(client)
+	rts := NewRatingTrackerService()
+	ctx := context.Background()
+
+	steps := []struct {
+		rating       float64
+		wantIsUpdate bool
+		wantAvg      string
+	}{
+		{3.0, false, "3.000000"},
+		{5.0, true, "5.000000"},
+		{1.5, true, "1.500000"},
+	}
+	for i, step := range steps {
+		isUpdate, err := rts.WriteRatingToHBase(ctx, "7", "42", step.rating, "api")
+		if err != nil {
+			t.Fatalf("第%d次评分失败: %v", i+1, err)
+		}
+		waitRecalcIdle(t, rts)
+		if isUpdate != step.wantIsUpdate {
+			t.Errorf("第%d次评分 isUpdate = %v, want %v", i+1, isUpdate, step.wantIsUpdate)
+		}
+
+		stats := client.Row(utils.MoviesTable(), rowkey.MovieStatsKey("7"))["info"]
+		if got := string(stats["rating_count"]); got != "1" {
+			t.Errorf("第%d次评分后 rating_count = %q, want 1", i+1, got)
+		}
+		if got := string(stats["avg_rating"]); got != step.wantAvg {
+			t.Errorf("第%d次评分后 avg_rating = %q, want %s", i+1, got, step.wantAvg)
+		}
+		if ratings := client.Row(utils.MoviesTable(), rowkey.MovieRatingsKey("7"))["ratings"]; len(ratings) != 1 {
+			t.Errorf("第%d次评分后_ratings行有%d个评分, want 1", i+1, len(ratings))
+		}
+	}
+
+	hotness, err := rts.GetMovieHotness("7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hotness.WriteCount != 3 || hotness.UpdateCount != 2 {
+		t.Errorf("WriteCount = %d, UpdateCount = %d, want 3, 2", hotness.WriteCount, hotness.UpdateCount)
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gohbase/models"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRecomputeWorkers = 4
+	maxRecomputeWorkers     = 16
+	defaultRecomputeRate    = 50 // 每秒最多处理的电影数
+	maxRecomputeRate        = 1000
+)
+
+// ErrRecomputeRunning 已有回填任务在运行
+var ErrRecomputeRunning = errors.New("stats回填任务正在运行")
+
+// RecomputeOptions 回填任务参数
+type RecomputeOptions struct {
+	ResumeFrom string // 从该ID之后继续（不含该ID），为空表示从头开始
+	Workers    int    // 并发数
+	Rate       int    // 每秒最多处理的电影数
+}
+
+// RecomputeStatus 回填任务进度
+type RecomputeStatus struct {
+	Status        string  `json:"status"` // idle, running, completed, failed
+	StartedAt     string  `json:"startedAt,omitempty"`
+	FinishedAt    string  `json:"finishedAt,omitempty"`
+	ResumeFrom    string  `json:"resumeFrom,omitempty"`
+	Workers       int     `json:"workers"`
+	Rate          int     `json:"rate"`
+	Total         int     `json:"total"`
+	Processed     int     `json:"processed"`
+	Succeeded     int     `json:"succeeded"`
+	Failed        int     `json:"failed"`               // 包括没有评分数据的电影
+	Checkpoint    string  `json:"checkpoint,omitempty"` // 该ID及之前的电影均已处理，中断后可从此处恢复
+	RowsPerSecond float64 `json:"rowsPerSecond"`
+	Duration      string  `json:"duration,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// StatsRecomputeService 全量_stats回填服务
+type StatsRecomputeService struct {
+	mu        sync.Mutex
+	status    RecomputeStatus
+	startedAt time.Time
+	// 按ID顺序分发，完成状态用于推进checkpoint
+	ids       []string
+	done      []bool
+	watermark int
+}
+
+// NewStatsRecomputeService 创建回填服务
+func NewStatsRecomputeService() *StatsRecomputeService {
+	return &StatsRecomputeService{status: RecomputeStatus{Status: "idle"}}
+}
+
+// Start 在后台启动全量回填，已有任务在运行时返回ErrRecomputeRunning
+func (s *StatsRecomputeService) Start(ctx context.Context, opts RecomputeOptions) (RecomputeStatus, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultRecomputeWorkers
+	}
+	if opts.Workers > maxRecomputeWorkers {
+		opts.Workers = maxRecomputeWorkers
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = defaultRecomputeRate
+	}
+	if opts.Rate > maxRecomputeRate {
+		opts.Rate = maxRecomputeRate
+	}
+
+	s.mu.Lock()
+	if s.status.Status == "running" {
+		status := s.snapshotLocked()
+		s.mu.Unlock()
+		return status, ErrRecomputeRunning
+	}
+	s.startedAt = time.Now()
+	s.status = RecomputeStatus{
+		Status:     "running",
+		StartedAt:  s.startedAt.Format(time.RFC3339),
+		ResumeFrom: opts.ResumeFrom,
+		Workers:    opts.Workers,
+		Rate:       opts.Rate,
+		Checkpoint: opts.ResumeFrom,
+	}
+	s.ids = nil
+	s.done = nil
+	s.watermark = 0
+	status := s.snapshotLocked()
+	s.mu.Unlock()
+
+	go s.run(ctx, opts)
+	return status, nil
+}
+
+// Status 获取回填进度
+func (s *StatsRecomputeService) Status() RecomputeStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+// LastCheckpoint 上次任务的checkpoint，用于中断后恢复
+func (s *StatsRecomputeService) LastCheckpoint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status.Checkpoint
+}
+
+// RecomputeOne 同步回填单部电影
+func (s *StatsRecomputeService) RecomputeOne(ctx context.Context, movieID string) (float64, int, error) {
+	return models.CalculateAndStoreMovieAvgRating(ctx, movieID)
+}
+
+// run 列出电影ID并用有限并发、限速的方式逐个回填
+func (s *StatsRecomputeService) run(ctx context.Context, opts RecomputeOptions) {
+	ids, err := models.ListAllMovieIDs(ctx)
+	if err != nil {
+		s.finish(err)
+		return
+	}
+
+	if opts.ResumeFrom != "" {
+		start := 0
+		for start < len(ids) && models.CompareMovieIDs(ids[start], opts.ResumeFrom) <= 0 {
+			start++
+		}
+		ids = ids[start:]
+	}
+
+	s.mu.Lock()
+	s.ids = ids
+	s.done = make([]bool, len(ids))
+	s.status.Total = len(ids)
+	s.mu.Unlock()
+
+	logrus.Infof("开始回填电影stats: 共 %d 部, 并发 %d, 限速 %d/秒", len(ids), opts.Workers, opts.Rate)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				_, _, err := models.CalculateAndStoreMovieAvgRating(ctx, ids[idx])
+				if err != nil {
+					logrus.Debugf("回填电影 %s stats失败: %v", ids[idx], err)
+				}
+				s.markDone(idx, err == nil)
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.Rate))
+	defer ticker.Stop()
+
+dispatch:
+	for idx := range ids {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case <-ticker.C:
+		}
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	s.finish(ctx.Err())
+}
+
+// markDone 记录单部电影的处理结果并推进checkpoint
+func (s *StatsRecomputeService) markDone(idx int, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.Processed++
+	if ok {
+		s.status.Succeeded++
+	} else {
+		s.status.Failed++
+	}
+
+	s.done[idx] = true
+	for s.watermark < len(s.done) && s.done[s.watermark] {
+		s.status.Checkpoint = s.ids[s.watermark]
+		s.watermark++
+	}
+}
+
+// finish 结束任务并记录最终状态
+func (s *StatsRecomputeService) finish(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status.FinishedAt = time.Now().Format(time.RFC3339)
+	if err != nil {
+		s.status.Status = "failed"
+		s.status.Error = err.Error()
+		logrus.Errorf("回填电影stats失败: %v (checkpoint=%s)", err, s.status.Checkpoint)
+	} else {
+		s.status.Status = "completed"
+		logrus.Infof("回填电影stats完成: 处理 %d 部, 成功 %d, 失败 %d, 耗时 %v",
+			s.status.Processed, s.status.Succeeded, s.status.Failed, time.Since(s.startedAt))
+	}
+	s.status.Duration = time.Since(s.startedAt).String()
+	s.status.RowsPerSecond = s.rowsPerSecondLocked(time.Since(s.startedAt))
+}
+
+// snapshotLocked 返回进度副本，运行中时实时计算速率（调用方持有mu）
+func (s *StatsRecomputeService) snapshotLocked() RecomputeStatus {
+	status := s.status
+	if status.Status == "running" {
+		elapsed := time.Since(s.startedAt)
+		status.Duration = elapsed.String()
+		status.RowsPerSecond = s.rowsPerSecondLocked(elapsed)
+	}
+	return status
+}
+
+// rowsPerSecondLocked 每秒处理的电影数
+func (s *StatsRecomputeService) rowsPerSecondLocked(elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(s.status.Processed) / elapsed.Seconds()
+}
+
+// GlobalStatsRecompute 全局回填服务实例
+var GlobalStatsRecompute = NewStatsRecomputeService()
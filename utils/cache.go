@@ -3,15 +3,36 @@ package utils
 import (
 	"gohbase/config"
 	"gohbase/utils/cache"
+
+	"github.com/sirupsen/logrus"
 )
 
 // Cache 全局缓存实例
-var Cache *cache.MemoryCache
+var Cache cache.Cache
 
-// InitCache 初始化缓存系统
+// InitCache 初始化缓存系统，cache.backend为redis时使用Redis，
+// 连接失败则回退到内存缓存
 func InitCache(cfg *config.Config) {
-	Cache = cache.NewMemoryCache(
+	if cfg.GetCacheBackend() == cache.BackendRedis {
+		redisCache, err := cache.NewRedisCache(cache.RedisOptions{
+			Addr:      cfg.GetRedisAddr(),
+			Password:  cfg.Cache.RedisPassword,
+			DB:        cfg.Cache.RedisDB,
+			KeyPrefix: cfg.GetRedisKeyPrefix(),
+		}, cfg.GetCacheDefaultExpiration())
+		if err == nil {
+			redisCache.SetExpirationJitter(cfg.GetCacheExpirationJitterPct())
+			Cache = redisCache
+			logrus.Infof("使用Redis缓存 [%s]", cfg.GetRedisAddr())
+			return
+		}
+		logrus.Errorf("初始化Redis缓存失败，回退到内存缓存: %v", err)
+	}
+
+	memoryCache := cache.NewMemoryCache(
 		cfg.GetCacheDefaultExpiration(),
 		cfg.GetCacheCleanupInterval(),
 	)
+	memoryCache.SetExpirationJitter(cfg.GetCacheExpirationJitterPct())
+	Cache = memoryCache
 }
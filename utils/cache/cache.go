@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// 缓存后端名称
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Cache 缓存接口，由MemoryCache和RedisCache实现。
+// Set/SetWithExpiration的过期时间为0表示使用默认过期时间，负数表示永不过期。
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	SetWithExpiration(key string, value interface{}, duration time.Duration)
+	Delete(key string)
+	DeletePrefix(prefix string) int
+	Flush()
+	Stats() CacheStats
+	SetExpirationJitter(pct float64)
+	SetDefaultExpiration(duration time.Duration)
+}
+
+var (
+	_ Cache = (*MemoryCache)(nil)
+	_ Cache = (*RedisCache)(nil)
+)
+
+// clampJitter 将抖动比例限制在[0, 1]
+func clampJitter(pct float64) float64 {
+	if pct < 0 {
+		return 0
+	}
+	if pct > 1 {
+		return 1
+	}
+	return pct
+}
+
+// applyJitter 按抖动比例随机调整过期时长
+func applyJitter(duration time.Duration, pct float64) time.Duration {
+	if pct <= 0 {
+		return duration
+	}
+	delta := (rand.Float64()*2 - 1) * pct * float64(duration)
+	return duration + time.Duration(delta)
+}
@@ -8,6 +8,7 @@ import (
 type CacheItem struct {
 	Value      interface{}
 	Expiration int64
+	Created    int64 // 写入时间（UnixNano）
 }
 
 // Expired 判断缓存项是否已过期
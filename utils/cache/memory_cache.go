@@ -1,9 +1,12 @@
 package cache
 
 import (
+	"encoding/json"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 )
 
 // MemoryCache 内存缓存实现
@@ -12,10 +15,24 @@ type MemoryCache struct {
 	mu                sync.RWMutex
 	defaultExpiration time.Duration
 	cleanupInterval   time.Duration
+	jitterPct         float64 // 过期时间随机抖动比例，避免同时写入的缓存项同时过期
 	stopCleanup       chan bool
-	hitCount          int64        // 缓存命中计数
-	missCount         int64        // 缓存未命中计数
-	hitCountMu        sync.RWMutex // 命中计数锁，避免与主缓存锁冲突
+	hitCount          int64 // 缓存命中计数（原子操作）
+	missCount         int64 // 缓存未命中计数（原子操作）
+}
+
+// CacheStats 缓存统计信息
+type CacheStats struct {
+	Backend         string         `json:"backend"` // memory或redis
+	ItemCount       int            `json:"item_count"`
+	Expired         int            `json:"expired"` // 已过期但尚未被清理的缓存项
+	HitCount        int64          `json:"hit_count"`
+	MissCount       int64          `json:"miss_count"`
+	HitRate         float64        `json:"hit_rate"`    // 百分比
+	TotalBytes      int64          `json:"total_bytes"` // 估算的内存占用
+	OldestEntry     time.Time      `json:"oldest_entry"`
+	TypeStats       map[string]int `json:"type_stats"` // 按键前缀统计的缓存项数量
+	CleanupInterval string         `json:"cleanup_interval"`
 }
 
 // NewMemoryCache 创建新的内存缓存
@@ -25,8 +42,6 @@ func NewMemoryCache(defaultExpiration, cleanupInterval time.Duration) *MemoryCac
 		defaultExpiration: defaultExpiration,
 		cleanupInterval:   cleanupInterval,
 		stopCleanup:       make(chan bool),
-		hitCount:          0,
-		missCount:         0,
 	}
 
 	// 如果清理间隔大于0，启动后台清理协程
@@ -37,28 +52,46 @@ func NewMemoryCache(defaultExpiration, cleanupInterval time.Duration) *MemoryCac
 	return cache
 }
 
+// SetExpirationJitter 设置过期时间的随机抖动比例，如0.1表示±10%，0表示不抖动
+func (c *MemoryCache) SetExpirationJitter(pct float64) {
+	c.mu.Lock()
+	c.jitterPct = clampJitter(pct)
+	c.mu.Unlock()
+}
+
+// SetDefaultExpiration 设置默认过期时间，只影响之后写入的缓存项
+func (c *MemoryCache) SetDefaultExpiration(duration time.Duration) {
+	c.mu.Lock()
+	c.defaultExpiration = duration
+	c.mu.Unlock()
+}
+
+// jitter 按抖动比例随机调整过期时长（调用方持有mu）
+func (c *MemoryCache) jitter(duration time.Duration) time.Duration {
+	return applyJitter(duration, c.jitterPct)
+}
+
 // Set 设置缓存项，使用默认过期时间
 func (c *MemoryCache) Set(key string, value interface{}) {
-	c.SetWithExpiration(key, value, c.defaultExpiration)
+	c.SetWithExpiration(key, value, 0)
 }
 
 // SetWithExpiration 设置缓存项，指定过期时间
 func (c *MemoryCache) SetWithExpiration(key string, value interface{}, duration time.Duration) {
 	var expiration int64
 
+	c.mu.Lock()
 	if duration == 0 {
 		// 0 表示使用默认过期时间
 		duration = c.defaultExpiration
 	}
-
 	if duration > 0 {
-		expiration = time.Now().Add(duration).UnixNano()
+		expiration = time.Now().Add(c.jitter(duration)).UnixNano()
 	}
-
-	c.mu.Lock()
 	c.items[key] = CacheItem{
 		Value:      value,
 		Expiration: expiration,
+		Created:    time.Now().UnixNano(),
 	}
 	c.mu.Unlock()
 }
@@ -81,16 +114,12 @@ func (c *MemoryCache) Get(key string) (interface{}, bool) {
 
 // recordHit 记录缓存命中
 func (c *MemoryCache) recordHit() {
-	c.hitCountMu.Lock()
-	c.hitCount++
-	c.hitCountMu.Unlock()
+	atomic.AddInt64(&c.hitCount, 1)
 }
 
 // recordMiss 记录缓存未命中
 func (c *MemoryCache) recordMiss() {
-	c.hitCountMu.Lock()
-	c.missCount++
-	c.hitCountMu.Unlock()
+	atomic.AddInt64(&c.missCount, 1)
 }
 
 // Delete 删除缓存项
@@ -100,6 +129,21 @@ func (c *MemoryCache) Delete(key string) {
 	c.mu.Unlock()
 }
 
+// DeletePrefix 删除所有以prefix开头的缓存项，返回删除数量
+func (c *MemoryCache) DeletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for k := range c.items {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.items, k)
+			deleted++
+		}
+	}
+	return deleted
+}
+
 // Flush 清空所有缓存项
 func (c *MemoryCache) Flush() {
 	c.mu.Lock()
@@ -141,55 +185,66 @@ func (c *MemoryCache) deleteExpired() {
 	}
 }
 
-// Stats 获取缓存统计信息
-func (c *MemoryCache) Stats() map[string]interface{} {
+// Stats 获取缓存统计信息。内存占用为估算值，统计在复制的快照上进行，不长时间持有锁。
+func (c *MemoryCache) Stats() CacheStats {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
+	items := make(map[string]CacheItem, len(c.items))
+	for key, item := range c.items {
+		items[key] = item
+	}
+	c.mu.RUnlock()
 
-	// 获取命中率统计
-	c.hitCountMu.RLock()
-	hits := c.hitCount
-	misses := c.missCount
-	c.hitCountMu.RUnlock()
+	hits := atomic.LoadInt64(&c.hitCount)
+	misses := atomic.LoadInt64(&c.missCount)
 
-	// 计算命中率
-	var hitRate float64
-	totalRequests := hits + misses
-	if totalRequests > 0 {
-		hitRate = float64(hits) / float64(totalRequests) * 100
+	stats := CacheStats{
+		Backend:         BackendMemory,
+		ItemCount:       len(items),
+		HitCount:        hits,
+		MissCount:       misses,
+		TypeStats:       make(map[string]int),
+		CleanupInterval: c.cleanupInterval.String(),
 	}
 
-	// 统计缓存项数量
-	total := len(c.items)
-
-	// 分析键类型统计
-	typeStats := make(map[string]int)
-	for key := range c.items {
-		// 根据键前缀分类
-		parts := strings.Split(key, ":")
-		if len(parts) > 0 {
-			prefix := parts[0]
-			typeStats[prefix]++
-		}
+	// 计算命中率
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total) * 100
 	}
 
-	// 统计过期项
 	now := time.Now().UnixNano()
-	expired := 0
-	for _, item := range c.items {
+	var oldest int64
+	for key, item := range items {
+		// 根据键前缀分类
+		stats.TypeStats[strings.Split(key, ":")[0]]++
+
 		if item.Expiration > 0 && now > item.Expiration {
-			expired++
+			stats.Expired++
+		}
+		if item.Created > 0 && (oldest == 0 || item.Created < oldest) {
+			oldest = item.Created
 		}
+		stats.TotalBytes += int64(unsafe.Sizeof(item)) + int64(len(key)) + estimateValueSize(item.Value)
 	}
+	if oldest > 0 {
+		stats.OldestEntry = time.Unix(0, oldest)
+	}
+
+	return stats
+}
 
-	return map[string]interface{}{
-		"total":            total,
-		"expired":          expired,
-		"hit_count":        hits,
-		"miss_count":       misses,
-		"hit_rate":         hitRate,
-		"type_stats":       typeStats,
-		"memory_size":      "未计算", // 计算内存占用较复杂，暂不实现
-		"cleanup_interval": c.cleanupInterval.String(),
+// estimateValueSize 估算缓存值占用的字节数：字符串和字节切片按长度计算，
+// 其他类型按JSON序列化后的长度近似（包含指针指向的数据）
+func estimateValueSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return int64(len(data))
 	}
+	return int64(unsafe.Sizeof(value))
 }
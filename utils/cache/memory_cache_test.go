@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// expirationSpread 返回缓存项过期时间的最早值和最晚值
+func expirationSpread(c *MemoryCache) (earliest, latest int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, item := range c.items {
+		if earliest == 0 || item.Expiration < earliest {
+			earliest = item.Expiration
+		}
+		if item.Expiration > latest {
+			latest = item.Expiration
+		}
+	}
+	return earliest, latest
+}
+
+// TestMemoryCacheExpirationJitter 同时写入100个相同TTL的缓存项，过期时间分散在±jitter范围内
+func TestMemoryCacheExpirationJitter(t *testing.T) {
+	const ttl = time.Minute
+	c := NewMemoryCache(ttl, 0)
+	c.SetExpirationJitter(0.1)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	end := time.Now()
+
+	earliest, latest := expirationSpread(c)
+	if spread := time.Duration(latest - earliest); spread < time.Millisecond {
+		t.Errorf("100个缓存项的过期时间相差 %v，应分散到不同的毫秒", spread)
+	}
+	if lower := start.Add(ttl - ttl/10).UnixNano(); earliest < lower {
+		t.Errorf("最早过期时间早于TTL-10%%: %v", time.Duration(lower-earliest))
+	}
+	if upper := end.Add(ttl + ttl/10).UnixNano(); latest > upper {
+		t.Errorf("最晚过期时间晚于TTL+10%%: %v", time.Duration(latest-upper))
+	}
+}
+
+func TestMemoryCacheWithoutJitter(t *testing.T) {
+	const ttl = time.Minute
+	c := NewMemoryCache(ttl, 0)
+	c.SetExpirationJitter(0)
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		c.Set(strconv.Itoa(i), i)
+	}
+	end := time.Now()
+
+	earliest, latest := expirationSpread(c)
+	if earliest < start.Add(ttl).UnixNano() || latest > end.Add(ttl).UnixNano() {
+		t.Errorf("不抖动时过期时间应为写入时间+TTL")
+	}
+}
+
+func TestApplyJitter(t *testing.T) {
+	tests := []struct {
+		pct      float64
+		min, max time.Duration
+	}{
+		{0, time.Minute, time.Minute},
+		{-0.5, time.Minute, time.Minute},
+		{0.1, 54 * time.Second, 66 * time.Second},
+		{clampJitter(5), 0, 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			if got := applyJitter(time.Minute, tt.pct); got < tt.min || got > tt.max {
+				t.Fatalf("applyJitter(1m, %v) = %v, want [%v, %v]", tt.pct, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestMemoryCacheExpiration(t *testing.T) {
+	c := NewMemoryCache(time.Minute, 0)
+	c.SetWithExpiration("short", 1, 10*time.Millisecond)
+	c.SetWithExpiration("forever", 2, -1)
+	c.Set("default", 3)
+
+	time.Sleep(20 * time.Millisecond)
+	tests := []struct {
+		key   string
+		found bool
+	}{
+		{"short", false},
+		{"forever", true},
+		{"default", true},
+		{"missing", false},
+	}
+	for _, tt := range tests {
+		if _, found := c.Get(tt.key); found != tt.found {
+			t.Errorf("Get(%q) found = %v, want %v", tt.key, found, tt.found)
+		}
+	}
+}
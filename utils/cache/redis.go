@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// redisOpTimeout 单次Redis操作超时，Redis不可用时请求按缓存未命中处理而不是长时间阻塞
+	redisOpTimeout = 500 * time.Millisecond
+	// redisScanBatch DeletePrefix/Stats每次SCAN返回的键数
+	redisScanBatch = 500
+)
+
+// RedisOptions Redis缓存连接参数
+type RedisOptions struct {
+	Addr      string
+	Password  string
+	DB        int
+	KeyPrefix string // 所有键的命名空间前缀，多个服务共用一个Redis时用于隔离
+}
+
+// RedisCache 基于Redis的缓存实现，多个服务实例共享，重启后仍然保留。
+// 值以JSON序列化，并记录Go类型名；读取时按类型名还原为原来的类型。
+// 当前进程未写入过也未通过RegisterType注册的类型按未命中处理。
+// 其他实例或旧版本可能在同一个键下写入不同的类型，调用方应使用comma-ok断言，类型不符时按未命中处理。
+type RedisCache struct {
+	client            *redis.Client
+	keyPrefix         string
+	mu                sync.RWMutex
+	defaultExpiration time.Duration
+	jitterPct         float64
+	hitCount          int64 // 缓存命中计数（原子操作）
+	missCount         int64 // 缓存未命中计数（原子操作）
+}
+
+// redisEntry Redis中保存的缓存值
+type redisEntry struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+var (
+	typeRegistryMu sync.RWMutex
+	typeRegistry   = make(map[string]reflect.Type)
+)
+
+func init() {
+	RegisterType(0, 0.0, "", false)
+}
+
+// RegisterType 注册缓存值的类型，使RedisCache在本进程写入该类型之前也能还原其他实例写入的值
+func RegisterType(values ...interface{}) {
+	typeRegistryMu.Lock()
+	defer typeRegistryMu.Unlock()
+	for _, v := range values {
+		t := reflect.TypeOf(v)
+		typeRegistry[t.String()] = t
+	}
+}
+
+// lookupType 按类型名查找已注册的类型
+func lookupType(name string) (reflect.Type, bool) {
+	typeRegistryMu.RLock()
+	defer typeRegistryMu.RUnlock()
+	t, ok := typeRegistry[name]
+	return t, ok
+}
+
+// NewRedisCache 创建Redis缓存并检查连接
+func NewRedisCache(opts RedisOptions, defaultExpiration time.Duration) (*RedisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:         opts.Addr,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		DialTimeout:  2 * time.Second,
+		ReadTimeout:  redisOpTimeout,
+		WriteTimeout: redisOpTimeout,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("连接Redis %s 失败: %w", opts.Addr, err)
+	}
+
+	return &RedisCache{
+		client:            client,
+		keyPrefix:         opts.KeyPrefix,
+		defaultExpiration: defaultExpiration,
+	}, nil
+}
+
+// opContext 返回单次操作使用的超时context
+func opContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisOpTimeout)
+}
+
+// SetExpirationJitter 设置过期时间的随机抖动比例，如0.1表示±10%，0表示不抖动
+func (c *RedisCache) SetExpirationJitter(pct float64) {
+	c.mu.Lock()
+	c.jitterPct = clampJitter(pct)
+	c.mu.Unlock()
+}
+
+// SetDefaultExpiration 设置默认过期时间，只影响之后写入的缓存项
+func (c *RedisCache) SetDefaultExpiration(duration time.Duration) {
+	c.mu.Lock()
+	c.defaultExpiration = duration
+	c.mu.Unlock()
+}
+
+// Set 设置缓存项，使用默认过期时间
+func (c *RedisCache) Set(key string, value interface{}) {
+	c.SetWithExpiration(key, value, 0)
+}
+
+// SetWithExpiration 设置缓存项，指定过期时间
+func (c *RedisCache) SetWithExpiration(key string, value interface{}, duration time.Duration) {
+	t := reflect.TypeOf(value)
+	if t == nil {
+		return
+	}
+	RegisterType(value)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logrus.Warnf("缓存值序列化失败 [%s]: %v", key, err)
+		return
+	}
+	entry, err := json.Marshal(redisEntry{Type: t.String(), Value: data})
+	if err != nil {
+		logrus.Warnf("缓存值序列化失败 [%s]: %v", key, err)
+		return
+	}
+
+	c.mu.RLock()
+	if duration == 0 {
+		// 0 表示使用默认过期时间
+		duration = c.defaultExpiration
+	}
+	if duration > 0 {
+		duration = applyJitter(duration, c.jitterPct)
+	} else {
+		// 负数表示永不过期
+		duration = 0
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := opContext()
+	defer cancel()
+	if err := c.client.Set(ctx, c.keyPrefix+key, entry, duration).Err(); err != nil {
+		logrus.Warnf("写入Redis缓存失败 [%s]: %v", key, err)
+	}
+}
+
+// Get 获取缓存项，Redis出错或类型无法还原时按未命中处理
+func (c *RedisCache) Get(key string) (interface{}, bool) {
+	ctx, cancel := opContext()
+	defer cancel()
+
+	data, err := c.client.Get(ctx, c.keyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logrus.Warnf("读取Redis缓存失败 [%s]: %v", key, err)
+		}
+		atomic.AddInt64(&c.missCount, 1)
+		return nil, false
+	}
+
+	value, err := decodeRedisEntry(data)
+	if err != nil {
+		logrus.Debugf("无法还原Redis缓存值 [%s]: %v", key, err)
+		atomic.AddInt64(&c.missCount, 1)
+		return nil, false
+	}
+
+	atomic.AddInt64(&c.hitCount, 1)
+	return value, true
+}
+
+// decodeRedisEntry 按记录的类型名还原缓存值
+func decodeRedisEntry(data []byte) (interface{}, error) {
+	var entry redisEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	t, ok := lookupType(entry.Type)
+	if !ok {
+		return nil, fmt.Errorf("未注册的类型 %s", entry.Type)
+	}
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(entry.Value, ptr.Interface()); err != nil {
+		return nil, err
+	}
+	return ptr.Elem().Interface(), nil
+}
+
+// Delete 删除缓存项
+func (c *RedisCache) Delete(key string) {
+	ctx, cancel := opContext()
+	defer cancel()
+	if err := c.client.Del(ctx, c.keyPrefix+key).Err(); err != nil {
+		logrus.Warnf("删除Redis缓存失败 [%s]: %v", key, err)
+	}
+}
+
+// DeletePrefix 删除所有以prefix开头的缓存项，返回删除的数量
+func (c *RedisCache) DeletePrefix(prefix string) int {
+	deleted := 0
+	err := c.scanKeys(prefix, func(keys []string) error {
+		ctx, cancel := opContext()
+		defer cancel()
+		n, err := c.client.Del(ctx, keys...).Result()
+		deleted += int(n)
+		return err
+	})
+	if err != nil {
+		logrus.Warnf("按前缀删除Redis缓存失败 [%s]: %v", prefix, err)
+	}
+	return deleted
+}
+
+// Flush 清空本服务的全部缓存项（只删除带keyPrefix的键）
+func (c *RedisCache) Flush() {
+	c.DeletePrefix("")
+}
+
+// scanKeys 用SCAN遍历以prefix开头的键（含keyPrefix），每批调用一次fn
+func (c *RedisCache) scanKeys(prefix string, fn func(keys []string) error) error {
+	pattern := escapeGlob(c.keyPrefix+prefix) + "*"
+	var cursor uint64
+	for {
+		ctx, cancel := opContext()
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, redisScanBatch).Result()
+		cancel()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// escapeGlob 转义Redis匹配模式中的特殊字符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Stats 获取缓存统计信息。命中率为本实例的统计，键数量和大小来自Redis。
+// Redis自行淘汰过期键，Expired始终为0；不记录写入时间，OldestEntry为空。
+func (c *RedisCache) Stats() CacheStats {
+	hits := atomic.LoadInt64(&c.hitCount)
+	misses := atomic.LoadInt64(&c.missCount)
+
+	stats := CacheStats{
+		Backend:   BackendRedis,
+		HitCount:  hits,
+		MissCount: misses,
+		TypeStats: make(map[string]int),
+	}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total) * 100
+	}
+
+	err := c.scanKeys("", func(keys []string) error {
+		ctx, cancel := opContext()
+		defer cancel()
+
+		pipe := c.client.Pipeline()
+		lengths := make([]*redis.IntCmd, len(keys))
+		for i, key := range keys {
+			lengths[i] = pipe.StrLen(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		for i, key := range keys {
+			key = strings.TrimPrefix(key, c.keyPrefix)
+			stats.ItemCount++
+			stats.TypeStats[strings.Split(key, ":")[0]]++
+			stats.TotalBytes += int64(len(key)) + lengths[i].Val()
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Warnf("统计Redis缓存失败: %v", err)
+	}
+
+	return stats
+}
+
+// Close 关闭Redis连接
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newTestRedisCache 返回连接到内存Redis的缓存
+func newTestRedisCache(t *testing.T, defaultExpiration time.Duration) (*RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	c, err := NewRedisCache(RedisOptions{Addr: server.Addr(), KeyPrefix: "test:"}, defaultExpiration)
+	if err != nil {
+		t.Fatalf("连接内存Redis失败: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+// TestRedisCacheExpirationJitter 同时写入100个相同TTL的键，Redis中的TTL分散在±jitter范围内
+func TestRedisCacheExpirationJitter(t *testing.T) {
+	const ttl = time.Minute
+	c, server := newTestRedisCache(t, ttl)
+	c.SetExpirationJitter(0.1)
+
+	ttls := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		key := "movie:" + strconv.Itoa(i)
+		c.Set(key, i)
+		got := server.TTL("test:" + key)
+		if got < ttl-ttl/10 || got > ttl+ttl/10 {
+			t.Errorf("%s 的TTL = %v, want 1m±10%%", key, got)
+		}
+		ttls[got.Truncate(time.Millisecond)] = true
+	}
+	if len(ttls) < 2 {
+		t.Errorf("100个键的TTL都在同一毫秒内过期")
+	}
+}
+
+// redisTestMovie 测试用的缓存值类型
+type redisTestMovie struct {
+	MovieID string
+	Genres  []string
+	Rating  float64
+}
+
+func TestRedisCacheRoundTrip(t *testing.T) {
+	c, _ := newTestRedisCache(t, time.Minute)
+
+	tests := []struct {
+		key   string
+		value interface{}
+	}{
+		{"count", 42},
+		{"rating", 3.75},
+		{"title", "Toy Story (1995)"},
+		{"ready", true},
+		{"movie:1", redisTestMovie{MovieID: "1", Genres: []string{"Animation", "Comedy"}, Rating: 3.9}},
+		{"movies", []redisTestMovie{{MovieID: "2"}, {MovieID: "3"}}},
+		{"movie_ptr", &redisTestMovie{MovieID: "4"}},
+		{"genres", map[string]int{"Drama": 3}},
+	}
+	for _, tt := range tests {
+		c.Set(tt.key, tt.value)
+		got, found := c.Get(tt.key)
+		if !found {
+			t.Errorf("Get(%q) 未命中", tt.key)
+			continue
+		}
+		// 还原为写入时的类型，调用方的类型断言能够成功
+		if reflect.TypeOf(got) != reflect.TypeOf(tt.value) || !reflect.DeepEqual(got, tt.value) {
+			t.Errorf("Get(%q) = %#v, want %#v", tt.key, got, tt.value)
+		}
+	}
+}
+
+// TestRedisCacheUnknownType 其他实例写入的、本进程未注册的类型按未命中处理
+func TestRedisCacheUnknownType(t *testing.T) {
+	c, server := newTestRedisCache(t, time.Minute)
+	server.Set("test:foreign", `{"t":"other.Type","v":{"a":1}}`)
+	server.Set("test:corrupt", `not json`)
+
+	for _, key := range []string{"foreign", "corrupt", "missing"} {
+		if value, found := c.Get(key); found {
+			t.Errorf("Get(%q) = %v, want 未命中", key, value)
+		}
+	}
+	if stats := c.Stats(); stats.MissCount != 3 || stats.HitCount != 0 {
+		t.Errorf("命中/未命中 = %d/%d, want 0/3", stats.HitCount, stats.MissCount)
+	}
+}
+
+// TestRedisCacheKeyPrefix 删除、按前缀删除和清空只影响本服务前缀下的键
+func TestRedisCacheKeyPrefix(t *testing.T) {
+	c, server := newTestRedisCache(t, time.Minute)
+	server.Set("other:search:1", "kept")
+
+	for _, key := range []string{"search:a", "search:b", "search:[x]*", "movie:1", "movie:2"} {
+		c.Set(key, key)
+	}
+	if !server.Exists("test:search:a") {
+		t.Fatal("键应带有test:前缀")
+	}
+
+	c.Delete("movie:1")
+	if _, found := c.Get("movie:1"); found {
+		t.Error("Delete后仍能读取movie:1")
+	}
+	if n := c.DeletePrefix("search:["); n != 1 {
+		t.Errorf("DeletePrefix(search:[) 删除了%d个, want 1（模式字符应转义）", n)
+	}
+	if n := c.DeletePrefix("search:"); n != 2 {
+		t.Errorf("DeletePrefix(search:) 删除了%d个, want 2", n)
+	}
+
+	stats := c.Stats()
+	if stats.Backend != BackendRedis || stats.ItemCount != 1 || stats.TypeStats["movie"] != 1 {
+		t.Errorf("Stats = %+v, want 1个movie键", stats)
+	}
+
+	c.Flush()
+	if keys := server.Keys(); len(keys) != 1 || keys[0] != "other:search:1" {
+		t.Errorf("Flush后剩余键 = %v, want [other:search:1]", keys)
+	}
+}
+
+func TestRedisCacheExpiration(t *testing.T) {
+	c, server := newTestRedisCache(t, time.Minute)
+	c.SetWithExpiration("short", 1, time.Second)
+	c.SetWithExpiration("forever", 2, -1)
+	c.Set("default", 3)
+
+	if ttl := server.TTL("test:forever"); ttl != 0 {
+		t.Errorf("永不过期的键TTL = %v, want 0", ttl)
+	}
+	if ttl := server.TTL("test:default"); ttl != time.Minute {
+		t.Errorf("默认TTL = %v, want 1m", ttl)
+	}
+
+	server.FastForward(2 * time.Second)
+	if _, found := c.Get("short"); found {
+		t.Error("过期的键仍能读取")
+	}
+	if _, found := c.Get("forever"); !found {
+		t.Error("永不过期的键未命中")
+	}
+}
+
+// TestNewRedisCacheUnreachable 连接失败时返回错误，由InitCache回退到内存缓存
+func TestNewRedisCacheUnreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	if _, err := NewRedisCache(RedisOptions{Addr: addr}, time.Minute); err == nil {
+		t.Error("连接已关闭的Redis应返回错误")
+	}
+}
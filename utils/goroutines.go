@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// goroutineHeaderPattern 匹配栈信息的头部，如 "goroutine 18 [chan receive, 6 minutes]:"
+var goroutineHeaderPattern = regexp.MustCompile(`^goroutine (\d+) \[([^\]]+)\]:$`)
+
+// suspiciousStackKeywords 长时间阻塞时值得关注的栈关键字
+var suspiciousStackKeywords = []string{"hbase", "scanner", "random_ratings", "randomratings"}
+
+// suspiciousWaitMinutes 阻塞超过该分钟数的相关协程标记为可疑
+const suspiciousWaitMinutes = 5
+
+// GoroutineInfo 单个协程的状态摘要
+type GoroutineInfo struct {
+	ID          int      `json:"id"`
+	State       string   `json:"state"`
+	WaitMinutes int      `json:"waitMinutes"` // 运行时只为阻塞超过1分钟的协程报告
+	Functions   []string `json:"functions"`
+	CreatedBy   string   `json:"createdBy,omitempty"`
+	Suspicious  bool     `json:"suspicious"`
+}
+
+// DumpGoroutineStacks 获取所有协程的栈信息，缓冲区不足时自动扩容（上限64MB）
+func DumpGoroutineStacks() []byte {
+	size := 1 << 20
+	for {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, true)
+		if n < size || size >= 64<<20 {
+			return buf[:n]
+		}
+		size *= 2
+	}
+}
+
+// ParseGoroutineStacks 解析runtime.Stack(buf, true)的输出，最多返回limit个协程，同时返回协程总数
+func ParseGoroutineStacks(dump []byte, limit int) ([]GoroutineInfo, int) {
+	goroutines := []GoroutineInfo{}
+	total := 0
+
+	var current *GoroutineInfo
+	var stackText strings.Builder
+	flush := func() {
+		if current == nil {
+			return
+		}
+		lower := strings.ToLower(stackText.String())
+		if current.WaitMinutes > suspiciousWaitMinutes {
+			for _, keyword := range suspiciousStackKeywords {
+				if strings.Contains(lower, keyword) {
+					current.Suspicious = true
+					break
+				}
+			}
+		}
+		goroutines = append(goroutines, *current)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(dump))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if matches := goroutineHeaderPattern.FindStringSubmatch(line); matches != nil {
+			flush()
+			total++
+			if len(goroutines) >= limit {
+				continue
+			}
+
+			id, _ := strconv.Atoi(matches[1])
+			current = &GoroutineInfo{ID: id, Functions: []string{}}
+			stackText.Reset()
+
+			// 状态格式: "chan receive, 6 minutes" 或 "select, locked to thread"
+			parts := strings.Split(matches[2], ", ")
+			current.State = parts[0]
+			for _, part := range parts[1:] {
+				if minutes, ok := strings.CutSuffix(part, " minutes"); ok {
+					current.WaitMinutes, _ = strconv.Atoi(minutes)
+				}
+			}
+			continue
+		}
+
+		if current == nil || line == "" {
+			continue
+		}
+		stackText.WriteString(line)
+		stackText.WriteByte('\n')
+
+		// 以制表符开头的是文件位置行
+		if strings.HasPrefix(line, "\t") {
+			continue
+		}
+		if createdBy, ok := strings.CutPrefix(line, "created by "); ok {
+			current.CreatedBy = trimStackArgs(createdBy)
+			continue
+		}
+		current.Functions = append(current.Functions, trimStackArgs(line))
+	}
+	flush()
+
+	return goroutines, total
+}
+
+// trimStackArgs 去掉函数名后的参数列表和"in goroutine N"后缀
+func trimStackArgs(line string) string {
+	if idx := strings.Index(line, " in goroutine "); idx >= 0 {
+		line = line[:idx]
+	}
+	if strings.HasSuffix(line, ")") {
+		if idx := strings.LastIndex(line, "("); idx > 0 {
+			line = line[:idx]
+		}
+	}
+	return line
+}
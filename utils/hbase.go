@@ -4,6 +4,7 @@ import (
 	"context"
 	"gohbase/config"
 	"gohbase/utils/hbase"
+	"io"
 
 	"github.com/tsuna/gohbase/hrpc"
 )
@@ -23,16 +24,89 @@ func ParseMovieData(movieID string, data map[string]map[string][]byte) map[strin
 	return hbase.ParseMovieData(movieID, data)
 }
 
+// ParseGenres 解析并规范化"A|B|C"格式的类型字符串
+func ParseGenres(raw string) []string {
+	return hbase.ParseGenres(raw)
+}
+
+// JoinGenres 规范化类型列表并拼接为HBase存储格式
+func JoinGenres(genres []string) string {
+	return hbase.JoinGenres(genres)
+}
+
+// MovieRows 按行类型组织的电影原始数据
+type MovieRows = hbase.MovieRows
+
+// ParseMovieDataByRowType 从按行类型组织的数据解析电影数据
+func ParseMovieDataByRowType(movieID string, rows MovieRows) map[string]interface{} {
+	return hbase.ParseMovieDataByRowType(movieID, rows)
+}
+
 // ScanMovies 扫描电影列表（带缓存）
 func ScanMovies(ctx context.Context, startRow, endRow string, limit int64) ([]*hrpc.Result, error) {
 	return hbase.ScanMovies(ctx, startRow, endRow, limit)
 }
 
+// ScanRatingRows 扫描全部_ratings行，对每行调用fn
+func ScanRatingRows(ctx context.Context, fn func(movieID string, cells []*hrpc.Cell) error) error {
+	return hbase.ScanRatingRows(ctx, fn)
+}
+
+// ScanStatsRows 扫描全部_stats行，对每行调用fn
+func ScanStatsRows(ctx context.Context, fn func(movieID string, cells []*hrpc.Cell) error) error {
+	return hbase.ScanStatsRows(ctx, fn)
+}
+
+// ScanUserRows 扫描users表的全部行，对每行调用fn
+func ScanUserRows(ctx context.Context, fn func(userID string, cells []*hrpc.Cell) error) error {
+	return hbase.ScanUserRows(ctx, fn)
+}
+
+// ListScanOptions 列表和搜索扫描选项：只返回_info行的标题和类型列
+func ListScanOptions(limit int64) []func(hrpc.Call) error {
+	return hbase.ListScanOptions(limit)
+}
+
+// StatsColumns _stats行的列投影
+func StatsColumns() map[string][]string {
+	return hbase.StatsColumns()
+}
+
+// LinksColumns _links行的列投影
+func LinksColumns() map[string][]string {
+	return hbase.LinksColumns()
+}
+
 // ScanMoviesWithPagination 扫描电影列表并支持分页
 func ScanMoviesWithPagination(ctx context.Context, page, pageSize int) ([]*hrpc.Result, int, error) {
 	return hbase.ScanMoviesWithPagination(ctx, page, pageSize)
 }
 
+// ParallelScanWithMerge 并行扫描电影列表并支持分页，结果与ScanMoviesWithPagination相同
+func ParallelScanWithMerge(ctx context.Context, page, pageSize, parallelism int) ([]*hrpc.Result, int, error) {
+	return hbase.ParallelScanWithMerge(ctx, page, pageSize, parallelism)
+}
+
+// ScanMovieIDsByTag 扫描带有指定标签（不区分大小写、精确匹配）的电影ID，最多limit个
+func ScanMovieIDsByTag(ctx context.Context, tag string, limit int64) ([]string, error) {
+	return hbase.ScanMovieIDsByTag(ctx, tag, limit)
+}
+
+// GetMoviesMultiple 并发获取多部电影的_info行，不存在或出错的电影不在结果中
+func GetMoviesMultiple(ctx context.Context, movieIDs []string) (map[string]map[string]map[string][]byte, error) {
+	return hbase.GetMoviesMultiple(ctx, movieIDs)
+}
+
+// ScanMoviesByTag 扫描标签包含tag的电影，返回其_info行数据，最多limit条
+func ScanMoviesByTag(ctx context.Context, tag string, limit int64) ([]*hrpc.Result, error) {
+	return hbase.ScanMoviesByTag(ctx, tag, limit)
+}
+
+// ScanTagCounts 统计全部标签的使用次数
+func ScanTagCounts(ctx context.Context) (map[string]int, error) {
+	return hbase.ScanTagCounts(ctx)
+}
+
 // GetMovieWithAllData 获取电影的所有数据
 func GetMovieWithAllData(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	return hbase.GetMovieWithAllData(ctx, movieID)
@@ -43,11 +117,18 @@ func GetMovieRatings(ctx context.Context, movieID string) (map[string]interface{
 	return hbase.GetMovieRatings(ctx, movieID)
 }
 
+// GetUserRating 获取用户对电影的评分和时间戳，未评分时评分为0
+func GetUserRating(ctx context.Context, movieID, userID string) (float64, int64, error) {
+	return hbase.GetUserRating(ctx, movieID, userID)
+}
+
 // GetTotalMoviesCount 获取电影总数（带缓存）
 func GetTotalMoviesCount(ctx context.Context) (int, error) {
 	cacheKey := "total_movies_count"
 	if cachedCount, found := Cache.Get(cacheKey); found {
-		return cachedCount.(int), nil
+		if count, ok := cachedCount.(int); ok {
+			return count, nil
+		}
 	}
 
 	// 使用 ScanMoviesWithPagination 获取总数
@@ -60,19 +141,105 @@ func GetTotalMoviesCount(ctx context.Context) (int, error) {
 	return totalCount, nil
 }
 
+// MoviesTable 获取电影表名
+func MoviesTable() string {
+	return hbase.MoviesTable()
+}
+
+// GetUserMovieRatings 从users表获取用户的全部评分
+func GetUserMovieRatings(ctx context.Context, userID string) (map[string]interface{}, error) {
+	return hbase.GetUserMovieRatings(ctx, userID)
+}
+
+// GetUserFavoriteGenres 统计用户评过分的电影的类型，返回类型 -> 电影数
+func GetUserFavoriteGenres(ctx context.Context, userID string) (map[string]int, error) {
+	return hbase.GetUserFavoriteGenres(ctx, userID)
+}
+
+// CountMovieGenres 分批读取电影的_info行，统计各类型的电影数
+func CountMovieGenres(ctx context.Context, movieIDs []string) map[string]int {
+	return hbase.CountMovieGenres(ctx, movieIDs)
+}
+
+// UserTag users表中用户的一个标签
+type UserTag = hbase.UserTag
+
+// GetUserTagEntries 获取用户的全部标签记录
+func GetUserTagEntries(ctx context.Context, userID string) ([]UserTag, error) {
+	return hbase.GetUserTagEntries(ctx, userID)
+}
+
+// UsersTable 获取用户表名
+func UsersTable() string {
+	return hbase.UsersTable()
+}
+
+// RatingValue 构建_ratings行评分值: "{rating}:{userId}:{timestamp}:{source}"
+func RatingValue(rating float64, userID string, timestamp int64, source string) []byte {
+	return hbase.RatingValue(rating, userID, timestamp, source)
+}
+
+// ParsedRating 解析后的_ratings行评分值
+type ParsedRating = hbase.ParsedRating
+
+// ParseRatingValue 解析"{rating}:{id}:{timestamp}[:{source}]"格式的评分值
+func ParseRatingValue(value string) (ParsedRating, bool) {
+	return hbase.ParseRatingValue(value)
+}
+
+// UserRatingValue 构建users表评分值
+func UserRatingValue(rating float64, movieID string, timestamp int64) []byte {
+	return hbase.UserRatingValue(rating, movieID, timestamp)
+}
+
+// NewUserMovieRatingsPut 构建写入users表的Put
+func NewUserMovieRatingsPut(ctx context.Context, userID string, values map[string][]byte) (*hrpc.Mutate, error) {
+	return hbase.NewUserMovieRatingsPut(ctx, userID, values)
+}
+
+// BatchPut 通过连接池并发执行Put，返回与puts一一对应的错误
+func BatchPut(ctx context.Context, puts []*hrpc.Mutate) []error {
+	return hbase.BatchPut(ctx, puts)
+}
+
+// PutUserMovieRatings 将用户的评分写入users表
+func PutUserMovieRatings(ctx context.Context, userID string, values map[string][]byte) error {
+	return hbase.PutUserMovieRatings(ctx, userID, values)
+}
+
+// PutMovieTag 写入用户对电影的标签到电影_tags行
+func PutMovieTag(ctx context.Context, movieID, userID, tag string, timestamp int64) error {
+	return hbase.PutMovieTag(ctx, movieID, userID, tag, timestamp)
+}
+
+// DeleteMovieAllRows 删除电影在movies表中的全部行
+func DeleteMovieAllRows(ctx context.Context, movieID string) error {
+	return hbase.DeleteMovieAllRows(ctx, movieID)
+}
+
+// PutUserTag 写入用户的标签到users表
+func PutUserTag(ctx context.Context, userID, movieID, tag string, timestamp int64) error {
+	return hbase.PutUserTag(ctx, userID, movieID, tag, timestamp)
+}
+
 // GetClient 获取HBase客户端
 func GetClient() interface{} {
 	return hbase.GetClient()
 }
 
+// GetMoviesWithAllDataBatch 批量获取多部电影的全部行，不存在的电影不在结果中
+func GetMoviesWithAllDataBatch(ctx context.Context, movieIDs []string) (map[string]map[string]interface{}, error) {
+	return hbase.GetMoviesWithAllDataBatch(ctx, movieIDs)
+}
+
 // GetMoviesRatingsBatch 批量获取多部电影的评分信息
 func GetMoviesRatingsBatch(ctx context.Context, movieIDs []string) (map[string]map[string]interface{}, error) {
 	return hbase.GetMoviesRatingsBatch(ctx, movieIDs)
 }
 
-// GetMovieLinks 获取电影外部链接（通用函数）
-func GetMovieLinks(ctx context.Context, movieID string) (map[string]interface{}, error) {
-	return hbase.GetMovieLinksWithUrls(ctx, movieID)
+// GetMovieLinks 获取电影外部链接（通用函数），options可以指定列投影
+func GetMovieLinks(ctx context.Context, movieID string, options ...func(hrpc.Call) error) (map[string]interface{}, error) {
+	return hbase.GetMovieLinksWithUrls(ctx, movieID, options...)
 }
 
 // GetMovieTags 获取电影标签（通用函数）
@@ -84,3 +251,53 @@ func GetMovieTags(ctx context.Context, movieID string) (map[string]interface{},
 func GetMovieStats(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	return hbase.GetMovieStats(ctx, movieID)
 }
+
+// GetMoviesStatsBatch 批量获取多部电影的统计信息
+func GetMoviesStatsBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return hbase.GetMoviesStatsBatch(ctx, movieIDs)
+}
+
+// GetMoviesLinksBatch 批量获取多部电影的外部链接
+func GetMoviesLinksBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return hbase.GetMoviesLinksBatch(ctx, movieIDs)
+}
+
+// GetMoviesTagsBatch 批量获取多部电影的标签
+func GetMoviesTagsBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return hbase.GetMoviesTagsBatch(ctx, movieIDs)
+}
+
+// GetHBaseOperationStats 获取HBase操作统计信息
+func GetHBaseOperationStats() map[string]hbase.OperationStats {
+	return hbase.GetOperationStats()
+}
+
+// GetHBaseSlowOperations 获取最慢的HBase操作记录
+func GetHBaseSlowOperations() []hbase.SlowOperation {
+	return hbase.GetSlowOperations()
+}
+
+// WriteHBaseMetrics 以Prometheus文本格式输出HBase操作指标
+func WriteHBaseMetrics(w io.Writer) error {
+	return hbase.WritePrometheusMetrics(w)
+}
+
+// HBaseMetricsEnabled 是否开启HBase操作统计
+func HBaseMetricsEnabled() bool {
+	return hbase.MetricsEnabled()
+}
+
+// SetHBaseMetricsEnabled 开启或关闭HBase操作统计
+func SetHBaseMetricsEnabled(enabled bool) {
+	hbase.SetMetricsEnabled(enabled)
+}
+
+// SetHBaseSlowOpsCapacity 调整保留的最慢操作条数
+func SetHBaseSlowOpsCapacity(capacity int) {
+	hbase.SetSlowOpsCapacity(capacity)
+}
+
+// GetPoolHealth 获取连接池各槽位的健康状态
+func GetPoolHealth() []hbase.PoolSlotHealth {
+	return hbase.GetPoolHealth()
+}
@@ -3,37 +3,20 @@ package hbase
 import (
 	"context"
 	"fmt"
+	"sync"
+
+	"github.com/tsuna/gohbase/hrpc"
 )
 
+// batchFetchWorkers 批量读取时的并发数，每批最多同时发出这么多个Get，而不是每个ID一个协程
+const batchFetchWorkers = 8
+
 // GetMoviesMultiple 根据多个ID获取电影信息
 func GetMoviesMultiple(ctx context.Context, movieIDs []string) (map[string]map[string]map[string][]byte, error) {
-	results := make(map[string]map[string]map[string][]byte)
-
-	// 使用goroutine并发获取多部电影信息
-	type result struct {
-		id   string
-		data map[string]map[string][]byte
-		err  error
-	}
-
-	resultChan := make(chan result, len(movieIDs))
-
-	for _, id := range movieIDs {
-		go func(movieID string) {
-			data, err := GetMovie(ctx, movieID)
-			resultChan <- result{id: movieID, data: data, err: err}
-		}(id)
-	}
-
-	// 收集结果
-	for range movieIDs {
-		res := <-resultChan
-		if res.err == nil && res.data != nil {
-			results[res.id] = res.data
-		}
-	}
-
-	return results, nil
+	return fetchEach(ctx, movieIDs, func(ctx context.Context, movieID string) (map[string]map[string][]byte, bool) {
+		data, err := GetMovie(ctx, movieID)
+		return data, err == nil && data != nil
+	}), nil
 }
 
 // GetMovieRatingStats 获取电影评分统计（使用新的数据库结构）
@@ -83,88 +66,93 @@ func GetMovieRatingStats(ctx context.Context, movieID string) (map[string]float6
 	return result, nil
 }
 
-// GetMoviesRatingsBatch 批量获取多部电影的评分信息
+// GetMoviesRatingsBatch 批量获取多部电影的评分信息，出错的电影评分为0
 func GetMoviesRatingsBatch(ctx context.Context, movieIDs []string) (map[string]map[string]interface{}, error) {
-	results := make(map[string]map[string]interface{})
-
-	// 使用goroutine并发获取多部电影评分
-	type result struct {
-		id   string
-		data map[string]interface{}
-		err  error
-	}
-
-	resultChan := make(chan result, len(movieIDs))
-
-	for _, id := range movieIDs {
-		go func(movieID string) {
-			// 先尝试从stats获取预计算的评分
-			statsData, err := GetMovieStats(ctx, movieID)
-			if err == nil && len(statsData) > 0 {
-				if avgRating, ok := statsData["avgRating"].(float64); ok {
-					data := map[string]interface{}{
-						"avgRating": avgRating,
-					}
-					if ratingCount, ok := statsData["ratingCount"].(int); ok {
-						data["count"] = ratingCount
-					}
-					resultChan <- result{id: movieID, data: data, err: nil}
-					return
+	return fetchEach(ctx, movieIDs, func(ctx context.Context, movieID string) (map[string]interface{}, bool) {
+		// 先尝试从stats获取预计算的评分
+		statsData, err := GetMovieStats(ctx, movieID)
+		if err == nil && len(statsData) > 0 {
+			if avgRating, ok := statsData["avgRating"].(float64); ok {
+				data := map[string]interface{}{
+					"avgRating": avgRating,
+				}
+				if ratingCount, ok := statsData["ratingCount"].(int); ok {
+					data["count"] = ratingCount
 				}
+				return data, true
 			}
+		}
 
-			// 如果没有预计算的统计信息，从ratings行获取
-			data, err := GetMovieRatings(ctx, movieID)
-			resultChan <- result{id: movieID, data: data, err: err}
-		}(id)
-	}
-
-	// 收集结果
-	for range movieIDs {
-		res := <-resultChan
-		if res.err == nil && res.data != nil {
-			results[res.id] = res.data
-		} else {
+		// 如果没有预计算的统计信息，从ratings行获取
+		data, err := GetMovieRatings(ctx, movieID)
+		if err != nil || data == nil {
 			// 为出错的电影提供默认值
-			results[res.id] = map[string]interface{}{
-				"avgRating": 0.0,
-				"count":     0,
-			}
+			return map[string]interface{}{"avgRating": 0.0, "count": 0}, true
 		}
-	}
-
-	return results, nil
+		return data, true
+	}), nil
 }
 
 // GetMoviesWithAllDataBatch 批量获取多部电影的完整信息
 func GetMoviesWithAllDataBatch(ctx context.Context, movieIDs []string) (map[string]map[string]interface{}, error) {
-	results := make(map[string]map[string]interface{})
+	return fetchMoviesBatch(ctx, movieIDs, GetMovieWithAllData), nil
+}
 
-	// 使用goroutine并发获取多部电影的完整信息
-	type result struct {
-		id   string
-		data map[string]interface{}
-		err  error
-	}
+// GetMoviesStatsBatch 批量获取多部电影stats行的评分列
+func GetMoviesStatsBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return fetchMoviesBatch(ctx, movieIDs, func(ctx context.Context, movieID string) (map[string]interface{}, error) {
+		return GetMovieStats(ctx, movieID, hrpc.Families(StatsColumns()))
+	})
+}
+
+// GetMoviesLinksBatch 批量获取多部电影的外部链接（含完整URL），只读取外部ID列
+func GetMoviesLinksBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return fetchMoviesBatch(ctx, movieIDs, func(ctx context.Context, movieID string) (map[string]interface{}, error) {
+		return GetMovieLinksWithUrls(ctx, movieID, hrpc.Families(LinksColumns()))
+	})
+}
 
-	resultChan := make(chan result, len(movieIDs))
+// GetMoviesTagsBatch 批量获取多部电影的标签
+func GetMoviesTagsBatch(ctx context.Context, movieIDs []string) map[string]map[string]interface{} {
+	return fetchMoviesBatch(ctx, movieIDs, GetMovieTagsWithDetails)
+}
 
-	for _, id := range movieIDs {
-		go func(movieID string) {
-			data, err := GetMovieWithAllData(ctx, movieID)
-			resultChan <- result{id: movieID, data: data, err: err}
-		}(id)
-	}
+// fetchMoviesBatch 并发地对每部电影执行fetch，忽略出错的电影
+func fetchMoviesBatch(ctx context.Context, movieIDs []string,
+	fetch func(ctx context.Context, movieID string) (map[string]interface{}, error)) map[string]map[string]interface{} {
+	return fetchEach(ctx, movieIDs, func(ctx context.Context, movieID string) (map[string]interface{}, bool) {
+		data, err := fetch(ctx, movieID)
+		return data, err == nil && data != nil
+	})
+}
 
-	// 收集结果
-	for range movieIDs {
-		res := <-resultChan
-		if res.err == nil && res.data != nil {
-			results[res.id] = res.data
-		}
+// fetchEach 用最多batchFetchWorkers个协程对每个ID执行fetch，返回ok为true的结果
+func fetchEach[T any](ctx context.Context, movieIDs []string, fetch func(ctx context.Context, movieID string) (T, bool)) map[string]T {
+	results := make(map[string]T, len(movieIDs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan string)
+	for i := 0; i < min(batchFetchWorkers, len(movieIDs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for movieID := range jobs {
+				if data, ok := fetch(ctx, movieID); ok {
+					mu.Lock()
+					results[movieID] = data
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, movieID := range movieIDs {
+		jobs <- movieID
 	}
+	close(jobs)
+	wg.Wait()
 
-	return results, nil
+	return results
 }
 
 // 解析浮点数，出错时返回默认值
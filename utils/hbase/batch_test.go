@@ -0,0 +1,62 @@
+package hbase
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFetchEachBoundsConcurrency 批量读取同时进行的fetch不超过batchFetchWorkers，且每个ID都被读取
+func TestFetchEachBoundsConcurrency(t *testing.T) {
+	for _, n := range []int{0, 1, batchFetchWorkers, 100} {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = strconv.Itoa(i)
+		}
+
+		var active, maxActive atomic.Int32
+		results := fetchEach(context.Background(), ids, func(_ context.Context, movieID string) (string, bool) {
+			current := active.Add(1)
+			for {
+				peak := maxActive.Load()
+				if current <= peak || maxActive.CompareAndSwap(peak, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			active.Add(-1)
+			// 奇数ID视为读取失败，不出现在结果中
+			id, _ := strconv.Atoi(movieID)
+			return "movie " + movieID, id%2 == 0
+		})
+
+		if peak := maxActive.Load(); peak > batchFetchWorkers {
+			t.Errorf("n=%d: 同时进行%d个fetch, want <= %d", n, peak, batchFetchWorkers)
+		}
+		if len(results) != (n+1)/2 {
+			t.Errorf("n=%d: 返回%d个结果, want %d", n, len(results), (n+1)/2)
+		}
+		for id, data := range results {
+			if data != "movie "+id {
+				t.Errorf("n=%d: results[%s] = %q", n, id, data)
+			}
+		}
+	}
+}
+
+func TestGetMoviesMultiple(t *testing.T) {
+	newFakeMovies(t, 30)
+	ids := []string{"1", "2", "15", "30", "999"}
+	movies, err := GetMoviesMultiple(context.Background(), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(movies) != 4 || movies["999"] != nil {
+		t.Fatalf("GetMoviesMultiple返回%d部电影, want 4（不含不存在的999）", len(movies))
+	}
+	if title := string(movies["15"]["info"]["title"]); title != "Movie 15 (1965)" {
+		t.Errorf("电影15的标题 = %q", title)
+	}
+}
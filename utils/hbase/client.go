@@ -2,10 +2,11 @@ package hbase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"gohbase/config"
+	"gohbase/utils/hbase/rowkey"
 	"sync"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tsuna/gohbase"
@@ -21,41 +22,111 @@ var (
 	poolSize     int = 5
 	poolMu       sync.Mutex
 	currentIndex int
+
+	// 表名，由InitHBase从配置设置
+	moviesTable = config.DefaultMoviesTable
+	usersTable  = config.DefaultUsersTable
 )
 
+// MoviesTable 电影表名
+func MoviesTable() string {
+	return moviesTable
+}
+
+// UsersTable 用户表名
+func UsersTable() string {
+	return usersTable
+}
+
+// SetTableNames 设置表名，用于集成测试指向临时表（须在发起请求前调用）
+func SetTableNames(movies, users string) {
+	if movies != "" {
+		moviesTable = movies
+	}
+	if users != "" {
+		usersTable = users
+	}
+}
+
 // InitHBase 初始化HBase客户端和连接池
 func InitHBase(conf *config.HBaseConfig) error {
 	// 构建ZooKeeper连接字符串
 	zkQuorum := fmt.Sprintf("%s:%s", conf.ZkQuorum, conf.ZkPort)
+	SetTableNames(conf.GetMoviesTable(), conf.GetUsersTable())
+	schemaConfig = *conf
+	SetMetricsEnabled(conf.IsMetricsEnabled())
+	SetSlowOpsCapacity(conf.GetSlowOpsCapacity())
 
-	// 创建主客户端
-	hbaseClient = gohbase.NewClient(zkQuorum)
+	// 创建主客户端（带操作统计）
+	zkTimeout := gohbase.ZookeeperTimeout(conf.GetZkSessionTimeout())
+	hbaseClient = newInstrumentedClient(gohbase.NewClient(zkQuorum, zkTimeout))
 
 	// 初始化连接池
+	newPoolClient := func() gohbase.Client {
+		return newInstrumentedClient(gohbase.NewClient(zkQuorum, zkTimeout))
+	}
+	poolMu.Lock()
 	clientPool = make([]gohbase.Client, poolSize)
+	poolHealth = make([]PoolSlotHealth, poolSize)
 	for i := 0; i < poolSize; i++ {
-		clientPool[i] = gohbase.NewClient(zkQuorum)
+		clientPool[i] = newPoolClient()
+		poolHealth[i] = PoolSlotHealth{Slot: i, HealthStatus: PoolSlotUnknown}
 	}
+	poolMu.Unlock()
 
 	// 测试连接是否成功
 	ctx := context.Background()
 	// 尝试获取一条记录来测试连接，使用新的表名和行键格式
-	get, err := hrpc.NewGetStr(ctx, "movies", "1_info")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieInfoKey("1"))
 	if err != nil {
 		logrus.Errorf("创建Get请求失败: %v", err)
 		return err
 	}
 
+	// 表不存在说明已连上集群，由下面的表结构检查处理
 	_, err = hbaseClient.Get(get)
-	if err != nil {
+	if err != nil && !errors.Is(err, gohbase.TableNotFound) {
 		logrus.Errorf("HBase连接失败: %v", err)
 		return err
 	}
 
+	// 检查必需的表和列族（按配置自动创建缺失的表），避免缺失时服务启动成功、首次写入才失败
+	if conf.SkipSchemaCheck {
+		logrus.Warn("已跳过HBase表结构检查")
+	} else if conf.IsCreateMissingTables() {
+		if err := EnsureSchema(ctx, hbaseClient, gohbase.NewAdminClient(zkQuorum, zkTimeout)); err != nil {
+			logrus.Error(err)
+			return err
+		}
+	} else if err := CheckSchema(ctx, hbaseClient); err != nil {
+		logrus.Error(err)
+		return err
+	}
+
+	// 定期探测连接池，替换会话已失效的客户端
+	startPoolHealthMonitor(PoolHealthCheckInterval, newPoolClient)
+
 	logrus.Infof("HBase连接成功，连接池大小: %d", poolSize)
 	return nil
 }
 
+// SetClient 用client替换主客户端和连接池的全部客户端，不连接集群，
+// 用于单元测试和基准测试（如hbasetest的内存实现），须在发起请求前调用
+func SetClient(client gohbase.Client) {
+	clientMu.Lock()
+	hbaseClient = client
+	clientMu.Unlock()
+
+	poolMu.Lock()
+	clientPool = make([]gohbase.Client, poolSize)
+	poolHealth = make([]PoolSlotHealth, poolSize)
+	for i := range clientPool {
+		clientPool[i] = client
+		poolHealth[i] = PoolSlotHealth{Slot: i, HealthStatus: PoolSlotUnknown}
+	}
+	poolMu.Unlock()
+}
+
 // GetClient 获取HBase客户端
 func GetClient() gohbase.Client {
 	clientMu.RLock()
@@ -73,48 +144,86 @@ func GetPooledClient() gohbase.Client {
 	return client
 }
 
-// BatchPut 批量写入操作
-func BatchPut(ctx context.Context, tableName string, puts []*hrpc.Mutate) error {
+// batchPutInFlightPerClient 每个连接池客户端同时进行的Put数
+const batchPutInFlightPerClient = 8
+
+// putter 执行Put的客户端
+type putter interface {
+	Put(request *hrpc.Mutate) (*hrpc.Result, error)
+}
+
+// BatchPut 将Put分散到连接池的各个客户端并发执行，每个客户端同时最多batchPutInFlightPerClient个请求。
+// 返回与puts一一对应的错误，单个失败不影响其他写入；ctx取消后未开始的写入返回ctx.Err()。
+func BatchPut(ctx context.Context, puts []*hrpc.Mutate) []error {
 	if len(puts) == 0 {
 		return nil
 	}
 
-	client := GetPooledClient()
-
-	// 分批处理，每批最多100个操作
-	batchSize := 100
-	for i := 0; i < len(puts); i += batchSize {
-		end := i + batchSize
-		if end > len(puts) {
-			end = len(puts)
+	poolMu.Lock()
+	clients := make([]putter, 0, len(clientPool))
+	for _, client := range clientPool {
+		if client != nil {
+			clients = append(clients, client)
 		}
+	}
+	poolMu.Unlock()
+	if len(clients) == 0 {
+		clients = append(clients, GetClient())
+	}
 
-		batch := puts[i:end]
-		for _, put := range batch {
-			_, err := client.Put(put)
-			if err != nil {
-				logrus.Errorf("批量写入失败: %v", err)
-				return err
-			}
-		}
+	return batchPutWith(ctx, clients, batchPutInFlightPerClient, puts)
+}
 
-		// 添加小延迟，避免过度压力
-		if len(puts) > batchSize {
-			time.Sleep(10 * time.Millisecond)
+// batchPutWith 每个客户端启动inFlight个worker，按轮询把Put分配给各客户端。
+// 分发通道无缓冲，客户端处理不过来时分发阻塞，形成背压。
+func batchPutWith(ctx context.Context, clients []putter, inFlight int, puts []*hrpc.Mutate) []error {
+	errs := make([]error, len(puts))
+
+	queues := make([]chan int, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		queues[i] = make(chan int)
+		for w := 0; w < inFlight; w++ {
+			wg.Add(1)
+			go func(client putter, queue <-chan int) {
+				defer wg.Done()
+				for idx := range queue {
+					if err := ctx.Err(); err != nil {
+						errs[idx] = err
+						continue
+					}
+					if _, err := client.Put(puts[idx]); err != nil {
+						errs[idx] = err
+					}
+				}
+			}(client, queues[i])
 		}
 	}
 
-	return nil
-}
+	for idx := range puts {
+		queues[idx%len(queues)] <- idx
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
 
-// EnableCompression 启用压缩
-func EnableCompression(compression string) error {
-	// 这里添加压缩相关功能
-	return nil
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		logrus.Warnf("批量写入: %d/%d 条失败", failed, len(puts))
+	}
+	return errs
 }
 
 // CloseClients 关闭所有客户端连接
 func CloseClients() {
+	stopPoolHealthMonitor()
+
 	clientMu.Lock()
 	defer clientMu.Unlock()
 
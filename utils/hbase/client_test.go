@@ -0,0 +1,161 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"gohbase/utils/hbase/rowkey"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// newRatingPuts 构造n个写入不同电影_ratings行的Put
+func newRatingPuts(tb testing.TB, ctx context.Context, n int) []*hrpc.Mutate {
+	tb.Helper()
+	puts := make([]*hrpc.Mutate, n)
+	for i := range puts {
+		put, err := hrpc.NewPutStr(ctx, MoviesTable(), rowkey.MovieRatingsKey(strconv.Itoa(i)),
+			map[string]map[string][]byte{"ratings": {"1": []byte("4.0:1:1000:test")}})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		puts[i] = put
+	}
+	return puts
+}
+
+// failingPutter 对指定行键的写入返回错误，并记录最大并发数
+type failingPutter struct {
+	fail      map[string]bool
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (p *failingPutter) Put(put *hrpc.Mutate) (*hrpc.Result, error) {
+	p.mu.Lock()
+	p.active++
+	if p.active > p.maxActive {
+		p.maxActive = p.active
+	}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.active--
+		p.mu.Unlock()
+	}()
+
+	time.Sleep(time.Millisecond)
+	if p.fail[string(put.Key())] {
+		return nil, fmt.Errorf("写入%s失败", put.Key())
+	}
+	return &hrpc.Result{}, nil
+}
+
+func TestBatchPutWithPerItemErrors(t *testing.T) {
+	ctx := context.Background()
+	puts := newRatingPuts(t, ctx, 100)
+	clients := []*failingPutter{
+		{fail: map[string]bool{rowkey.MovieRatingsKey("3"): true, rowkey.MovieRatingsKey("42"): true}},
+		{fail: map[string]bool{rowkey.MovieRatingsKey("3"): true, rowkey.MovieRatingsKey("42"): true}},
+	}
+	putters := []putter{clients[0], clients[1]}
+
+	errs := batchPutWith(ctx, putters, 4, puts)
+	if len(errs) != len(puts) {
+		t.Fatalf("返回%d个错误, want %d", len(errs), len(puts))
+	}
+	for i, err := range errs {
+		wantErr := i == 3 || i == 42
+		if (err != nil) != wantErr {
+			t.Errorf("errs[%d] = %v, want error: %v", i, err, wantErr)
+		}
+	}
+	for i, client := range clients {
+		if client.maxActive > 4 {
+			t.Errorf("客户端%d同时进行%d个写入，超过上限4", i, client.maxActive)
+		}
+	}
+}
+
+func TestBatchPutWithCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	puts := newRatingPuts(t, context.Background(), 10)
+	cancel()
+
+	errs := batchPutWith(ctx, []putter{&failingPutter{}}, 2, puts)
+	for i, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("errs[%d] = %v, want context.Canceled", i, err)
+		}
+	}
+}
+
+func TestBatchPutWritesAllRows(t *testing.T) {
+	client := hbasetest.New()
+	SetClient(client)
+	ctx := context.Background()
+
+	for i, err := range BatchPut(ctx, newRatingPuts(t, ctx, 250)) {
+		if err != nil {
+			t.Fatalf("errs[%d] = %v", i, err)
+		}
+	}
+	if keys := client.RowKeys(MoviesTable()); len(keys) != 250 {
+		t.Errorf("写入了%d行, want 250", len(keys))
+	}
+	if errs := BatchPut(ctx, nil); errs != nil {
+		t.Errorf("BatchPut(nil) = %v, want nil", errs)
+	}
+}
+
+// serialBatchPut 改为并发写入前的BatchPut：单个客户端逐个写入，每100个之间固定等待10ms，遇到错误即中止
+func serialBatchPut(client putter, puts []*hrpc.Mutate) error {
+	const batchSize = 100
+	for i := 0; i < len(puts); i += batchSize {
+		end := i + batchSize
+		if end > len(puts) {
+			end = len(puts)
+		}
+		for _, put := range puts[i:end] {
+			if _, err := client.Put(put); err != nil {
+				return err
+			}
+		}
+		if len(puts) > batchSize {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// BenchmarkBatchPut 比较逐个写入与按连接池并发写入，内存客户端每次Put延迟200µs模拟RPC开销
+func BenchmarkBatchPut(b *testing.B) {
+	client := hbasetest.New()
+	client.Latency = 200 * time.Microsecond
+	SetClient(client)
+	ctx := context.Background()
+	puts := newRatingPuts(b, ctx, 500)
+
+	b.Run("serial", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := serialBatchPut(GetPooledClient(), puts); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, err := range BatchPut(ctx, puts) {
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package hbase
+
+// 列投影（列族 -> 列名），传给hrpc.Families，只让需要的列经过网络。
+// 每次返回新的map，调用方可以修改。
+
+// InfoListColumns 列表和搜索只需要_info行的标题和类型
+func InfoListColumns() map[string][]string {
+	return map[string][]string{"info": {"title", "genres"}}
+}
+
+// StatsColumns 列表和搜索结果需要的_stats行列
+func StatsColumns() map[string][]string {
+	return map[string][]string{"info": {"avg_rating", "rating_count"}}
+}
+
+// LinksColumns _links行的外部ID列
+func LinksColumns() map[string][]string {
+	return map[string][]string{"info": {"imdbId", "tmdbId"}}
+}
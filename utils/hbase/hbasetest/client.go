@@ -0,0 +1,620 @@
+// Package hbasetest 提供内存中的gohbase.Client实现，供不连接HBase集群的单元测试和基准测试使用。
+// 支持Get、Put、Delete、Increment、CheckAndPut和按行键区间的Scan，
+// 并在服务端执行本项目用到的过滤器（FilterList、RowFilter+正则、SingleColumnValueFilter+子串/二进制比较）
+// 和列族、列投影，行为与真实集群一致：被过滤或投影后没有单元格的行不会返回。
+package hbasetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+	"github.com/tsuna/gohbase/pb"
+	"github.com/tsuna/gohbase/region"
+	"google.golang.org/protobuf/proto"
+)
+
+// filterPath HBase过滤器和比较器类名的公共前缀
+const filterPath = "org.apache.hadoop.hbase.filter."
+
+// ErrUnsupportedFilter 扫描使用了内存实现不支持的过滤器，相当于服务端拒绝该过滤器
+var ErrUnsupportedFilter = errors.New("hbasetest: 不支持的过滤器")
+
+// row 列族 -> 列 -> 值
+type row map[string]map[string][]byte
+
+// Client 内存中的HBase客户端，零值不可用，使用New创建。可以被多个协程并发使用
+type Client struct {
+	mu     sync.RWMutex
+	tables map[string]map[string]row
+
+	// Latency 每次Get、Put、Delete、Increment、CheckAndPut以及扫描器每返回一行前的模拟延迟
+	Latency time.Duration
+	// ScanErr 不为nil时，扫描器在返回ScanErrAfter行之后的下一次Next返回该错误，模拟扫描中途RPC失败
+	ScanErr      error
+	ScanErrAfter int
+
+	// schemas 由SetSchema设置的表 -> 列族；设置后Get校验表和列族是否存在
+	schemas map[string]map[string]bool
+}
+
+// New 创建空的内存客户端
+func New() *Client {
+	return &Client{tables: make(map[string]map[string]row)}
+}
+
+var _ gohbase.Client = (*Client)(nil)
+
+// SetRow 写入测试数据：table表中rowKey行的列族 -> 列 -> 值，与已有的列合并
+func (c *Client) SetRow(table, rowKey string, values map[string]map[string][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(table, rowKey, values)
+}
+
+// SetSchema 声明table表的列族。声明过任意表后，Get未声明的表返回gohbase.TableNotFound，
+// 请求未声明的列族返回NoSuchColumnFamilyException，与真实集群一致；未声明时不做校验
+func (c *Client) SetSchema(table string, families ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.schemas == nil {
+		c.schemas = make(map[string]map[string]bool)
+	}
+	c.schemas[table] = make(map[string]bool, len(families))
+	for _, family := range families {
+		c.schemas[table][family] = true
+	}
+}
+
+// checkSchema 按SetSchema声明的表结构校验请求的表和列族，调用方持有mu
+func (c *Client) checkSchema(table string, columns []*pb.Column) error {
+	if c.schemas == nil {
+		return nil
+	}
+	families, ok := c.schemas[table]
+	if !ok {
+		return gohbase.TableNotFound
+	}
+	for _, column := range columns {
+		if family := string(column.GetFamily()); !families[family] {
+			return fmt.Errorf("org.apache.hadoop.hbase.regionserver.NoSuchColumnFamilyException: Column family %s does not exist in region %s", family, table)
+		}
+	}
+	return nil
+}
+
+// Row 返回table表中rowKey行的副本，行不存在时返回nil
+func (c *Client) Row(table, rowKey string) map[string]map[string][]byte {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.tables[table][rowKey]
+	if !ok {
+		return nil
+	}
+	return copyRow(r)
+}
+
+// RowKeys 返回table表中按行键排序的全部行键
+func (c *Client) RowKeys(table string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sortedKeys(table)
+}
+
+func (c *Client) put(table, rowKey string, values map[string]map[string][]byte) {
+	t := c.tables[table]
+	if t == nil {
+		t = make(map[string]row)
+		c.tables[table] = t
+	}
+	r := t[rowKey]
+	if r == nil {
+		r = make(row)
+		t[rowKey] = r
+	}
+	for family, qualifiers := range values {
+		if r[family] == nil {
+			r[family] = make(map[string][]byte)
+		}
+		for qualifier, value := range qualifiers {
+			r[family][qualifier] = append([]byte(nil), value...)
+		}
+	}
+}
+
+func (c *Client) sortedKeys(table string) []string {
+	keys := make([]string, 0, len(c.tables[table]))
+	for key := range c.tables[table] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *Client) sleep() {
+	if c.Latency > 0 {
+		time.Sleep(c.Latency)
+	}
+}
+
+// Get 读取一行，按请求的列族、列投影
+func (c *Client) Get(g *hrpc.Get) (*hrpc.Result, error) {
+	if err := g.Context().Err(); err != nil {
+		return nil, err
+	}
+	c.sleep()
+
+	req := requestProto(g).(*pb.GetRequest).GetGet()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err := c.checkSchema(string(g.Table()), req.GetColumn()); err != nil {
+		return nil, err
+	}
+	r, ok := c.tables[string(g.Table())][string(g.Key())]
+	if !ok {
+		return &hrpc.Result{}, nil
+	}
+	cells := project(string(g.Key()), r, req.GetColumn())
+	if req.GetFilter() != nil {
+		match, err := matchFilter(req.GetFilter(), string(g.Key()), r)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			cells = nil
+		}
+	}
+	return &hrpc.Result{Cells: cells}, nil
+}
+
+// Put 写入一行中的若干列
+func (c *Client) Put(p *hrpc.Mutate) (*hrpc.Result, error) {
+	if err := p.Context().Err(); err != nil {
+		return nil, err
+	}
+	c.sleep()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.put(string(p.Table()), string(p.Key()), p.Values())
+	return &hrpc.Result{}, nil
+}
+
+// Delete 删除整行（未指定列族）、整个列族（未指定列）或指定的列
+func (c *Client) Delete(d *hrpc.Mutate) (*hrpc.Result, error) {
+	if err := d.Context().Err(); err != nil {
+		return nil, err
+	}
+	c.sleep()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	table, rowKey := string(d.Table()), string(d.Key())
+	r, ok := c.tables[table][rowKey]
+	if !ok {
+		return &hrpc.Result{}, nil
+	}
+
+	values := d.Values()
+	if len(values) == 0 {
+		delete(c.tables[table], rowKey)
+		return &hrpc.Result{}, nil
+	}
+	for family, qualifiers := range values {
+		if len(qualifiers) == 0 {
+			delete(r, family)
+			continue
+		}
+		for qualifier := range qualifiers {
+			delete(r[family], qualifier)
+		}
+		if len(r[family]) == 0 {
+			delete(r, family)
+		}
+	}
+	if len(r) == 0 {
+		delete(c.tables[table], rowKey)
+	}
+	return &hrpc.Result{}, nil
+}
+
+// Append 把值追加到已有列的末尾
+func (c *Client) Append(a *hrpc.Mutate) (*hrpc.Result, error) {
+	if err := a.Context().Err(); err != nil {
+		return nil, err
+	}
+	c.sleep()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	table, rowKey := string(a.Table()), string(a.Key())
+	appended := make(map[string]map[string][]byte)
+	for family, qualifiers := range a.Values() {
+		appended[family] = make(map[string][]byte)
+		for qualifier, value := range qualifiers {
+			old := c.tables[table][rowKey][family][qualifier]
+			appended[family][qualifier] = append(append([]byte(nil), old...), value...)
+		}
+	}
+	c.put(table, rowKey, appended)
+	return &hrpc.Result{}, nil
+}
+
+// Increment 把8字节大端整数列加上给定值，返回新值。只支持单列
+func (c *Client) Increment(i *hrpc.Mutate) (int64, error) {
+	if err := i.Context().Err(); err != nil {
+		return 0, err
+	}
+	c.sleep()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	table, rowKey := string(i.Table()), string(i.Key())
+	var result int64
+	for family, qualifiers := range i.Values() {
+		for qualifier, delta := range qualifiers {
+			var current int64
+			if old := c.tables[table][rowKey][family][qualifier]; len(old) == 8 {
+				current = int64(binary.BigEndian.Uint64(old))
+			}
+			result = current + int64(binary.BigEndian.Uint64(delta))
+			value := make([]byte, 8)
+			binary.BigEndian.PutUint64(value, uint64(result))
+			c.put(table, rowKey, map[string]map[string][]byte{family: {qualifier: value}})
+		}
+	}
+	return result, nil
+}
+
+// CheckAndPut 当family:qualifier的当前值等于expectedValue（nil表示列不存在）时写入p，返回是否写入
+func (c *Client) CheckAndPut(p *hrpc.Mutate, family string, qualifier string, expectedValue []byte) (bool, error) {
+	if err := p.Context().Err(); err != nil {
+		return false, err
+	}
+	c.sleep()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	table, rowKey := string(p.Table()), string(p.Key())
+	current, exists := c.tables[table][rowKey][family][qualifier]
+	if expectedValue == nil {
+		if exists {
+			return false, nil
+		}
+	} else if !exists || !bytes.Equal(current, expectedValue) {
+		return false, nil
+	}
+	c.put(table, rowKey, p.Values())
+	return true, nil
+}
+
+// SendBatch 逐个执行批量中的Get和Mutate
+func (c *Client) SendBatch(ctx context.Context, batch []hrpc.Call) ([]hrpc.RPCResult, bool) {
+	results := make([]hrpc.RPCResult, len(batch))
+	allOK := true
+	for i, call := range batch {
+		var result *hrpc.Result
+		var err error
+		switch rpc := call.(type) {
+		case *hrpc.Get:
+			result, err = c.Get(rpc)
+		case *hrpc.Mutate:
+			switch rpc.Description() {
+			case "DELETE":
+				result, err = c.Delete(rpc)
+			default:
+				result, err = c.Put(rpc)
+			}
+		default:
+			err = fmt.Errorf("hbasetest: 不支持批量执行%s", call.Name())
+		}
+		if err != nil {
+			allOK = false
+		}
+		results[i] = hrpc.RPCResult{Msg: resultProto(result), Error: err}
+	}
+	return results, allOK
+}
+
+// resultProto 把Result转换为批量调用返回的protobuf消息
+func resultProto(result *hrpc.Result) proto.Message {
+	if result == nil {
+		return nil
+	}
+	cells := make([]*pb.Cell, 0, len(result.Cells))
+	for _, cell := range result.Cells {
+		cells = append(cells, (*pb.Cell)(cell))
+	}
+	return &pb.Result{Cell: cells}
+}
+
+// CacheRegions 内存实现没有region，直接返回
+func (c *Client) CacheRegions(table []byte) error { return nil }
+
+// Close 内存实现没有需要释放的资源
+func (c *Client) Close() {}
+
+// Scan 按行键顺序扫描[startRow, stopRow)，在"服务端"执行过滤器和投影。
+// 结果在创建扫描器时确定，之后的写入不影响正在进行的扫描
+func (c *Client) Scan(s *hrpc.Scan) hrpc.Scanner {
+	req := requestProto(s).(*pb.ScanRequest).GetScan()
+	start, stop := string(s.StartRow()), string(s.StopRow())
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var results []*hrpc.Result
+	var scanErr error
+	for _, key := range c.sortedKeys(string(s.Table())) {
+		if key < start || (stop != "" && key >= stop) {
+			continue
+		}
+		r := c.tables[string(s.Table())][key]
+		if req.GetFilter() != nil {
+			match, err := matchFilter(req.GetFilter(), key, r)
+			if err != nil {
+				scanErr = err
+				break
+			}
+			if !match {
+				continue
+			}
+		}
+		if cells := project(key, r, req.GetColumn()); len(cells) > 0 {
+			results = append(results, &hrpc.Result{Cells: cells})
+		}
+	}
+
+	return &scanner{
+		ctx:      s.Context(),
+		results:  results,
+		err:      scanErr,
+		latency:  c.Latency,
+		failErr:  c.ScanErr,
+		failFrom: c.ScanErrAfter,
+	}
+}
+
+// scanner 预先计算好结果的扫描器
+type scanner struct {
+	ctx      context.Context
+	results  []*hrpc.Result
+	err      error // 创建扫描器时的错误（如不支持的过滤器），第一次Next返回
+	latency  time.Duration
+	failErr  error
+	failFrom int
+	returned int
+	closed   bool
+}
+
+func (s *scanner) Next() (*hrpc.Result, error) {
+	if s.err != nil {
+		err := s.err
+		s.err = nil
+		s.closed = true
+		return nil, err
+	}
+	if s.closed {
+		return nil, io.EOF
+	}
+	if err := s.ctx.Err(); err != nil {
+		s.closed = true
+		return nil, err
+	}
+	if s.failErr != nil && s.returned >= s.failFrom {
+		s.closed = true
+		return nil, s.failErr
+	}
+	if len(s.results) == 0 {
+		s.closed = true
+		return nil, io.EOF
+	}
+	if s.latency > 0 {
+		time.Sleep(s.latency)
+	}
+	result := s.results[0]
+	s.results = s.results[1:]
+	s.returned++
+	return result, nil
+}
+
+func (s *scanner) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *scanner) GetScanMetrics() map[string]int64 { return nil }
+
+// requestProto 返回请求的protobuf消息，从中读取列投影和过滤器。
+// ToProto需要region，这里设置一个覆盖整张表的region，与真实客户端定位region后的状态相同
+func requestProto(call interface {
+	hrpc.Call
+	ToProto() proto.Message
+}) proto.Message {
+	if call.Region() == nil {
+		call.SetRegion(region.NewInfo(0, nil, call.Table(), []byte("hbasetest"), nil, nil))
+	}
+	return call.ToProto()
+}
+
+// RequestedColumns 返回Get或Scan请求的列投影（列族 -> 列，整个列族时列为空），
+// 未指定投影（读取全部列族）或其他请求类型时返回空map
+func RequestedColumns(call hrpc.Call) map[string][]string {
+	var columns []*pb.Column
+	switch c := call.(type) {
+	case *hrpc.Get:
+		columns = requestProto(c).(*pb.GetRequest).GetGet().GetColumn()
+	case *hrpc.Scan:
+		columns = requestProto(c).(*pb.ScanRequest).GetScan().GetColumn()
+	}
+
+	requested := make(map[string][]string, len(columns))
+	for _, column := range columns {
+		family := string(column.GetFamily())
+		qualifiers := requested[family]
+		for _, qualifier := range column.GetQualifier() {
+			qualifiers = append(qualifiers, string(qualifier))
+		}
+		requested[family] = qualifiers
+	}
+	return requested
+}
+
+// project 按列投影返回行的单元格，按列族、列排序；columns为空时返回全部列
+func project(rowKey string, r row, columns []*pb.Column) []*hrpc.Cell {
+	wanted := make(map[string]map[string]bool, len(columns))
+	for _, column := range columns {
+		family := string(column.GetFamily())
+		if len(column.GetQualifier()) == 0 {
+			wanted[family] = nil
+			continue
+		}
+		if _, whole := wanted[family]; whole && wanted[family] == nil {
+			continue
+		}
+		if wanted[family] == nil {
+			wanted[family] = make(map[string]bool)
+		}
+		for _, qualifier := range column.GetQualifier() {
+			wanted[family][string(qualifier)] = true
+		}
+	}
+
+	families := make([]string, 0, len(r))
+	for family := range r {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+
+	var cells []*hrpc.Cell
+	for _, family := range families {
+		qualifiersWanted, familyWanted := wanted[family]
+		if len(columns) > 0 && !familyWanted {
+			continue
+		}
+		qualifiers := make([]string, 0, len(r[family]))
+		for qualifier := range r[family] {
+			qualifiers = append(qualifiers, qualifier)
+		}
+		sort.Strings(qualifiers)
+		for _, qualifier := range qualifiers {
+			if qualifiersWanted != nil && !qualifiersWanted[qualifier] {
+				continue
+			}
+			cells = append(cells, &hrpc.Cell{
+				Row:       []byte(rowKey),
+				Family:    []byte(family),
+				Qualifier: []byte(qualifier),
+				Value:     append([]byte(nil), r[family][qualifier]...),
+			})
+		}
+	}
+	return cells
+}
+
+// matchFilter 在行上执行过滤器，返回该行是否保留
+func matchFilter(f *pb.Filter, rowKey string, r row) (bool, error) {
+	switch strings.TrimPrefix(f.GetName(), filterPath) {
+	case "FilterList":
+		var list pb.FilterList
+		if err := proto.Unmarshal(f.GetSerializedFilter(), &list); err != nil {
+			return false, err
+		}
+		mustPassAll := list.GetOperator() == pb.FilterList_MUST_PASS_ALL
+		for _, child := range list.GetFilters() {
+			match, err := matchFilter(child, rowKey, r)
+			if err != nil {
+				return false, err
+			}
+			if mustPassAll && !match {
+				return false, nil
+			}
+			if !mustPassAll && match {
+				return true, nil
+			}
+		}
+		return mustPassAll, nil
+
+	case "RowFilter":
+		var rf pb.RowFilter
+		if err := proto.Unmarshal(f.GetSerializedFilter(), &rf); err != nil {
+			return false, err
+		}
+		cf := rf.GetCompareFilter()
+		return compare(cf.GetCompareOp(), cf.GetComparator(), []byte(rowKey))
+
+	case "SingleColumnValueFilter":
+		var sf pb.SingleColumnValueFilter
+		if err := proto.Unmarshal(f.GetSerializedFilter(), &sf); err != nil {
+			return false, err
+		}
+		value, ok := r[string(sf.GetColumnFamily())][string(sf.GetColumnQualifier())]
+		if !ok {
+			return !sf.GetFilterIfMissing(), nil
+		}
+		return compare(sf.GetCompareOp(), sf.GetComparator(), value)
+	}
+	return false, fmt.Errorf("%w: %s", ErrUnsupportedFilter, f.GetName())
+}
+
+// compare 用比较器比较value，只支持EQUAL和NOT_EQUAL
+func compare(op pb.CompareType, comparator *pb.Comparator, value []byte) (bool, error) {
+	var equal bool
+	switch strings.TrimPrefix(comparator.GetName(), filterPath) {
+	case "RegexStringComparator":
+		var rc pb.RegexStringComparator
+		if err := proto.Unmarshal(comparator.GetSerializedComparator(), &rc); err != nil {
+			return false, err
+		}
+		re, err := regexp.Compile(rc.GetPattern())
+		if err != nil {
+			return false, err
+		}
+		equal = re.Match(value)
+	case "SubstringComparator":
+		var sc pb.SubstringComparator
+		if err := proto.Unmarshal(comparator.GetSerializedComparator(), &sc); err != nil {
+			return false, err
+		}
+		// 与HBase一致，子串比较不区分大小写
+		equal = strings.Contains(strings.ToLower(string(value)), strings.ToLower(sc.GetSubstr()))
+	case "BinaryComparator":
+		var bc pb.BinaryComparator
+		if err := proto.Unmarshal(comparator.GetSerializedComparator(), &bc); err != nil {
+			return false, err
+		}
+		equal = bytes.Equal(value, bc.GetComparable().GetValue())
+	default:
+		return false, fmt.Errorf("%w: %s", ErrUnsupportedFilter, comparator.GetName())
+	}
+
+	switch op {
+	case pb.CompareType_EQUAL:
+		return equal, nil
+	case pb.CompareType_NOT_EQUAL:
+		return !equal, nil
+	}
+	return false, fmt.Errorf("%w: 比较操作%s", ErrUnsupportedFilter, op)
+}
+
+// copyRow 深拷贝一行
+func copyRow(r row) map[string]map[string][]byte {
+	out := make(map[string]map[string][]byte, len(r))
+	for family, qualifiers := range r {
+		out[family] = make(map[string][]byte, len(qualifiers))
+		for qualifier, value := range qualifiers {
+			out[family][qualifier] = append([]byte(nil), value...)
+		}
+	}
+	return out
+}
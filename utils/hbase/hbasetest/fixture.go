@@ -0,0 +1,84 @@
+package hbasetest
+
+import (
+	"gohbase/config"
+	"gohbase/utils/hbase/rowkey"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Movie 测试数据中的一部电影，空字段对应的行不写入
+type Movie struct {
+	ID      string
+	Title   string
+	Genres  string
+	Stats   map[string]string // _stats行info列族：avg_rating、rating_count等
+	Links   map[string]string // _links行info列族：imdbId、tmdbId
+	Genome  map[string]string // 标签ID -> 相关度
+	Ratings map[string]string // 用户ID -> 评分单元格值
+	Tags    map[string]string // 用户ID -> 标签单元格值
+}
+
+// SetMovies 按"{movieId}_{rowType}"行键把movies写入table表
+func (c *Client) SetMovies(table string, movies ...Movie) {
+	for _, movie := range movies {
+		if movie.Title != "" || movie.Genres != "" {
+			info := map[string][]byte{}
+			if movie.Title != "" {
+				info["title"] = []byte(movie.Title)
+			}
+			if movie.Genres != "" {
+				info["genres"] = []byte(movie.Genres)
+			}
+			c.SetRow(table, rowkey.MovieInfoKey(movie.ID), map[string]map[string][]byte{"info": info})
+		}
+		rows := []struct {
+			key, family string
+			values      map[string]string
+		}{
+			{rowkey.MovieStatsKey(movie.ID), "info", movie.Stats},
+			{rowkey.MovieLinksKey(movie.ID), "info", movie.Links},
+			{rowkey.MovieGenomeKey(movie.ID), "genome", movie.Genome},
+			{rowkey.MovieRatingsKey(movie.ID), "ratings", movie.Ratings},
+			{rowkey.MovieTagsKey(movie.ID), "tags", movie.Tags},
+		}
+		for _, r := range rows {
+			if r.values != nil {
+				c.SetRow(table, r.key, map[string]map[string][]byte{r.family: byteValues(r.values)})
+			}
+		}
+	}
+}
+
+// byteValues 将字符串值转换为单元格值
+func byteValues(values map[string]string) map[string][]byte {
+	cells := make(map[string][]byte, len(values))
+	for qualifier, value := range values {
+		cells[qualifier] = []byte(value)
+	}
+	return cells
+}
+
+// Main 供各包的TestMain调用：把搜索索引放到临时目录下尚不存在的子目录中并降低日志级别，
+// 执行setup后运行测试，结束后删除临时目录并以测试结果退出。
+// 包初始化时可能已经读取了配置，除设置SEARCH_INDEX_PATH外也直接修改已加载的配置。
+func Main(m *testing.M, setup func(indexPath string)) {
+	dir, err := os.MkdirTemp("", "gohbase-test")
+	if err != nil {
+		panic(err)
+	}
+	indexPath := filepath.Join(dir, "index", "movie_index.db")
+	os.Setenv("SEARCH_INDEX_PATH", indexPath)
+	config.GetConfig().SearchIndex.Path = indexPath
+	logrus.SetLevel(logrus.WarnLevel)
+	if setup != nil {
+		setup(indexPath)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,471 @@
+package hbase
+
+import (
+	"bufio"
+	"fmt"
+	"gohbase/utils/hbase/rowkey"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// HBase操作类型
+const (
+	OpGet         = "get"
+	OpPut         = "put"
+	OpDelete      = "delete"
+	OpScan        = "scan"
+	OpAppend      = "append"
+	OpIncrement   = "increment"
+	OpCheckAndPut = "check_and_put"
+)
+
+// latencyBucketsMs 延迟直方图的桶上界（毫秒），最后一个桶之外为+Inf
+var latencyBucketsMs = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// opKey 统计维度：操作类型和行类型（如info、ratings、user）
+type opKey struct {
+	op      string
+	rowType string
+}
+
+// opCounter 单个维度的计数器（原子操作）
+type opCounter struct {
+	count        int64
+	errors       int64
+	totalLatency int64   // 纳秒
+	buckets      []int64 // 与latencyBucketsMs对应，最后一个为+Inf（非累积）
+}
+
+// OperationStats 单类操作的统计快照
+type OperationStats struct {
+	Count        int64                     `json:"count"`
+	Errors       int64                     `json:"errors"`
+	ErrorRate    float64                   `json:"errorRate"`
+	AvgLatencyMs float64                   `json:"avgLatencyMs"`
+	P50Ms        float64                   `json:"p50Ms"` // 由直方图估算（桶上界）
+	P95Ms        float64                   `json:"p95Ms"`
+	P99Ms        float64                   `json:"p99Ms"`
+	ByRowType    map[string]OperationStats `json:"byRowType,omitempty"`
+}
+
+// SlowOperation 慢操作记录
+type SlowOperation struct {
+	Op         string    `json:"op"`
+	Table      string    `json:"table"`
+	RowKey     string    `json:"rowKey"`
+	DurationMs float64   `json:"durationMs"`
+	Err        string    `json:"error,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+var (
+	opCounters   = make(map[opKey]*opCounter)
+	opCountersMu sync.RWMutex
+
+	// metricsEnabled 关闭后instrumentedClient直接调用底层客户端，不计时
+	metricsEnabled int32 = 1
+
+	// 最慢的N次操作，按耗时降序
+	slowOps         []SlowOperation
+	slowOpsCapacity = 20
+	slowOpsMin      int64 // 进入列表所需的最小耗时（纳秒），列表未满时为0
+	slowOpsMu       sync.Mutex
+)
+
+// SetMetricsEnabled 开启或关闭操作统计
+func SetMetricsEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&metricsEnabled, v)
+}
+
+// MetricsEnabled 是否开启操作统计
+func MetricsEnabled() bool {
+	return atomic.LoadInt32(&metricsEnabled) == 1
+}
+
+// SetSlowOpsCapacity 设置保留的慢操作数量
+func SetSlowOpsCapacity(capacity int) {
+	if capacity <= 0 {
+		return
+	}
+	slowOpsMu.Lock()
+	defer slowOpsMu.Unlock()
+	slowOpsCapacity = capacity
+	if len(slowOps) > capacity {
+		slowOps = slowOps[:capacity]
+	}
+	updateSlowOpsMinLocked()
+}
+
+// counterFor 获取（必要时创建）指定维度的计数器
+func counterFor(key opKey) *opCounter {
+	opCountersMu.RLock()
+	counter, ok := opCounters[key]
+	opCountersMu.RUnlock()
+	if ok {
+		return counter
+	}
+
+	opCountersMu.Lock()
+	defer opCountersMu.Unlock()
+	if counter, ok = opCounters[key]; !ok {
+		counter = &opCounter{buckets: make([]int64, len(latencyBucketsMs)+1)}
+		opCounters[key] = counter
+	}
+	return counter
+}
+
+// rowTypeOf 根据表名和行键得到统计用的行类型
+func rowTypeOf(table, key string) string {
+	if table == UsersTable() {
+		return "user"
+	}
+	if _, rowType, err := rowkey.ParseMovieRowKey(key); err == nil {
+		return rowType
+	}
+	return "other"
+}
+
+// recordOp 记录一次HBase操作的耗时和结果
+func recordOp(op string, call hrpc.Call, start time.Time, err error) {
+	duration := time.Since(start)
+
+	table, key := "", ""
+	rowType := "range"
+	if call != nil {
+		table, key = string(call.Table()), string(call.Key())
+		if op != OpScan {
+			rowType = rowTypeOf(table, key)
+		}
+	}
+
+	counter := counterFor(opKey{op: op, rowType: rowType})
+	atomic.AddInt64(&counter.count, 1)
+	atomic.AddInt64(&counter.totalLatency, int64(duration))
+	if err != nil {
+		atomic.AddInt64(&counter.errors, 1)
+	}
+	atomic.AddInt64(&counter.buckets[bucketIndex(duration)], 1)
+
+	if int64(duration) > atomic.LoadInt64(&slowOpsMin) {
+		recordSlowOp(SlowOperation{
+			Op:         op,
+			Table:      table,
+			RowKey:     key,
+			DurationMs: float64(duration) / float64(time.Millisecond),
+			Err:        errString(err),
+			Timestamp:  start,
+		}, duration)
+	}
+}
+
+// bucketIndex 返回耗时所在的直方图桶
+func bucketIndex(duration time.Duration) int {
+	ms := float64(duration) / float64(time.Millisecond)
+	for i, upper := range latencyBucketsMs {
+		if ms <= upper {
+			return i
+		}
+	}
+	return len(latencyBucketsMs)
+}
+
+// recordSlowOp 将慢操作插入按耗时降序的列表，超出容量时丢弃最快的
+func recordSlowOp(op SlowOperation, duration time.Duration) {
+	slowOpsMu.Lock()
+	defer slowOpsMu.Unlock()
+
+	idx := len(slowOps)
+	for i, existing := range slowOps {
+		if op.DurationMs > existing.DurationMs {
+			idx = i
+			break
+		}
+	}
+	if idx >= slowOpsCapacity {
+		return
+	}
+	slowOps = append(slowOps, SlowOperation{})
+	copy(slowOps[idx+1:], slowOps[idx:])
+	slowOps[idx] = op
+	if len(slowOps) > slowOpsCapacity {
+		slowOps = slowOps[:slowOpsCapacity]
+	}
+	updateSlowOpsMinLocked()
+}
+
+// updateSlowOpsMinLocked 列表已满时，只有比最快一条更慢的操作才需要加锁插入（调用方持有slowOpsMu）
+func updateSlowOpsMinLocked() {
+	var min int64
+	if len(slowOps) >= slowOpsCapacity {
+		min = int64(slowOps[len(slowOps)-1].DurationMs * float64(time.Millisecond))
+	}
+	atomic.StoreInt64(&slowOpsMin, min)
+}
+
+// GetSlowOperations 获取最慢的操作记录，按耗时降序
+func GetSlowOperations() []SlowOperation {
+	slowOpsMu.Lock()
+	defer slowOpsMu.Unlock()
+	return append([]SlowOperation{}, slowOps...)
+}
+
+// errString 返回错误信息，nil时为空
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// snapshot 计数器快照
+func (c *opCounter) snapshot() (count, errors, latency int64, buckets []int64) {
+	buckets = make([]int64, len(c.buckets))
+	for i := range c.buckets {
+		buckets[i] = atomic.LoadInt64(&c.buckets[i])
+	}
+	return atomic.LoadInt64(&c.count), atomic.LoadInt64(&c.errors), atomic.LoadInt64(&c.totalLatency), buckets
+}
+
+// newOperationStats 由累计值计算统计快照
+func newOperationStats(count, errors, latency int64, buckets []int64) OperationStats {
+	s := OperationStats{Count: count, Errors: errors}
+	if count > 0 {
+		s.ErrorRate = float64(errors) / float64(count)
+		s.AvgLatencyMs = float64(latency) / float64(count) / float64(time.Millisecond)
+		s.P50Ms = histogramQuantile(buckets, 0.50)
+		s.P95Ms = histogramQuantile(buckets, 0.95)
+		s.P99Ms = histogramQuantile(buckets, 0.99)
+	}
+	return s
+}
+
+// histogramQuantile 返回包含第q分位的桶上界，落在+Inf桶时返回最大有限上界
+func histogramQuantile(buckets []int64, q float64) float64 {
+	var total int64
+	for _, n := range buckets {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var cumulative int64
+	for i, n := range buckets {
+		cumulative += n
+		if cumulative >= rank && i < len(latencyBucketsMs) {
+			return latencyBucketsMs[i]
+		}
+	}
+	return latencyBucketsMs[len(latencyBucketsMs)-1]
+}
+
+// GetOperationStats 获取各类HBase操作的统计信息，包含按行类型的细分
+func GetOperationStats() map[string]OperationStats {
+	type total struct {
+		count, errors, latency int64
+		buckets                []int64
+		byRowType              map[string]OperationStats
+	}
+
+	opCountersMu.RLock()
+	totals := make(map[string]*total)
+	for key, counter := range opCounters {
+		count, errors, latency, buckets := counter.snapshot()
+
+		t, ok := totals[key.op]
+		if !ok {
+			t = &total{buckets: make([]int64, len(buckets)), byRowType: make(map[string]OperationStats)}
+			totals[key.op] = t
+		}
+		t.count += count
+		t.errors += errors
+		t.latency += latency
+		for i, n := range buckets {
+			t.buckets[i] += n
+		}
+		t.byRowType[key.rowType] = newOperationStats(count, errors, latency, buckets)
+	}
+	opCountersMu.RUnlock()
+
+	stats := make(map[string]OperationStats, len(totals))
+	for op, t := range totals {
+		s := newOperationStats(t.count, t.errors, t.latency, t.buckets)
+		s.ByRowType = t.byRowType
+		stats[op] = s
+	}
+	return stats
+}
+
+// WritePrometheusMetrics 以Prometheus文本格式输出操作计数、错误数和延迟直方图
+func WritePrometheusMetrics(w io.Writer) error {
+	opCountersMu.RLock()
+	keys := make([]opKey, 0, len(opCounters))
+	for key := range opCounters {
+		keys = append(keys, key)
+	}
+	opCountersMu.RUnlock()
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].op != keys[j].op {
+			return keys[i].op < keys[j].op
+		}
+		return keys[i].rowType < keys[j].rowType
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# HELP hbase_operations_total Total number of HBase operations.")
+	fmt.Fprintln(bw, "# TYPE hbase_operations_total counter")
+	snapshots := make([][]int64, len(keys))
+	counts := make([]int64, len(keys))
+	errs := make([]int64, len(keys))
+	latencies := make([]int64, len(keys))
+	for i, key := range keys {
+		counts[i], errs[i], latencies[i], snapshots[i] = counterFor(key).snapshot()
+		fmt.Fprintf(bw, "hbase_operations_total{op=%q,row_type=%q} %d\n", key.op, key.rowType, counts[i])
+	}
+
+	fmt.Fprintln(bw, "# HELP hbase_operation_errors_total Total number of failed HBase operations.")
+	fmt.Fprintln(bw, "# TYPE hbase_operation_errors_total counter")
+	for i, key := range keys {
+		fmt.Fprintf(bw, "hbase_operation_errors_total{op=%q,row_type=%q} %d\n", key.op, key.rowType, errs[i])
+	}
+
+	fmt.Fprintln(bw, "# HELP hbase_operation_duration_seconds HBase operation latency.")
+	fmt.Fprintln(bw, "# TYPE hbase_operation_duration_seconds histogram")
+	for i, key := range keys {
+		var cumulative int64
+		for b, upper := range latencyBucketsMs {
+			cumulative += snapshots[i][b]
+			fmt.Fprintf(bw, "hbase_operation_duration_seconds_bucket{op=%q,row_type=%q,le=\"%g\"} %d\n",
+				key.op, key.rowType, upper/1000, cumulative)
+		}
+		cumulative += snapshots[i][len(latencyBucketsMs)]
+		fmt.Fprintf(bw, "hbase_operation_duration_seconds_bucket{op=%q,row_type=%q,le=\"+Inf\"} %d\n", key.op, key.rowType, cumulative)
+		fmt.Fprintf(bw, "hbase_operation_duration_seconds_sum{op=%q,row_type=%q} %g\n",
+			key.op, key.rowType, time.Duration(latencies[i]).Seconds())
+		fmt.Fprintf(bw, "hbase_operation_duration_seconds_count{op=%q,row_type=%q} %d\n", key.op, key.rowType, counts[i])
+	}
+
+	return bw.Flush()
+}
+
+// instrumentedClient 包装gohbase.Client，为每次操作记录统计信息
+type instrumentedClient struct {
+	gohbase.Client
+}
+
+// newInstrumentedClient 创建带统计的客户端
+func newInstrumentedClient(client gohbase.Client) gohbase.Client {
+	return &instrumentedClient{Client: client}
+}
+
+func (c *instrumentedClient) Get(g *hrpc.Get) (*hrpc.Result, error) {
+	if !MetricsEnabled() {
+		return c.Client.Get(g)
+	}
+	start := time.Now()
+	res, err := c.Client.Get(g)
+	recordOp(OpGet, g, start, err)
+	return res, err
+}
+
+func (c *instrumentedClient) Put(p *hrpc.Mutate) (*hrpc.Result, error) {
+	if !MetricsEnabled() {
+		return c.Client.Put(p)
+	}
+	start := time.Now()
+	res, err := c.Client.Put(p)
+	recordOp(OpPut, p, start, err)
+	return res, err
+}
+
+func (c *instrumentedClient) Delete(d *hrpc.Mutate) (*hrpc.Result, error) {
+	if !MetricsEnabled() {
+		return c.Client.Delete(d)
+	}
+	start := time.Now()
+	res, err := c.Client.Delete(d)
+	recordOp(OpDelete, d, start, err)
+	return res, err
+}
+
+func (c *instrumentedClient) Append(a *hrpc.Mutate) (*hrpc.Result, error) {
+	if !MetricsEnabled() {
+		return c.Client.Append(a)
+	}
+	start := time.Now()
+	res, err := c.Client.Append(a)
+	recordOp(OpAppend, a, start, err)
+	return res, err
+}
+
+func (c *instrumentedClient) Increment(i *hrpc.Mutate) (int64, error) {
+	if !MetricsEnabled() {
+		return c.Client.Increment(i)
+	}
+	start := time.Now()
+	res, err := c.Client.Increment(i)
+	recordOp(OpIncrement, i, start, err)
+	return res, err
+}
+
+func (c *instrumentedClient) CheckAndPut(p *hrpc.Mutate, family string, qualifier string, expectedValue []byte) (bool, error) {
+	if !MetricsEnabled() {
+		return c.Client.CheckAndPut(p, family, qualifier, expectedValue)
+	}
+	start := time.Now()
+	ok, err := c.Client.CheckAndPut(p, family, qualifier, expectedValue)
+	recordOp(OpCheckAndPut, p, start, err)
+	return ok, err
+}
+
+func (c *instrumentedClient) Scan(s *hrpc.Scan) hrpc.Scanner {
+	if !MetricsEnabled() {
+		return c.Client.Scan(s)
+	}
+	return &instrumentedScanner{Scanner: c.Client.Scan(s), scan: s, start: time.Now()}
+}
+
+// instrumentedScanner 包装扫描器，在扫描结束（EOF、出错或关闭）时记录一次扫描操作
+type instrumentedScanner struct {
+	hrpc.Scanner
+	scan  *hrpc.Scan
+	start time.Time
+	once  sync.Once
+}
+
+func (s *instrumentedScanner) Next() (*hrpc.Result, error) {
+	res, err := s.Scanner.Next()
+	if err != nil {
+		s.finish(err)
+	}
+	return res, err
+}
+
+func (s *instrumentedScanner) Close() error {
+	s.finish(nil)
+	return s.Scanner.Close()
+}
+
+// finish 记录扫描结果，io.EOF视为正常结束
+func (s *instrumentedScanner) finish(err error) {
+	s.once.Do(func() {
+		if err == io.EOF {
+			err = nil
+		}
+		recordOp(OpScan, s.scan, s.start, err)
+	})
+}
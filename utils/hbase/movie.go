@@ -2,8 +2,11 @@ package hbase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils/hbase/rowkey"
+	"io"
 	"strconv"
-	"strings"
 
 	"github.com/tsuna/gohbase/hrpc"
 )
@@ -11,7 +14,7 @@ import (
 // GetMovie 根据ID获取电影的基本信息
 func GetMovie(ctx context.Context, movieID string) (map[string]map[string][]byte, error) {
 	// 根据新的数据库结构，获取电影的info数据
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_info")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieInfoKey(movieID))
 	if err != nil {
 		return nil, err
 	}
@@ -46,54 +49,53 @@ func GetMovie(ctx context.Context, movieID string) (map[string]map[string][]byte
 // GetMovieWithAllData 获取电影的所有信息（包括评分、标签等）
 func GetMovieWithAllData(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	// 使用scan获取电影的所有相关数据
-	startRow := movieID + "_"
-	endRow := movieID + "`"
+	startRow, endRow := rowkey.ScanRangeForMovie(movieID)
 
-	scan, err := hrpc.NewScanRangeStr(ctx, "movies", startRow, endRow)
+	scan, err := hrpc.NewScanRangeStr(ctx, MoviesTable(), startRow, endRow)
 	if err != nil {
 		return nil, err
 	}
 
 	scanner := hbaseClient.Scan(scan)
+	defer scanner.Close()
 
-	// 收集所有数据
-	allData := make(map[string]map[string][]byte)
+	// 按行类型收集数据，_stats与_links等同为info列族的行不会互相覆盖
+	rows := make(MovieRows)
 
 	for {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		if len(result.Cells) == 0 {
 			continue
 		}
 
-		for _, cell := range result.Cells {
-			family := string(cell.Family)
-			qualifier := string(cell.Qualifier)
-
-			if _, ok := allData[family]; !ok {
-				allData[family] = make(map[string][]byte)
-			}
-
-			allData[family][qualifier] = cell.Value
+		// 扫描区间可能包含ID以"{movieID}_"开头的其他电影，需核对ID
+		rowMovieID, rowType, err := rowkey.ParseMovieRowKey(string(result.Cells[0].Row))
+		if err != nil || rowMovieID != movieID {
+			continue
 		}
+		rows.AddCells(rowType, result.Cells)
 	}
 
-	if len(allData) == 0 {
+	if len(rows) == 0 {
 		return nil, nil
 	}
 
 	// 解析电影数据
-	movieData := ParseMovieData(movieID, allData)
+	movieData := ParseMovieDataByRowType(movieID, rows)
 	return movieData, nil
 }
 
 // GetMovieRatings 获取电影评分（使用新的宽列格式）
 func GetMovieRatings(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	// 获取电影的ratings行
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_ratings")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieRatingsKey(movieID))
 	if err != nil {
 		return nil, err
 	}
@@ -120,27 +122,10 @@ func GetMovieRatings(ctx context.Context, movieID string) (map[string]interface{
 
 	for _, cell := range result.Cells {
 		if string(cell.Family) == "ratings" {
-			userID := string(cell.Qualifier)
-			// 解析评分数据格式: "{rating}:{userId}:{timestamp}"
-			ratingStr := string(cell.Value)
-			parts := strings.Split(ratingStr, ":")
-
-			if len(parts) >= 3 {
-				if ratingValue, err := strconv.ParseFloat(parts[0], 64); err == nil {
-					ratings = append(ratings, ratingValue)
-
-					ratingInfo := map[string]interface{}{
-						"userId": userID,
-						"rating": ratingValue,
-					}
-
-					// 添加时间戳
-					if timestamp, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
-						ratingInfo["timestamp"] = timestamp
-					}
-
-					ratingsData = append(ratingsData, ratingInfo)
-				}
+			// 解析评分数据格式: "{rating}:{userId}:{timestamp}[:{source}]"
+			if parsed, ok := ParseRatingValue(string(cell.Value)); ok {
+				ratings = append(ratings, parsed.Rating)
+				ratingsData = append(ratingsData, ratingInfo(string(cell.Qualifier), parsed))
 			}
 		}
 	}
@@ -193,10 +178,38 @@ func GetMovieTags(ctx context.Context, movieID string) (map[string]interface{},
 	return GetMovieTagsWithDetails(ctx, movieID)
 }
 
-// GetMovieStats 获取电影统计信息
-func GetMovieStats(ctx context.Context, movieID string) (map[string]interface{}, error) {
+// PutMovieTag 写入用户对电影的一个标签到{movieId}_tags行（tags列族，列名为{userId}_{timestamp}，值为"{tag}:{userId}:{timestamp}"）
+func PutMovieTag(ctx context.Context, movieID, userID, tag string, timestamp int64) error {
+	ts := strconv.FormatInt(timestamp, 10)
+	put, err := hrpc.NewPutStr(ctx, MoviesTable(), rowkey.MovieTagsKey(movieID), map[string]map[string][]byte{
+		"tags": {userID + "_" + ts: []byte(tag + ":" + userID + ":" + ts)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = hbaseClient.Put(put)
+	return err
+}
+
+// DeleteMovieAllRows 删除电影的全部行（_info、_stats、_links、_ratings、_tags、_genome），行不存在不算失败
+func DeleteMovieAllRows(ctx context.Context, movieID string) error {
+	for _, rowType := range rowkey.RowTypes {
+		del, err := hrpc.NewDelStr(ctx, MoviesTable(), rowkey.MovieKey(movieID, rowType), nil)
+		if err != nil {
+			return err
+		}
+		if _, err := hbaseClient.Delete(del); err != nil {
+			return fmt.Errorf("删除电影 %s 的%s行失败: %w", movieID, rowType, err)
+		}
+	}
+	return nil
+}
+
+// GetMovieStats 获取电影统计信息，options可以指定列投影（如hrpc.Families(StatsColumns())）
+func GetMovieStats(ctx context.Context, movieID string, options ...func(hrpc.Call) error) (map[string]interface{}, error) {
 	// 获取电影的stats行
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_stats")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieStatsKey(movieID), options...)
 	if err != nil {
 		return nil, err
 	}
@@ -236,14 +249,14 @@ func GetMovieStats(ctx context.Context, movieID string) (map[string]interface{},
 }
 
 // GetMovieLinks 获取电影外部链接（使用通用函数）
-func GetMovieLinks(ctx context.Context, movieID string) (map[string]interface{}, error) {
-	return GetMovieLinksWithUrls(ctx, movieID)
+func GetMovieLinks(ctx context.Context, movieID string, options ...func(hrpc.Call) error) (map[string]interface{}, error) {
+	return GetMovieLinksWithUrls(ctx, movieID, options...)
 }
 
 // GetMovieGenome 获取电影基因分数（使用新的宽列格式）
 func GetMovieGenome(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	// 获取电影的genome行
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_genome")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieGenomeKey(movieID))
 	if err != nil {
 		return nil, err
 	}
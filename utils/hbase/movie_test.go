@@ -0,0 +1,69 @@
+package hbase
+
+import (
+	"context"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"gohbase/utils/hbase/rowkey"
+	"testing"
+)
+
+// TestGetMovieWithAllDataRowTypeCollision 不同行类型使用相同列族和列名时各自的值都不丢失
+func TestGetMovieWithAllDataRowTypeCollision(t *testing.T) {
+	client := hbasetest.New()
+	cells := func(family string, values map[string]string) map[string]map[string][]byte {
+		columns := make(map[string][]byte, len(values))
+		for qualifier, value := range values {
+			columns[qualifier] = []byte(value)
+		}
+		return map[string]map[string][]byte{family: columns}
+	}
+	// _stats和_links都使用info列族并都有updated_time；_tags行（排在_info之后）的info:title与_info行冲突
+	client.SetRow(MoviesTable(), rowkey.MovieInfoKey("1"), cells("info", map[string]string{
+		"title": "Toy Story (1995)", "genres": "Animation|Comedy",
+	}))
+	client.SetRow(MoviesTable(), rowkey.MovieStatsKey("1"), cells("info", map[string]string{
+		"avg_rating": "3.9", "rating_count": "215", "updated_time": "100",
+	}))
+	client.SetRow(MoviesTable(), rowkey.MovieLinksKey("1"), cells("info", map[string]string{
+		"imdbId": "0114709", "tmdbId": "862", "updated_time": "200", "avg_rating": "1.0",
+	}))
+	client.SetRow(MoviesTable(), rowkey.MovieTagsKey("1"), cells("info", map[string]string{
+		"title": "pixar:7:300",
+	}))
+	// ID以"1_"开头的其他电影不混入
+	client.SetRow(MoviesTable(), rowkey.MovieStatsKey("1_2"), cells("info", map[string]string{
+		"avg_rating": "0.5", "rating_count": "1",
+	}))
+	SetClient(client)
+
+	movie, err := GetMovieWithAllData(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetMovieWithAllData失败: %v", err)
+	}
+
+	tests := []struct {
+		field string
+		want  interface{}
+	}{
+		{"title", "Toy Story (1995)"},
+		{"avgRating", 3.9},
+		{"ratingCount", 215},
+		{"updatedTime", int64(100)},
+		{"uniqueTags", []string{"pixar"}},
+		{"genres", []string{"Animation", "Comedy"}},
+	}
+	for _, tt := range tests {
+		if got := movie[tt.field]; fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+	links, _ := movie["links"].(map[string]interface{})
+	if links["imdbId"] != "0114709" || links["tmdbId"] != "862" {
+		t.Errorf("links = %v, want imdbId 0114709, tmdbId 862", movie["links"])
+	}
+
+	if movie, err := GetMovieWithAllData(context.Background(), "404"); err != nil || movie != nil {
+		t.Errorf("不存在的电影 = %v, %v, want nil, nil", movie, err)
+	}
+}
@@ -3,121 +3,215 @@ package hbase
 import (
 	"context"
 	"fmt"
+	"gohbase/utils/hbase/rowkey"
 	"strconv"
 	"strings"
 
 	"github.com/tsuna/gohbase/hrpc"
 )
 
+// MovieRows 按行类型组织的电影原始数据：行类型(info/stats/links/...) -> 列族 -> 列 -> 值
+// 不同行类型即使使用相同的列族和列名也不会互相覆盖
+type MovieRows map[string]map[string]map[string][]byte
+
+// AddCells 将一行的单元格加入指定行类型
+func (r MovieRows) AddCells(rowType string, cells []*hrpc.Cell) {
+	if _, ok := r[rowType]; !ok {
+		r[rowType] = make(map[string]map[string][]byte)
+	}
+	for _, cell := range cells {
+		family := string(cell.Family)
+		if _, ok := r[rowType][family]; !ok {
+			r[rowType][family] = make(map[string][]byte)
+		}
+		r[rowType][family][string(cell.Qualifier)] = cell.Value
+	}
+}
+
 // ParseMovieData 从HBase结果解析电影数据（适配新的数据库结构）
+// data按列族组织，适用于单行结果或已将stats合并到info列族的数据
 func ParseMovieData(movieID string, data map[string]map[string][]byte) map[string]interface{} {
 	result := map[string]interface{}{
 		"movieId": movieID,
 	}
 
-	// 处理基本信息（info列族）
+	// 处理基本信息和统计信息（info列族）
 	if infoData, ok := data["info"]; ok {
-		if title, ok := infoData["title"]; ok {
-			result["title"] = string(title)
-		}
-		if genres, ok := infoData["genres"]; ok {
-			result["genres"] = strings.Split(string(genres), "|")
-		}
-
-		// 处理统计信息
-		if avgRating, ok := infoData["avg_rating"]; ok {
-			if rating, err := strconv.ParseFloat(string(avgRating), 64); err == nil {
-				result["avgRating"] = rating
-			}
-		}
-		if ratingCount, ok := infoData["rating_count"]; ok {
-			if count, err := strconv.Atoi(string(ratingCount)); err == nil {
-				result["ratingCount"] = count
-			}
-		}
-		if updatedTime, ok := infoData["updated_time"]; ok {
-			if timestamp, err := strconv.ParseInt(string(updatedTime), 10, 64); err == nil {
-				result["updatedTime"] = timestamp
-			}
-		}
+		parseInfoColumns(result, infoData)
+		parseStatsColumns(result, infoData)
 	}
 
 	// 处理评分数据（ratings列族 - 宽列格式）
 	if ratingsData, ok := data["ratings"]; ok {
-		var ratings []map[string]interface{}
-		var ratingValues []float64
-
-		for userID, ratingBytes := range ratingsData {
-			// 解析评分数据格式: "{rating}:{userId}:{timestamp}"
-			ratingStr := string(ratingBytes)
-			parts := strings.Split(ratingStr, ":")
-
-			if len(parts) >= 3 {
-				if rating, err := strconv.ParseFloat(parts[0], 64); err == nil {
-					ratingInfo := map[string]interface{}{
-						"userId": userID,
-						"rating": rating,
-					}
+		parseRatingsColumns(result, ratingsData)
+	}
 
-					// 添加时间戳
-					if timestamp, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
-						ratingInfo["timestamp"] = timestamp
-					}
+	// 处理标签数据（tags列族 - 宽列格式）
+	if tagsData, ok := data["tags"]; ok {
+		parseTagsColumns(result, tagsData)
+	}
 
-					ratings = append(ratings, ratingInfo)
-					ratingValues = append(ratingValues, rating)
-				}
+	// 处理基因分数数据（genome列族 - 宽列格式）
+	if genomeData, ok := data["genome"]; ok {
+		parseGenomeColumns(result, genomeData)
+	}
+
+	setParsedDefaults(result)
+	return result
+}
+
+// ParseMovieDataByRowType 从按行类型组织的数据解析电影数据
+// 与ParseMovieData返回相同的结构，另外包含"links"
+func ParseMovieDataByRowType(movieID string, rows MovieRows) map[string]interface{} {
+	result := map[string]interface{}{
+		"movieId": movieID,
+	}
+
+	if infoData, ok := rows["info"]["info"]; ok {
+		parseInfoColumns(result, infoData)
+	}
+
+	// stats行优先于从ratings行实时计算的平均分
+	if statsData, ok := rows["stats"]["info"]; ok {
+		parseStatsColumns(result, statsData)
+	}
+
+	if linksData, ok := rows["links"]["info"]; ok {
+		result["links"] = parseLinksColumns(linksData)
+	}
+
+	if ratingsData, ok := rows["ratings"]["ratings"]; ok {
+		parseRatingsColumns(result, ratingsData)
+	}
+
+	// _tags行的列族在不同导入版本中为info或tags，合并处理
+	if tagsRow, ok := rows["tags"]; ok {
+		tagsData := make(map[string][]byte)
+		for _, columns := range tagsRow {
+			for qualifier, value := range columns {
+				tagsData[qualifier] = value
 			}
 		}
+		parseTagsColumns(result, tagsData)
+	}
 
-		result["ratings"] = ratings
+	if genomeData, ok := rows["genome"]["genome"]; ok {
+		parseGenomeColumns(result, genomeData)
+	}
 
-		// 计算平均评分（如果没有预计算的值）
-		if _, hasAvgRating := result["avgRating"]; !hasAvgRating && len(ratingValues) > 0 {
-			var sum float64
-			for _, rating := range ratingValues {
-				sum += rating
-			}
-			result["avgRating"] = sum / float64(len(ratingValues))
+	setParsedDefaults(result)
+	return result
+}
+
+// parseInfoColumns 解析_info行的标题和类型
+func parseInfoColumns(result map[string]interface{}, infoData map[string][]byte) {
+	if title, ok := infoData["title"]; ok {
+		result["title"] = string(title)
+	}
+	if genres, ok := infoData["genres"]; ok {
+		result["genres"] = normalizeGenres(string(genres))
+	}
+}
+
+// parseStatsColumns 解析_stats行的预计算统计信息
+func parseStatsColumns(result map[string]interface{}, statsData map[string][]byte) {
+	if avgRating, ok := statsData["avg_rating"]; ok {
+		if rating, err := strconv.ParseFloat(string(avgRating), 64); err == nil {
+			result["avgRating"] = rating
+		}
+	}
+	if ratingCount, ok := statsData["rating_count"]; ok {
+		if count, err := strconv.Atoi(string(ratingCount)); err == nil {
+			result["ratingCount"] = count
+		}
+	}
+	if updatedTime, ok := statsData["updated_time"]; ok {
+		if timestamp, err := strconv.ParseInt(string(updatedTime), 10, 64); err == nil {
+			result["updatedTime"] = timestamp
 		}
 	}
+}
 
-	// 处理标签数据（tags列族 - 宽列格式）
-	if tagsData, ok := data["tags"]; ok {
-		var uniqueTags []string
-		tagSet := make(map[string]bool)
-
-		for _, tagBytes := range tagsData {
-			// 解析标签数据格式: "{tag}:{userId}:{timestamp}"
-			tagStr := string(tagBytes)
-			parts := strings.Split(tagStr, ":")
-
-			if len(parts) >= 1 {
-				tag := parts[0]
-				if !tagSet[tag] {
-					uniqueTags = append(uniqueTags, tag)
-					tagSet[tag] = true
-				}
-			}
+// parseRatingsColumns 解析_ratings行的宽列评分数据
+func parseRatingsColumns(result map[string]interface{}, ratingsData map[string][]byte) {
+	var ratings []map[string]interface{}
+	var ratingValues []float64
+
+	for userID, ratingBytes := range ratingsData {
+		// 解析评分数据格式: "{rating}:{userId}:{timestamp}[:{source}]"
+		if parsed, ok := ParseRatingValue(string(ratingBytes)); ok {
+			ratings = append(ratings, ratingInfo(userID, parsed))
+			ratingValues = append(ratingValues, parsed.Rating)
 		}
+	}
+
+	result["ratings"] = ratings
 
-		result["uniqueTags"] = uniqueTags
+	// 计算平均评分（如果没有预计算的值）
+	if _, hasAvgRating := result["avgRating"]; !hasAvgRating && len(ratingValues) > 0 {
+		var sum float64
+		for _, rating := range ratingValues {
+			sum += rating
+		}
+		result["avgRating"] = sum / float64(len(ratingValues))
 	}
+}
 
-	// 处理基因分数数据（genome列族 - 宽列格式）
-	if genomeData, ok := data["genome"]; ok {
-		genome := make(map[string]float64)
+// parseTagsColumns 解析_tags行的宽列标签数据
+func parseTagsColumns(result map[string]interface{}, tagsData map[string][]byte) {
+	var uniqueTags []string
+	tagSet := make(map[string]bool)
+
+	for _, tagBytes := range tagsData {
+		// 解析标签数据格式: "{tag}:{userId}:{timestamp}"
+		tagStr := string(tagBytes)
+		parts := strings.Split(tagStr, ":")
 
-		for tagID, relevanceBytes := range genomeData {
-			if relevance, err := strconv.ParseFloat(string(relevanceBytes), 64); err == nil {
-				genome[tagID] = relevance
+		if len(parts) >= 1 {
+			tag := parts[0]
+			if !tagSet[tag] {
+				uniqueTags = append(uniqueTags, tag)
+				tagSet[tag] = true
 			}
 		}
+	}
+
+	result["uniqueTags"] = uniqueTags
+}
+
+// parseGenomeColumns 解析_genome行的基因分数
+func parseGenomeColumns(result map[string]interface{}, genomeData map[string][]byte) {
+	genome := make(map[string]float64)
+
+	for tagID, relevanceBytes := range genomeData {
+		if relevance, err := strconv.ParseFloat(string(relevanceBytes), 64); err == nil {
+			genome[tagID] = relevance
+		}
+	}
+
+	result["genome"] = genome
+}
+
+// parseLinksColumns 解析_links行并生成完整URL
+func parseLinksColumns(linksData map[string][]byte) map[string]interface{} {
+	links := make(map[string]interface{})
 
-		result["genome"] = genome
+	if imdbID, ok := linksData["imdbId"]; ok {
+		links["imdbId"] = string(imdbID)
+		// 生成IMDB URL
+		links["imdbUrl"] = fmt.Sprintf("https://www.imdb.com/title/tt%s/", string(imdbID))
 	}
+	if tmdbID, ok := linksData["tmdbId"]; ok {
+		links["tmdbId"] = string(tmdbID)
+		// 生成TMDB URL
+		links["tmdbUrl"] = fmt.Sprintf("https://www.themoviedb.org/movie/%s", string(tmdbID))
+	}
+
+	return links
+}
 
-	// 设置默认值
+// setParsedDefaults 设置解析结果的默认值
+func setParsedDefaults(result map[string]interface{}) {
 	if result["avgRating"] == nil {
 		result["avgRating"] = 0.0
 	}
@@ -130,14 +224,63 @@ func ParseMovieData(movieID string, data map[string]map[string][]byte) map[strin
 	if result["genome"] == nil {
 		result["genome"] = map[string]float64{}
 	}
+}
 
-	return result
+// canonicalGenres 不符合首字母大写规则的类型名称
+var canonicalGenres = map[string]string{
+	"imax":               "IMAX",
+	"(no genres listed)": "(no genres listed)",
+}
+
+// normalizeGenres 解析"A|B|C"格式的类型字符串：去除空白、忽略大小写去重，
+// 并统一为首字母大写形式（如 "Action|action| Drama |Drama" -> ["Action", "Drama"]）
+func normalizeGenres(raw string) []string {
+	genres := []string{}
+	seen := make(map[string]bool)
+
+	for _, genre := range strings.Split(raw, "|") {
+		key := strings.ToLower(strings.TrimSpace(genre))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		genres = append(genres, titleCaseGenre(key))
+	}
+
+	return genres
 }
 
-// GetMovieLinksWithUrls 获取电影外部链接并生成完整URL（通用函数）
-func GetMovieLinksWithUrls(ctx context.Context, movieID string) (map[string]interface{}, error) {
+// ParseGenres 解析并规范化类型字符串（供其他包使用）
+func ParseGenres(raw string) []string {
+	return normalizeGenres(raw)
+}
+
+// JoinGenres 规范化类型列表并拼接为HBase存储格式
+func JoinGenres(genres []string) string {
+	return strings.Join(normalizeGenres(strings.Join(genres, "|")), "|")
+}
+
+// titleCaseGenre 将小写类型名转换为首字母大写形式，连字符后的单词同样大写（如 sci-fi -> Sci-Fi）
+func titleCaseGenre(genre string) string {
+	if canonical, ok := canonicalGenres[genre]; ok {
+		return canonical
+	}
+
+	b := []byte(genre)
+	upperNext := true
+	for i, ch := range b {
+		if upperNext && ch >= 'a' && ch <= 'z' {
+			b[i] = ch - 'a' + 'A'
+		}
+		upperNext = ch == ' ' || ch == '-'
+	}
+	return string(b)
+}
+
+// GetMovieLinksWithUrls 获取电影外部链接并生成完整URL（通用函数），options可以指定列投影
+func GetMovieLinksWithUrls(ctx context.Context, movieID string, options ...func(hrpc.Call) error) (map[string]interface{}, error) {
 	// 获取电影的links行
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_links")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieLinksKey(movieID), options...)
 	if err != nil {
 		return nil, err
 	}
@@ -147,27 +290,13 @@ func GetMovieLinksWithUrls(ctx context.Context, movieID string) (map[string]inte
 		return nil, err
 	}
 
-	links := make(map[string]interface{})
-
-	if result.Cells != nil {
-		for _, cell := range result.Cells {
-			if string(cell.Family) == "info" {
-				qualifier := string(cell.Qualifier)
-				value := string(cell.Value)
-
-				switch qualifier {
-				case "imdbId":
-					links["imdbId"] = value
-					// 生成IMDB URL
-					links["imdbUrl"] = fmt.Sprintf("https://www.imdb.com/title/tt%s/", value)
-				case "tmdbId":
-					links["tmdbId"] = value
-					// 生成TMDB URL
-					links["tmdbUrl"] = fmt.Sprintf("https://www.themoviedb.org/movie/%s", value)
-				}
-			}
+	linksData := make(map[string][]byte)
+	for _, cell := range result.Cells {
+		if string(cell.Family) == "info" {
+			linksData[string(cell.Qualifier)] = cell.Value
 		}
 	}
+	links := parseLinksColumns(linksData)
 
 	// 如果没有找到任何链接数据，返回空的links对象
 	if len(links) == 0 {
@@ -185,7 +314,7 @@ func GetMovieLinksWithUrls(ctx context.Context, movieID string) (map[string]inte
 // GetMovieTagsWithDetails 获取电影标签并返回详细信息（通用函数）
 func GetMovieTagsWithDetails(ctx context.Context, movieID string) (map[string]interface{}, error) {
 	// 获取电影的tags行
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_tags")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieTagsKey(movieID))
 	if err != nil {
 		return nil, err
 	}
@@ -202,14 +331,15 @@ func GetMovieTagsWithDetails(ctx context.Context, movieID string) (map[string]in
 
 	if result.Cells != nil {
 		for _, cell := range result.Cells {
-			if string(cell.Family) == "info" {
-				userID := string(cell.Qualifier)
+			// 旧导入数据的列族为info、列名为userId；接口写入的列族为tags、列名为{userId}_{timestamp}
+			if family := string(cell.Family); family == "info" || family == "tags" {
 				// 解析标签数据格式: "{tag}:{userId}:{timestamp}"
 				tagStr := string(cell.Value)
 				parts := strings.Split(tagStr, ":")
 
 				if len(parts) >= 3 {
 					tag := parts[0]
+					userID := parts[1]
 					timestamp := parts[2]
 
 					// 添加到唯一标签列表
@@ -0,0 +1,90 @@
+package hbase
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNormalizeGenres(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{"Action|action| Drama |Drama", []string{"Action", "Drama"}},
+		{"sci-fi|SCI-FI|film-noir", []string{"Sci-Fi", "Film-Noir"}},
+		{"imax|IMAX|Imax", []string{"IMAX"}},
+		{"(no genres listed)", []string{"(no genres listed)"}},
+		{"children|||  |comedy", []string{"Children", "Comedy"}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		got := normalizeGenres(tt.raw)
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("normalizeGenres(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestJoinGenres(t *testing.T) {
+	tests := []struct {
+		genres []string
+		want   string
+	}{
+		{[]string{"action", "Action ", "drama"}, "Action|Drama"},
+		{[]string{"Comedy|comedy", "romance"}, "Comedy|Romance"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := JoinGenres(tt.genres); got != tt.want {
+			t.Errorf("JoinGenres(%q) = %q, want %q", tt.genres, got, tt.want)
+		}
+	}
+}
+
+func TestParseMovieDataNormalizesGenres(t *testing.T) {
+	data := map[string]map[string][]byte{
+		"info": {"title": []byte("Heat (1995)"), "genres": []byte("Action|action| Crime |Thriller|crime")},
+	}
+	got := ParseMovieData("6", data)["genres"]
+	if want := []string{"Action", "Crime", "Thriller"}; fmt.Sprintf("%q", got) != fmt.Sprintf("%q", want) {
+		t.Errorf("ParseMovieData genres = %q, want %q", got, want)
+	}
+}
+
+func TestParseMovieDataByRowType(t *testing.T) {
+	info := map[string]map[string][]byte{"info": {"title": []byte("Heat (1995)"), "genres": []byte("Action|Crime")}}
+	stats := map[string]map[string][]byte{"info": {"avg_rating": []byte("4.25"), "rating_count": []byte("8")}}
+	ratings := map[string]map[string][]byte{"ratings": {"1": []byte("3.0:1:1000"), "2": []byte("5.0:2:1000")}}
+	links := map[string]map[string][]byte{"info": {"imdbId": []byte("0113277"), "tmdbId": []byte("949")}}
+
+	tests := []struct {
+		name            string
+		rows            MovieRows
+		wantAvg         float64
+		wantRatingCount interface{}
+	}{
+		{"只有stats行", MovieRows{"info": info, "stats": stats}, 4.25, 8},
+		{"stats行优先于实时计算", MovieRows{"info": info, "stats": stats, "ratings": ratings}, 4.25, 8},
+		{"没有stats行时按ratings计算", MovieRows{"info": info, "ratings": ratings}, 4.0, nil},
+		{"都没有", MovieRows{"info": info}, 0.0, nil},
+		{"links行不覆盖stats", MovieRows{"info": info, "stats": stats, "links": links}, 4.25, 8},
+	}
+	for _, tt := range tests {
+		got := ParseMovieDataByRowType("6", tt.rows)
+		if got["title"] != "Heat (1995)" {
+			t.Errorf("%s: title = %v", tt.name, got["title"])
+		}
+		if got["avgRating"] != tt.wantAvg {
+			t.Errorf("%s: avgRating = %v, want %v", tt.name, got["avgRating"], tt.wantAvg)
+		}
+		if got["ratingCount"] != tt.wantRatingCount {
+			t.Errorf("%s: ratingCount = %v, want %v", tt.name, got["ratingCount"], tt.wantRatingCount)
+		}
+	}
+
+	got := ParseMovieDataByRowType("6", MovieRows{"info": info, "stats": stats, "links": links})
+	wantLinks := "map[imdbId:0113277 imdbUrl:https://www.imdb.com/title/tt0113277/ tmdbId:949 tmdbUrl:https://www.themoviedb.org/movie/949]"
+	if fmt.Sprint(got["links"]) != wantLinks {
+		t.Errorf("links = %v, want %s", got["links"], wantLinks)
+	}
+}
@@ -0,0 +1,181 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"gohbase/utils/hbase/rowkey"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// PoolHealthCheckInterval 连接池健康检查的间隔
+const PoolHealthCheckInterval = 30 * time.Second
+
+// poolProbeTimeout 单次探测的超时时间
+const poolProbeTimeout = 5 * time.Second
+
+// 连接池槽位的健康状态
+const (
+	PoolSlotUnknown  = "unknown"  // 尚未检查
+	PoolSlotHealthy  = "healthy"  // 最近一次探测成功
+	PoolSlotReplaced = "replaced" // 探测失败，已换成新客户端
+)
+
+// PoolSlotHealth 连接池单个槽位的健康状态
+type PoolSlotHealth struct {
+	Slot            int       `json:"slot"`
+	LastHealthCheck time.Time `json:"lastHealthCheck"`
+	HealthStatus    string    `json:"healthStatus"`
+	LastError       string    `json:"lastError,omitempty"` // 最近一次失败探测的错误
+	Replacements    int       `json:"replacements"`        // 累计替换次数
+}
+
+var (
+	// poolHealth 与clientPool一一对应，由poolMu保护
+	poolHealth []PoolSlotHealth
+
+	poolMonitor   *PoolHealthMonitor
+	poolMonitorMu sync.Mutex
+)
+
+// PoolHealthMonitor 定期探测连接池中的每个客户端，探测失败（如ZooKeeper会话过期）时换成新客户端
+type PoolHealthMonitor struct {
+	interval  time.Duration
+	newClient func() gohbase.Client
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// startPoolHealthMonitor 启动连接池健康检查，替换之前启动的检查
+func startPoolHealthMonitor(interval time.Duration, newClient func() gohbase.Client) {
+	poolMonitorMu.Lock()
+	defer poolMonitorMu.Unlock()
+
+	if poolMonitor != nil {
+		poolMonitor.stop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	poolMonitor = &PoolHealthMonitor{
+		interval:  interval,
+		newClient: newClient,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	go poolMonitor.run(ctx)
+}
+
+// stopPoolHealthMonitor 停止连接池健康检查并等待正在进行的探测结束
+func stopPoolHealthMonitor() {
+	poolMonitorMu.Lock()
+	defer poolMonitorMu.Unlock()
+
+	if poolMonitor != nil {
+		poolMonitor.stop()
+		poolMonitor = nil
+	}
+}
+
+func (m *PoolHealthMonitor) stop() {
+	m.cancel()
+	<-m.done
+}
+
+func (m *PoolHealthMonitor) run(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkAll(ctx)
+		}
+	}
+}
+
+// checkAll 并发探测所有槽位
+func (m *PoolHealthMonitor) checkAll(ctx context.Context) {
+	poolMu.Lock()
+	clients := append([]gohbase.Client(nil), clientPool...)
+	poolMu.Unlock()
+
+	var wg sync.WaitGroup
+	for slot, client := range clients {
+		if client == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(slot int, client gohbase.Client) {
+			defer wg.Done()
+			m.checkSlot(ctx, slot, client)
+		}(slot, client)
+	}
+	wg.Wait()
+}
+
+// checkSlot 探测一个槽位，失败时换成新客户端并关闭旧客户端
+func (m *PoolHealthMonitor) checkSlot(ctx context.Context, slot int, client gohbase.Client) {
+	err := probeClient(ctx, client)
+	if ctx.Err() != nil {
+		// 监控已停止，探测结果没有意义
+		return
+	}
+
+	var replacement gohbase.Client
+	if err != nil {
+		replacement = m.newClient()
+	}
+
+	poolMu.Lock()
+	// 期间槽位已被替换（如重新初始化）时不覆盖
+	if slot >= len(clientPool) || clientPool[slot] != client {
+		poolMu.Unlock()
+		if replacement != nil {
+			replacement.Close()
+		}
+		return
+	}
+	health := &poolHealth[slot]
+	health.LastHealthCheck = time.Now()
+	if err == nil {
+		health.HealthStatus = PoolSlotHealthy
+		poolMu.Unlock()
+		return
+	}
+	clientPool[slot] = replacement
+	health.HealthStatus = PoolSlotReplaced
+	health.LastError = err.Error()
+	health.Replacements++
+	poolMu.Unlock()
+
+	logrus.Warnf("连接池客户端 %d 健康检查失败，已替换为新客户端: %v", slot, err)
+	client.Close()
+}
+
+// probeClient 读取一行电影数据检查客户端是否可用，行或表不存在不算失败
+func probeClient(ctx context.Context, client gohbase.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, poolProbeTimeout)
+	defer cancel()
+
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieInfoKey("1"))
+	if err != nil {
+		return err
+	}
+	if _, err := client.Get(get); err != nil && !errors.Is(err, gohbase.TableNotFound) {
+		return err
+	}
+	return nil
+}
+
+// GetPoolHealth 返回连接池各槽位的健康状态
+func GetPoolHealth() []PoolSlotHealth {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	return append([]PoolSlotHealth{}, poolHealth...)
+}
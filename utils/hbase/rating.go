@@ -0,0 +1,62 @@
+package hbase
+
+import (
+	"strconv"
+	"strings"
+)
+
+// RatingValue 构建_ratings行的评分值: "{rating}:{userId}:{timestamp}:{source}"，source为空时省略最后一段
+func RatingValue(rating float64, userID string, timestamp int64, source string) []byte {
+	value := strconv.FormatFloat(rating, 'f', 1, 64) + ":" + userID + ":" + strconv.FormatInt(timestamp, 10)
+	if source != "" {
+		value += ":" + source
+	}
+	return []byte(value)
+}
+
+// ParsedRating 解析后的_ratings行评分值
+type ParsedRating struct {
+	Rating       float64
+	UserID       string
+	Timestamp    int64
+	HasTimestamp bool   // 时间戳段是有效的整数
+	Source       string // 评分来源（如api、test_batch），旧的三段格式为空
+}
+
+// ParseRatingValue 解析_ratings行的评分值，兼容旧的三段格式"{rating}:{userId}:{timestamp}"。
+// 少于三段或评分不是数字时返回false
+func ParseRatingValue(value string) (ParsedRating, bool) {
+	parts := strings.SplitN(value, ":", 4)
+	if len(parts) < 3 {
+		return ParsedRating{}, false
+	}
+	rating, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return ParsedRating{}, false
+	}
+
+	parsed := ParsedRating{Rating: rating, UserID: parts[1]}
+	if timestamp, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
+		parsed.Timestamp = timestamp
+		parsed.HasTimestamp = true
+	}
+	if len(parts) == 4 {
+		parsed.Source = parts[3]
+	}
+	return parsed, true
+}
+
+// ratingInfo 构建评分列表中的一项：userId、rating，有效时加上timestamp和source
+func ratingInfo(userID string, parsed ParsedRating) map[string]interface{} {
+	info := map[string]interface{}{
+		"userId": userID,
+		"rating": parsed.Rating,
+	}
+	if parsed.HasTimestamp {
+		info["timestamp"] = parsed.Timestamp
+	}
+	if parsed.Source != "" {
+		info["source"] = parsed.Source
+	}
+	return info
+}
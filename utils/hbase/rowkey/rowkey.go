@@ -0,0 +1,103 @@
+// Package rowkey 集中定义movies表"{movieId}_{rowType}"行键的构造和解析。
+package rowkey
+
+import (
+	"errors"
+	"strings"
+)
+
+// 电影行类型
+const (
+	TypeInfo    = "info"
+	TypeStats   = "stats"
+	TypeLinks   = "links"
+	TypeRatings = "ratings"
+	TypeTags    = "tags"
+	TypeGenome  = "genome"
+)
+
+// separator 电影ID与行类型之间的分隔符
+const separator = "_"
+
+// scanRangeEnd ASCII中紧跟"_"的字符，用作前缀扫描的结束行
+const scanRangeEnd = "`"
+
+// RowTypes 每部电影的全部行类型
+var RowTypes = []string{TypeInfo, TypeStats, TypeLinks, TypeRatings, TypeTags, TypeGenome}
+
+// ErrInvalidRowKey 行键不符合"{movieId}_{rowType}"格式
+var ErrInvalidRowKey = errors.New("无效的电影行键")
+
+// MovieKey 构造电影指定类型行的行键
+func MovieKey(movieID, rowType string) string {
+	return movieID + separator + rowType
+}
+
+// MovieInfoKey 电影基本信息行
+func MovieInfoKey(movieID string) string { return MovieKey(movieID, TypeInfo) }
+
+// MovieStatsKey 电影统计行
+func MovieStatsKey(movieID string) string { return MovieKey(movieID, TypeStats) }
+
+// MovieLinksKey 电影外部链接行
+func MovieLinksKey(movieID string) string { return MovieKey(movieID, TypeLinks) }
+
+// MovieRatingsKey 电影评分宽列行
+func MovieRatingsKey(movieID string) string { return MovieKey(movieID, TypeRatings) }
+
+// MovieTagsKey 电影标签行
+func MovieTagsKey(movieID string) string { return MovieKey(movieID, TypeTags) }
+
+// MovieGenomeKey 电影基因标签行
+func MovieGenomeKey(movieID string) string { return MovieKey(movieID, TypeGenome) }
+
+// ParseMovieRowKey 解析行键，返回电影ID和行类型。
+// 按最后一个"_"切分，电影ID本身可以包含下划线；行类型必须是已知类型。
+func ParseMovieRowKey(key string) (string, string, error) {
+	idx := strings.LastIndex(key, separator)
+	if idx <= 0 || idx == len(key)-1 {
+		return "", "", ErrInvalidRowKey
+	}
+
+	movieID, rowType := key[:idx], key[idx+1:]
+	if !isKnownRowType(rowType) {
+		return "", "", ErrInvalidRowKey
+	}
+	return movieID, rowType, nil
+}
+
+// MovieIDFromKey 行键属于指定行类型时返回其电影ID
+func MovieIDFromKey(key, rowType string) (string, bool) {
+	movieID, keyType, err := ParseMovieRowKey(key)
+	if err != nil || keyType != rowType {
+		return "", false
+	}
+	return movieID, true
+}
+
+// IsMovieKeyOfType 行键是否属于指定行类型
+func IsMovieKeyOfType(key, rowType string) bool {
+	_, ok := MovieIDFromKey(key, rowType)
+	return ok
+}
+
+// ScanRangeForMovie 返回覆盖电影全部行的扫描区间[start, stop)。
+// 电影ID含下划线时区间可能包含其他电影（如"1"会包含"1_2_info"），调用方应用ParseMovieRowKey核对ID。
+func ScanRangeForMovie(movieID string) (string, string) {
+	return movieID + separator, movieID + scanRangeEnd
+}
+
+// SuffixRegex 匹配指定行类型行键的正则表达式，用于服务端RowFilter
+func SuffixRegex(rowType string) string {
+	return ".*" + separator + rowType + "$"
+}
+
+// isKnownRowType 是否为已知行类型
+func isKnownRowType(rowType string) bool {
+	for _, t := range RowTypes {
+		if t == rowType {
+			return true
+		}
+	}
+	return false
+}
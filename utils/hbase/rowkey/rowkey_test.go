@@ -0,0 +1,150 @@
+package rowkey
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestParseMovieRowKey(t *testing.T) {
+	tests := []struct {
+		key         string
+		wantID      string
+		wantRowType string
+		wantErr     bool
+	}{
+		{"1_info", "1", TypeInfo, false},
+		{"1_stats", "1", TypeStats, false},
+		{"1_links", "1", TypeLinks, false},
+		{"1_ratings", "1", TypeRatings, false},
+		{"1_tags", "1", TypeTags, false},
+		{"1_genome", "1", TypeGenome, false},
+		{"193609_info", "193609", TypeInfo, false},
+		{"tt_0111161_info", "tt_0111161", TypeInfo, false},
+		{"a_b_c_ratings", "a_b_c", TypeRatings, false},
+		{"1__info", "1_", TypeInfo, false},
+		{"_1_info", "_1", TypeInfo, false},
+		{"1_info_", "", "", true},
+		{"_info", "", "", true},
+		{"info", "", "", true},
+		{"1_", "", "", true},
+		{"1", "", "", true},
+		{"", "", "", true},
+		{"_", "", "", true},
+		{"1_Info", "", "", true},
+		{"1_unknown", "", "", true},
+		{"1_info_extra", "", "", true},
+		{"1-info", "", "", true},
+	}
+	for _, tt := range tests {
+		id, rowType, err := ParseMovieRowKey(tt.key)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRowKey) {
+				t.Errorf("ParseMovieRowKey(%q) err = %v, want ErrInvalidRowKey", tt.key, err)
+			}
+			continue
+		}
+		if err != nil || id != tt.wantID || rowType != tt.wantRowType {
+			t.Errorf("ParseMovieRowKey(%q) = (%q, %q, %v), want (%q, %q, nil)",
+				tt.key, id, rowType, err, tt.wantID, tt.wantRowType)
+		}
+	}
+}
+
+// TestMovieKeyRoundTrip 构造的行键都能解析回原来的电影ID和行类型
+func TestMovieKeyRoundTrip(t *testing.T) {
+	builders := map[string]func(string) string{
+		TypeInfo:    MovieInfoKey,
+		TypeStats:   MovieStatsKey,
+		TypeLinks:   MovieLinksKey,
+		TypeRatings: MovieRatingsKey,
+		TypeTags:    MovieTagsKey,
+		TypeGenome:  MovieGenomeKey,
+	}
+	if len(builders) != len(RowTypes) {
+		t.Fatalf("RowTypes有%d种，构造函数有%d个", len(RowTypes), len(builders))
+	}
+	for _, movieID := range []string{"1", "193609", "a_b", "x__y", "_"} {
+		for rowType, build := range builders {
+			key := build(movieID)
+			if key != MovieKey(movieID, rowType) {
+				t.Errorf("%s行构造函数 = %q, want %q", rowType, key, MovieKey(movieID, rowType))
+			}
+			id, gotType, err := ParseMovieRowKey(key)
+			if err != nil || id != movieID || gotType != rowType {
+				t.Errorf("ParseMovieRowKey(%q) = (%q, %q, %v), want (%q, %q, nil)", key, id, gotType, err, movieID, rowType)
+			}
+		}
+	}
+}
+
+func TestMovieIDFromKey(t *testing.T) {
+	tests := []struct {
+		key, rowType string
+		wantID       string
+		wantOK       bool
+	}{
+		{"1_info", TypeInfo, "1", true},
+		{"1_stats", TypeInfo, "", false},
+		{"a_b_genome", TypeGenome, "a_b", true},
+		{"a_b_genome", TypeTags, "", false},
+		{"garbage", TypeInfo, "", false},
+	}
+	for _, tt := range tests {
+		id, ok := MovieIDFromKey(tt.key, tt.rowType)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("MovieIDFromKey(%q, %q) = (%q, %v), want (%q, %v)", tt.key, tt.rowType, id, ok, tt.wantID, tt.wantOK)
+		}
+		if got := IsMovieKeyOfType(tt.key, tt.rowType); got != tt.wantOK {
+			t.Errorf("IsMovieKeyOfType(%q, %q) = %v, want %v", tt.key, tt.rowType, got, tt.wantOK)
+		}
+	}
+}
+
+// TestScanRangeForMovie 扫描区间包含该电影的全部行，不包含ID为其前缀的相邻电影
+func TestScanRangeForMovie(t *testing.T) {
+	start, stop := ScanRangeForMovie("1")
+	inRange := func(key string) bool { return key >= start && key < stop }
+
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"1_info", true},
+		{"1_stats", true},
+		{"1_genome", true},
+		{"1_2_info", true}, // ID含下划线的电影落在区间内，由调用方核对ID
+		{"1", false},
+		{"10_info", false},
+		{"11_ratings", false},
+		{"1a_info", false},
+		{"0_info", false},
+		{"2_info", false},
+	}
+	for _, tt := range tests {
+		if got := inRange(tt.key); got != tt.want {
+			t.Errorf("%q 在 [%q, %q) 中 = %v, want %v", tt.key, start, stop, got, tt.want)
+		}
+	}
+}
+
+func TestSuffixRegex(t *testing.T) {
+	tests := []struct {
+		key, rowType string
+		want         bool
+	}{
+		{"1_info", TypeInfo, true},
+		{"a_b_info", TypeInfo, true},
+		{"1_info_x", TypeInfo, false},
+		{"1_stats", TypeInfo, false},
+		{"1info", TypeInfo, false},
+		{"12_tags", TypeTags, true},
+	}
+	for _, tt := range tests {
+		// HBase的RegexStringComparator与regexp.MatchString一样按find语义匹配
+		re := regexp.MustCompile(SuffixRegex(tt.rowType))
+		if got := re.MatchString(tt.key); got != tt.want {
+			t.Errorf("SuffixRegex(%q) 匹配 %q = %v, want %v", tt.rowType, tt.key, got, tt.want)
+		}
+	}
+}
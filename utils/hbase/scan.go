@@ -2,30 +2,72 @@ package hbase
 
 import (
 	"context"
+	"errors"
+	"gohbase/utils/hbase/rowkey"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase/filter"
 	"github.com/tsuna/gohbase/hrpc"
 )
 
-// ScanMovies 扫描电影（使用新的数据库结构）
+// defaultGenreScanParallelism 类型扫描默认并行度
+const defaultGenreScanParallelism = 3
+
+// maxScanBatchRows 单次RPC最多返回的行数
+const maxScanBatchRows = 1000
+
+// infoScanOptions 返回只扫描_info行的选项：服务端行键过滤，
+// 并按limit设置每次RPC返回的行数，避免为少量结果拉取大批数据。limit<=0表示不限制。
+// columns为列投影（列族 -> 列名，列名为nil表示整个列族），为空时返回全部列。
+func infoScanOptions(limit int64, columns map[string][]string) []func(hrpc.Call) error {
+	options := []func(hrpc.Call) error{hrpc.Filters(infoRowFilter())}
+
+	batch := int64(maxScanBatchRows)
+	if limit > 0 && limit < batch {
+		batch = limit
+	}
+	options = append(options, hrpc.NumberOfRows(uint32(batch)))
+
+	if len(columns) > 0 {
+		options = append(options, hrpc.Families(columns))
+	}
+
+	return options
+}
+
+// ListScanOptions 列表和搜索回退路径的扫描选项：只返回_info行的info:title和info:genres
+func ListScanOptions(limit int64) []func(hrpc.Call) error {
+	return infoScanOptions(limit, InfoListColumns())
+}
+
+// ScanMovies 扫描[startRow, endRow)区间内的_info行，最多返回limit条。
+// 非_info行在服务端过滤，只返回标题和类型列，每次RPC最多返回limit行，扫描器在达到limit后关闭。
 func ScanMovies(ctx context.Context, startRow, endRow string, limit int64) ([]*hrpc.Result, error) {
 	// 构建Scan对象，扫描movies表
-	scanRequest, err := hrpc.NewScanRangeStr(ctx, "movies", startRow, endRow)
+	scanRequest, err := hrpc.NewScanRangeStr(ctx, MoviesTable(), startRow, endRow, ListScanOptions(limit)...)
 	if err != nil {
 		return nil, err
 	}
 
 	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 	var results []*hrpc.Result
 	count := int64(0)
 
 	// 收集结果，只获取_info行（电影基本信息）
 	for count < limit {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		// 确保至少有一个单元格
 		if len(result.Cells) == 0 {
@@ -36,7 +78,7 @@ func ScanMovies(ctx context.Context, startRow, endRow string, limit int64) ([]*h
 		rowKey := string(result.Cells[0].Row)
 
 		// 只处理_info行（电影基本信息）
-		if strings.HasSuffix(rowKey, "_info") {
+		if rowkey.IsMovieKeyOfType(rowKey, rowkey.TypeInfo) {
 			results = append(results, result)
 			count++
 		}
@@ -45,31 +87,34 @@ func ScanMovies(ctx context.Context, startRow, endRow string, limit int64) ([]*h
 	return results, nil
 }
 
-// ScanMoviesWithFamilies 使用指定列族扫描电影
+// ScanMoviesWithFamilies 使用指定列族扫描[startRow, endRow)区间内的_info行，最多返回limit条。
+// 与ScanMovies相同，非_info行在服务端过滤。
 func ScanMoviesWithFamilies(ctx context.Context, startRow, endRow string, families []string, limit int64) ([]*hrpc.Result, error) {
-	// 构建列族映射
-	familiesMap := make(map[string][]string)
+	// 构建Scan对象，并指定列族
+	columns := make(map[string][]string, len(families))
 	for _, family := range families {
-		familiesMap[family] = nil
+		columns[family] = nil
 	}
-
-	// 构建Scan对象，并指定列族
-	scanRequest, err := hrpc.NewScanRangeStr(ctx, "movies", startRow, endRow, hrpc.Families(familiesMap))
+	scanRequest, err := hrpc.NewScanRangeStr(ctx, MoviesTable(), startRow, endRow, infoScanOptions(limit, columns)...)
 	if err != nil {
 		return nil, err
 	}
 
 	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 	var results []*hrpc.Result
 	count := int64(0)
 
 	// 收集结果，只获取_info行
 	for count < limit {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		// 确保至少有一个单元格
 		if len(result.Cells) == 0 {
@@ -80,7 +125,7 @@ func ScanMoviesWithFamilies(ctx context.Context, startRow, endRow string, famili
 		rowKey := string(result.Cells[0].Row)
 
 		// 只处理_info行（电影基本信息）
-		if strings.HasSuffix(rowKey, "_info") {
+		if rowkey.IsMovieKeyOfType(rowKey, rowkey.TypeInfo) {
 			results = append(results, result)
 			count++
 		}
@@ -89,48 +134,102 @@ func ScanMoviesWithFamilies(ctx context.Context, startRow, endRow string, famili
 	return results, nil
 }
 
-// ScanMoviesByGenre 根据电影类型扫描电影
+// ScanMoviesByGenre 根据电影类型扫描电影（按行键区间并行扫描）
 func ScanMoviesByGenre(ctx context.Context, genre string, limit int64) ([]*hrpc.Result, error) {
-	// 扫描所有_info行，然后在应用层做过滤
-	scanRequest, err := hrpc.NewScanStr(ctx, "movies")
+	return ParallelScanByGenre(ctx, genre, limit, defaultGenreScanParallelism)
+}
+
+// ParallelScanByGenre 将行键空间切分为多个区间，每个区间一个协程并行扫描_info行，
+// 最后按行键顺序合并结果。返回结果与顺序扫描的前limit条一致。
+func ParallelScanByGenre(ctx context.Context, genre string, limit int64, parallelism int) ([]*hrpc.Result, error) {
+	if limit <= 0 {
+		return []*hrpc.Result{}, nil
+	}
+
+	ranges := splitRowKeyRanges(parallelism)
+	genreLower := strings.ToLower(genre)
+
+	type rangeResult struct {
+		results []*hrpc.Result
+		err     error
+	}
+
+	rangeResults := make([]rangeResult, len(ranges))
+	var wg sync.WaitGroup
+
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(idx int, startRow, stopRow string) {
+			defer wg.Done()
+			results, err := scanGenreRange(ctx, startRow, stopRow, genreLower, limit)
+			rangeResults[idx] = rangeResult{results: results, err: err}
+		}(i, r[0], r[1])
+	}
+
+	wg.Wait()
+
+	// 合并各区间结果
+	var merged []*hrpc.Result
+	for _, rr := range rangeResults {
+		if rr.err != nil {
+			return nil, rr.err
+		}
+		merged = append(merged, rr.results...)
+	}
+
+	// 按行键排序，保证与顺序扫描结果一致
+	sort.Slice(merged, func(i, j int) bool {
+		return string(merged[i].Cells[0].Row) < string(merged[j].Cells[0].Row)
+	})
+
+	if int64(len(merged)) > limit {
+		merged = merged[:limit]
+	}
+
+	return merged, nil
+}
+
+// scanGenreRange 扫描单个行键区间内包含指定类型的_info行
+func scanGenreRange(ctx context.Context, startRow, stopRow, genreLower string, limit int64) ([]*hrpc.Result, error) {
+	// 服务端过滤：只返回_info行的info列族，避免传输ratings/tags/genome等行
+	scanRequest, err := hrpc.NewScanRangeStr(ctx, MoviesTable(), startRow, stopRow,
+		hrpc.Families(InfoListColumns()),
+		hrpc.Filters(infoRowFilter()))
 	if err != nil {
 		return nil, err
 	}
 
-	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
 	var results []*hrpc.Result
 	count := int64(0)
 
-	// 收集结果并筛选包含指定类型的电影
 	for count < limit {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
-		// 确保至少有一个单元格
 		if len(result.Cells) == 0 {
 			continue
 		}
 
-		// 获取行键
-		rowKey := string(result.Cells[0].Row)
-
-		// 只处理_info行（电影基本信息）
-		if !strings.HasSuffix(rowKey, "_info") {
+		// 服务端过滤器不可用时仍在应用层确认行类型
+		if !rowkey.IsMovieKeyOfType(string(result.Cells[0].Row), rowkey.TypeInfo) {
 			continue
 		}
 
-		// 检查这个结果是否包含指定的类型
 		for _, cell := range result.Cells {
 			if string(cell.Family) == "info" && string(cell.Qualifier) == "genres" {
-				genreValue := string(cell.Value)
-				if strings.Contains(strings.ToLower(genreValue), strings.ToLower(genre)) {
+				if strings.Contains(strings.ToLower(string(cell.Value)), genreLower) {
 					results = append(results, result)
 					count++
-					break
 				}
+				break
 			}
 		}
 	}
@@ -138,108 +237,355 @@ func ScanMoviesByGenre(ctx context.Context, genre string, limit int64) ([]*hrpc.
 	return results, nil
 }
 
-// ScanMoviesByTag 根据标签扫描电影
-func ScanMoviesByTag(ctx context.Context, tag string, limit int64) ([]*hrpc.Result, error) {
-	// 扫描所有_tags行，然后在应用层做过滤
-	scanRequest, err := hrpc.NewScanStr(ctx, "movies")
+// ScanMoviesByGenreWithFilter 使用服务端SingleColumnValueFilter按类型扫描电影，
+// 只有info:genres包含指定类型的_info行会被返回。
+// 如果服务端不支持该过滤器（扫描出错），回退到ScanMoviesByGenre。
+func ScanMoviesByGenreWithFilter(ctx context.Context, genre string, limit int64) ([]*hrpc.Result, error) {
+	if limit <= 0 {
+		return []*hrpc.Result{}, nil
+	}
+
+	results, err := scanGenreWithColumnFilter(ctx, genre, limit)
+	if err != nil {
+		logrus.Warnf("服务端类型过滤扫描失败，回退到客户端过滤: %v", err)
+		return ScanMoviesByGenre(ctx, genre, limit)
+	}
+
+	return results, nil
+}
+
+// scanGenreWithColumnFilter 执行带列值过滤器的类型扫描
+func scanGenreWithColumnFilter(ctx context.Context, genre string, limit int64) ([]*hrpc.Result, error) {
+	// SubstringComparator在服务端不区分大小写；缺少genres列的行直接过滤
+	genreFilter := filter.NewSingleColumnValueFilter([]byte("info"), []byte("genres"), filter.Equal,
+		filter.NewSubstringComparator(genre), true, true)
+
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(),
+		hrpc.Families(InfoListColumns()),
+		hrpc.Filters(filter.NewList(filter.MustPassAll, infoRowFilter(), genreFilter)))
 	if err != nil {
 		return nil, err
 	}
 
-	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
+	genreLower := strings.ToLower(genre)
 	var results []*hrpc.Result
 	count := int64(0)
-	processedMovies := make(map[string]bool) // 避免重复处理同一部电影
 
-	// 收集结果并筛选包含指定标签的电影
 	for count < limit {
 		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
 		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Cells) == 0 {
+			continue
+		}
+
+		// 与客户端过滤保持相同的匹配语义
+		for _, cell := range result.Cells {
+			if string(cell.Qualifier) == "genres" {
+				if strings.Contains(strings.ToLower(string(cell.Value)), genreLower) {
+					results = append(results, result)
+					count++
+				}
+				break
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// infoRowFilter 返回只匹配_info行的行键过滤器
+func infoRowFilter() filter.Filter {
+	return rowTypeFilter(rowkey.TypeInfo)
+}
+
+// rowTypeFilter 返回只匹配指定行类型的行键过滤器
+func rowTypeFilter(rowType string) filter.Filter {
+	return filter.NewRowFilter(filter.NewCompareFilter(filter.Equal,
+		filter.NewRegexStringComparator(rowkey.SuffixRegex(rowType), 0, "UTF-8", "JAVA")))
+}
+
+// ScanTagCounts 扫描全部_tags行，统计每个标签（小写、去除首尾空白）的使用次数。
+// 非_tags行在服务端过滤。
+func ScanTagCounts(ctx context.Context) (map[string]int, error) {
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(),
+		hrpc.Filters(rowTypeFilter(rowkey.TypeTags)),
+		hrpc.Families(map[string][]string{"tags": nil}),
+		hrpc.NumberOfRows(maxScanBatchRows))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
+	counts := make(map[string]int)
+	for {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
-		// 确保至少有一个单元格
+		for _, cell := range result.Cells {
+			// 标签数据格式: "{tag}:{userId}:{timestamp}"
+			tag := strings.ToLower(strings.TrimSpace(strings.Split(string(cell.Value), ":")[0]))
+			if tag != "" {
+				counts[tag]++
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// ScanRatingRows 扫描全部_ratings行（只读取ratings列族），按行键顺序对每行调用fn，fn返回错误时停止。
+// 非_ratings行在服务端过滤。
+func ScanRatingRows(ctx context.Context, fn func(movieID string, cells []*hrpc.Cell) error) error {
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(),
+		hrpc.Filters(rowTypeFilter(rowkey.TypeRatings)),
+		hrpc.Families(map[string][]string{"ratings": nil}),
+		hrpc.NumberOfRows(maxScanBatchRows))
+	if err != nil {
+		return err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
+	for {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 		if len(result.Cells) == 0 {
 			continue
 		}
 
-		// 获取行键
-		rowKey := string(result.Cells[0].Row)
+		movieID, ok := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeRatings)
+		if !ok {
+			continue
+		}
+		if err := fn(movieID, result.Cells); err != nil {
+			return err
+		}
+	}
+}
+
+// ScanStatsRows 扫描全部_stats行（只读取评分统计列），按行键顺序对每行调用fn，fn返回错误时停止。
+func ScanStatsRows(ctx context.Context, fn func(movieID string, cells []*hrpc.Cell) error) error {
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(),
+		hrpc.Filters(rowTypeFilter(rowkey.TypeStats)),
+		hrpc.Families(StatsColumns()),
+		hrpc.NumberOfRows(maxScanBatchRows))
+	if err != nil {
+		return err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 
-		// 只处理_tags行
-		if !strings.HasSuffix(rowKey, "_tags") {
+	for {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(result.Cells) == 0 {
+			continue
+		}
+
+		movieID, ok := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeStats)
+		if !ok {
 			continue
 		}
+		if err := fn(movieID, result.Cells); err != nil {
+			return err
+		}
+	}
+}
+
+// ScanUserRows 扫描users表的全部行（movies和tags列族），按行键顺序对每行调用fn，fn返回错误时停止。
+func ScanUserRows(ctx context.Context, fn func(userID string, cells []*hrpc.Cell) error) error {
+	scanRequest, err := hrpc.NewScanStr(ctx, UsersTable(),
+		hrpc.Families(map[string][]string{"movies": nil, "tags": nil}),
+		hrpc.NumberOfRows(maxScanBatchRows))
+	if err != nil {
+		return err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 
-		// 提取电影ID
-		movieID := strings.TrimSuffix(rowKey, "_tags")
-		if processedMovies[movieID] {
+	for {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(result.Cells) == 0 {
+			continue
+		}
+		if err := fn(string(result.Cells[0].Row), result.Cells); err != nil {
+			return err
+		}
+	}
+}
+
+// splitRowKeyRanges 按电影ID首位数字将行键空间切分为parallelism个区间
+// 返回的区间为[startRow, stopRow)，首尾区间分别以空字符串表示无界
+func splitRowKeyRanges(parallelism int) [][2]string {
+	const digits = 10
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > digits {
+		parallelism = digits
+	}
+
+	ranges := make([][2]string, 0, parallelism)
+	for i := 0; i < parallelism; i++ {
+		var startRow, stopRow string
+		if i > 0 {
+			startRow = string(rune('0' + i*digits/parallelism))
+		}
+		if i < parallelism-1 {
+			stopRow = string(rune('0' + (i+1)*digits/parallelism))
+		}
+		ranges = append(ranges, [2]string{startRow, stopRow})
+	}
+
+	return ranges
+}
+
+// scanTagMovieIDs 扫描_tags行（非_tags行在服务端过滤），返回存在满足match的标签的电影ID，按行键顺序，最多limit个
+func scanTagMovieIDs(ctx context.Context, limit int64, match func(tag string) bool) ([]string, error) {
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(),
+		hrpc.Filters(rowTypeFilter(rowkey.TypeTags)),
+		hrpc.Families(map[string][]string{"tags": nil}),
+		hrpc.NumberOfRows(maxScanBatchRows))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
+	var movieIDs []string
+	for int64(len(movieIDs)) < limit {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Cells) == 0 {
+			continue
+		}
+
+		movieID, ok := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeTags)
+		if !ok {
 			continue
 		}
 
-		// 检查是否有匹配的标签
-		foundTag := false
 		for _, cell := range result.Cells {
-			if string(cell.Family) == "tags" {
-				// 解析标签数据格式: "{tag}:{userId}:{timestamp}"
-				tagData := string(cell.Value)
-				parts := strings.Split(tagData, ":")
-				if len(parts) >= 1 {
-					tagValue := parts[0]
-					if strings.Contains(strings.ToLower(tagValue), strings.ToLower(tag)) {
-						foundTag = true
-						break
-					}
-				}
+			// 标签数据格式: "{tag}:{userId}:{timestamp}"
+			tag := strings.ToLower(strings.TrimSpace(strings.Split(string(cell.Value), ":")[0]))
+			if tag != "" && match(tag) {
+				movieIDs = append(movieIDs, movieID)
+				break
 			}
 		}
+	}
 
-		if foundTag {
-			// 获取对应的电影基本信息
-			movieInfoResult, err := GetMovie(ctx, movieID)
-			if err == nil && movieInfoResult != nil {
-				// 构建一个包含电影基本信息的Result
-				infoResult := &hrpc.Result{}
-				for family, qualifiers := range movieInfoResult {
-					for qualifier, value := range qualifiers {
-						cell := &hrpc.Cell{
-							Row:       []byte(movieID + "_info"),
-							Family:    []byte(family),
-							Qualifier: []byte(qualifier),
-							Value:     value,
-						}
-						infoResult.Cells = append(infoResult.Cells, cell)
-					}
-				}
-				results = append(results, infoResult)
-				count++
-				processedMovies[movieID] = true
+	return movieIDs, nil
+}
+
+// ScanMovieIDsByTag 扫描带有指定标签（不区分大小写、精确匹配）的电影ID，按行键顺序，最多limit个
+func ScanMovieIDsByTag(ctx context.Context, tag string, limit int64) ([]string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return scanTagMovieIDs(ctx, limit, func(t string) bool { return t == tag })
+}
+
+// ScanMoviesByTag 扫描标签包含tag的电影，返回其_info行数据，最多limit条。
+// 先收集匹配的电影ID，再并发批量获取_info行。
+func ScanMoviesByTag(ctx context.Context, tag string, limit int64) ([]*hrpc.Result, error) {
+	tag = strings.ToLower(tag)
+	movieIDs, err := scanTagMovieIDs(ctx, limit, func(t string) bool { return strings.Contains(t, tag) })
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := GetMoviesMultiple(ctx, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*hrpc.Result, 0, len(movieIDs))
+	for _, movieID := range movieIDs {
+		movieInfo, ok := infos[movieID]
+		if !ok {
+			continue
+		}
+		// 构建一个包含电影基本信息的Result
+		infoResult := &hrpc.Result{}
+		for family, qualifiers := range movieInfo {
+			for qualifier, value := range qualifiers {
+				infoResult.Cells = append(infoResult.Cells, &hrpc.Cell{
+					Row:       []byte(rowkey.MovieInfoKey(movieID)),
+					Family:    []byte(family),
+					Qualifier: []byte(qualifier),
+					Value:     value,
+				})
 			}
 		}
+		results = append(results, infoResult)
 	}
 
 	return results, nil
 }
 
 // ScanMoviesWithPagination 带分页的电影扫描
+// 需要统计总数，因此会遍历全部_info行，但非_info行在服务端过滤
 func ScanMoviesWithPagination(ctx context.Context, page, pageSize int) ([]*hrpc.Result, int, error) {
 	// 构建扫描请求，只扫描_info行
-	scanRequest, err := hrpc.NewScanStr(ctx, "movies")
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(), ListScanOptions(0)...)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 	var allResults []*hrpc.Result
 
 	// 收集所有_info行结果
 	for {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, 0, err
+		}
 
 		// 确保至少有一个单元格
 		if len(result.Cells) == 0 {
@@ -250,7 +596,7 @@ func ScanMoviesWithPagination(ctx context.Context, page, pageSize int) ([]*hrpc.
 		rowKey := string(result.Cells[0].Row)
 
 		// 只处理_info行（电影基本信息）
-		if strings.HasSuffix(rowKey, "_info") {
+		if rowkey.IsMovieKeyOfType(rowKey, rowkey.TypeInfo) {
 			allResults = append(allResults, result)
 		}
 	}
@@ -274,27 +620,135 @@ func ScanMoviesWithPagination(ctx context.Context, page, pageSize int) ([]*hrpc.
 	return allResults[startIndex:endIndex], totalRows, nil
 }
 
-// SearchMovies 搜索电影
+// ParallelScanWithMerge ScanMoviesWithPagination的并行版本，返回相同的分页结果和总数。
+// 行键空间按splitRowKeyRanges切分，每个区间一个协程扫描全部_info行并计数，
+// 只保留区间内前page*pageSize行（排在更后面的行不可能落在本页）。
+// 总数本来就需要遍历每个区间，稀疏区间只会提前结束，合并时按各区间的行数
+// 计算全局偏移，因此不需要在区间之间重新分配扫描任务。parallelism<2时退化为顺序扫描。
+func ParallelScanWithMerge(ctx context.Context, page, pageSize, parallelism int) ([]*hrpc.Result, int, error) {
+	if parallelism < 2 {
+		return ScanMoviesWithPagination(ctx, page, pageSize)
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	startIndex := (page - 1) * pageSize
+	endIndex := startIndex + pageSize
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type rangeResult struct {
+		results []*hrpc.Result // 区间内按行键排序的前endIndex行
+		count   int            // 区间内_info行总数
+		err     error
+	}
+
+	ranges := splitRowKeyRanges(parallelism)
+	rangeResults := make([]rangeResult, len(ranges))
+	var wg sync.WaitGroup
+
+	for i, r := range ranges {
+		wg.Add(1)
+		go func(idx int, startRow, stopRow string) {
+			defer wg.Done()
+			results, count, err := scanInfoRange(ctx, startRow, stopRow, endIndex)
+			if err != nil {
+				// 一个区间失败后其余区间的结果已无用
+				cancel()
+			}
+			rangeResults[idx] = rangeResult{results: results, count: count, err: err}
+		}(i, r[0], r[1])
+	}
+
+	wg.Wait()
+
+	// 优先返回真正的扫描错误，而不是因cancel产生的context.Canceled
+	var firstErr error
+	for _, rr := range rangeResults {
+		if rr.err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = rr.err
+		}
+	}
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+
+	// 区间互不重叠且按行键递增，区间i中第j行的全局序号为之前各区间行数之和加j
+	totalRows := 0
+	pageResults := make([]*hrpc.Result, 0, pageSize)
+	for _, rr := range rangeResults {
+		for j, result := range rr.results {
+			if globalIndex := totalRows + j; globalIndex >= startIndex && globalIndex < endIndex {
+				pageResults = append(pageResults, result)
+			}
+		}
+		totalRows += rr.count
+	}
+
+	return pageResults, totalRows, nil
+}
+
+// scanInfoRange 扫描单个行键区间内的全部_info行，返回前keep行和总行数
+func scanInfoRange(ctx context.Context, startRow, stopRow string, keep int) ([]*hrpc.Result, int, error) {
+	scanRequest, err := hrpc.NewScanRangeStr(ctx, MoviesTable(), startRow, stopRow, ListScanOptions(0)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
+
+	var results []*hrpc.Result
+	count := 0
+	for {
+		result, err := scanner.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(result.Cells) == 0 || !rowkey.IsMovieKeyOfType(string(result.Cells[0].Row), rowkey.TypeInfo) {
+			continue
+		}
+
+		if count < keep {
+			results = append(results, result)
+		}
+		count++
+	}
+
+	return results, count, nil
+}
+
+// SearchMovies 搜索电影，标题或类型包含query的_info行，最多返回limit条
 func SearchMovies(ctx context.Context, query string, limit int64) ([]*hrpc.Result, error) {
 	query = strings.ToLower(query)
 
-	// 扫描所有_info行，然后在应用层做过滤
-	scanRequest, err := hrpc.NewScanStr(ctx, "movies")
+	// 服务端只返回_info行的info列族，然后在应用层做匹配
+	scanRequest, err := hrpc.NewScanStr(ctx, MoviesTable(), ListScanOptions(0)...)
 	if err != nil {
 		return nil, err
 	}
 
 	// 执行扫描
 	scanner := hbaseClient.Scan(scanRequest)
+	defer scanner.Close()
 	var results []*hrpc.Result
 	count := int64(0)
 
 	// 收集结果并筛选匹配查询的电影
 	for count < limit {
 		result, err := scanner.Next()
-		if err != nil {
+		if errors.Is(err, io.EOF) {
 			break
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		// 确保至少有一个单元格
 		if len(result.Cells) == 0 {
@@ -305,7 +759,7 @@ func SearchMovies(ctx context.Context, query string, limit int64) ([]*hrpc.Resul
 		rowKey := string(result.Cells[0].Row)
 
 		// 只处理_info行（电影基本信息）
-		if !strings.HasSuffix(rowKey, "_info") {
+		if !rowkey.IsMovieKeyOfType(rowKey, rowkey.TypeInfo) {
 			continue
 		}
 
@@ -339,8 +793,7 @@ func GetMovieIDsFromInfoRows(results []*hrpc.Result) []string {
 	for _, result := range results {
 		if len(result.Cells) > 0 {
 			rowKey := string(result.Cells[0].Row)
-			if strings.HasSuffix(rowKey, "_info") {
-				movieID := strings.TrimSuffix(rowKey, "_info")
+			if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeInfo); ok {
 				movieIDs = append(movieIDs, movieID)
 			}
 		}
@@ -0,0 +1,283 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"gohbase/utils/hbase/rowkey"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// fakeGenres 测试电影依次使用的类型
+var fakeGenres = []string{"Comedy|Drama", "Action|Thriller", "Drama", "Animation|Comedy", "Horror"}
+
+// newFakeMovies 安装内存客户端，写入电影1..n的_info、_stats和_ratings行
+func newFakeMovies(tb testing.TB, n int) *hbasetest.Client {
+	tb.Helper()
+	client := hbasetest.New()
+	for i := 1; i <= n; i++ {
+		client.SetMovies(MoviesTable(), hbasetest.Movie{
+			ID:      strconv.Itoa(i),
+			Title:   fmt.Sprintf("Movie %d (%d)", i, 1950+i%70),
+			Genres:  fakeGenres[i%len(fakeGenres)],
+			Stats:   map[string]string{"avg_rating": "3.5", "rating_count": "2"},
+			Ratings: map[string]string{"1": "3.0", "2": "4.0"},
+		})
+	}
+	SetClient(client)
+	return client
+}
+
+// resultMovieIDs 返回结果对应的电影ID
+func resultMovieIDs(results []*hrpc.Result) []string {
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		id, _ := rowkey.MovieIDFromKey(string(result.Cells[0].Row), rowkey.TypeInfo)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestParallelScanByGenreMatchesSequentialScan(t *testing.T) {
+	newFakeMovies(t, 200)
+	ctx := context.Background()
+
+	tests := []struct {
+		genre string
+		limit int64
+	}{
+		{"comedy", 10},
+		{"Drama", 1000},
+		{"thriller", 5},
+		{"Western", 10},
+	}
+	for _, tt := range tests {
+		want, err := ParallelScanByGenre(ctx, tt.genre, tt.limit, 1)
+		if err != nil {
+			t.Fatalf("顺序扫描%q失败: %v", tt.genre, err)
+		}
+		for _, parallelism := range []int{2, 3, 10} {
+			got, err := ParallelScanByGenre(ctx, tt.genre, tt.limit, parallelism)
+			if err != nil {
+				t.Fatalf("并行扫描%q（%d）失败: %v", tt.genre, parallelism, err)
+			}
+			if fmt.Sprint(resultMovieIDs(got)) != fmt.Sprint(resultMovieIDs(want)) {
+				t.Errorf("ParallelScanByGenre(%q, %d, %d) = %v, 顺序扫描为 %v",
+					tt.genre, tt.limit, parallelism, resultMovieIDs(got), resultMovieIDs(want))
+			}
+		}
+	}
+}
+
+func TestSplitRowKeyRanges(t *testing.T) {
+	tests := []struct {
+		parallelism int
+		want        string
+	}{
+		{0, "[[ ]]"},
+		{1, "[[ ]]"},
+		{3, "[[ 3] [3 6] [6 ]]"},
+		{20, "[[ 1] [1 2] [2 3] [3 4] [4 5] [5 6] [6 7] [7 8] [8 9] [9 ]]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(splitRowKeyRanges(tt.parallelism)); got != tt.want {
+			t.Errorf("splitRowKeyRanges(%d) = %s, want %s", tt.parallelism, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkScanMoviesByGenre 比较单区间扫描与按行键区间并行扫描，内存客户端每返回一行延迟50µs模拟RPC开销
+func BenchmarkScanMoviesByGenre(b *testing.B) {
+	client := newFakeMovies(b, 150)
+	client.Latency = 50 * time.Microsecond
+	ctx := context.Background()
+
+	for _, parallelism := range []int{1, defaultGenreScanParallelism, 5} {
+		b.Run(fmt.Sprintf("parallelism=%d", parallelism), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ParallelScanByGenre(ctx, "drama", 1000, parallelism); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestScanMoviesByGenreWithFilterMatchesClientFilter(t *testing.T) {
+	newFakeMovies(t, 100)
+	ctx := context.Background()
+
+	for _, genre := range []string{"Comedy", "thriller", "HORROR", "Western"} {
+		want, err := ParallelScanByGenre(ctx, genre, 1000, 1)
+		if err != nil {
+			t.Fatalf("客户端过滤扫描%q失败: %v", genre, err)
+		}
+		for _, limit := range []int64{1000, 3} {
+			got, err := ScanMoviesByGenreWithFilter(ctx, genre, limit)
+			if err != nil {
+				t.Fatalf("服务端过滤扫描%q失败: %v", genre, err)
+			}
+			wantIDs := resultMovieIDs(want)
+			if int64(len(wantIDs)) > limit {
+				wantIDs = wantIDs[:limit]
+			}
+			if fmt.Sprint(resultMovieIDs(got)) != fmt.Sprint(wantIDs) {
+				t.Errorf("ScanMoviesByGenreWithFilter(%q, %d) = %v, want %v", genre, limit, resultMovieIDs(got), wantIDs)
+			}
+		}
+	}
+}
+
+// BenchmarkScanMoviesByGenreWithFilter 比较服务端列值过滤与客户端过滤。
+// 内存客户端只对返回的行计延迟，服务端过滤少返回不匹配的行，对应节省的网络传输
+func BenchmarkScanMoviesByGenreWithFilter(b *testing.B) {
+	client := newFakeMovies(b, 150)
+	client.Latency = 50 * time.Microsecond
+	ctx := context.Background()
+
+	scans := []struct {
+		name string
+		scan func() ([]*hrpc.Result, error)
+	}{
+		{"client-filter", func() ([]*hrpc.Result, error) { return ParallelScanByGenre(ctx, "horror", 1000, 1) }},
+		{"client-filter-parallel", func() ([]*hrpc.Result, error) { return ScanMoviesByGenre(ctx, "horror", 1000) }},
+		{"server-filter", func() ([]*hrpc.Result, error) { return ScanMoviesByGenreWithFilter(ctx, "horror", 1000) }},
+	}
+	for _, s := range scans {
+		b.Run(s.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := s.scan(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestScanErrorsReachCallers 扫描中途RPC失败时调用方得到错误，而不是被当作扫描结束返回截断的结果
+func TestScanErrorsReachCallers(t *testing.T) {
+	client := newFakeMovies(t, 50)
+	for _, id := range []string{"1", "2", "3"} {
+		client.SetRow(MoviesTable(), rowkey.MovieTagsKey(id), map[string]map[string][]byte{
+			"tags": {"1": []byte("classic:1:1000")},
+		})
+	}
+	errRegionDown := errors.New("region server不可用")
+	client.ScanErr = errRegionDown
+	client.ScanErrAfter = 1
+	ctx := context.Background()
+	noop := func(string, []*hrpc.Cell) error { return nil }
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"ScanMovies", func() error { _, err := ScanMovies(ctx, "", "", 100); return err }},
+		{"ParallelScanByGenre", func() error { _, err := ParallelScanByGenre(ctx, "drama", 100, 1); return err }},
+		{"ParallelScanByGenre并行", func() error { _, err := ParallelScanByGenre(ctx, "drama", 100, 3); return err }},
+		{"ScanMoviesByGenreWithFilter", func() error { _, err := ScanMoviesByGenreWithFilter(ctx, "drama", 100); return err }},
+		{"ScanMoviesWithPagination", func() error { _, _, err := ScanMoviesWithPagination(ctx, 1, 10); return err }},
+		{"ParallelScanWithMerge", func() error { _, _, err := ParallelScanWithMerge(ctx, 1, 10, 3); return err }},
+		{"SearchMovies", func() error { _, err := SearchMovies(ctx, "movie", 100); return err }},
+		{"ScanTagCounts", func() error { _, err := ScanTagCounts(ctx); return err }},
+		{"ScanMovieIDsByTag", func() error { _, err := ScanMovieIDsByTag(ctx, "classic", 100); return err }},
+		{"ScanRatingRows", func() error { return ScanRatingRows(ctx, noop) }},
+		{"ScanStatsRows", func() error { return ScanStatsRows(ctx, noop) }},
+		{"GetMovieWithAllData", func() error { _, err := GetMovieWithAllData(ctx, "1"); return err }},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, errRegionDown) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, errRegionDown)
+		}
+	}
+}
+
+// countingClient 统计扫描器实际返回的行数，并记录每次扫描请求的NumberOfRows
+type countingClient struct {
+	*hbasetest.Client
+	mu      sync.Mutex
+	rows    int
+	batches []uint32
+}
+
+func (c *countingClient) Scan(s *hrpc.Scan) hrpc.Scanner {
+	c.mu.Lock()
+	c.batches = append(c.batches, s.NumberOfRows())
+	c.mu.Unlock()
+	return &countingScanner{Scanner: c.Client.Scan(s), client: c}
+}
+
+// reset 清零计数
+func (c *countingClient) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rows = 0
+	c.batches = nil
+}
+
+// countingScanner 每返回一行给countingClient计数
+type countingScanner struct {
+	hrpc.Scanner
+	client *countingClient
+}
+
+func (s *countingScanner) Next() (*hrpc.Result, error) {
+	result, err := s.Scanner.Next()
+	if err == nil {
+		s.client.mu.Lock()
+		s.client.rows++
+		s.client.mu.Unlock()
+	}
+	return result, err
+}
+
+// TestScanYieldsOnlyNeededRows 服务端按行类型过滤并按limit设置NumberOfRows，扫描器只返回需要的行，
+// 而不是遍历每部电影的全部行类型
+func TestScanYieldsOnlyNeededRows(t *testing.T) {
+	const movieCount = 100
+	client := &countingClient{Client: newFakeMovies(t, movieCount)} // 每部电影有_info、_stats、_ratings三行
+	for _, id := range []string{"1", "2", "3"} {
+		client.SetRow(MoviesTable(), rowkey.MovieTagsKey(id), map[string]map[string][]byte{
+			"tags": {"1": []byte("classic:1:1000")},
+		})
+	}
+	SetClient(client)
+	ctx := context.Background()
+	noop := func(string, []*hrpc.Cell) error { return nil }
+
+	tests := []struct {
+		name      string
+		call      func() error
+		wantRows  int
+		wantBatch uint32
+	}{
+		{"ScanMovies limit=5", func() error { _, err := ScanMovies(ctx, "", "", 5); return err }, 5, 5},
+		{"ScanMovies limit=20从中间开始", func() error { _, err := ScanMovies(ctx, "5", "", 20); return err }, 20, 20},
+		{"ScanMovies limit大于电影数", func() error { _, err := ScanMovies(ctx, "", "", 5000); return err }, movieCount, maxScanBatchRows},
+		{"ScanMoviesWithPagination", func() error { _, _, err := ScanMoviesWithPagination(ctx, 2, 10); return err }, movieCount, maxScanBatchRows},
+		{"ScanStatsRows", func() error { return ScanStatsRows(ctx, noop) }, movieCount, maxScanBatchRows},
+		{"ScanRatingRows", func() error { return ScanRatingRows(ctx, noop) }, movieCount, maxScanBatchRows},
+		{"ScanTagCounts", func() error { _, err := ScanTagCounts(ctx); return err }, 3, maxScanBatchRows},
+	}
+	for _, tt := range tests {
+		client.reset()
+		if err := tt.call(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		client.mu.Lock()
+		rows, batches := client.rows, client.batches
+		client.mu.Unlock()
+		if rows != tt.wantRows {
+			t.Errorf("%s: 扫描器返回%d行, want %d", tt.name, rows, tt.wantRows)
+		}
+		if len(batches) != 1 || batches[0] != tt.wantBatch {
+			t.Errorf("%s: NumberOfRows = %v, want [%d]", tt.name, batches, tt.wantBatch)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/config"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tsuna/gohbase"
+	"github.com/tsuna/gohbase/hrpc"
+)
+
+// schemaProbeTimeout 单次探测的超时时间，表不存在时gohbase会重试查找region直到超时
+const schemaProbeTimeout = 10 * time.Second
+
+// schemaProbeRow 探测用的行键，行是否存在不影响结果
+const schemaProbeRow = "_schema_probe"
+
+// schemaGetter 执行探测Get的客户端，便于替换为模拟实现
+type schemaGetter interface {
+	Get(request *hrpc.Get) (*hrpc.Result, error)
+}
+
+// schemaConfig 创建表时使用的列族设置，由InitHBase从配置设置
+var schemaConfig config.HBaseConfig
+
+// hbaseForeverTTL HBase中表示永久保留的TTL
+const hbaseForeverTTL = "2147483647"
+
+// tableCreator 创建表的管理客户端，便于替换为模拟实现
+type tableCreator interface {
+	CreateTable(t *hrpc.CreateTable) error
+}
+
+// RequiredSchema 服务运行所需的表和列族（表名 -> 列族）
+func RequiredSchema() map[string][]string {
+	return map[string][]string{
+		MoviesTable(): {"info", "ratings", "tags", "genome"},
+		UsersTable():  {"movies", "tags"},
+	}
+}
+
+// configTableName 表在hbase.schema.families中的名称（movies或users）
+func configTableName(table string) string {
+	if table == UsersTable() {
+		return "users"
+	}
+	return "movies"
+}
+
+// TableFamilies 创建表时使用的列族及其设置：必需的列族加上配置中额外列出的列族
+func TableFamilies(table string) map[string]config.HBaseFamilyConfig {
+	name := configTableName(table)
+	families := make(map[string]config.HBaseFamilyConfig)
+	for _, family := range RequiredSchema()[table] {
+		families[family] = schemaConfig.GetFamilyConfig(name, family)
+	}
+	for family := range schemaConfig.Schema.Families[name] {
+		families[family] = schemaConfig.GetFamilyConfig(name, family)
+	}
+	return families
+}
+
+// familyAttributes 列族设置对应的HBase列族属性
+func familyAttributes(fc config.HBaseFamilyConfig) map[string]string {
+	ttl := hbaseForeverTTL
+	if fc.TTLSeconds > 0 {
+		ttl = strconv.Itoa(fc.TTLSeconds)
+	}
+	return map[string]string{
+		"COMPRESSION": fc.Compression,
+		"VERSIONS":    strconv.Itoa(fc.Versions),
+		"TTL":         ttl,
+	}
+}
+
+// shellFamily 生成HBase shell的列族参数，如 {NAME => 'info', VERSIONS => 1, COMPRESSION => 'SNAPPY'}
+func shellFamily(family string, fc config.HBaseFamilyConfig) string {
+	spec := fmt.Sprintf("{NAME => '%s', VERSIONS => %d, COMPRESSION => '%s'", family, fc.Versions, fc.Compression)
+	if fc.TTLSeconds > 0 {
+		spec += fmt.Sprintf(", TTL => %d", fc.TTLSeconds)
+	}
+	return spec + "}"
+}
+
+// SchemaError 缺失的表和列族
+type SchemaError struct {
+	MissingTables   []string            // 不存在的表
+	MissingFamilies map[string][]string // 表名 -> 不存在的列族
+}
+
+// Error 列出缺失项以及在HBase shell中创建它们的命令
+func (e *SchemaError) Error() string {
+	var b strings.Builder
+	b.WriteString("HBase表结构不完整:")
+	for _, table := range e.MissingTables {
+		fmt.Fprintf(&b, "\n  - 缺少表 %s", table)
+	}
+	for _, table := range sortedKeys(e.MissingFamilies) {
+		fmt.Fprintf(&b, "\n  - 表 %s 缺少列族 %s", table, strings.Join(e.MissingFamilies[table], ", "))
+	}
+
+	b.WriteString("\n可在HBase shell中执行以下命令创建:")
+	for _, table := range e.MissingTables {
+		families := TableFamilies(table)
+		specs := make([]string, 0, len(families))
+		for _, family := range sortedKeys(families) {
+			specs = append(specs, shellFamily(family, families[family]))
+		}
+		fmt.Fprintf(&b, "\n  create '%s', %s", table, strings.Join(specs, ", "))
+	}
+	for _, table := range sortedKeys(e.MissingFamilies) {
+		families := TableFamilies(table)
+		for _, family := range e.MissingFamilies[table] {
+			fmt.Fprintf(&b, "\n  alter '%s', %s", table, shellFamily(family, families[family]))
+		}
+	}
+	b.WriteString("\n（设置 hbase.skip_schema_check: true 或使用 --skip-schema-check 可跳过检查）")
+	return b.String()
+}
+
+// CheckSchema 对每个必需的表和列族执行限定列族的Get，汇总缺失项。
+// 缺失时返回*SchemaError，连接等其他错误直接返回。
+func CheckSchema(ctx context.Context, client schemaGetter) error {
+	schemaErr := &SchemaError{MissingFamilies: make(map[string][]string)}
+	required := RequiredSchema()
+
+	for _, table := range sortedKeys(required) {
+		for _, family := range required[table] {
+			missing, err := probeFamily(ctx, client, table, family)
+			if err != nil {
+				return fmt.Errorf("检查表 %s 列族 %s 失败: %w", table, family, err)
+			}
+			if missing == "table" {
+				schemaErr.MissingTables = append(schemaErr.MissingTables, table)
+				break
+			}
+			if missing == "family" {
+				schemaErr.MissingFamilies[table] = append(schemaErr.MissingFamilies[table], family)
+			}
+		}
+	}
+
+	if len(schemaErr.MissingTables) > 0 || len(schemaErr.MissingFamilies) > 0 {
+		return schemaErr
+	}
+	return nil
+}
+
+// EnsureSchema 检查表结构，自动创建缺失的表，列族按hbase.schema配置设置压缩算法、版本数和TTL。
+// gohbase不支持修改已有的表，已有表缺少列族时仍返回*SchemaError；
+// gohbase创建表时不支持命名空间，带命名空间的表（如staging:movies）也需手动创建。
+func EnsureSchema(ctx context.Context, client schemaGetter, admin tableCreator) error {
+	err := CheckSchema(ctx, client)
+	var schemaErr *SchemaError
+	if err == nil || !errors.As(err, &schemaErr) {
+		return err
+	}
+
+	var remaining []string
+	for _, table := range schemaErr.MissingTables {
+		if strings.Contains(table, ":") {
+			logrus.Warnf("表 %s 带命名空间，无法自动创建", table)
+			remaining = append(remaining, table)
+			continue
+		}
+		if err := createTable(ctx, admin, table); err != nil {
+			return fmt.Errorf("创建表 %s 失败: %w", table, err)
+		}
+	}
+	schemaErr.MissingTables = remaining
+
+	if len(schemaErr.MissingTables) > 0 || len(schemaErr.MissingFamilies) > 0 {
+		return schemaErr
+	}
+	return nil
+}
+
+// createTable 按配置的列族设置创建表，表已被其他实例创建时视为成功
+func createTable(ctx context.Context, admin tableCreator, table string) error {
+	families := TableFamilies(table)
+	attrs := make(map[string]map[string]string, len(families))
+	for family, fc := range families {
+		attrs[family] = familyAttributes(fc)
+	}
+
+	err := admin.CreateTable(hrpc.NewCreateTable(ctx, []byte(table), attrs))
+	if err != nil && strings.Contains(err.Error(), "TableExistsException") {
+		logrus.Infof("表 %s 已存在", table)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logrus.Infof("已创建HBase表 %s，列族: %s", table, strings.Join(sortedKeys(families), ", "))
+	return nil
+}
+
+// probeFamily 探测单个列族，返回"table"（表不存在）、"family"（列族不存在）或""（存在）
+func probeFamily(ctx context.Context, client schemaGetter, table, family string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, schemaProbeTimeout)
+	defer cancel()
+
+	get, err := hrpc.NewGetStr(ctx, table, schemaProbeRow, hrpc.Families(map[string][]string{family: nil}))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = client.Get(get)
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.Is(err, gohbase.TableNotFound):
+		return "table", nil
+	case strings.Contains(err.Error(), "NoSuchColumnFamilyException"):
+		return "family", nil
+	}
+	return "", err
+}
+
+// sortedKeys 返回排序后的map键，保证输出顺序稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
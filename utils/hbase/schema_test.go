@@ -0,0 +1,98 @@
+package hbase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gohbase/utils/hbase/hbasetest"
+	"strings"
+	"testing"
+)
+
+// TestCheckSchema 内存客户端缺少表或列族时，错误列出缺失项和对应的create/alter命令
+func TestCheckSchema(t *testing.T) {
+	movies, users := MoviesTable(), UsersTable()
+
+	tests := []struct {
+		name         string
+		schema       map[string][]string
+		wantTables   []string
+		wantFamilies map[string][]string
+		wantInError  []string
+	}{
+		{
+			name:   "完整",
+			schema: map[string][]string{movies: {"info", "ratings", "tags", "genome"}, users: {"movies", "tags"}},
+		},
+		{
+			name:         "movies缺少ratings",
+			schema:       map[string][]string{movies: {"info", "tags", "genome"}, users: {"movies", "tags"}},
+			wantFamilies: map[string][]string{movies: {"ratings"}},
+			wantInError: []string{
+				"表 movies 缺少列族 ratings",
+				"alter 'movies', {NAME => 'ratings', VERSIONS => 1, COMPRESSION => 'NONE'}",
+			},
+		},
+		{
+			name:         "两个表都缺少列族",
+			schema:       map[string][]string{movies: {"info", "genome"}, users: {"movies"}},
+			wantFamilies: map[string][]string{movies: {"ratings", "tags"}, users: {"tags"}},
+			wantInError: []string{
+				"表 movies 缺少列族 ratings, tags",
+				"表 users 缺少列族 tags",
+				"alter 'movies', {NAME => 'tags', VERSIONS => 1, COMPRESSION => 'NONE'}",
+				"alter 'users', {NAME => 'tags', VERSIONS => 1, COMPRESSION => 'NONE'}",
+			},
+		},
+		{
+			name:       "缺少users表",
+			schema:     map[string][]string{movies: {"info", "ratings", "tags", "genome"}},
+			wantTables: []string{users},
+			wantInError: []string{
+				"缺少表 users",
+				"create 'users', {NAME => 'movies', VERSIONS => 1, COMPRESSION => 'NONE'}, {NAME => 'tags', VERSIONS => 1, COMPRESSION => 'NONE'}",
+			},
+		},
+	}
+	for _, tt := range tests {
+		client := hbasetest.New()
+		for table, families := range tt.schema {
+			client.SetSchema(table, families...)
+		}
+
+		err := CheckSchema(context.Background(), client)
+		if tt.wantTables == nil && tt.wantFamilies == nil {
+			if err != nil {
+				t.Errorf("%s: CheckSchema = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatalf("%s: CheckSchema = %v, want *SchemaError", tt.name, err)
+		}
+		if fmt.Sprint(schemaErr.MissingTables) != fmt.Sprint(tt.wantTables) {
+			t.Errorf("%s: MissingTables = %v, want %v", tt.name, schemaErr.MissingTables, tt.wantTables)
+		}
+		if fmt.Sprint(schemaErr.MissingFamilies) != fmt.Sprint(tt.wantFamilies) {
+			t.Errorf("%s: MissingFamilies = %v, want %v", tt.name, schemaErr.MissingFamilies, tt.wantFamilies)
+		}
+		for _, want := range tt.wantInError {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: 错误信息缺少 %q:\n%s", tt.name, want, err)
+			}
+		}
+	}
+}
+
+// TestCheckSchemaOtherErrors 连接等其他错误直接返回，不当作缺失的表结构
+func TestCheckSchemaOtherErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := CheckSchema(ctx, hbasetest.New())
+	var schemaErr *SchemaError
+	if err == nil || errors.As(err, &schemaErr) {
+		t.Errorf("CheckSchema(已取消) = %v, want 非SchemaError的错误", err)
+	}
+}
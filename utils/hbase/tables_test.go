@@ -0,0 +1,83 @@
+package hbase
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// hardcodedTables 表名应取自配置（MoviesTable、UsersTable），不能写成字面量
+var hardcodedTables = map[string]bool{"movies": true, "users": true}
+
+// tableLiteral 返回表名参数中的字符串字面量，支持"movies"和[]byte("movies")两种写法
+func tableLiteral(arg ast.Expr) (string, bool) {
+	if call, ok := arg.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		if array, ok := call.Fun.(*ast.ArrayType); ok && array.Len == nil {
+			arg = call.Args[0]
+		}
+	}
+	lit, ok := arg.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(lit.Value)
+	return value, err == nil
+}
+
+// TestNoHardcodedTableNames 整个模块中hrpc.New*调用的表名参数（第二个参数）都不是"movies"或"users"字面量
+func TestNoHardcodedTableNames(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	checked := 0
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (strings.HasPrefix(name, ".") || name == "docs") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !strings.HasPrefix(sel.Sel.Name, "New") {
+				return true
+			}
+			if pkg, ok := sel.X.(*ast.Ident); !ok || pkg.Name != "hrpc" {
+				return true
+			}
+			checked++
+			if table, ok := tableLiteral(call.Args[1]); ok && hardcodedTables[table] {
+				t.Errorf("%s: hrpc.%s 使用了字面量表名%q，应使用MoviesTable()/UsersTable()",
+					fset.Position(call.Pos()), sel.Sel.Name, table)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("遍历源文件失败: %v", err)
+	}
+	if checked == 0 {
+		t.Fatal("没有找到任何hrpc.New*调用，检查的目录可能不对")
+	}
+}
@@ -2,6 +2,7 @@ package hbase
 
 import (
 	"context"
+	"gohbase/utils/hbase/rowkey"
 	"strconv"
 	"strings"
 
@@ -11,7 +12,7 @@ import (
 // GetUserRating 获取用户对电影的评分（适配新的数据库结构）
 func GetUserRating(ctx context.Context, movieID string, userID string) (float64, int64, error) {
 	// 根据新的数据库结构，评分存储在{movieId}_ratings行的宽列中
-	get, err := hrpc.NewGetStr(ctx, "movies", movieID+"_ratings")
+	get, err := hrpc.NewGetStr(ctx, MoviesTable(), rowkey.MovieRatingsKey(movieID))
 	if err != nil {
 		return 0, 0, err
 	}
@@ -30,17 +31,9 @@ func GetUserRating(ctx context.Context, movieID string, userID string) (float64,
 	// 查找特定用户的评分
 	for _, cell := range result.Cells {
 		if string(cell.Family) == "ratings" && string(cell.Qualifier) == userID {
-			// 解析评分数据格式: "{rating}:{userId}:{timestamp}"
-			ratingStr := string(cell.Value)
-			parts := strings.Split(ratingStr, ":")
-
-			if len(parts) >= 3 {
-				if rating, err := strconv.ParseFloat(parts[0], 64); err == nil {
-					if timestamp, err := strconv.ParseInt(parts[2], 10, 64); err == nil {
-						return rating, timestamp, nil
-					}
-					return rating, 0, nil
-				}
+			// 解析评分数据格式: "{rating}:{userId}:{timestamp}[:{source}]"
+			if parsed, ok := ParseRatingValue(string(cell.Value)); ok {
+				return parsed.Rating, parsed.Timestamp, nil
 			}
 		}
 	}
@@ -51,7 +44,7 @@ func GetUserRating(ctx context.Context, movieID string, userID string) (float64,
 // GetUserMovieRatings 获取用户的所有电影评分（使用users表）
 func GetUserMovieRatings(ctx context.Context, userID string) (map[string]interface{}, error) {
 	// 根据新的数据库结构，从users表获取用户的所有评分
-	get, err := hrpc.NewGetStr(ctx, "users", userID)
+	get, err := hrpc.NewGetStr(ctx, UsersTable(), userID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,10 +93,37 @@ func GetUserMovieRatings(ctx context.Context, userID string) (map[string]interfa
 	}, nil
 }
 
+// UserRatingValue 构建users表评分值: "{rating}:{movieId}:{timestamp}"
+func UserRatingValue(rating float64, movieID string, timestamp int64) []byte {
+	return []byte(strconv.FormatFloat(rating, 'f', 1, 64) + ":" + movieID + ":" + strconv.FormatInt(timestamp, 10))
+}
+
+// NewUserMovieRatingsPut 构建写入users表{userId}行的Put（movies列族，列名为电影ID）
+func NewUserMovieRatingsPut(ctx context.Context, userID string, values map[string][]byte) (*hrpc.Mutate, error) {
+	return hrpc.NewPutStr(ctx, UsersTable(), userID, map[string]map[string][]byte{
+		"movies": values,
+	})
+}
+
+// PutUserMovieRatings 将用户的多条评分写入users表
+func PutUserMovieRatings(ctx context.Context, userID string, values map[string][]byte) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	put, err := NewUserMovieRatingsPut(ctx, userID, values)
+	if err != nil {
+		return err
+	}
+
+	_, err = hbaseClient.Put(put)
+	return err
+}
+
 // GetUserTags 获取用户的标签（使用users表）
 func GetUserTags(ctx context.Context, userID string) ([]string, error) {
 	// 根据新的数据库结构，从users表获取用户的所有标签
-	get, err := hrpc.NewGetStr(ctx, "users", userID)
+	get, err := hrpc.NewGetStr(ctx, UsersTable(), userID)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +159,62 @@ func GetUserTags(ctx context.Context, userID string) ([]string, error) {
 	return tags, nil
 }
 
-// GetUserFavoriteGenres 获取用户最喜欢的电影类型
+// UserTag users表中用户的一个标签
+type UserTag struct {
+	Tag       string
+	MovieID   string
+	Timestamp int64 // Unix秒，无法解析时为0
+}
+
+// GetUserTagEntries 获取用户的全部标签记录（users表tags列族，值为"{tag}:{movieId}:{timestamp}"）
+func GetUserTagEntries(ctx context.Context, userID string) ([]UserTag, error) {
+	get, err := hrpc.NewGetStr(ctx, UsersTable(), userID, hrpc.Families(map[string][]string{"tags": nil}))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := hbaseClient.Get(get)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []UserTag
+	for _, cell := range result.Cells {
+		// 标签本身可能包含冒号，从右侧切出电影ID和时间戳
+		value := string(cell.Value)
+		i := strings.LastIndex(value, ":")
+		if i < 0 {
+			continue
+		}
+		j := strings.LastIndex(value[:i], ":")
+		if j < 0 {
+			continue
+		}
+		entry := UserTag{Tag: value[:j], MovieID: value[j+1 : i]}
+		entry.Timestamp, _ = strconv.ParseInt(value[i+1:], 10, 64)
+		tags = append(tags, entry)
+	}
+	return tags, nil
+}
+
+// PutUserTag 写入用户的一个标签到users表{userId}行（tags列族，列名为{movieId}_{timestamp}，值为"{tag}:{movieId}:{timestamp}"）
+func PutUserTag(ctx context.Context, userID, movieID, tag string, timestamp int64) error {
+	ts := strconv.FormatInt(timestamp, 10)
+	put, err := hrpc.NewPutStr(ctx, UsersTable(), userID, map[string]map[string][]byte{
+		"tags": {movieID + "_" + ts: []byte(tag + ":" + movieID + ":" + ts)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = hbaseClient.Put(put)
+	return err
+}
+
+// genreLookupBatchSize 统计类型时每批并发读取的电影数
+const genreLookupBatchSize = 100
+
+// GetUserFavoriteGenres 获取用户最喜欢的电影类型：用户评过分的电影按类型计数
 func GetUserFavoriteGenres(ctx context.Context, userID string) (map[string]int, error) {
 	// 获取用户的所有评分
 	userRatings, err := GetUserMovieRatings(ctx, userID)
@@ -147,32 +222,35 @@ func GetUserFavoriteGenres(ctx context.Context, userID string) (map[string]int,
 		return map[string]int{}, err
 	}
 
-	genreCount := make(map[string]int)
-
+	var movieIDs []string
 	if ratings, ok := userRatings["ratings"].([]map[string]interface{}); ok {
 		for _, rating := range ratings {
 			if movieID, ok := rating["movieId"].(string); ok {
-				// 获取电影信息以获取类型
-				movieData, err := GetMovie(ctx, movieID)
-				if err == nil && movieData != nil {
-					if infoData, ok := movieData["info"]; ok {
-						if genresBytes, ok := infoData["genres"]; ok {
-							genresStr := string(genresBytes)
-							genres := strings.Split(genresStr, "|")
-							for _, genre := range genres {
-								genre = strings.TrimSpace(genre)
-								if genre != "" {
-									genreCount[genre]++
-								}
-							}
-						}
-					}
-				}
+				movieIDs = append(movieIDs, movieID)
 			}
 		}
 	}
+	return CountMovieGenres(ctx, movieIDs), nil
+}
 
-	return genreCount, nil
+// CountMovieGenres 分批并发读取电影的_info行，统计各类型的电影数，不存在或读取失败的电影不计入
+func CountMovieGenres(ctx context.Context, movieIDs []string) map[string]int {
+	genreCount := make(map[string]int)
+	for start := 0; start < len(movieIDs); start += genreLookupBatchSize {
+		batch := movieIDs[start:min(start+genreLookupBatchSize, len(movieIDs))]
+		movies, err := GetMoviesMultiple(ctx, batch)
+		if err != nil {
+			continue
+		}
+		for _, movieData := range movies {
+			if genresBytes, ok := movieData["info"]["genres"]; ok {
+				for _, genre := range normalizeGenres(string(genresBytes)) {
+					genreCount[genre]++
+				}
+			}
+		}
+	}
+	return genreCount
 }
 
 // GetRecommendedMoviesForUser 获取推荐给用户的电影
@@ -198,7 +276,7 @@ func GetRecommendedMoviesForUser(ctx context.Context, userID string) ([]string,
 	}
 
 	// 根据最喜欢的类型搜索电影
-	results, err := ScanMoviesByGenre(ctx, topGenre, 10)
+	results, err := ScanMoviesByGenreWithFilter(ctx, topGenre, 10)
 	if err != nil {
 		return []string{}, err
 	}
@@ -207,8 +285,7 @@ func GetRecommendedMoviesForUser(ctx context.Context, userID string) ([]string,
 	for _, result := range results {
 		if len(result.Cells) > 0 {
 			rowKey := string(result.Cells[0].Row)
-			if strings.HasSuffix(rowKey, "_info") {
-				movieID := strings.TrimSuffix(rowKey, "_info")
+			if movieID, ok := rowkey.MovieIDFromKey(rowKey, rowkey.TypeInfo); ok {
 				movieIDs = append(movieIDs, movieID)
 			}
 		}
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogEntry 日志条目
+type LogEntry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogBuffer 固定容量的环形日志缓冲区，实现logrus.Hook以捕获最近的日志
+type LogBuffer struct {
+	mu      sync.RWMutex
+	entries []LogEntry
+	levels  []logrus.Level
+	next    int
+	full    bool
+}
+
+// SystemLogs 全局系统日志缓冲区
+var SystemLogs = NewLogBuffer(1000)
+
+// NewLogBuffer 创建指定容量的日志缓冲区
+func NewLogBuffer(capacity int) *LogBuffer {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &LogBuffer{
+		entries: make([]LogEntry, capacity),
+		levels:  make([]logrus.Level, capacity),
+	}
+}
+
+// Levels 捕获所有级别的日志
+func (b *LogBuffer) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 将日志写入环形缓冲区，超出容量时覆盖最旧的条目
+func (b *LogBuffer) Fire(entry *logrus.Entry) error {
+	var fields map[string]interface{}
+	if len(entry.Data) > 0 {
+		fields = make(map[string]interface{}, len(entry.Data))
+		for k, v := range entry.Data {
+			if err, ok := v.(error); ok {
+				v = err.Error()
+			}
+			fields[k] = v
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = LogEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  fields,
+	}
+	b.levels[b.next] = entry.Level
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	return nil
+}
+
+// Recent 按时间顺序返回最近limit条不低于minLevel严重程度的日志
+func (b *LogBuffer) Recent(limit int, minLevel logrus.Level) []LogEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	size := b.next
+	if b.full {
+		size = len(b.entries)
+	}
+
+	// 从最新的条目向前收集
+	result := make([]LogEntry, 0)
+	for i := 0; i < size && (limit <= 0 || len(result) < limit); i++ {
+		idx := (b.next - 1 - i + len(b.entries)) % len(b.entries)
+		if b.levels[idx] <= minLevel {
+			result = append(result, b.entries[idx])
+		}
+	}
+
+	// 反转为时间正序
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+
+	return result
+}
+
+// Capacity 缓冲区容量
+func (b *LogBuffer) Capacity() int {
+	return len(b.entries)
+}
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"gohbase/utils/hbase/hbasetest"
+	"testing"
+)
+
+// testIndexPath 测试使用的索引文件，位于尚不存在的子目录中（非默认路径）
+var testIndexPath string
+
+// TestMain 把索引文件放到临时目录，测试不读写工作目录下的索引
+func TestMain(m *testing.M) {
+	hbasetest.Main(m, func(indexPath string) { testIndexPath = indexPath })
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -51,6 +52,16 @@ func BadRequest(c *gin.Context, message string) {
 	Error(c, http.StatusBadRequest, message, nil)
 }
 
+// Unauthorized 401错误
+func Unauthorized(c *gin.Context, message string) {
+	Error(c, http.StatusUnauthorized, message, nil)
+}
+
+// Forbidden 403错误
+func Forbidden(c *gin.Context, message string) {
+	Error(c, http.StatusForbidden, message, nil)
+}
+
 // NotFound 404错误
 func NotFound(c *gin.Context, message string) {
 	Error(c, http.StatusNotFound, message, nil)
@@ -60,3 +71,8 @@ func NotFound(c *gin.Context, message string) {
 func InternalError(c *gin.Context, message string, err error) {
 	Error(c, http.StatusInternalServerError, message, err)
 }
+
+// RequestEntityTooLarge 413错误
+func RequestEntityTooLarge(c *gin.Context, limit int64) {
+	Error(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("请求体过大，最大允许 %d 字节", limit), nil)
+}
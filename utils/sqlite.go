@@ -3,55 +3,177 @@ package utils
 import (
 	"database/sql"
 	"fmt"
+	"gohbase/config"
+	"net/url"
 	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/sirupsen/logrus"
 	_ "modernc.org/sqlite" // 导入纯Go版本的sqlite驱动
 )
 
-const dbFile = "./movie_index.db"
+// sqliteMaxOpenConns 索引连接池的最大连接数。WAL模式下读可以并发，写入由SQLite串行化
+const sqliteMaxOpenConns = 8
 
 var (
+	// dbMu 保护当前使用的索引连接：AcquireDB取得的连接在release前持有读锁，重建完成后在写锁下切换并关闭旧连接
+	dbMu sync.RWMutex
 	db   *sql.DB
-	once sync.Once
+
+	// indexConf 首次使用索引时从配置读取，之后不再变化（修改需重启）
+	indexConf     config.SearchIndexConfig
+	indexConfOnce sync.Once
 )
 
-// InitDB 初始化数据库连接，并创建基础表。
-func InitDB() (*sql.DB, error) {
-	var err error
-	once.Do(func() {
-		db, err = sql.Open("sqlite", dbFile)
-		if err != nil {
-			logrus.Errorf("打开SQLite数据库失败: %v", err)
-			return
-		}
+// indexConfig 返回索引文件配置
+func indexConfig() config.SearchIndexConfig {
+	indexConfOnce.Do(func() {
+		indexConf = config.GetConfig().SearchIndex
+	})
+	return indexConf
+}
+
+// IndexPath 返回索引数据库文件路径
+func IndexPath() string {
+	return indexConfig().GetPath()
+}
 
-		if err = createTables(db); err != nil {
-			logrus.Errorf("创建表失败: %v", err)
-			db.Close()
-			db = nil // 失败时将db重置为nil
-			return
+// stagingPath 重建索引时写入的临时数据库文件，构建完成后重命名为IndexPath
+func stagingPath() string {
+	return IndexPath() + ".building"
+}
+
+// indexDSN 生成打开索引文件的DSN：设置忙等待时间和日志模式；
+// 事务以BEGIN IMMEDIATE开始，避免先读后写的事务在升级写锁时直接返回SQLITE_BUSY
+func indexDSN(path string, conf config.SearchIndexConfig) string {
+	journalMode := "DELETE"
+	if conf.IsWALEnabled() {
+		journalMode = "WAL"
+	}
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", conf.GetBusyTimeout().Milliseconds()))
+	query.Add("_pragma", fmt.Sprintf("journal_mode(%s)", journalMode))
+	query.Set("_txlock", "immediate")
+	return path + "?" + query.Encode()
+}
+
+// openDB 打开数据库文件并创建基础表
+func openDB(path string) (*sql.DB, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("创建索引目录失败: %w", err)
 		}
-		logrus.Info("SQLite数据库初始化成功。")
-	})
+	}
 
+	conn, err := sql.Open("sqlite", indexDSN(path, indexConfig()))
 	if err != nil {
-		return nil, fmt.Errorf("SQLite初始化失败: %w", err)
+		return nil, fmt.Errorf("打开SQLite数据库失败: %w", err)
+	}
+	conn.SetMaxOpenConns(sqliteMaxOpenConns)
+	conn.SetMaxIdleConns(sqliteMaxOpenConns)
+
+	if err := createTables(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("创建表失败: %w", err)
 	}
-	if db == nil {
-		return nil, fmt.Errorf("初始化后数据库连接为nil")
+	return conn, nil
+}
+
+// InitDB 初始化数据库连接，并创建基础表。已初始化时返回当前连接。
+func InitDB() (*sql.DB, error) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	if db != nil {
+		return db, nil
 	}
 
+	conn, err := openDB(IndexPath())
+	if err != nil {
+		logrus.Errorf("SQLite初始化失败: %v", err)
+		return nil, fmt.Errorf("SQLite初始化失败: %w", err)
+	}
+	db = conn
+	logrus.Infof("SQLite数据库初始化成功: %s", IndexPath())
 	return db, nil
 }
 
-// GetDB 返回数据库单例连接。
-func GetDB() (*sql.DB, error) {
-	if db == nil {
-		return InitDB()
+// AcquireDB 取得当前使用的索引连接，调用方用完后必须调用release。
+// release前持有读锁，切换索引时等待所有连接释放后再关闭旧连接，因此不会用到已关闭的连接。
+// 持有期间不能再调用AcquireDB、GetIndexMeta等（重复获取读锁在切换等待时会死锁），
+// 也不应访问HBase等慢速服务，以免阻塞切换
+func AcquireDB() (conn *sql.DB, release func(), err error) {
+	for {
+		dbMu.RLock()
+		if db != nil {
+			return db, dbMu.RUnlock, nil
+		}
+		dbMu.RUnlock()
+		if _, err := InitDB(); err != nil {
+			return nil, nil, err
+		}
 	}
-	return db, nil
+}
+
+// CreateStagingDB 创建用于重建索引的临时数据库（删除上次残留的临时文件），
+// 当前索引在构建期间继续提供查询。构建完成后调用SwapStagingDB切换，失败时调用DiscardStagingDB。
+func CreateStagingDB() (*sql.DB, error) {
+	if err := removeDBFiles(stagingPath()); err != nil {
+		return nil, fmt.Errorf("删除残留的临时索引失败: %w", err)
+	}
+	return openDB(stagingPath())
+}
+
+// SwapStagingDB 关闭临时数据库并将其原子地重命名为正式索引文件，然后切换到新连接。
+// 写锁等待AcquireDB取得的连接全部释放，之后没有调用方持有旧连接，可以立即关闭。
+func SwapStagingDB(staging *sql.DB) error {
+	if err := staging.Close(); err != nil {
+		return fmt.Errorf("关闭临时索引失败: %w", err)
+	}
+
+	dbMu.Lock()
+	defer dbMu.Unlock()
+
+	// WAL模式下旧连接的-wal和-shm文件按索引路径命名，新文件会误用它们，
+	// 因此先关闭旧连接（合并WAL），再替换文件
+	if db != nil {
+		if err := db.Close(); err != nil {
+			logrus.Warnf("关闭旧索引连接时出错: %v", err)
+		}
+		db = nil
+	}
+
+	path := IndexPath()
+	if err := os.Rename(stagingPath(), path); err != nil {
+		return fmt.Errorf("替换索引文件失败: %w", err)
+	}
+	conn, err := openDB(path)
+	if err != nil {
+		return err
+	}
+	db = conn
+	return nil
+}
+
+// DiscardStagingDB 关闭并删除临时数据库
+func DiscardStagingDB(staging *sql.DB) {
+	if staging != nil {
+		staging.Close()
+	}
+	if err := removeDBFiles(stagingPath()); err != nil {
+		logrus.Warnf("删除临时索引失败: %v", err)
+	}
+}
+
+// removeDBFiles 删除数据库文件及其日志文件，文件不存在时忽略
+func removeDBFiles(path string) error {
+	for _, file := range []string{path, path + "-journal", path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 // createTables 创建索引所需的基础表。
@@ -64,23 +186,203 @@ func createTables(db *sql.DB) error {
         title TEXT
     );`
 
+	// 电影基因标签Top-K表，用于相似度计算
+	genomeTopKTable := `
+    CREATE TABLE IF NOT EXISTS movie_genome_topk (
+        movie_id TEXT NOT NULL,
+        tag_id TEXT NOT NULL,
+        relevance REAL NOT NULL,
+        PRIMARY KEY (movie_id, tag_id)
+    );
+    CREATE INDEX IF NOT EXISTS idx_genome_topk_tag ON movie_genome_topk(tag_id);`
+
+	// 电影标签表（标签小写），用于按标签搜索
+	tagsTable := `
+    CREATE TABLE IF NOT EXISTS movie_tags (
+        movie_id TEXT NOT NULL,
+        tag TEXT NOT NULL,
+        count INTEGER NOT NULL,
+        PRIMARY KEY (movie_id, tag)
+    );
+    CREATE INDEX IF NOT EXISTS idx_movie_tags_tag ON movie_tags(tag);`
+
+	// 标题三元组表，用于拼写纠错时筛选候选标题
+	trigramsTable := `
+    CREATE TABLE IF NOT EXISTS movie_trigrams (
+        trigram TEXT NOT NULL,
+        movie_id TEXT NOT NULL,
+        PRIMARY KEY (trigram, movie_id)
+    ) WITHOUT ROWID;
+    CREATE INDEX IF NOT EXISTS idx_movie_trigrams_movie ON movie_trigrams(movie_id);`
+
+	// 索引元数据表，记录最近构建时间等信息
+	metaTable := `
+    CREATE TABLE IF NOT EXISTS movie_index_meta (
+        key TEXT PRIMARY KEY,
+        value TEXT
+    );`
+
+	// 用户活跃度表，由后台任务扫描users表定期重新计算
+	userActivityTable := `
+    CREATE TABLE IF NOT EXISTS user_activity (
+        user_id TEXT PRIMARY KEY,
+        rating_count INTEGER NOT NULL,
+        rating_sum REAL NOT NULL,
+        tag_count INTEGER NOT NULL,
+        last_activity INTEGER NOT NULL
+    ) WITHOUT ROWID;
+    CREATE INDEX IF NOT EXISTS idx_user_activity_ratings ON user_activity(rating_count);
+    CREATE INDEX IF NOT EXISTS idx_user_activity_tags ON user_activity(tag_count);`
+
+	// 压力测试生成的用户，无法从HBase数据中区分，写入时记录
+	testUsersTable := `
+    CREATE TABLE IF NOT EXISTS test_users (
+        user_id TEXT PRIMARY KEY
+    ) WITHOUT ROWID;`
+
 	// 注意: FTS5表在构建时动态创建，以优化批量插入性能。
 	if _, err := db.Exec(movieIndexTable); err != nil {
 		return fmt.Errorf("创建movie_index表失败: %w", err)
 	}
+	if _, err := db.Exec(genomeTopKTable); err != nil {
+		return fmt.Errorf("创建movie_genome_topk表失败: %w", err)
+	}
+	if _, err := db.Exec(tagsTable); err != nil {
+		return fmt.Errorf("创建movie_tags表失败: %w", err)
+	}
+	if _, err := db.Exec(trigramsTable); err != nil {
+		return fmt.Errorf("创建movie_trigrams表失败: %w", err)
+	}
+	if _, err := db.Exec(metaTable); err != nil {
+		return fmt.Errorf("创建movie_index_meta表失败: %w", err)
+	}
+	if _, err := db.Exec(userActivityTable); err != nil {
+		return fmt.Errorf("创建user_activity表失败: %w", err)
+	}
+	if _, err := db.Exec(testUsersTable); err != nil {
+		return fmt.Errorf("创建test_users表失败: %w", err)
+	}
+
+	// 为旧版本数据库补充新增列
+	if err := ensureColumn(db, "movie_index", "genres", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "movie_index", "year", "INTEGER"); err != nil {
+		return err
+	}
+	// 评分统计，用于搜索结果按热度或评分排序
+	if err := ensureColumn(db, "movie_index", "avg_rating", "REAL"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "movie_index", "rating_count", "INTEGER"); err != nil {
+		return err
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_movie_index_year ON movie_index(year)"); err != nil {
+		return fmt.Errorf("创建年份索引失败: %w", err)
+	}
+	// 外部ID（去掉tt前缀和前导0），用于按IMDB/TMDB ID精确查找
+	if err := ensureColumn(db, "movie_index", "imdb_id", "TEXT"); err != nil {
+		return err
+	}
+	if err := ensureColumn(db, "movie_index", "tmdb_id", "TEXT"); err != nil {
+		return err
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_movie_index_imdb ON movie_index(imdb_id)"); err != nil {
+		return fmt.Errorf("创建IMDB ID索引失败: %w", err)
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_movie_index_tmdb ON movie_index(tmdb_id)"); err != nil {
+		return fmt.Errorf("创建TMDB ID索引失败: %w", err)
+	}
+	// 电影的添加时间（Unix秒），用于最近添加的电影列表
+	if err := ensureColumn(db, "movie_index", "added_time", "INTEGER"); err != nil {
+		return err
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_movie_index_added_time ON movie_index(added_time)"); err != nil {
+		return fmt.Errorf("创建添加时间索引失败: %w", err)
+	}
+	// 标题的规范形式（见NormalizeTitle），作为FTS表的第二个检索列
+	if err := ensureColumn(db, "movie_index", "title_norm", "TEXT"); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-// ResetDatabase 删除数据库文件并重置连接。
-func ResetDatabase() error {
-	if db != nil {
-		if err := db.Close(); err != nil {
-			logrus.Warnf("重置前关闭数据库时出错: %v", err)
+// ensureColumn 如果表中不存在指定列则添加该列（兼容旧版本索引文件）
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("读取%s表结构失败: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
 		}
-		db = nil
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("为%s表添加%s列失败: %w", table, column, err)
+	}
+	return nil
+}
+
+// SetIndexMeta 写入当前索引的元数据
+func SetIndexMeta(key, value string) error {
+	conn, release, err := AcquireDB()
+	if err != nil {
+		return err
+	}
+	defer release()
+	return WriteIndexMeta(conn, key, value)
+}
+
+// GetIndexMeta 读取当前索引的元数据，不存在时返回空字符串
+func GetIndexMeta(key string) (string, error) {
+	conn, release, err := AcquireDB()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return ReadIndexMeta(conn, key)
+}
+
+// WriteIndexMeta 向指定数据库写入索引元数据（如重建中的临时索引）
+func WriteIndexMeta(conn *sql.DB, key, value string) error {
+	_, err := conn.Exec("INSERT OR REPLACE INTO movie_index_meta (key, value) VALUES (?, ?)", key, value)
+	return err
+}
+
+// ReadIndexMeta 从指定数据库读取索引元数据，不存在时返回空字符串
+func ReadIndexMeta(conn *sql.DB, key string) (string, error) {
+	var value string
+	err := conn.QueryRow("SELECT value FROM movie_index_meta WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// DBFileSize 返回索引数据库文件大小（字节），文件不存在时返回0
+func DBFileSize() int64 {
+	info, err := os.Stat(IndexPath())
+	if err != nil {
+		return 0
 	}
-	// 允许再次执行InitDB中的once.Do
-	once = sync.Once{}
-	return os.Remove(dbFile)
+	return info.Size()
 }
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"fmt"
+	"gohbase/config"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestIndexAtConfiguredPath 索引按配置的路径打开（自动创建目录），并使用WAL和忙等待
+func TestIndexAtConfiguredPath(t *testing.T) {
+	if IndexPath() != testIndexPath {
+		t.Fatalf("IndexPath() = %q, want %q", IndexPath(), testIndexPath)
+	}
+
+	conn, release, err := AcquireDB()
+	if err != nil {
+		t.Fatalf("打开索引失败: %v", err)
+	}
+	defer release()
+
+	if _, err := os.Stat(testIndexPath); err != nil {
+		t.Errorf("索引文件未创建在配置的路径: %v", err)
+	}
+	if _, err := os.Stat(config.DefaultSearchIndexPath); err == nil {
+		t.Errorf("不应在默认路径 %s 创建索引", config.DefaultSearchIndexPath)
+	}
+
+	var journalMode string
+	if err := conn.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "wal" {
+		t.Errorf("journal_mode = %q, %v, want wal", journalMode, err)
+	}
+	var busyTimeout int
+	if err := conn.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout); err != nil || busyTimeout != config.DefaultIndexBusyTimeoutMs {
+		t.Errorf("busy_timeout = %d, %v, want %d", busyTimeout, err, config.DefaultIndexBusyTimeoutMs)
+	}
+	if got := conn.Stats().MaxOpenConnections; got != sqliteMaxOpenConns {
+		t.Errorf("MaxOpenConnections = %d, want %d", got, sqliteMaxOpenConns)
+	}
+}
+
+func TestIndexDSN(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		conf        config.SearchIndexConfig
+		wantPragmas []string
+	}{
+		{config.SearchIndexConfig{}, []string{"busy_timeout(5000)", "journal_mode(WAL)"}},
+		{config.SearchIndexConfig{EnableWAL: &disabled, BusyTimeoutMs: 250}, []string{"busy_timeout(250)", "journal_mode(DELETE)"}},
+	}
+	for _, tt := range tests {
+		dsn := indexDSN("/data/index.db", tt.conf)
+		path, rawQuery, _ := strings.Cut(dsn, "?")
+		query, err := url.ParseQuery(rawQuery)
+		if err != nil || path != "/data/index.db" {
+			t.Fatalf("indexDSN = %q, 无法解析: %v", dsn, err)
+		}
+		if fmt.Sprint(query["_pragma"]) != fmt.Sprint(tt.wantPragmas) || query.Get("_txlock") != "immediate" {
+			t.Errorf("indexDSN(%+v) = %q, want pragmas %v and _txlock=immediate", tt.conf, dsn, tt.wantPragmas)
+		}
+	}
+}
+
+// TestIndexConcurrentWriterAndReaders 一个写入者和多个读取者同时访问索引时不出现SQLITE_BUSY等错误
+func TestIndexConcurrentWriterAndReaders(t *testing.T) {
+	conn, release, err := AcquireDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if _, err := conn.Exec("DELETE FROM movie_index"); err != nil {
+		t.Fatal(err)
+	}
+
+	const writes = 200
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	done := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < writes; i++ {
+			// 先读后写的事务，需要BEGIN IMMEDIATE才不会在升级写锁时失败
+			tx, err := conn.Begin()
+			if err != nil {
+				errs <- err
+				return
+			}
+			var count int
+			if err := tx.QueryRow("SELECT COUNT(*) FROM movie_index").Scan(&count); err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+			if _, err := tx.Exec("INSERT INTO movie_index (movie_id, title) VALUES (?, ?)",
+				fmt.Sprint(count+1), fmt.Sprintf("Movie %d", count+1)); err != nil {
+				tx.Rollback()
+				errs <- err
+				return
+			}
+			if err := tx.Commit(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := 0
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				var count int
+				if err := conn.QueryRow("SELECT COUNT(*) FROM movie_index").Scan(&count); err != nil {
+					errs <- err
+					return
+				}
+				if count < last {
+					errs <- fmt.Errorf("读到的行数从%d减少到%d", last, count)
+					return
+				}
+				last = count
+			}
+		}()
+	}
+
+	// 第二个写入者在另一个连接上同时写入，依赖busy_timeout等待
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			if _, err := conn.Exec("INSERT OR REPLACE INTO movie_index_meta (key, value) VALUES (?, ?)",
+				"writer2", time.Now().String()); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	var count int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM movie_index").Scan(&count); err != nil || count != writes {
+		t.Errorf("写入后行数 = %d, %v, want %d", count, err, writes)
+	}
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// trailingYearPattern 匹配标题末尾的"(dddd)"年份，如 "(500) Days of Summer (2009)"
+var trailingYearPattern = regexp.MustCompile(`^(.*?)\s*\((\d{4})\)\s*$`)
+
+// ExtractYearFromTitle 从MovieLens格式的标题中提取末尾年份
+// 返回去掉年份后的标题、年份以及是否找到年份；没有年份时返回原标题（去除首尾空白）
+func ExtractYearFromTitle(title string) (string, int, bool) {
+	matches := trailingYearPattern.FindStringSubmatch(title)
+	if matches == nil {
+		return strings.TrimSpace(title), 0, false
+	}
+
+	year, err := strconv.Atoi(matches[2])
+	if err != nil {
+		return strings.TrimSpace(title), 0, false
+	}
+
+	return strings.TrimSpace(matches[1]), year, true
+}
+
+// titleFoldings NFKD分解后仍保留的拉丁字母，按常见转写替换
+var titleFoldings = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "đ", "d", "ð", "d", "ł", "l", "þ", "th", "ı", "i",
+)
+
+// NormalizeTitle 返回用于全文检索的标题规范形式：转为小写、去掉变音符号（Amélie → amelie）、
+// 全角字符转为半角，中日韩文字逐字以空格分隔，使不带空格的标题也能按其中的词查找。
+func NormalizeTitle(title string) string {
+	// NFKD会把谚文音节拆成字母，去掉变音符号后再按NFC组合回音节
+	stripped := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(strings.ToLower(title)))
+	composed := norm.NFC.String(stripped)
+
+	var b strings.Builder
+	b.Grow(len(composed))
+	prevSpaced := true
+	for _, r := range composed {
+		switch {
+		case isCJK(r):
+			if !prevSpaced {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			b.WriteByte(' ')
+			prevSpaced = true
+		default:
+			b.WriteRune(r)
+			prevSpaced = unicode.IsSpace(r)
+		}
+	}
+	return strings.Join(strings.Fields(titleFoldings.Replace(b.String())), " ")
+}
+
+// isCJK 是否为中日韩文字（汉字、假名、谚文）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import "testing"
+
+func TestExtractYearFromTitle(t *testing.T) {
+	tests := []struct {
+		title     string
+		wantTitle string
+		wantYear  int
+		wantOK    bool
+	}{
+		{"Toy Story (1995)", "Toy Story", 1995, true},
+		{"(500) Days of Summer (2009)", "(500) Days of Summer", 2009, true},
+		{"Blade Runner (Director's Cut) (1982)", "Blade Runner (Director's Cut)", 1982, true},
+		{"1984 (1984)", "1984", 1984, true},
+		{"Babylon 5 (1994) ", "Babylon 5", 1994, true},
+		{"Hyena Road", "Hyena Road", 0, false},
+		{"  Hyena Road  ", "Hyena Road", 0, false},
+		{"Cosmos (2014-)", "Cosmos (2014-)", 0, false},
+		{"Year in the middle (1999) Remastered", "Year in the middle (1999) Remastered", 0, false},
+		{"Short year (99)", "Short year (99)", 0, false},
+		{"(2009)", "", 2009, true},
+		{"", "", 0, false},
+	}
+	for _, tt := range tests {
+		title, year, ok := ExtractYearFromTitle(tt.title)
+		if title != tt.wantTitle || year != tt.wantYear || ok != tt.wantOK {
+			t.Errorf("ExtractYearFromTitle(%q) = (%q, %d, %v), want (%q, %d, %v)",
+				tt.title, title, year, ok, tt.wantTitle, tt.wantYear, tt.wantOK)
+		}
+	}
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Amélie (Fabuleux destin d'Amélie Poulain, Le) (2001)", "amelie (fabuleux destin d'amelie poulain, le) (2001)"},
+		{"Crème Brûlée", "creme brulee"},
+		{"Das Boot ÄÖÜ ß", "das boot aou ss"},
+		{"Smørrebrød Łódź Æon Œuvre", "smorrebrod lodz aeon oeuvre"},
+		{"Ｔｏｋｙｏ　Ｓｔｏｒｙ", "tokyo story"},
+		{"千与千寻 (2001)", "千 与 千 寻 (2001)"},
+		{"Spirited Away 千と千尋の神隠し", "spirited away 千 と 千 尋 の 神 隠 し"},
+		{"기생충", "기 생 충"},
+		{"  Toy   Story  ", "toy story"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTitle(tt.title); got != tt.want {
+			t.Errorf("NormalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}